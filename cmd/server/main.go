@@ -4,11 +4,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/tosharewith/llmproxy_auth/internal/auth"
+	"github.com/tosharewith/llmproxy_auth/internal/budget"
+	"github.com/tosharewith/llmproxy_auth/internal/cache"
 	"github.com/tosharewith/llmproxy_auth/internal/handlers"
 	"github.com/tosharewith/llmproxy_auth/internal/health"
 	"github.com/tosharewith/llmproxy_auth/internal/instance"
@@ -21,7 +28,13 @@ import (
 	"github.com/tosharewith/llmproxy_auth/internal/providers/openai"
 	"github.com/tosharewith/llmproxy_auth/internal/providers/oracle"
 	"github.com/tosharewith/llmproxy_auth/internal/providers/vertex"
+	"github.com/tosharewith/llmproxy_auth/internal/ratelimit"
+	"github.com/tosharewith/llmproxy_auth/internal/reload"
 	"github.com/tosharewith/llmproxy_auth/internal/router"
+	"github.com/tosharewith/llmproxy_auth/internal/storage"
+	"github.com/tosharewith/llmproxy_auth/internal/storage/s3"
+	"github.com/tosharewith/llmproxy_auth/internal/usage"
+	"github.com/tosharewith/llmproxy_auth/pkg/metrics"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
@@ -39,6 +52,16 @@ func main() {
 	tlsEnabled := getEnv("TLS_ENABLED", "false") == "true"
 	modelMappingConfig := getEnv("MODEL_MAPPING_CONFIG", "configs/model-mapping.yaml")
 	providerInstancesConfig := getEnv("PROVIDER_INSTANCES_CONFIG", "configs/provider-instances.yaml")
+	rateLimitConfig := getEnv("RATE_LIMIT_CONFIG", "configs/rate-limits.yaml")
+	budgetConfig := getEnv("BUDGET_CONFIG", "configs/budgets.yaml")
+	jwtAuthConfig := getEnv("JWT_AUTH_CONFIG", "configs/jwt-auth.yaml")
+	mtlsConfigPath := getEnv("MTLS_CONFIG", "configs/mtls.yaml")
+	dbPath := getEnv("DB_PATH", "")
+	require2FA := getEnv("REQUIRE_2FA", "false") == "true"
+	sessionDuration := getEnvDuration("SESSION_DURATION", 12*time.Hour)
+	probeInterval := getEnvDuration("HEALTH_PROBE_INTERVAL", 30*time.Second)
+	probeTimeout := getEnvDuration("HEALTH_PROBE_TIMEOUT", 10*time.Second)
+	configWatchInterval := getEnvDuration("CONFIG_WATCH_INTERVAL", 10*time.Second)
 
 	// Set Gin mode
 	gin.SetMode(ginMode)
@@ -183,9 +206,17 @@ func main() {
 	enabledProviders := routerConfig.ListEnabledProviders()
 	log.Printf("Enabled providers: %s", strings.Join(enabledProviders, ", "))
 
+	// Probe providers in the background so /ready never blocks on upstream calls
+	providerProber := health.NewProber(aiRouter.HealthCheck, probeInterval, probeTimeout)
+	providerProber.Start(context.Background())
+	log.Printf("✓ Provider health probes started (interval: %s)", probeInterval)
+
 	// Load provider instances configuration for transparent and protocol modes
 	log.Printf("Loading provider instances configuration from: %s", providerInstancesConfig)
 	instanceConfig, err := instance.LoadConfig(providerInstancesConfig)
+	if err == nil {
+		err = instanceConfig.ValidateConfig()
+	}
 	if err != nil {
 		log.Printf("Warning: Failed to load provider instances config: %v", err)
 		log.Println("Continuing without transparent/protocol mode support")
@@ -198,15 +229,219 @@ func main() {
 		log.Printf("  - Protocol mode instances: %d", len(protocolInstances))
 	}
 
+	// Reload model mappings and provider instances on SIGHUP and when the files change
+	var instanceStore *instance.Store
+	var reloaders []*reload.Reloader
+	if r, err := reload.New("model_mapping", modelMappingConfig, func(data []byte) ([]string, error) {
+		config, err := router.ParseConfig(data)
+		if err != nil {
+			return nil, err
+		}
+		previous, err := aiRouter.UpdateConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		return reload.Diff(previous, config)
+	}); err != nil {
+		log.Printf("Warning: Model mapping configuration will not be reloaded: %v", err)
+	} else {
+		reloaders = append(reloaders, r)
+	}
+	if instanceConfig != nil {
+		instanceStore = instance.NewStore(instanceConfig)
+		if r, err := reload.New("provider_instances", providerInstancesConfig, func(data []byte) ([]string, error) {
+			config, err := instance.ParseConfig(data)
+			if err != nil {
+				return nil, err
+			}
+			previous, err := instanceStore.Update(config)
+			if err != nil {
+				return nil, err
+			}
+			return reload.Diff(previous, config)
+		}); err != nil {
+			log.Printf("Warning: Provider instances configuration will not be reloaded: %v", err)
+		} else {
+			reloaders = append(reloaders, r)
+		}
+	}
+	go reload.ReloadOnSIGHUP(context.Background(), reloaders...)
+	if configWatchInterval > 0 {
+		for _, r := range reloaders {
+			go r.Watch(context.Background(), configWatchInterval)
+		}
+		log.Printf("✓ Configuration reload enabled (SIGHUP, file check interval: %s)", configWatchInterval)
+	} else {
+		log.Println("✓ Configuration reload enabled (SIGHUP)")
+	}
+
+	// Load rate limiting configuration
+	var rateLimiter gin.HandlerFunc
+	if rlConfig, err := ratelimit.LoadConfig(rateLimitConfig); err != nil {
+		log.Printf("Warning: Failed to load rate limit config: %v", err)
+		log.Println("Continuing without rate limiting")
+	} else if rlConfig.Enabled {
+		store, err := ratelimit.NewStore(rlConfig)
+		if err != nil {
+			log.Fatalf("Failed to create rate limit store: %v", err)
+		}
+		rateLimiter = middleware.RateLimitByUser(ratelimit.NewLimiter(rlConfig, store))
+		log.Printf("✓ Rate limiting enabled (store: %s)", rlConfig.Store)
+	}
+
+	// Open the API key database, which also holds sessions, 2FA secrets and the usage ledger
+	if authEnabled && isDBAuthMode(authMode) && dbPath == "" {
+		log.Fatalf("AUTH_MODE=%s requires DB_PATH", authMode)
+	}
+	var apiKeyDB *auth.APIKeyDB
+	var totpManager *auth.TOTPManager
+	var sessionManager *auth.SessionManager
+	var usageLedger *usage.Ledger
+	if dbPath != "" {
+		apiKeyDB, err = auth.NewAPIKeyDB(dbPath)
+		if err != nil {
+			log.Fatalf("Failed to open database: %v", err)
+		}
+		defer apiKeyDB.Close()
+		apiKeyDB.SetCacheTTL(getEnvDuration("API_KEY_CACHE_TTL", auth.DefaultKeyCacheTTL))
+		apiKeyDB.SetLegacyKeysAllowed(getEnv("ALLOW_LEGACY_API_KEYS", "true") == "true")
+		if count, err := apiKeyDB.CountLegacyAPIKeys(); err == nil && count > 0 {
+			log.Printf("Warning: %d API keys use the legacy format and are slow to validate; rotate them via POST /admin/keys/:id/rotate", count)
+		}
+		totpManager = auth.NewTOTPManager(apiKeyDB.DB())
+		sessionManager = auth.NewSessionManager(apiKeyDB.DB())
+		go cleanupExpiredSessions(sessionManager, time.Hour)
+
+		usageLedger, err = usage.NewLedger(apiKeyDB.DB())
+		if err != nil {
+			log.Fatalf("Failed to create usage ledger: %v", err)
+		}
+		defer usageLedger.Close()
+		log.Printf("✓ Usage ledger enabled (database: %s)", dbPath)
+	}
+
+	// Load the JWT validator, fetching the issuer's signing keys
+	var jwtValidator *auth.JWTValidator
+	if authEnabled && authMode == "jwt" {
+		jwtConfig, err := auth.LoadJWTConfig(jwtAuthConfig)
+		if err != nil {
+			log.Fatalf("Failed to load JWT auth config: %v", err)
+		}
+		jwtValidator, err = auth.NewJWTValidator(jwtConfig)
+		if err != nil {
+			log.Fatalf("Invalid JWT auth config: %v", err)
+		}
+		log.Printf("✓ JWT auth enabled (issuer: %q)", jwtConfig.Issuer)
+	}
+
+	// Load the cluster's service account issuer keys
+	var saValidator *auth.ServiceAccountValidator
+	if authEnabled && authMode == "service_account" {
+		saValidator, err = auth.NewServiceAccountValidator(auth.ServiceAccountConfig{
+			Issuer:   getEnv("SA_TOKEN_ISSUER", ""),
+			Audience: getEnv("SA_TOKEN_AUDIENCE", "bedrock-proxy"),
+			JWKSURL:  getEnv("SA_JWKS_URL", auth.DefaultServiceAccountJWKSURL),
+			JWKSFile: getEnv("SA_JWKS_FILE", ""),
+			Refresh:  getEnvDuration("SA_JWKS_REFRESH", time.Hour),
+		})
+		if err != nil {
+			log.Fatalf("Failed to load service account issuer keys: %v", err)
+		}
+		log.Println("✓ Service account token validation enabled")
+	}
+
+	// Load client certificate authentication, used by AUTH_MODE=mtls or, when
+	// enabled with another mode, required alongside its credentials
+	var certVerifier *auth.ClientCertVerifier
+	if mtlsConfig, err := auth.LoadMTLSConfig(mtlsConfigPath); err != nil {
+		log.Printf("Warning: Failed to load mTLS config: %v", err)
+	} else if mtlsConfig.Enabled {
+		if !tlsEnabled {
+			log.Fatal("mTLS requires TLS_ENABLED=true")
+		}
+		certVerifier, err = auth.NewClientCertVerifier(mtlsConfig)
+		if err != nil {
+			log.Fatalf("Invalid mTLS config: %v", err)
+		}
+		log.Printf("✓ Client certificate authentication enabled (%d identity rules)", len(mtlsConfig.Identities))
+	}
+	if authEnabled && authMode == "mtls" && certVerifier == nil {
+		log.Fatalf("AUTH_MODE=mtls requires mtls.enabled in %s", mtlsConfigPath)
+	}
+
+	backends := &authBackends{
+		apiKeyDB:       apiKeyDB,
+		totpManager:    totpManager,
+		sessionManager: sessionManager,
+		jwtValidator:   jwtValidator,
+		saValidator:    saValidator,
+		certVerifier:   certVerifier,
+		require2FA:     require2FA,
+	}
+
+	// Load budget configuration; spend is persisted in the database when one is configured.
+	// Only the OpenAI-compatible and protocol endpoints report priced usage, so
+	// passthrough (transparent, /providers and legacy Bedrock) is not budgeted
+	var budgetEnforcer gin.HandlerFunc
+	if bConfig, err := budget.LoadConfig(budgetConfig); err != nil {
+		log.Printf("Warning: Failed to load budget config: %v", err)
+		log.Println("Continuing without budgets")
+	} else if bConfig.Enabled {
+		var tracker *budget.Tracker
+		if apiKeyDB != nil {
+			tracker, err = budget.NewTracker(apiKeyDB.DB())
+		} else {
+			log.Println("Warning: DB_PATH not set, budget spend will reset on restart")
+			tracker, err = budget.NewTracker(nil)
+		}
+		if err != nil {
+			log.Fatalf("Failed to create budget tracker: %v", err)
+		}
+		budgetEnforcer = middleware.Budget(budget.NewEnforcer(bConfig, tracker, aiRouter.ModelOutputPrice))
+		log.Println("✓ Budgets enabled")
+	}
+
 	// Initialize handlers
 	openaiHandler := handlers.NewOpenAIHandler(aiRouter)
 
+	// Fetch image_url references (https:// and s3://) and inline them for providers
+	if routerConfig.Media.Enabled {
+		storageProviders := make(map[string]storage.StorageProvider)
+		s3Region := routerConfig.Media.S3Region
+		if s3Region == "" {
+			s3Region = region
+		}
+		if s3Provider, err := s3.NewS3Provider(s3.S3Config{Region: s3Region}); err != nil {
+			log.Printf("Warning: Failed to create S3 client, s3:// image URLs are disabled: %v", err)
+		} else {
+			storageProviders["s3"] = s3Provider
+		}
+		openaiHandler.SetMediaResolver(storage.NewMediaResolver(routerConfig.Media, storageProviders))
+		log.Println("✓ Image URL resolution enabled (https://, s3://)")
+	}
+
+	// Cache non-streaming chat completions
+	if routerConfig.Features.ResponseCaching {
+		responseCache, err := cache.New("response", routerConfig.ResponseCache)
+		if err != nil {
+			log.Fatalf("Failed to create response cache: %v", err)
+		}
+		openaiHandler.SetResponseCache(responseCache)
+		log.Println("✓ Response caching enabled")
+	}
+
+	// Answer near-duplicate questions from earlier answers
+	if routerConfig.SemanticCache.Enabled {
+		openaiHandler.SetSemanticCache(cache.NewSemanticIndex("semantic", routerConfig.SemanticCache))
+		log.Printf("✓ Semantic caching enabled (embedding model: %s)", routerConfig.SemanticCache.EmbeddingModel)
+	}
+
 	// Initialize transparent and protocol handlers if config is available
 	var transparentHandler *handlers.TransparentHandler
 	var protocolHandler *handlers.ProtocolHandler
 	if instanceConfig != nil {
-		transparentHandler = handlers.NewTransparentHandler(providerRegistry, instanceConfig)
-		protocolHandler = handlers.NewProtocolHandler(providerRegistry, instanceConfig)
+		transparentHandler = handlers.NewTransparentHandler(providerRegistry, instanceStore)
+		protocolHandler = handlers.NewProtocolHandler(providerRegistry, instanceStore, aiRouter)
 		log.Println("✓ Transparent and protocol handlers initialized")
 	}
 
@@ -222,27 +457,84 @@ func main() {
 
 	// Health endpoints (no auth required)
 	ginRouter.GET("/health", healthHandler(healthChecker))
-	ginRouter.GET("/ready", readyHandler(healthChecker, aiRouter))
+	ginRouter.GET("/ready", readyHandler(healthChecker, providerProber, aiRouter))
 	ginRouter.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	// OpenAI-compatible API endpoints
 	openaiGroup := ginRouter.Group("/v1")
 	if authEnabled {
 		log.Printf("Authentication enabled for OpenAI API: mode=%s", authMode)
-		openaiGroup.Use(getAuthMiddleware(authMode))
+		openaiGroup.Use(getAuthMiddleware(authMode, backends))
+		openaiGroup.Use(middleware.Authorize(auth.ScopeOpenAI, aiRouter, nil))
+	}
+	if rateLimiter != nil {
+		openaiGroup.Use(rateLimiter)
+	}
+	if budgetEnforcer != nil {
+		openaiGroup.Use(budgetEnforcer)
+	}
+	if usageLedger != nil {
+		openaiGroup.Use(middleware.UsageLedger(usageLedger))
 	}
 	{
 		openaiGroup.POST("/chat/completions", openaiHandler.ChatCompletions)
+		openaiGroup.POST("/embeddings", openaiHandler.Embeddings)
 		openaiGroup.GET("/models", openaiHandler.ListModels)
 		openaiGroup.GET("/models/:model", openaiHandler.GetModel)
 	}
 
+	// Session endpoints: log in with API key + TOTP, then use the session token
+	if authEnabled && (authMode == "session" || authMode == "hybrid") {
+		authHandler := handlers.NewAuthHandler(apiKeyDB, totpManager, sessionManager, sessionDuration)
+		authGroup := ginRouter.Group("/auth")
+		if certVerifier != nil {
+			authGroup.Use(withClientCert(certVerifier, func(c *gin.Context) { c.Next() }))
+		}
+		{
+			authGroup.POST("/login", authHandler.Login)
+			authGroup.POST("/refresh", authHandler.Refresh)
+			authGroup.POST("/logout", authHandler.Logout)
+			authGroup.GET("/sessions", authHandler.ListSessions)
+			authGroup.DELETE("/sessions/:id", authHandler.RevokeSession)
+		}
+		log.Printf("✓ Session endpoints registered: /auth/login, /auth/refresh, /auth/logout, /auth/sessions (session duration: %s)", sessionDuration)
+	}
+
+	// Admin endpoints, restricted to database API keys with the admin permission
+	if apiKeyDB != nil {
+		if getEnv("ADMIN_BOOTSTRAP", "false") == "true" {
+			bootstrapAdminKey(apiKeyDB)
+		}
+
+		adminGroup := ginRouter.Group("/admin")
+		adminGroup.Use(withClientCert(certVerifier, middleware.EnhancedAPIKeyAuth(apiKeyDB, totpManager, false)))
+		adminGroup.Use(middleware.RequirePermission(auth.PermissionAdmin))
+		{
+			adminGroup.GET("/usage", handlers.NewUsageHandler(usageLedger).GetUsage)
+
+			keyHandler := handlers.NewAPIKeyHandler(apiKeyDB, totpManager, sessionManager)
+			adminGroup.GET("/keys", keyHandler.ListKeys)
+			adminGroup.POST("/keys", keyHandler.CreateKey)
+			adminGroup.GET("/keys/:id", keyHandler.GetKey)
+			adminGroup.PATCH("/keys/:id", keyHandler.UpdateKey)
+			adminGroup.POST("/keys/:id/rotate", keyHandler.RotateKey)
+			adminGroup.POST("/keys/:id/revoke", keyHandler.RevokeKey)
+			adminGroup.POST("/keys/:id/totp", keyHandler.EnrollTOTP)
+			adminGroup.DELETE("/keys/:id/totp", keyHandler.ResetTOTP)
+		}
+		log.Println("✓ Admin endpoints registered: /admin/usage, /admin/keys")
+	}
+
 	// Transparent mode endpoints (/transparent/{provider}/*)
 	if transparentHandler != nil && instanceConfig != nil && instanceConfig.IsFeatureEnabled("transparent_mode") {
 		transparentGroup := ginRouter.Group("/transparent")
 		if authEnabled {
 			log.Printf("Authentication enabled for transparent mode: mode=%s", authMode)
-			transparentGroup.Use(getAuthMiddleware(authMode))
+			transparentGroup.Use(getAuthMiddleware(authMode, backends))
+			transparentGroup.Use(middleware.Authorize(auth.ScopeTransparent, nil, middleware.PathProvider(1)))
+		}
+		if rateLimiter != nil {
+			transparentGroup.Use(rateLimiter)
 		}
 		{
 			transparentGroup.Any("/*path", transparentHandler.HandleRequest)
@@ -255,12 +547,23 @@ func main() {
 		protocolGroup := ginRouter.Group("/")
 		if authEnabled {
 			log.Printf("Authentication enabled for protocol mode: mode=%s", authMode)
-			protocolGroup.Use(getAuthMiddleware(authMode))
+			protocolGroup.Use(getAuthMiddleware(authMode, backends))
+			protocolGroup.Use(middleware.Authorize(auth.ScopeProtocol, nil, instanceProvider(instanceStore)))
+		}
+		if rateLimiter != nil {
+			protocolGroup.Use(rateLimiter)
+		}
+		if budgetEnforcer != nil {
+			protocolGroup.Use(budgetEnforcer)
+		}
+		if usageLedger != nil {
+			protocolGroup.Use(middleware.UsageLedger(usageLedger))
 		}
 		{
 			// Register protocol endpoints (e.g., /openai/bedrock_us1_openai/*)
 			protocolGroup.POST("/openai/*path", protocolHandler.HandleRequest)
 			protocolGroup.POST("/anthropic/*path", protocolHandler.HandleRequest)
+			protocolGroup.POST("/converse/*path", protocolHandler.HandleRequest)
 		}
 		log.Println("✓ Protocol mode endpoints registered: /{protocol}/*")
 	}
@@ -269,7 +572,11 @@ func main() {
 	providersGroup := ginRouter.Group("/providers")
 	if authEnabled {
 		log.Printf("Authentication enabled for provider APIs: mode=%s", authMode)
-		providersGroup.Use(getAuthMiddleware(authMode))
+		providersGroup.Use(getAuthMiddleware(authMode, backends))
+		providersGroup.Use(middleware.Authorize(auth.ScopeProviders, nil, middleware.PathProvider(1)))
+	}
+	if rateLimiter != nil {
+		providersGroup.Use(rateLimiter)
 	}
 	{
 		// Register native API endpoints for each provider
@@ -300,7 +607,11 @@ func main() {
 	if bedrockProvider, ok := providerRegistry["bedrock"]; ok {
 		legacyGroup := ginRouter.Group("/")
 		if authEnabled {
-			legacyGroup.Use(getAuthMiddleware(authMode))
+			legacyGroup.Use(getAuthMiddleware(authMode, backends))
+			legacyGroup.Use(middleware.Authorize(auth.ScopeProviders, nil, func(*gin.Context) string { return "bedrock" }))
+		}
+		if rateLimiter != nil {
+			legacyGroup.Use(rateLimiter)
 		}
 		{
 			legacyGroup.Any("/v1/bedrock/*path", createProviderHandler(bedrockProvider, healthChecker))
@@ -326,7 +637,13 @@ func main() {
 		// Start HTTPS/TLS server (blocking)
 		addrTLS := fmt.Sprintf(":%s", tlsPort)
 		log.Printf("Starting HTTPS/TLS server on %s", addrTLS)
-		if err := ginRouter.RunTLS(addrTLS, tlsCertFile, tlsKeyFile); err != nil {
+		if certVerifier != nil {
+			// Request and verify client certificates
+			server := &http.Server{Addr: addrTLS, Handler: ginRouter, TLSConfig: certVerifier.TLSConfig()}
+			if err := server.ListenAndServeTLS(tlsCertFile, tlsKeyFile); err != nil {
+				log.Fatalf("Failed to start HTTPS/TLS server: %v", err)
+			}
+		} else if err := ginRouter.RunTLS(addrTLS, tlsCertFile, tlsKeyFile); err != nil {
 			log.Fatalf("Failed to start HTTPS/TLS server: %v", err)
 		}
 	} else {
@@ -388,9 +705,105 @@ func createProviderHandler(provider providers.Provider, healthChecker *health.Ch
 	}
 }
 
-// getAuthMiddleware returns the appropriate auth middleware
-func getAuthMiddleware(authMode string) gin.HandlerFunc {
+// bootstrapAdminKey creates an admin API key if the database has none, so
+// the admin API can be reached on a fresh install. The key is printed once.
+func bootstrapAdminKey(apiKeyDB *auth.APIKeyDB) {
+	keys, err := apiKeyDB.ListAPIKeys()
+	if err != nil {
+		log.Fatalf("Failed to list API keys: %v", err)
+	}
+	for _, key := range keys {
+		if key.IsActive && auth.HasPermission(key.Permissions, auth.PermissionAdmin) {
+			return
+		}
+	}
+
+	_, apiKey, err := apiKeyDB.CreateAPIKey("admin", "", "Bootstrap admin key", nil, `["`+auth.PermissionAdmin+`"]`, "{}")
+	if err != nil {
+		log.Fatalf("Failed to create bootstrap admin key: %v", err)
+	}
+	log.Printf("Created bootstrap admin API key (store it securely, it will not be shown again): %s", apiKey)
+}
+
+// isDBAuthMode reports whether an auth mode authenticates against the API key database
+func isDBAuthMode(authMode string) bool {
+	return authMode == "db" || authMode == "session" || authMode == "hybrid"
+}
+
+// instanceProvider returns the provider type of the protocol-mode instance a request targets
+func instanceProvider(instanceStore *instance.Store) func(c *gin.Context) string {
+	return func(c *gin.Context) string {
+		instanceCfg, _, err := instanceStore.Load().GetInstanceByPath(c.Request.URL.Path)
+		if err != nil {
+			return ""
+		}
+		return instanceCfg.Type
+	}
+}
+
+// cleanupExpiredSessions periodically deletes expired session tokens
+func cleanupExpiredSessions(sessionManager *auth.SessionManager, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := sessionManager.CleanupExpiredSessions(); err != nil {
+			log.Printf("Failed to clean up expired sessions: %v", err)
+		}
+	}
+}
+
+// authBackends are the credential stores and validators used by the auth modes
+type authBackends struct {
+	apiKeyDB       *auth.APIKeyDB
+	totpManager    *auth.TOTPManager
+	sessionManager *auth.SessionManager
+	jwtValidator   *auth.JWTValidator
+	saValidator    *auth.ServiceAccountValidator
+	certVerifier   *auth.ClientCertVerifier
+	require2FA     bool
+}
+
+// getAuthMiddleware returns the appropriate auth middleware. When mTLS is
+// enabled alongside another mode, that mode's credentials are only accepted
+// over a client certificate from an allowed identity.
+func getAuthMiddleware(authMode string, backends *authBackends) gin.HandlerFunc {
+	if authMode == "mtls" {
+		return middleware.ClientCertAuth(backends.certVerifier, nil)
+	}
+	return withClientCert(backends.certVerifier, authModeMiddleware(authMode, backends))
+}
+
+// withClientCert requires an allowed client certificate before next, if mTLS
+// is enabled
+func withClientCert(certVerifier *auth.ClientCertVerifier, next gin.HandlerFunc) gin.HandlerFunc {
+	if certVerifier == nil {
+		return next
+	}
+	return middleware.ClientCertAuth(certVerifier, next)
+}
+
+// authModeMiddleware returns the middleware for a credential-based auth mode.
+// The db, session and hybrid modes use the API key database opened from
+// DB_PATH, the jwt mode the validator loaded from JWT_AUTH_CONFIG, and the
+// service_account mode the cluster's service account issuer keys.
+func authModeMiddleware(authMode string, backends *authBackends) gin.HandlerFunc {
 	switch authMode {
+	case "db":
+		// API key (+ TOTP if enrolled) on every request
+		return middleware.EnhancedAPIKeyAuth(backends.apiKeyDB, backends.totpManager, backends.require2FA)
+
+	case "session":
+		// Session tokens from POST /auth/login only
+		return middleware.SessionTokenAuth(backends.sessionManager, backends.apiKeyDB)
+
+	case "hybrid":
+		// Session token, or API key (+ TOTP) on every request
+		return middleware.HybridAuth(backends.sessionManager, backends.apiKeyDB, backends.totpManager, backends.require2FA)
+
+	case "jwt":
+		// JWT/OIDC bearer tokens, configured by JWT_AUTH_CONFIG
+		return middleware.JWTAuth(backends.jwtValidator)
+
 	case "api_key":
 		apiKeys := middleware.LoadAPIKeysFromEnv()
 		if len(apiKeys) == 0 {
@@ -411,7 +824,7 @@ func getAuthMiddleware(authMode string) gin.HandlerFunc {
 		if len(allowedSAs) == 0 {
 			log.Fatal("Service account auth enabled but no allowed accounts found")
 		}
-		return middleware.ServiceAccountAuth(allowedSAs)
+		return middleware.ServiceAccountAuth(backends.saValidator, allowedSAs)
 
 	default:
 		log.Printf("Unknown auth mode: %s, running without auth", authMode)
@@ -435,27 +848,38 @@ func healthHandler(checker *health.Checker) gin.HandlerFunc {
 	}
 }
 
-func readyHandler(checker *health.Checker, aiRouter *router.Router) gin.HandlerFunc {
+// readyHandler reports readiness from cached provider probes and circuit breaker
+// state. The gateway is ready while at least one provider can serve traffic.
+func readyHandler(checker *health.Checker, prober *health.Prober, aiRouter *router.Router) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Check if providers are healthy
-		healthResults := aiRouter.HealthCheck(c.Request.Context())
-		allHealthy := true
-		for _, err := range healthResults {
-			if err != nil {
-				allHealthy = false
-				break
+		providerStatus := make(gin.H)
+		available := 0
+		for name, result := range prober.Results() {
+			circuit := aiRouter.ProviderCircuitState(name)
+			if result.Healthy && circuit != router.CircuitOpen {
+				available++
+			}
+			providerStatus[name] = gin.H{
+				"healthy":    result.Healthy,
+				"error":      result.Error,
+				"checked_at": result.CheckedAt.UTC().Format(time.RFC3339),
+				"circuit":    circuit.String(),
 			}
 		}
 
-		if checker.IsHealthy() && allHealthy {
-			c.JSON(200, gin.H{
-				"status": "ready",
-			})
-		} else {
-			c.JSON(503, gin.H{
-				"status": "not ready",
-			})
+		ready := checker.IsHealthy() && available > 0
+		metrics.SetHealthStatus("readiness", ready)
+
+		status, code := "ready", 200
+		if !ready {
+			status, code = "not ready", 503
 		}
+		c.JSON(code, gin.H{
+			"status":           status,
+			"providers":        providerStatus,
+			"circuit_breakers": aiRouter.CircuitBreakerStatuses(),
+			"last_probe":       prober.LastRun().UTC().Format(time.RFC3339),
+		})
 	}
 }
 
@@ -478,7 +902,7 @@ func loadBasicAuthCredentials() map[string]string {
 func loadAllowedServiceAccounts() []string {
 	var accounts []string
 
-	// Load from ALLOWED_SERVICE_ACCOUNTS env var (format: ns1/sa1,ns2/sa2)
+	// Load from ALLOWED_SERVICE_ACCOUNTS env var (format: ns1/sa1,ns2/*)
 	if sasEnv := os.Getenv("ALLOWED_SERVICE_ACCOUNTS"); sasEnv != "" {
 		for _, sa := range strings.Split(sasEnv, ",") {
 			sa = strings.TrimSpace(sa)
@@ -498,6 +922,29 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	// Accept whole days ("7d") in addition to Go durations
+	var d time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(value)
+	}
+	if err != nil || d <= 0 {
+		log.Printf("Warning: Invalid %s %q, using %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return d
+}
+
 func printStartupBanner(port, tlsPort string, tlsEnabled, authEnabled bool, enabledProviders []string, instanceConfig *instance.Config) {
 	banner := `
 ╔══════════════════════════════════════════════════════════════╗
@@ -527,6 +974,7 @@ Configuration:
 	fmt.Println()
 	fmt.Println("API Endpoints:")
 	fmt.Printf("  • OpenAI-compatible: http://localhost:%s/v1/chat/completions\n", port)
+	fmt.Printf("  • Embeddings:        http://localhost:%s/v1/embeddings\n", port)
 	fmt.Printf("  • List models:       http://localhost:%s/v1/models\n", port)
 
 	// Show transparent mode endpoints
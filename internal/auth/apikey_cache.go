@@ -0,0 +1,101 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// DefaultKeyCacheTTL is how long a validated API key is trusted without
+// checking the database again
+const DefaultKeyCacheTTL = 30 * time.Second
+
+// keyCache holds recently validated API keys so that repeated requests skip
+// the bcrypt check. Entries are keyed by a SHA-256 digest so plaintext keys
+// are not kept in memory.
+type keyCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[[sha256.Size]byte]keyCacheEntry
+	now     func() time.Time
+}
+
+type keyCacheEntry struct {
+	key     APIKey
+	expires time.Time
+}
+
+func newKeyCache(ttl time.Duration) *keyCache {
+	return &keyCache{
+		ttl:     ttl,
+		entries: make(map[[sha256.Size]byte]keyCacheEntry),
+		now:     time.Now,
+	}
+}
+
+// get returns a copy of the cached key for apiKey, if present and fresh
+func (c *keyCache) get(apiKey string) (*APIKey, bool) {
+	digest := sha256.Sum256([]byte(apiKey))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[digest]
+	if !ok {
+		return nil, false
+	}
+	if c.now().After(entry.expires) {
+		delete(c.entries, digest)
+		return nil, false
+	}
+
+	key := entry.key
+	return &key, true
+}
+
+// put caches a validated key
+func (c *keyCache) put(apiKey string, key *APIKey) {
+	if c.ttl <= 0 {
+		return
+	}
+	digest := sha256.Sum256([]byte(apiKey))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	// Drop stale entries occasionally so keys that stop being used don't accumulate
+	if len(c.entries) >= 1024 {
+		for d, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, d)
+			}
+		}
+	}
+	c.entries[digest] = keyCacheEntry{key: *key, expires: now.Add(c.ttl)}
+}
+
+// invalidate removes every cached entry for a key ID, after it is revoked,
+// rotated or updated
+func (c *keyCache) invalidate(keyID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for digest, entry := range c.entries {
+		if entry.key.ID == keyID {
+			delete(c.entries, digest)
+		}
+	}
+}
+
+// setTTL changes the cache lifetime and clears existing entries
+func (c *keyCache) setTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ttl = ttl
+	c.entries = make(map[[sha256.Size]byte]keyCacheEntry)
+}
@@ -0,0 +1,215 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// insertLegacyKey stores a key in the pre-public-ID format, bcrypt-hashing the whole key
+func insertLegacyKey(t testing.TB, db *sql.DB, name string, cost int) string {
+	keyBytes := make([]byte, 32)
+	rand.Read(keyBytes)
+	apiKey := "bdrk_" + hex.EncodeToString(keyBytes)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(apiKey), cost)
+	if err != nil {
+		t.Fatalf("Failed to hash key: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO api_keys (key_hash, name, email, description) VALUES (?, ?, ?, ?)", string(hash), name, "", ""); err != nil {
+		t.Fatalf("Failed to insert legacy key: %v", err)
+	}
+	return apiKey
+}
+
+func TestLegacyAPIKeyMigration(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "legacy.db")
+
+	// A database created before keys carried a public ID
+	raw, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_, err = raw.Exec(`CREATE TABLE api_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		key_hash TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL,
+		email TEXT,
+		description TEXT,
+		is_active BOOLEAN DEFAULT 1,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		last_used_at TIMESTAMP,
+		expires_at TIMESTAMP,
+		permissions TEXT DEFAULT '[]',
+		metadata TEXT DEFAULT '{}'
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create legacy schema: %v", err)
+	}
+	legacyKey := insertLegacyKey(t, raw, "Legacy", bcrypt.MinCost)
+	raw.Close()
+
+	db, err := NewAPIKeyDB(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	defer db.Close()
+	db.SetCacheTTL(0)
+
+	keyInfo, err := db.ValidateAPIKey(legacyKey)
+	if err != nil {
+		t.Fatalf("Legacy key should still validate: %v", err)
+	}
+	if keyInfo.PublicID != "" {
+		t.Errorf("Legacy key should have no public ID, got %q", keyInfo.PublicID)
+	}
+	if count, _ := db.CountLegacyAPIKeys(); count != 1 {
+		t.Errorf("Expected 1 legacy key, got %d", count)
+	}
+
+	db.SetLegacyKeysAllowed(false)
+	if _, err := db.ValidateAPIKey(legacyKey); err == nil {
+		t.Error("Legacy key should be rejected when legacy keys are disabled")
+	}
+
+	rotated, err := db.RotateAPIKey(keyInfo.ID)
+	if err != nil {
+		t.Fatalf("Failed to rotate legacy key: %v", err)
+	}
+	rotatedInfo, err := db.ValidateAPIKey(rotated)
+	if err != nil {
+		t.Fatalf("Rotated key should validate: %v", err)
+	}
+	if rotatedInfo.ID != keyInfo.ID || rotatedInfo.PublicID == "" {
+		t.Errorf("Rotation should keep the key ID and assign a public ID, got %+v", rotatedInfo)
+	}
+	if count, _ := db.CountLegacyAPIKeys(); count != 0 {
+		t.Errorf("Expected no legacy keys after rotation, got %d", count)
+	}
+}
+
+func TestAPIKeyCache(t *testing.T) {
+	db, err := NewAPIKeyDB(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	db.cache.now = func() time.Time { return now }
+
+	id, apiKey, err := db.CreateAPIKey("Cached", "", "", nil, "[]", "{}")
+	if err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+
+	t.Run("Hit skips the database", func(t *testing.T) {
+		if _, err := db.ValidateAPIKey(apiKey); err != nil {
+			t.Fatalf("Failed to validate API key: %v", err)
+		}
+		// Deactivate behind the cache's back
+		db.db.Exec("UPDATE api_keys SET is_active = 0 WHERE id = ?", id)
+
+		if _, err := db.ValidateAPIKey(apiKey); err != nil {
+			t.Errorf("Expected cached key to validate: %v", err)
+		}
+
+		now = now.Add(DefaultKeyCacheTTL + time.Second)
+		if _, err := db.ValidateAPIKey(apiKey); err == nil {
+			t.Error("Expected expired cache entry to be rechecked")
+		}
+		db.db.Exec("UPDATE api_keys SET is_active = 1 WHERE id = ?", id)
+	})
+
+	t.Run("Revoke invalidates", func(t *testing.T) {
+		if _, err := db.ValidateAPIKey(apiKey); err != nil {
+			t.Fatalf("Failed to validate API key: %v", err)
+		}
+		if err := db.RevokeAPIKey(id); err != nil {
+			t.Fatalf("Failed to revoke API key: %v", err)
+		}
+		if _, err := db.ValidateAPIKey(apiKey); err == nil {
+			t.Error("Revoked key should not validate from the cache")
+		}
+	})
+
+	t.Run("Wrong secret", func(t *testing.T) {
+		_, validKey, _ := db.CreateAPIKey("Other", "", "", nil, "[]", "{}")
+		publicID, _, _ := parseAPIKey(validKey)
+		if _, err := db.ValidateAPIKey("bdrk_" + publicID + "_" + fmt.Sprintf("%064x", 0)); err == nil {
+			t.Error("Expected wrong secret to be rejected")
+		}
+	})
+}
+
+// BenchmarkValidateAPIKey compares the legacy scan, which bcrypt-checks every
+// active key, with the indexed public ID lookup. Keys use bcrypt.MinCost so
+// the database builds quickly; at DefaultCost each bcrypt check costs ~64x more.
+func BenchmarkValidateAPIKey(b *testing.B) {
+	for _, n := range []int{10, 100} {
+		db, err := NewAPIKeyDB(filepath.Join(b.TempDir(), "bench.db"))
+		if err != nil {
+			b.Fatalf("Failed to create database: %v", err)
+		}
+		db.SetCacheTTL(0)
+
+		var legacyKey string
+		for i := 0; i < n; i++ {
+			legacyKey = insertLegacyKey(b, db.db, fmt.Sprintf("legacy-%d", i), bcrypt.MinCost)
+		}
+
+		publicID, secret, hash := benchmarkKey(b)
+		db.db.Exec("INSERT INTO api_keys (public_id, key_hash, name, email, description) VALUES (?, ?, ?, ?, ?)", publicID, hash, "indexed", "", "")
+		indexedKey := "bdrk_" + publicID + "_" + secret
+
+		b.Run(fmt.Sprintf("Legacy/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := db.ValidateAPIKey(legacyKey); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("PublicID/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := db.ValidateAPIKey(indexedKey); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("Cached/%d", n), func(b *testing.B) {
+			db.SetCacheTTL(time.Minute)
+			defer db.SetCacheTTL(0)
+			for i := 0; i < b.N; i++ {
+				if _, err := db.ValidateAPIKey(indexedKey); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		db.Close()
+	}
+}
+
+// benchmarkKey creates a bdrk_<id>_<secret> key hashed at bcrypt.MinCost
+func benchmarkKey(b *testing.B) (publicID, secret, hash string) {
+	idBytes := make([]byte, 8)
+	keyBytes := make([]byte, 32)
+	rand.Read(idBytes)
+	rand.Read(keyBytes)
+	secret = hex.EncodeToString(keyBytes)
+
+	h, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.MinCost)
+	if err != nil {
+		b.Fatalf("Failed to hash key: %v", err)
+	}
+	return hex.EncodeToString(idBytes), secret, string(h)
+}
@@ -7,7 +7,9 @@ import (
 	"crypto/rand"
 	"database/sql"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -17,6 +19,7 @@ import (
 // APIKey represents an API key in the database
 type APIKey struct {
 	ID          int64
+	PublicID    string // key ID embedded in the key; empty for legacy keys
 	KeyHash     string
 	Name        string
 	Email       string
@@ -29,9 +32,20 @@ type APIKey struct {
 	Metadata    string // JSON metadata
 }
 
+// ErrAPIKeyNotFound is returned when no API key has the requested ID
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+// apiKeyPrefix starts every API key
+const apiKeyPrefix = "bdrk_"
+
+// apiKeyColumns are the columns scanned by scanAPIKey
+const apiKeyColumns = `id, public_id, key_hash, name, email, description, is_active, created_at, last_used_at, expires_at, permissions, metadata`
+
 // APIKeyDB manages API keys in SQLite
 type APIKeyDB struct {
-	db *sql.DB
+	db          *sql.DB
+	cache       *keyCache
+	allowLegacy bool
 }
 
 // NewAPIKeyDB creates a new API key database
@@ -45,6 +59,7 @@ func NewAPIKeyDB(dbPath string) (*APIKeyDB, error) {
 	schema := `
 	CREATE TABLE IF NOT EXISTS api_keys (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		public_id TEXT,
 		key_hash TEXT NOT NULL UNIQUE,
 		name TEXT NOT NULL,
 		email TEXT,
@@ -92,25 +107,76 @@ func NewAPIKeyDB(dbPath string) (*APIKeyDB, error) {
 	if _, err := db.Exec(schema); err != nil {
 		return nil, fmt.Errorf("failed to create schema: %w", err)
 	}
+	if err := migratePublicID(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
 
-	return &APIKeyDB{db: db}, nil
+	return &APIKeyDB{
+		db:          db,
+		cache:       newKeyCache(DefaultKeyCacheTTL),
+		allowLegacy: true,
+	}, nil
 }
 
-// GenerateAPIKey creates a new secure API key
-func (db *APIKeyDB) GenerateAPIKey(name, email, description string, expiresIn *time.Duration) (string, error) {
-	// Generate secure random key
-	keyBytes := make([]byte, 32)
-	if _, err := rand.Read(keyBytes); err != nil {
-		return "", fmt.Errorf("failed to generate random key: %w", err)
+// migratePublicID adds the indexed public_id column to databases created
+// before keys carried their ID. Existing keys keep a NULL public_id and are
+// validated by the legacy scan until they are rotated.
+func migratePublicID(db *sql.DB) error {
+	rows, err := db.Query("PRAGMA table_info(api_keys)")
+	if err != nil {
+		return err
+	}
+	found := false
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		if name == "public_id" {
+			found = true
+		}
+	}
+	rows.Close()
+
+	if !found {
+		if _, err := db.Exec("ALTER TABLE api_keys ADD COLUMN public_id TEXT"); err != nil {
+			return err
+		}
 	}
-	apiKey := "bdrk_" + hex.EncodeToString(keyBytes)
 
-	// Hash the key for storage (bcrypt)
-	hash, err := bcrypt.GenerateFromPassword([]byte(apiKey), bcrypt.DefaultCost)
+	_, err = db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_public_id ON api_keys(public_id)")
+	return err
+}
+
+// SetCacheTTL sets how long validated keys are cached; 0 disables the cache.
+// Revoking, rotating or updating a key clears it from this instance's cache,
+// other replicas see the change within the TTL.
+func (db *APIKeyDB) SetCacheTTL(ttl time.Duration) {
+	db.cache.setTTL(ttl)
+}
+
+// SetLegacyKeysAllowed controls whether keys issued without a public ID
+// (bdrk_<secret>) are still accepted. Validating them bcrypt-checks every
+// legacy key, so disable this once all of them have been rotated.
+func (db *APIKeyDB) SetLegacyKeysAllowed(allowed bool) {
+	db.allowLegacy = allowed
+}
+
+// CountLegacyAPIKeys returns the number of active keys without a public ID
+func (db *APIKeyDB) CountLegacyAPIKeys() (int, error) {
+	var count int
+	err := db.db.QueryRow("SELECT COUNT(*) FROM api_keys WHERE is_active = 1 AND public_id IS NULL").Scan(&count)
 	if err != nil {
-		return "", fmt.Errorf("failed to hash key: %w", err)
+		return 0, fmt.Errorf("failed to count legacy keys: %w", err)
 	}
+	return count, nil
+}
 
+// GenerateAPIKey creates a new secure API key
+func (db *APIKeyDB) GenerateAPIKey(name, email, description string, expiresIn *time.Duration) (string, error) {
 	// Calculate expiration
 	var expiresAt *time.Time
 	if expiresIn != nil {
@@ -118,26 +184,131 @@ func (db *APIKeyDB) GenerateAPIKey(name, email, description string, expiresIn *t
 		expiresAt = &exp
 	}
 
+	_, apiKey, err := db.CreateAPIKey(name, email, description, expiresAt, "[]", "{}")
+	return apiKey, err
+}
+
+// CreateAPIKey creates a new API key with permissions and metadata and returns
+// its ID and the plaintext key, which is not stored
+func (db *APIKeyDB) CreateAPIKey(name, email, description string, expiresAt *time.Time, permissions, metadata string) (int64, string, error) {
+	apiKey, publicID, hash, err := newAPIKeySecret()
+	if err != nil {
+		return 0, "", err
+	}
+
 	// Insert into database
-	_, err = db.db.Exec(`
-		INSERT INTO api_keys (key_hash, name, email, description, expires_at)
-		VALUES (?, ?, ?, ?, ?)
-	`, string(hash), name, email, description, expiresAt)
+	result, err := db.db.Exec(`
+		INSERT INTO api_keys (public_id, key_hash, name, email, description, expires_at, permissions, metadata)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, publicID, hash, name, email, description, expiresAt, permissions, metadata)
 
 	if err != nil {
-		return "", fmt.Errorf("failed to insert API key: %w", err)
+		return 0, "", fmt.Errorf("failed to insert API key: %w", err)
 	}
 
-	return apiKey, nil
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to get API key ID: %w", err)
+	}
+
+	return id, apiKey, nil
+}
+
+// newAPIKeySecret generates a random API key of the form bdrk_<id>_<secret>.
+// It returns the key, its public ID and the bcrypt hash of the secret.
+func newAPIKeySecret() (string, string, string, error) {
+	// Generate secure random ID and secret
+	idBytes := make([]byte, 8)
+	keyBytes := make([]byte, 32)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate random key: %w", err)
+	}
+	if _, err := rand.Read(keyBytes); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate random key: %w", err)
+	}
+	publicID := hex.EncodeToString(idBytes)
+	secret := hex.EncodeToString(keyBytes)
+
+	// Hash the secret for storage (bcrypt). The full key exceeds bcrypt's 72 byte limit.
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to hash key: %w", err)
+	}
+
+	return apiKeyPrefix + publicID + "_" + secret, publicID, string(hash), nil
 }
 
-// ValidateAPIKey checks if an API key is valid and returns the key info
+// parseAPIKey splits a bdrk_<id>_<secret> key; ok is false for legacy keys
+func parseAPIKey(apiKey string) (publicID, secret string, ok bool) {
+	if !strings.HasPrefix(apiKey, apiKeyPrefix) {
+		return "", "", false
+	}
+	return strings.Cut(strings.TrimPrefix(apiKey, apiKeyPrefix), "_")
+}
+
+// ValidateAPIKey checks if an API key is valid and returns the key info.
+// Keys with a public ID are looked up by index and need a single bcrypt
+// check; recently validated keys are served from the cache.
 func (db *APIKeyDB) ValidateAPIKey(apiKey string) (*APIKey, error) {
-	// Get all active keys
+	if key, ok := db.cache.get(apiKey); ok {
+		if key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt) {
+			return nil, fmt.Errorf("API key expired")
+		}
+		return key, nil
+	}
+
+	var key *APIKey
+	var err error
+	if publicID, secret, ok := parseAPIKey(apiKey); ok {
+		key, err = db.validateByPublicID(publicID, secret)
+	} else if db.allowLegacy {
+		key, err = db.validateLegacy(apiKey)
+	} else {
+		err = fmt.Errorf("invalid API key")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Check expiration
+	if key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt) {
+		return nil, fmt.Errorf("API key expired")
+	}
+
+	// Update last used timestamp (cache hits skip this, so it is accurate to the cache TTL)
+	db.db.Exec("UPDATE api_keys SET last_used_at = ? WHERE id = ?", time.Now(), key.ID)
+
+	db.cache.put(apiKey, key)
+	return key, nil
+}
+
+// validateByPublicID checks a secret against the key with the given public ID
+func (db *APIKeyDB) validateByPublicID(publicID, secret string) (*APIKey, error) {
+	key, err := scanAPIKey(db.db.QueryRow(`
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE public_id = ? AND is_active = 1
+	`, publicID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("invalid API key")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query key: %w", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(key.KeyHash), []byte(secret)); err != nil {
+		return nil, fmt.Errorf("invalid API key")
+	}
+	return key, nil
+}
+
+// validateLegacy checks a key issued before public IDs against every active
+// legacy key
+func (db *APIKeyDB) validateLegacy(apiKey string) (*APIKey, error) {
 	rows, err := db.db.Query(`
-		SELECT id, key_hash, name, email, description, is_active, created_at, last_used_at, expires_at, permissions, metadata
+		SELECT ` + apiKeyColumns + `
 		FROM api_keys
-		WHERE is_active = 1
+		WHERE is_active = 1 AND public_id IS NULL
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query keys: %w", err)
@@ -146,55 +317,151 @@ func (db *APIKeyDB) ValidateAPIKey(apiKey string) (*APIKey, error) {
 
 	// Check each key with constant-time comparison
 	for rows.Next() {
-		var key APIKey
-		var lastUsed, expires sql.NullTime
-
-		err := rows.Scan(
-			&key.ID, &key.KeyHash, &key.Name, &key.Email, &key.Description,
-			&key.IsActive, &key.CreatedAt, &lastUsed, &expires,
-			&key.Permissions, &key.Metadata,
-		)
+		key, err := scanAPIKey(rows)
 		if err != nil {
 			continue
 		}
 
-		if lastUsed.Valid {
-			key.LastUsedAt = &lastUsed.Time
-		}
-		if expires.Valid {
-			key.ExpiresAt = &expires.Time
-		}
-
 		// Check if key matches (constant-time comparison via bcrypt)
 		if err := bcrypt.CompareHashAndPassword([]byte(key.KeyHash), []byte(apiKey)); err == nil {
-			// Check expiration
-			if key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt) {
-				return nil, fmt.Errorf("API key expired")
-			}
-
-			// Update last used timestamp
-			db.db.Exec("UPDATE api_keys SET last_used_at = ? WHERE id = ?", time.Now(), key.ID)
-
-			return &key, nil
+			return key, nil
 		}
 	}
 
 	return nil, fmt.Errorf("invalid API key")
 }
 
+// scanAPIKey reads a row selected with apiKeyColumns
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (*APIKey, error) {
+	var key APIKey
+	var publicID sql.NullString
+	var lastUsed, expires sql.NullTime
+
+	err := row.Scan(
+		&key.ID, &publicID, &key.KeyHash, &key.Name, &key.Email, &key.Description,
+		&key.IsActive, &key.CreatedAt, &lastUsed, &expires,
+		&key.Permissions, &key.Metadata,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	key.PublicID = publicID.String
+	if lastUsed.Valid {
+		key.LastUsedAt = &lastUsed.Time
+	}
+	if expires.Valid {
+		key.ExpiresAt = &expires.Time
+	}
+
+	return &key, nil
+}
+
 // RevokeAPIKey deactivates an API key
 func (db *APIKeyDB) RevokeAPIKey(keyID int64) error {
 	_, err := db.db.Exec("UPDATE api_keys SET is_active = 0 WHERE id = ?", keyID)
+	db.cache.invalidate(keyID)
 	if err != nil {
 		return fmt.Errorf("failed to revoke key: %w", err)
 	}
 	return nil
 }
 
+// RotateAPIKey replaces an active key's ID and secret and returns the new
+// plaintext key. The old key stops working immediately. Rotating a legacy
+// key moves it to the bdrk_<id>_<secret> format.
+func (db *APIKeyDB) RotateAPIKey(keyID int64) (string, error) {
+	apiKey, publicID, hash, err := newAPIKeySecret()
+	if err != nil {
+		return "", err
+	}
+
+	result, err := db.db.Exec("UPDATE api_keys SET public_id = ?, key_hash = ? WHERE id = ? AND is_active = 1", publicID, hash, keyID)
+	db.cache.invalidate(keyID)
+	if err != nil {
+		return "", fmt.Errorf("failed to rotate key: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return "", ErrAPIKeyNotFound
+	}
+
+	return apiKey, nil
+}
+
+// APIKeyUpdate holds the fields to change on an API key; nil fields are left as is
+type APIKeyUpdate struct {
+	Name           *string
+	Description    *string
+	ExpiresAt      *time.Time
+	ClearExpiresAt bool // remove the expiry
+	Permissions    *string
+	Metadata       *string
+}
+
+// UpdateAPIKey changes an API key's details
+func (db *APIKeyDB) UpdateAPIKey(keyID int64, update APIKeyUpdate) error {
+	var sets []string
+	var args []interface{}
+	if update.Name != nil {
+		sets = append(sets, "name = ?")
+		args = append(args, *update.Name)
+	}
+	if update.Description != nil {
+		sets = append(sets, "description = ?")
+		args = append(args, *update.Description)
+	}
+	if update.ClearExpiresAt {
+		sets = append(sets, "expires_at = NULL")
+	} else if update.ExpiresAt != nil {
+		sets = append(sets, "expires_at = ?")
+		args = append(args, *update.ExpiresAt)
+	}
+	if update.Permissions != nil {
+		sets = append(sets, "permissions = ?")
+		args = append(args, *update.Permissions)
+	}
+	if update.Metadata != nil {
+		sets = append(sets, "metadata = ?")
+		args = append(args, *update.Metadata)
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+
+	args = append(args, keyID)
+	result, err := db.db.Exec("UPDATE api_keys SET "+strings.Join(sets, ", ")+" WHERE id = ?", args...)
+	db.cache.invalidate(keyID)
+	if err != nil {
+		return fmt.Errorf("failed to update key: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// GetAPIKey returns API key info by ID, including revoked keys (for admin)
+func (db *APIKeyDB) GetAPIKey(id int64) (*APIKey, error) {
+	key, err := scanAPIKey(db.db.QueryRow(`
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE id = ?
+	`, id))
+
+	if err == sql.ErrNoRows {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return key, nil
+}
+
 // ListAPIKeys returns all API keys (for admin)
 func (db *APIKeyDB) ListAPIKeys() ([]APIKey, error) {
 	rows, err := db.db.Query(`
-		SELECT id, key_hash, name, email, description, is_active, created_at, last_used_at, expires_at, permissions, metadata
+		SELECT ` + apiKeyColumns + `
 		FROM api_keys
 		ORDER BY created_at DESC
 	`)
@@ -205,26 +472,12 @@ func (db *APIKeyDB) ListAPIKeys() ([]APIKey, error) {
 
 	var keys []APIKey
 	for rows.Next() {
-		var key APIKey
-		var lastUsed, expires sql.NullTime
-
-		err := rows.Scan(
-			&key.ID, &key.KeyHash, &key.Name, &key.Email, &key.Description,
-			&key.IsActive, &key.CreatedAt, &lastUsed, &expires,
-			&key.Permissions, &key.Metadata,
-		)
+		key, err := scanAPIKey(rows)
 		if err != nil {
 			continue
 		}
 
-		if lastUsed.Valid {
-			key.LastUsedAt = &lastUsed.Time
-		}
-		if expires.Valid {
-			key.ExpiresAt = &expires.Time
-		}
-
-		keys = append(keys, key)
+		keys = append(keys, *key)
 	}
 
 	return keys, nil
@@ -242,19 +495,12 @@ func (db *APIKeyDB) LogAPIKeyUsage(keyID int64, action, ip, userAgent, path stri
 
 // GetAPIKeyByEmail returns API key info by email
 func (db *APIKeyDB) GetAPIKeyByEmail(email string) (*APIKey, error) {
-	var key APIKey
-	var lastUsed, expires sql.NullTime
-
-	err := db.db.QueryRow(`
-		SELECT id, key_hash, name, email, description, is_active, created_at, last_used_at, expires_at, permissions, metadata
+	key, err := scanAPIKey(db.db.QueryRow(`
+		SELECT `+apiKeyColumns+`
 		FROM api_keys
 		WHERE email = ? AND is_active = 1
 		LIMIT 1
-	`, email).Scan(
-		&key.ID, &key.KeyHash, &key.Name, &key.Email, &key.Description,
-		&key.IsActive, &key.CreatedAt, &lastUsed, &expires,
-		&key.Permissions, &key.Metadata,
-	)
+	`, email))
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("no active API key found for email: %s", email)
@@ -263,31 +509,17 @@ func (db *APIKeyDB) GetAPIKeyByEmail(email string) (*APIKey, error) {
 		return nil, fmt.Errorf("failed to get API key: %w", err)
 	}
 
-	if lastUsed.Valid {
-		key.LastUsedAt = &lastUsed.Time
-	}
-	if expires.Valid {
-		key.ExpiresAt = &expires.Time
-	}
-
-	return &key, nil
+	return key, nil
 }
 
 // GetAPIKeyByID returns API key info by ID
 func (db *APIKeyDB) GetAPIKeyByID(id int64) (*APIKey, error) {
-	var key APIKey
-	var lastUsed, expires sql.NullTime
-
-	err := db.db.QueryRow(`
-		SELECT id, key_hash, name, email, description, is_active, created_at, last_used_at, expires_at, permissions, metadata
+	key, err := scanAPIKey(db.db.QueryRow(`
+		SELECT `+apiKeyColumns+`
 		FROM api_keys
 		WHERE id = ? AND is_active = 1
 		LIMIT 1
-	`, id).Scan(
-		&key.ID, &key.KeyHash, &key.Name, &key.Email, &key.Description,
-		&key.IsActive, &key.CreatedAt, &lastUsed, &expires,
-		&key.Permissions, &key.Metadata,
-	)
+	`, id))
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("API key not found: %d", id)
@@ -296,14 +528,13 @@ func (db *APIKeyDB) GetAPIKeyByID(id int64) (*APIKey, error) {
 		return nil, fmt.Errorf("failed to get API key: %w", err)
 	}
 
-	if lastUsed.Valid {
-		key.LastUsedAt = &lastUsed.Time
-	}
-	if expires.Valid {
-		key.ExpiresAt = &expires.Time
-	}
+	return key, nil
+}
 
-	return &key, nil
+// DB returns the underlying database so that related tables (2FA, usage)
+// can share the same file
+func (db *APIKeyDB) DB() *sql.DB {
+	return db.db
 }
 
 // Close closes the database connection
@@ -4,7 +4,9 @@
 package auth
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -32,8 +34,8 @@ func TestAPIKeyDB(t *testing.T) {
 			t.Errorf("API key should start with 'bdrk_', got: %s", apiKey[:5])
 		}
 
-		if len(apiKey) != 69 { // bdrk_ (5) + 64 hex chars
-			t.Errorf("API key should be 69 chars, got: %d", len(apiKey))
+		if len(apiKey) != 86 { // bdrk_ (5) + 16 hex ID + _ + 64 hex chars
+			t.Errorf("API key should be 86 chars, got: %d", len(apiKey))
 		}
 	})
 
@@ -251,3 +253,202 @@ func TestTOTP(t *testing.T) {
 		}
 	})
 }
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	db, err := NewAPIKeyDB(filepath.Join(t.TempDir(), "apikeys.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	id, apiKey, err := db.CreateAPIKey("Admin", "admin@example.com", "Admin key", nil, `["admin"]`, `{"team":"ops"}`)
+	if err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+
+	t.Run("CreateAPIKey", func(t *testing.T) {
+		keyInfo, err := db.ValidateAPIKey(apiKey)
+		if err != nil {
+			t.Fatalf("Failed to validate API key: %v", err)
+		}
+		if keyInfo.ID != id || keyInfo.Permissions != `["admin"]` || keyInfo.Metadata != `{"team":"ops"}` {
+			t.Errorf("Unexpected key info: %+v", keyInfo)
+		}
+	})
+
+	t.Run("UpdateAPIKey", func(t *testing.T) {
+		name := "Renamed"
+		permissions := `["admin","models"]`
+		expiresAt := time.Now().Add(time.Hour)
+		err := db.UpdateAPIKey(id, APIKeyUpdate{Name: &name, Permissions: &permissions, ExpiresAt: &expiresAt})
+		if err != nil {
+			t.Fatalf("Failed to update API key: %v", err)
+		}
+
+		keyInfo, err := db.GetAPIKey(id)
+		if err != nil {
+			t.Fatalf("Failed to get API key: %v", err)
+		}
+		if keyInfo.Name != name || keyInfo.Permissions != permissions || keyInfo.ExpiresAt == nil {
+			t.Errorf("Update not applied: %+v", keyInfo)
+		}
+		if keyInfo.Description != "Admin key" || keyInfo.Metadata != `{"team":"ops"}` {
+			t.Errorf("Unchanged fields were modified: %+v", keyInfo)
+		}
+
+		if err := db.UpdateAPIKey(id, APIKeyUpdate{ClearExpiresAt: true}); err != nil {
+			t.Fatalf("Failed to clear expiry: %v", err)
+		}
+		if keyInfo, _ := db.GetAPIKey(id); keyInfo.ExpiresAt != nil {
+			t.Errorf("Expected expiry to be cleared, got %v", keyInfo.ExpiresAt)
+		}
+
+		if err := db.UpdateAPIKey(9999, APIKeyUpdate{Name: &name}); !errors.Is(err, ErrAPIKeyNotFound) {
+			t.Errorf("Expected ErrAPIKeyNotFound, got %v", err)
+		}
+	})
+
+	t.Run("RotateAPIKey", func(t *testing.T) {
+		rotated, err := db.RotateAPIKey(id)
+		if err != nil {
+			t.Fatalf("Failed to rotate API key: %v", err)
+		}
+		if rotated == apiKey {
+			t.Fatal("Rotation should issue a new key")
+		}
+
+		if _, err := db.ValidateAPIKey(apiKey); err == nil {
+			t.Error("Old key should not validate after rotation")
+		}
+		if keyInfo, err := db.ValidateAPIKey(rotated); err != nil || keyInfo.ID != id {
+			t.Errorf("Rotated key should validate as key %d: %v", id, err)
+		}
+	})
+
+	t.Run("GetRevokedAPIKey", func(t *testing.T) {
+		if err := db.RevokeAPIKey(id); err != nil {
+			t.Fatalf("Failed to revoke API key: %v", err)
+		}
+
+		keyInfo, err := db.GetAPIKey(id)
+		if err != nil {
+			t.Fatalf("Failed to get revoked API key: %v", err)
+		}
+		if keyInfo.IsActive {
+			t.Error("Key should be inactive")
+		}
+
+		if _, err := db.RotateAPIKey(id); !errors.Is(err, ErrAPIKeyNotFound) {
+			t.Errorf("Rotating a revoked key should fail with ErrAPIKeyNotFound, got %v", err)
+		}
+		if _, err := db.GetAPIKey(9999); !errors.Is(err, ErrAPIKeyNotFound) {
+			t.Errorf("Expected ErrAPIKeyNotFound, got %v", err)
+		}
+	})
+}
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		permissions string
+		expected    bool
+	}{
+		{`["admin"]`, true},
+		{`["models","admin"]`, true},
+		{`["models"]`, false},
+		{`[]`, false},
+		{``, false},
+		{`not json`, false},
+	}
+
+	for _, tt := range tests {
+		if got := HasPermission(tt.permissions, PermissionAdmin); got != tt.expected {
+			t.Errorf("HasPermission(%q) = %v, expected %v", tt.permissions, got, tt.expected)
+		}
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	t.Run("Scopes", func(t *testing.T) {
+		tests := []struct {
+			permissions string
+			scope       string
+			expected    bool
+		}{
+			{``, ScopeOpenAI, true},
+			{`[]`, ScopeAdmin, false},
+			{`["admin"]`, ScopeOpenAI, true},
+			{`["admin"]`, ScopeAdmin, true},
+			{`["openai"]`, ScopeTransparent, false},
+			{`{"scopes":["openai","providers"]}`, ScopeProviders, true},
+			{`{"scopes":["openai"]}`, ScopeAdmin, false},
+		}
+		for _, tt := range tests {
+			p, err := ParsePermissions(tt.permissions)
+			if err != nil {
+				t.Fatalf("ParsePermissions(%q): %v", tt.permissions, err)
+			}
+			if got := p.AllowsScope(tt.scope); got != tt.expected {
+				t.Errorf("%s AllowsScope(%q) = %v, expected %v", tt.permissions, tt.scope, got, tt.expected)
+			}
+		}
+	})
+
+	t.Run("Models and providers", func(t *testing.T) {
+		p, err := ParsePermissions(`{
+			"models": {"allow": ["claude-*", "gpt-4o*"], "deny": ["*opus*"]},
+			"providers": ["bedrock", "anthropic"],
+			"max_tokens": 2048
+		}`)
+		if err != nil {
+			t.Fatalf("Failed to parse permissions: %v", err)
+		}
+
+		if !p.AllowsModel("claude-3-sonnet") || !p.AllowsModel("gpt-4o-mini") {
+			t.Error("Expected allowed models to pass")
+		}
+		if p.AllowsModel("claude-3-opus") {
+			t.Error("Expected denied model to be refused")
+		}
+		if p.AllowsModel("sonnet", "anthropic.claude-3-opus-20240229-v1:0") {
+			t.Error("Expected deny match on the provider model ID to refuse")
+		}
+		if !p.AllowsModel("my-alias", "claude-3-haiku") {
+			t.Error("Expected allow match on the provider model ID to permit")
+		}
+		if p.AllowsModel("llama-3") {
+			t.Error("Expected model outside the allow list to be refused")
+		}
+		if !p.AllowsProvider("bedrock") || p.AllowsProvider("openai") {
+			t.Error("Unexpected provider allowlist result")
+		}
+		if p.MaxTokens != 2048 {
+			t.Errorf("Expected max_tokens 2048, got %d", p.MaxTokens)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, value := range []string{`"admin"`, `{"max_tokens":-1}`, `{"scopes":"admin"}`} {
+			if _, err := ParsePermissions(value); err == nil {
+				t.Errorf("Expected error for %s", value)
+			}
+		}
+	})
+
+	t.Run("MatchGlob", func(t *testing.T) {
+		tests := []struct {
+			pattern, name string
+			expected      bool
+		}{
+			{"*", "meta-llama/Llama-3-70b", true},
+			{"claude-3-?aiku", "claude-3-haiku", true},
+			{"gpt-4*", "gpt-35-turbo", false},
+			{"*-opus-*", "claude-3-opus-20240229", true},
+			{"bedrock", "bedrock-us", false},
+		}
+		for _, tt := range tests {
+			if got := MatchGlob(tt.pattern, tt.name); got != tt.expected {
+				t.Errorf("MatchGlob(%q, %q) = %v, expected %v", tt.pattern, tt.name, got, tt.expected)
+			}
+		}
+	})
+}
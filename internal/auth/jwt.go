@@ -0,0 +1,380 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidToken is wrapped by every JWT validation failure
+var ErrInvalidToken = errors.New("invalid token")
+
+// JWTConfig configures bearer token validation for AUTH_MODE=jwt
+type JWTConfig struct {
+	Issuer   string   `yaml:"issuer"`   // required iss, if set
+	Audience []string `yaml:"audience"` // accepted aud values, if any
+
+	// HS256 shared secret and/or RS256/ES256 keys from a JWKS URL or file
+	HS256Secret string        `yaml:"hs256_secret"`
+	JWKSURL     string        `yaml:"jwks_url"`
+	JWKSFile    string        `yaml:"jwks_file"`
+	JWKSRefresh time.Duration `yaml:"jwks_refresh"`
+
+	// Leeway tolerates clock skew when checking exp and nbf
+	Leeway time.Duration `yaml:"leeway"`
+
+	Claims JWTClaimNames `yaml:"claims"`
+
+	// GroupPermissions map group claims to permissions; the first rule
+	// naming one of the token's groups applies, else DefaultPermissions.
+	// Without either the token is not restricted (admin is never granted).
+	GroupPermissions   []GroupPermissions `yaml:"group_permissions"`
+	DefaultPermissions *Permissions       `yaml:"default_permissions"`
+}
+
+// JWTClaimNames names the claims holding the identity. Dotted names reach
+// into nested objects, e.g. realm_access.roles.
+type JWTClaimNames struct {
+	User   string `yaml:"user"`
+	Email  string `yaml:"email"`
+	Groups string `yaml:"groups"`
+}
+
+// GroupPermissions grants permissions to members of a group (a glob pattern)
+type GroupPermissions struct {
+	Group       string      `yaml:"group"`
+	Permissions Permissions `yaml:"permissions"`
+}
+
+// JWTIdentity is the caller described by a validated token
+type JWTIdentity struct {
+	User   string
+	Email  string
+	Groups []string
+	Claims map[string]interface{}
+}
+
+// LoadJWTConfig loads the JWT configuration from a YAML file
+func LoadJWTConfig(path string) (*JWTConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var wrapper struct {
+		JWT JWTConfig `yaml:"jwt"`
+	}
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), &wrapper); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return &wrapper.JWT, nil
+}
+
+// JWTValidator verifies bearer tokens against a JWTConfig
+type JWTValidator struct {
+	config   *JWTConfig
+	audience []string
+	secret   []byte
+	keys     *KeySet
+	now      func() time.Time
+}
+
+// NewJWTValidator checks the configuration and loads the JWKS, if any
+func NewJWTValidator(jwtConfig *JWTConfig) (*JWTValidator, error) {
+	var keys *KeySet
+	if jwtConfig.JWKSURL != "" || jwtConfig.JWKSFile != "" {
+		refresh := jwtConfig.JWKSRefresh
+		if refresh == 0 {
+			refresh = time.Hour
+		}
+		var err error
+		keys, err = NewKeySet(jwtConfig.JWKSURL, jwtConfig.JWKSFile, refresh, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return newJWTValidator(jwtConfig, keys)
+}
+
+// newJWTValidator creates a validator using an already loaded key set
+func newJWTValidator(jwtConfig *JWTConfig, keys *KeySet) (*JWTValidator, error) {
+	config := *jwtConfig
+	v := &JWTValidator{
+		config: &config,
+		secret: []byte(config.HS256Secret),
+		keys:   keys,
+		now:    time.Now,
+	}
+	// Drop entries left empty by unset environment variables
+	for _, aud := range config.Audience {
+		if aud != "" {
+			v.audience = append(v.audience, aud)
+		}
+	}
+
+	if len(v.secret) > 0 && len(v.secret) < sha256.Size {
+		return nil, fmt.Errorf("hs256_secret must be at least %d bytes", sha256.Size)
+	}
+	if len(v.secret) == 0 && v.keys == nil {
+		return nil, errors.New("hs256_secret, jwks_url or jwks_file is required")
+	}
+
+	if config.Claims.User == "" {
+		config.Claims.User = "sub"
+	}
+	if config.Claims.Email == "" {
+		config.Claims.Email = "email"
+	}
+	if config.Claims.Groups == "" {
+		config.Claims.Groups = "groups"
+	}
+	for _, rule := range config.GroupPermissions {
+		if rule.Group == "" {
+			return nil, errors.New("group_permissions entries require a group")
+		}
+		if rule.Permissions.MaxTokens < 0 {
+			return nil, fmt.Errorf("group %q: max_tokens must not be negative", rule.Group)
+		}
+	}
+
+	return v, nil
+}
+
+// Validate verifies a token's signature, issuer, audience and validity period
+// and returns the identity it carries
+func (v *JWTValidator) Validate(ctx context.Context, token string) (*JWTIdentity, error) {
+	claims, err := v.verify(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	identity := &JWTIdentity{
+		User:   claimString(claims, v.config.Claims.User),
+		Email:  claimString(claims, v.config.Claims.Email),
+		Groups: claimStrings(claims, v.config.Claims.Groups),
+		Claims: claims,
+	}
+	if identity.User == "" {
+		return nil, fmt.Errorf("%w: missing %s claim", ErrInvalidToken, v.config.Claims.User)
+	}
+	return identity, nil
+}
+
+// Permissions returns the permissions JSON for a set of groups, or false if
+// the caller is unrestricted
+func (v *JWTValidator) Permissions(groups []string) (string, bool) {
+	perms := v.config.DefaultPermissions
+	for i, rule := range v.config.GroupPermissions {
+		if groupMatches(rule.Group, groups) {
+			perms = &v.config.GroupPermissions[i].Permissions
+			break
+		}
+	}
+	if perms == nil {
+		return "", false
+	}
+
+	data, err := json.Marshal(perms)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+func groupMatches(pattern string, groups []string) bool {
+	for _, group := range groups {
+		if MatchGlob(pattern, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// verify checks the token signature and returns its claims
+func (v *JWTValidator) verify(ctx context.Context, token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("signature: %w", err)
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	switch header.Alg {
+	case "HS256":
+		if len(v.secret) == 0 {
+			return nil, errors.New("HS256 tokens are not accepted")
+		}
+		mac := hmac.New(sha256.New, v.secret)
+		mac.Write(signed)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return nil, errors.New("signature mismatch")
+		}
+
+	case "RS256", "ES256":
+		if v.keys == nil {
+			return nil, fmt.Errorf("%s tokens are not accepted", header.Alg)
+		}
+		key, err := v.keys.Key(ctx, header.Kid)
+		if err != nil {
+			return nil, err
+		}
+		if err := verifySignature(header.Alg, key, signed, signature); err != nil {
+			return nil, err
+		}
+
+	default:
+		// Includes "none"
+		return nil, fmt.Errorf("unsupported algorithm %q", header.Alg)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+	return claims, nil
+}
+
+// verifySignature checks an RS256 or ES256 signature
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	digest := sha256.Sum256(signed)
+
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("key is not an RSA key")
+		}
+		if err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("signature mismatch")
+		}
+
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || ecKey.Curve != elliptic.P256() {
+			return errors.New("key is not a P-256 key")
+		}
+		// JWS encodes the signature as fixed-width r || s
+		if len(signature) != 64 {
+			return errors.New("malformed signature")
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecKey, digest[:], r, s) {
+			return errors.New("signature mismatch")
+		}
+
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	return nil
+}
+
+// checkClaims checks iss, aud, exp and nbf
+func (v *JWTValidator) checkClaims(claims map[string]interface{}) error {
+	if v.config.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.config.Issuer {
+			return fmt.Errorf("unexpected issuer %q", iss)
+		}
+	}
+
+	if len(v.audience) > 0 {
+		matched := false
+		for _, aud := range claimStrings(claims, "aud") {
+			if contains(v.audience, aud) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return errors.New("token is not intended for this audience")
+		}
+	}
+
+	now := v.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("missing exp claim")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(v.config.Leeway)) {
+		return errors.New("token has expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(v.config.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("token is not valid yet")
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// claimValue looks up a claim by dotted path
+func claimValue(claims map[string]interface{}, path string) interface{} {
+	var value interface{} = claims
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+	return value
+}
+
+func claimString(claims map[string]interface{}, path string) string {
+	value, _ := claimValue(claims, path).(string)
+	return value
+}
+
+// claimStrings reads a claim holding an array of strings or a space or comma
+// separated string
+func claimStrings(claims map[string]interface{}, path string) []string {
+	switch value := claimValue(claims, path).(type) {
+	case string:
+		return strings.FieldsFunc(value, func(r rune) bool { return r == ' ' || r == ',' })
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
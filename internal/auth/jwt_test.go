@@ -0,0 +1,285 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// signJWT builds a token signed with an HMAC secret, RSA key or EC key
+func signJWT(t *testing.T, alg, kid string, key interface{}, claims map[string]interface{}) string {
+	t.Helper()
+
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("Failed to sign: %v", err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatalf("Failed to sign: %v", err)
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// jwksDocument serializes public keys as a JWKS
+func jwksDocument(keys map[string]interface{}) []byte {
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	for kid, key := range keys {
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, map[string]string{
+				"kty": "RSA", "kid": kid, "use": "sig",
+				"n": encode(k.N.Bytes()), "e": encode(big.NewInt(int64(k.E)).Bytes()),
+			})
+		case *ecdsa.PublicKey:
+			set.Keys = append(set.Keys, map[string]string{
+				"kty": "EC", "kid": kid, "crv": "P-256",
+				"x": encode(k.X.FillBytes(make([]byte, 32))), "y": encode(k.Y.FillBytes(make([]byte, 32))),
+			})
+		}
+	}
+	data, _ := json.Marshal(set)
+	return data
+}
+
+func validClaims() map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"iss":    "https://issuer.example.com",
+		"aud":    []string{"other", "ai-gateway"},
+		"sub":    "alice",
+		"email":  "alice@example.com",
+		"groups": []string{"ml-research", "staff"},
+		"exp":    now.Add(time.Hour).Unix(),
+		"nbf":    now.Add(-time.Minute).Unix(),
+	}
+}
+
+func TestJWTValidator(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rotatedKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	var mu sync.Mutex
+	published := map[string]interface{}{"rsa-1": &rsaKey.PublicKey, "ec-1": &ecKey.PublicKey}
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetches++
+		w.Write(jwksDocument(published))
+	}))
+	defer server.Close()
+
+	validator, err := NewJWTValidator(&JWTConfig{
+		Issuer:      "https://issuer.example.com",
+		Audience:    []string{"ai-gateway", ""},
+		HS256Secret: testSecret,
+		JWKSURL:     server.URL,
+		Leeway:      30 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	ctx := context.Background()
+
+	t.Run("Algorithms", func(t *testing.T) {
+		tokens := map[string]string{
+			"HS256": signJWT(t, "HS256", "", []byte(testSecret), validClaims()),
+			"RS256": signJWT(t, "RS256", "rsa-1", rsaKey, validClaims()),
+			"ES256": signJWT(t, "ES256", "ec-1", ecKey, validClaims()),
+		}
+		for alg, token := range tokens {
+			identity, err := validator.Validate(ctx, token)
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", alg, err)
+				continue
+			}
+			if identity.User != "alice" || identity.Email != "alice@example.com" {
+				t.Errorf("%s: unexpected identity %+v", alg, identity)
+			}
+			if len(identity.Groups) != 2 || identity.Groups[0] != "ml-research" {
+				t.Errorf("%s: unexpected groups %v", alg, identity.Groups)
+			}
+		}
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		claims := func(change func(map[string]interface{})) map[string]interface{} {
+			c := validClaims()
+			change(c)
+			return c
+		}
+		now := time.Now()
+		parts := strings.Split(signJWT(t, "RS256", "rsa-1", rsaKey, validClaims()), ".")
+		other := strings.Split(signJWT(t, "RS256", "rsa-1", rsaKey, claims(func(c map[string]interface{}) { c["sub"] = "mallory" })), ".")
+		tampered := parts[0] + "." + other[1] + "." + parts[2]
+		tokens := map[string]string{
+			"wrong secret":    signJWT(t, "HS256", "", []byte(strings.Repeat("x", 32)), validClaims()),
+			"wrong issuer":    signJWT(t, "HS256", "", []byte(testSecret), claims(func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" })),
+			"wrong audience":  signJWT(t, "HS256", "", []byte(testSecret), claims(func(c map[string]interface{}) { c["aud"] = "other" })),
+			"expired":         signJWT(t, "HS256", "", []byte(testSecret), claims(func(c map[string]interface{}) { c["exp"] = now.Add(-time.Minute).Unix() })),
+			"no exp":          signJWT(t, "HS256", "", []byte(testSecret), claims(func(c map[string]interface{}) { delete(c, "exp") })),
+			"not yet valid":   signJWT(t, "HS256", "", []byte(testSecret), claims(func(c map[string]interface{}) { c["nbf"] = now.Add(time.Minute).Unix() })),
+			"no subject":      signJWT(t, "HS256", "", []byte(testSecret), claims(func(c map[string]interface{}) { delete(c, "sub") })),
+			"key type":        signJWT(t, "ES256", "rsa-1", ecKey, validClaims()),
+			"unsigned":        signJWT(t, "none", "", nil, validClaims()),
+			"malformed":       "not.a-token",
+			"tampered claims": tampered,
+		}
+		for name, token := range tokens {
+			if _, err := validator.Validate(ctx, token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("Leeway", func(t *testing.T) {
+		c := validClaims()
+		c["exp"] = time.Now().Add(-10 * time.Second).Unix()
+		if _, err := validator.Validate(ctx, signJWT(t, "HS256", "", []byte(testSecret), c)); err != nil {
+			t.Errorf("Token within leeway should be accepted: %v", err)
+		}
+	})
+
+	t.Run("KeyRotation", func(t *testing.T) {
+		token := signJWT(t, "RS256", "rsa-2", rotatedKey, validClaims())
+
+		// Unknown key IDs are refetched at most once a minute
+		if _, err := validator.Validate(ctx, token); !errors.Is(err, ErrUnknownKey) {
+			t.Fatalf("Expected ErrUnknownKey before rotation, got %v", err)
+		}
+
+		mu.Lock()
+		published = map[string]interface{}{"rsa-2": &rotatedKey.PublicKey}
+		before := fetches
+		mu.Unlock()
+
+		validator.keys.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+		defer func() { validator.keys.now = time.Now }()
+
+		if _, err := validator.Validate(ctx, token); err != nil {
+			t.Fatalf("Rotated key should be fetched: %v", err)
+		}
+		mu.Lock()
+		if fetches != before+1 {
+			t.Errorf("Expected one JWKS fetch, got %d", fetches-before)
+		}
+		mu.Unlock()
+	})
+}
+
+func TestJWTValidatorConfig(t *testing.T) {
+	if _, err := NewJWTValidator(&JWTConfig{}); err == nil {
+		t.Error("Expected an error without a secret or JWKS")
+	}
+	if _, err := NewJWTValidator(&JWTConfig{HS256Secret: "short"}); err == nil {
+		t.Error("Expected an error for a short secret")
+	}
+
+	// RS256 is refused when only a secret is configured
+	validator, err := NewJWTValidator(&JWTConfig{HS256Secret: testSecret})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	if _, err := validator.Validate(context.Background(), signJWT(t, "RS256", "", rsaKey, validClaims())); err == nil {
+		t.Error("Expected RS256 token to be refused without a JWKS")
+	}
+}
+
+func TestJWTClaimsAndGroups(t *testing.T) {
+	validator, err := NewJWTValidator(&JWTConfig{
+		HS256Secret: testSecret,
+		Claims:      JWTClaimNames{User: "preferred_username", Email: "mail", Groups: "realm_access.roles"},
+		GroupPermissions: []GroupPermissions{
+			{Group: "admins", Permissions: Permissions{Scopes: []string{ScopeOpenAI, ScopeProviders}}},
+			{Group: "ml-*", Permissions: Permissions{Scopes: []string{ScopeOpenAI}, MaxTokens: 4096}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	claims := validClaims()
+	claims["preferred_username"] = "alice.smith"
+	claims["mail"] = "asmith@example.com"
+	claims["realm_access"] = map[string]interface{}{"roles": "ml-research staff"}
+
+	identity, err := validator.Validate(context.Background(), signJWT(t, "HS256", "", []byte(testSecret), claims))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if identity.User != "alice.smith" || identity.Email != "asmith@example.com" {
+		t.Errorf("Unexpected identity %+v", identity)
+	}
+
+	raw, ok := validator.Permissions(identity.Groups)
+	if !ok {
+		t.Fatal("Expected permissions for ml-research")
+	}
+	perms, err := ParsePermissions(raw)
+	if err != nil {
+		t.Fatalf("Mapped permissions should parse: %v", err)
+	}
+	if perms.MaxTokens != 4096 || !perms.AllowsScope(ScopeOpenAI) || perms.AllowsScope(ScopeProviders) {
+		t.Errorf("Unexpected permissions %s", raw)
+	}
+
+	// First matching rule wins
+	raw, _ = validator.Permissions([]string{"ml-research", "admins"})
+	if perms, _ := ParsePermissions(raw); !perms.AllowsScope(ScopeProviders) {
+		t.Errorf("Expected the admins rule, got %s", raw)
+	}
+
+	// No rule and no default: unrestricted
+	if _, ok := validator.Permissions([]string{"staff"}); ok {
+		t.Error("Expected no permissions for unmatched groups")
+	}
+}
@@ -0,0 +1,317 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrCertRevoked is returned for client certificates listed in the CRL
+var ErrCertRevoked = errors.New("client certificate has been revoked")
+
+// MTLSConfig configures client certificate authentication
+type MTLSConfig struct {
+	Enabled      bool   `yaml:"enabled"`
+	ClientCAFile string `yaml:"client_ca_file"`
+
+	// CRLFile holds the CRLs of the client CAs: PEM with one CRL per CA, or
+	// a single DER CRL. It is reloaded when the file changes, checked at most
+	// every CRLRefresh.
+	CRLFile    string        `yaml:"crl_file"`
+	CRLRefresh time.Duration `yaml:"crl_refresh"`
+
+	// Identities admit certificates; the first matching rule applies and
+	// certificates matching none are refused
+	Identities []CertIdentityRule `yaml:"identities"`
+}
+
+// CertIdentityRule maps certificates to a gateway identity. Exactly one of
+// CN, URI (SAN URI such as a SPIFFE ID) or DNS (SAN DNS name) is set, as a
+// glob pattern.
+type CertIdentityRule struct {
+	CN  string `yaml:"cn"`
+	URI string `yaml:"uri"`
+	DNS string `yaml:"dns"`
+
+	// Identity names the caller; defaults to the matched CN, URI or DNS name
+	Identity    string       `yaml:"identity"`
+	Permissions *Permissions `yaml:"permissions"`
+}
+
+// CertIdentity is the caller a client certificate was mapped to
+type CertIdentity struct {
+	Name        string
+	Subject     string // the matched value, e.g. uri:spiffe://cluster.local/ns/a/sa/b
+	Permissions string // permissions JSON; empty when the rule sets none
+}
+
+// LoadMTLSConfig loads the mTLS configuration from a YAML file
+func LoadMTLSConfig(path string) (*MTLSConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var wrapper struct {
+		MTLS MTLSConfig `yaml:"mtls"`
+	}
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), &wrapper); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return &wrapper.MTLS, nil
+}
+
+// ClientCertVerifier checks client certificates against the CA bundle and CRL
+// and maps them to identities
+type ClientCertVerifier struct {
+	config *MTLSConfig
+	pool   *x509.CertPool
+	cas    []*x509.Certificate
+
+	mu         sync.RWMutex
+	revoked    map[revokedCert]bool
+	crlModTime time.Time
+	crlChecked time.Time
+	now        func() time.Time
+}
+
+// NewClientCertVerifier loads the client CA bundle and CRL
+func NewClientCertVerifier(config *MTLSConfig) (*ClientCertVerifier, error) {
+	if config.ClientCAFile == "" {
+		return nil, errors.New("client_ca_file is required")
+	}
+	data, err := os.ReadFile(config.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA bundle: %w", err)
+	}
+
+	v := &ClientCertVerifier{
+		config:  config,
+		pool:    x509.NewCertPool(),
+		revoked: make(map[revokedCert]bool),
+		now:     time.Now,
+	}
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		ca, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client CA certificate: %w", err)
+		}
+		v.pool.AddCert(ca)
+		v.cas = append(v.cas, ca)
+	}
+	if len(v.cas) == 0 {
+		return nil, errors.New("client CA bundle contains no certificates")
+	}
+
+	if len(config.Identities) == 0 {
+		return nil, errors.New("at least one identity rule is required")
+	}
+	for i, rule := range config.Identities {
+		set := 0
+		for _, pattern := range []string{rule.CN, rule.URI, rule.DNS} {
+			if pattern != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return nil, fmt.Errorf("identity rule %d must set exactly one of cn, uri or dns", i+1)
+		}
+		if rule.Permissions != nil && rule.Permissions.MaxTokens < 0 {
+			return nil, fmt.Errorf("identity rule %d: max_tokens must not be negative", i+1)
+		}
+	}
+
+	if config.CRLFile != "" {
+		if config.CRLRefresh == 0 {
+			config.CRLRefresh = 5 * time.Minute
+		}
+		if err := v.loadCRL(); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// TLSConfig returns a server TLS configuration that requests client
+// certificates, verifies them against the CA bundle and rejects revoked ones.
+// Certificates are optional at the TLS layer so that unauthenticated routes
+// such as /health stay reachable; ClientCertAuth requires them.
+func (v *ClientCertVerifier) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientCAs:  v.pool,
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return nil
+			}
+			return v.CheckRevocation(cs.PeerCertificates[0])
+		},
+	}
+}
+
+// revokedCert identifies a certificate by issuer and serial number, since
+// serial numbers are only unique per CA
+type revokedCert struct {
+	issuer string // raw DER of the issuer name
+	serial string
+}
+
+// CheckRevocation returns ErrCertRevoked if the certificate is in its issuer's CRL
+func (v *ClientCertVerifier) CheckRevocation(cert *x509.Certificate) error {
+	if v.config.CRLFile == "" {
+		return nil
+	}
+	v.refreshCRL()
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.revoked[revokedCert{issuer: string(cert.RawIssuer), serial: cert.SerialNumber.String()}] {
+		return ErrCertRevoked
+	}
+	return nil
+}
+
+// Identify maps a verified client certificate to an identity
+func (v *ClientCertVerifier) Identify(cert *x509.Certificate) (*CertIdentity, error) {
+	for _, rule := range v.config.Identities {
+		subject, value, ok := rule.match(cert)
+		if !ok {
+			continue
+		}
+
+		identity := &CertIdentity{Name: rule.Identity, Subject: subject + ":" + value}
+		if identity.Name == "" {
+			identity.Name = value
+		}
+		if rule.Permissions != nil {
+			data, err := json.Marshal(rule.Permissions)
+			if err != nil {
+				return nil, err
+			}
+			identity.Permissions = string(data)
+		}
+		return identity, nil
+	}
+	return nil, fmt.Errorf("certificate %q matches no allowed identity", cert.Subject.String())
+}
+
+// match returns the certificate field and value a rule matched
+func (r *CertIdentityRule) match(cert *x509.Certificate) (subject, value string, ok bool) {
+	switch {
+	case r.CN != "":
+		if cert.Subject.CommonName != "" && MatchGlob(r.CN, cert.Subject.CommonName) {
+			return "cn", cert.Subject.CommonName, true
+		}
+	case r.URI != "":
+		for _, uri := range cert.URIs {
+			if MatchGlob(r.URI, uri.String()) {
+				return "uri", uri.String(), true
+			}
+		}
+	case r.DNS != "":
+		for _, name := range cert.DNSNames {
+			if MatchGlob(r.DNS, name) {
+				return "dns", name, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// refreshCRL reloads the CRL file if it changed. A CRL that fails to load
+// keeps the previous list in force.
+func (v *ClientCertVerifier) refreshCRL() {
+	v.mu.RLock()
+	due := v.now().Sub(v.crlChecked) >= v.config.CRLRefresh
+	v.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	if err := v.loadCRL(); err != nil {
+		log.Printf("Failed to reload CRL, keeping the previous one: %v", err)
+	}
+}
+
+func (v *ClientCertVerifier) loadCRL() error {
+	v.mu.Lock()
+	v.crlChecked = v.now()
+	lastModTime := v.crlModTime
+	v.mu.Unlock()
+
+	info, err := os.Stat(v.config.CRLFile)
+	if err != nil {
+		return fmt.Errorf("failed to read CRL: %w", err)
+	}
+	if info.ModTime().Equal(lastModTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(v.config.CRLFile)
+	if err != nil {
+		return fmt.Errorf("failed to read CRL: %w", err)
+	}
+	var crls [][]byte
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == "X509 CRL" {
+			crls = append(crls, block.Bytes)
+		}
+	}
+	if len(crls) == 0 {
+		crls = [][]byte{data}
+	}
+
+	revoked := make(map[revokedCert]bool)
+	for _, der := range crls {
+		crl, err := x509.ParseRevocationList(der)
+		if err != nil {
+			return fmt.Errorf("invalid CRL: %w", err)
+		}
+		ca := v.crlIssuer(crl)
+		if ca == nil {
+			return fmt.Errorf("CRL for %q is not signed by a client CA", crl.Issuer.String())
+		}
+		if !crl.NextUpdate.IsZero() && v.now().After(crl.NextUpdate) {
+			log.Printf("Warning: CRL %s for %q is past its next update time (%s)",
+				v.config.CRLFile, crl.Issuer.String(), crl.NextUpdate.Format(time.RFC3339))
+		}
+
+		for _, entry := range crl.RevokedCertificateEntries {
+			revoked[revokedCert{issuer: string(ca.RawSubject), serial: entry.SerialNumber.String()}] = true
+		}
+	}
+
+	v.mu.Lock()
+	v.revoked = revoked
+	v.crlModTime = info.ModTime()
+	v.mu.Unlock()
+	log.Printf("Loaded %d CRLs with %d revoked certificates", len(crls), len(revoked))
+	return nil
+}
+
+// crlIssuer returns the client CA that issued and signed a CRL
+func (v *ClientCertVerifier) crlIssuer(crl *x509.RevocationList) *x509.Certificate {
+	for _, ca := range v.cas {
+		if bytes.Equal(crl.RawIssuer, ca.RawSubject) && crl.CheckSignatureFrom(ca) == nil {
+			return ca
+		}
+	}
+	return nil
+}
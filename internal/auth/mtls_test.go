@@ -0,0 +1,270 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create CA: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key}
+}
+
+// issue creates a client certificate; uri and dns may be empty
+func (ca *testCA) issue(t *testing.T, serial int64, cn, uri, dns string) tls.Certificate {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if uri != "" {
+		u, _ := url.Parse(uri)
+		template.URIs = []*url.URL{u}
+	}
+	if dns != "" {
+		template.DNSNames = []string{dns}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("Failed to issue certificate: %v", err)
+	}
+	leaf, _ := x509.ParseCertificate(der)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// writeCRL writes a PEM CRL revoking serials
+func (ca *testCA) writeCRL(t *testing.T, path string, number int64, serials ...int64) {
+	t.Helper()
+	if err := os.WriteFile(path, ca.crl(t, number, serials...), 0600); err != nil {
+		t.Fatalf("Failed to write CRL: %v", err)
+	}
+}
+
+// crl returns a PEM CRL revoking serials
+func (ca *testCA) crl(t *testing.T, number int64, serials ...int64) []byte {
+	t.Helper()
+	var entries []x509.RevocationListEntry
+	for _, serial := range serials {
+		entries = append(entries, x509.RevocationListEntry{SerialNumber: big.NewInt(serial), RevocationTime: time.Now()})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(number),
+		ThisUpdate:                time.Now(),
+		NextUpdate:                time.Now().Add(time.Hour),
+		RevokedCertificateEntries: entries,
+	}, ca.cert, ca.key)
+	if err != nil {
+		t.Fatalf("Failed to create CRL: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+}
+
+func TestClientCertVerifier(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "Test Client CA")
+	caFile := filepath.Join(dir, "ca.crt")
+	crlFile := filepath.Join(dir, "ca.crl")
+	os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0600)
+	ca.writeCRL(t, crlFile, 1, 99)
+
+	verifier, err := NewClientCertVerifier(&MTLSConfig{
+		Enabled:      true,
+		ClientCAFile: caFile,
+		CRLFile:      crlFile,
+		Identities: []CertIdentityRule{
+			{CN: "billing-*", Identity: "billing", Permissions: &Permissions{Scopes: []string{ScopeOpenAI}}},
+			{URI: "spiffe://cluster.local/ns/ml/sa/*"},
+			{DNS: "*.internal.example.com"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create verifier: %v", err)
+	}
+
+	t.Run("Identify", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			cert        tls.Certificate
+			wantName    string
+			wantSubject string
+			wantPerms   bool
+		}{
+			{"cn", ca.issue(t, 10, "billing-api", "", ""), "billing", "cn:billing-api", true},
+			{"spiffe", ca.issue(t, 11, "", "spiffe://cluster.local/ns/ml/sa/trainer", ""), "spiffe://cluster.local/ns/ml/sa/trainer", "uri:spiffe://cluster.local/ns/ml/sa/trainer", false},
+			{"dns", ca.issue(t, 12, "", "", "reports.internal.example.com"), "reports.internal.example.com", "dns:reports.internal.example.com", false},
+		}
+		for _, tt := range tests {
+			identity, err := verifier.Identify(tt.cert.Leaf)
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+				continue
+			}
+			if identity.Name != tt.wantName || identity.Subject != tt.wantSubject || (identity.Permissions != "") != tt.wantPerms {
+				t.Errorf("%s: unexpected identity %+v", tt.name, identity)
+			}
+		}
+
+		if _, err := verifier.Identify(ca.issue(t, 13, "intruder", "spiffe://cluster.local/ns/other/sa/x", "").Leaf); err == nil {
+			t.Error("Expected unmatched certificate to be refused")
+		}
+	})
+
+	t.Run("CRLReload", func(t *testing.T) {
+		cert := ca.issue(t, 20, "billing-api", "", "")
+		if err := verifier.CheckRevocation(cert.Leaf); err != nil {
+			t.Fatalf("Certificate should not be revoked yet: %v", err)
+		}
+		if err := verifier.CheckRevocation(ca.issue(t, 99, "billing-api", "", "").Leaf); !errors.Is(err, ErrCertRevoked) {
+			t.Errorf("Expected ErrCertRevoked, got %v", err)
+		}
+
+		ca.writeCRL(t, crlFile, 2, 99, 20)
+		later := time.Now().Add(time.Minute)
+		os.Chtimes(crlFile, later, later)
+		verifier.now = func() time.Time { return time.Now().Add(10 * time.Minute) }
+
+		if err := verifier.CheckRevocation(cert.Leaf); !errors.Is(err, ErrCertRevoked) {
+			t.Errorf("Expected ErrCertRevoked after CRL reload, got %v", err)
+		}
+	})
+
+	t.Run("Handshake", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(r.TLS.PeerCertificates) == 0 {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}))
+		server.TLS = verifier.TLSConfig()
+		server.StartTLS()
+		defer server.Close()
+
+		get := func(certs ...tls.Certificate) (*http.Response, error) {
+			transport := server.Client().Transport.(*http.Transport).Clone()
+			transport.TLSClientConfig.Certificates = certs
+			return (&http.Client{Transport: transport}).Get(server.URL)
+		}
+
+		resp, err := get(ca.issue(t, 30, "billing-api", "", ""))
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected a trusted certificate to be accepted, got %v", err)
+		}
+		resp, err = get()
+		if err != nil || resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected the handler to see no certificate, got %v", err)
+		}
+		if _, err := get(ca.issue(t, 20, "billing-api", "", "")); err == nil {
+			t.Error("Expected a revoked certificate to fail the handshake")
+		}
+		if _, err := get(newTestCA(t, "Test Client CA").issue(t, 31, "billing-api", "", "")); err == nil {
+			t.Error("Expected a certificate from another CA to fail the handshake")
+		}
+	})
+}
+
+func TestCRLIssuers(t *testing.T) {
+	dir := t.TempDir()
+	teamA, teamB := newTestCA(t, "Team A CA"), newTestCA(t, "Team B CA")
+	caFile := filepath.Join(dir, "ca.crt")
+	crlFile := filepath.Join(dir, "ca.crl")
+	os.WriteFile(caFile, append(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: teamA.cert.Raw}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: teamB.cert.Raw})...), 0600)
+	config := &MTLSConfig{
+		ClientCAFile: caFile,
+		CRLFile:      crlFile,
+		Identities:   []CertIdentityRule{{CN: "*"}},
+	}
+
+	t.Run("Serials are revoked per issuer", func(t *testing.T) {
+		os.WriteFile(crlFile, append(teamA.crl(t, 1, 40), teamB.crl(t, 1, 50)...), 0600)
+		verifier, err := NewClientCertVerifier(config)
+		if err != nil {
+			t.Fatalf("Failed to create verifier: %v", err)
+		}
+
+		tests := []struct {
+			name        string
+			cert        tls.Certificate
+			wantRevoked bool
+		}{
+			{"revoked by team A", teamA.issue(t, 40, "a", "", ""), true},
+			{"same serial from team B", teamB.issue(t, 40, "b", "", ""), false},
+			{"revoked by team B", teamB.issue(t, 50, "b", "", ""), true},
+			{"same serial from team A", teamA.issue(t, 50, "a", "", ""), false},
+		}
+		for _, tt := range tests {
+			err := verifier.CheckRevocation(tt.cert.Leaf)
+			if errors.Is(err, ErrCertRevoked) != tt.wantRevoked {
+				t.Errorf("%s: CheckRevocation() = %v, want revoked %v", tt.name, err, tt.wantRevoked)
+			}
+		}
+	})
+
+	t.Run("CRL must be signed by its issuer", func(t *testing.T) {
+		// Team B signs a CRL that names team A as its issuer
+		der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:     big.NewInt(2),
+			ThisUpdate: time.Now(),
+			NextUpdate: time.Now().Add(time.Hour),
+			RevokedCertificateEntries: []x509.RevocationListEntry{
+				{SerialNumber: big.NewInt(40), RevocationTime: time.Now()},
+			},
+		}, &x509.Certificate{
+			Subject:            teamA.cert.Subject,
+			RawSubject:         teamA.cert.RawSubject,
+			PublicKey:          teamB.cert.PublicKey,
+			KeyUsage:           x509.KeyUsageCRLSign,
+			SubjectKeyId:       teamB.cert.SubjectKeyId,
+			SignatureAlgorithm: teamB.cert.SignatureAlgorithm,
+		}, teamB.key)
+		if err != nil {
+			t.Fatalf("Failed to create CRL: %v", err)
+		}
+		os.WriteFile(crlFile, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), 0600)
+
+		if _, err := NewClientCertVerifier(config); err == nil {
+			t.Error("Expected a CRL not signed by its issuer to be rejected")
+		}
+	})
+}
@@ -0,0 +1,176 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Route groups an API key can be scoped to
+const (
+	ScopeOpenAI      = "openai"
+	ScopeTransparent = "transparent"
+	ScopeProtocol    = "protocol"
+	ScopeProviders   = "providers"
+	ScopeStorage     = "storage"
+	ScopeAdmin       = "admin"
+)
+
+// PermissionAdmin grants access to the /admin API
+const PermissionAdmin = ScopeAdmin
+
+// routeScopes are the scopes granted when a key lists none of them
+var routeScopes = []string{ScopeOpenAI, ScopeTransparent, ScopeProtocol, ScopeProviders, ScopeStorage}
+
+// Permissions is the access policy stored in an API key's permissions column,
+// either a JSON array of scopes (["admin"]) or an object:
+//
+//	{
+//	  "scopes": ["openai", "admin"],
+//	  "models": {"allow": ["gpt-4o*", "claude-*"], "deny": ["*opus*"]},
+//	  "providers": ["bedrock", "anthropic"],
+//	  "max_tokens": 4096
+//	}
+//
+// Route groups are all allowed unless scopes names at least one of them, and
+// admin must always be granted explicitly. Empty lists allow everything.
+type Permissions struct {
+	Scopes    []string   `json:"scopes" yaml:"scopes"`
+	Models    ModelRules `json:"models" yaml:"models"`
+	Providers []string   `json:"providers" yaml:"providers"`
+	MaxTokens int        `json:"max_tokens" yaml:"max_tokens"` // ceiling on max_tokens; 0 means none
+}
+
+// ModelRules are glob patterns (* and ?) matched against model names
+type ModelRules struct {
+	Allow []string `json:"allow" yaml:"allow"`
+	Deny  []string `json:"deny" yaml:"deny"`
+}
+
+// ParsePermissions parses a permissions column value. An empty value grants
+// every route group except admin.
+func ParsePermissions(value string) (*Permissions, error) {
+	var p Permissions
+
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "":
+		return &p, nil
+	case strings.HasPrefix(value, "["):
+		if err := json.Unmarshal([]byte(value), &p.Scopes); err != nil {
+			return nil, fmt.Errorf("invalid permissions: %w", err)
+		}
+	case strings.HasPrefix(value, "{"):
+		if err := json.Unmarshal([]byte(value), &p); err != nil {
+			return nil, fmt.Errorf("invalid permissions: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid permissions: expected a JSON array or object")
+	}
+
+	if p.MaxTokens < 0 {
+		return nil, fmt.Errorf("invalid permissions: max_tokens must not be negative")
+	}
+	return &p, nil
+}
+
+// AllowsScope reports whether the route group scope may be used
+func (p *Permissions) AllowsScope(scope string) bool {
+	if contains(p.Scopes, scope) {
+		return true
+	}
+	if scope == ScopeAdmin {
+		return false
+	}
+
+	// Keys that name no route group may use all of them
+	for _, s := range routeScopes {
+		if contains(p.Scopes, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// AllowsModel reports whether a model may be used. names are the requested
+// model and, once routed, the provider's model ID; a deny match on any name
+// refuses the model, an allow match on any name permits it.
+func (p *Permissions) AllowsModel(names ...string) bool {
+	for _, name := range names {
+		if name != "" && matchAny(p.Models.Deny, name) {
+			return false
+		}
+	}
+	if len(p.Models.Allow) == 0 {
+		return true
+	}
+	for _, name := range names {
+		if name != "" && matchAny(p.Models.Allow, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsProvider reports whether a provider may serve requests
+func (p *Permissions) AllowsProvider(provider string) bool {
+	return len(p.Providers) == 0 || contains(p.Providers, provider)
+}
+
+// HasPermission reports whether a key's permissions JSON grants permission
+func HasPermission(permissions, permission string) bool {
+	p, err := ParsePermissions(permissions)
+	if err != nil {
+		return false
+	}
+	return contains(p.Scopes, permission)
+}
+
+// MatchGlob reports whether name matches pattern, where * matches any run of
+// characters (including /) and ? matches one character
+func MatchGlob(pattern, name string) bool {
+	// Iterative matching with backtracking to the last *
+	p, n := 0, 0
+	star, mark := -1, 0
+	for n < len(name) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == name[n]):
+			p++
+			n++
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, n
+			p++
+		case star >= 0:
+			p = star + 1
+			mark++
+			n = mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if MatchGlob(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// In-cluster credentials used to read the API server's JWKS
+const (
+	inClusterTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	inClusterCAFile    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+// DefaultServiceAccountJWKSURL is the API server's service account issuer JWKS
+const DefaultServiceAccountJWKSURL = "https://kubernetes.default.svc/openid/v1/jwks"
+
+// ServiceAccountConfig configures Kubernetes service account token validation
+type ServiceAccountConfig struct {
+	Issuer   string // required iss, if set
+	Audience string // audience the tokens are projected for
+	JWKSURL  string
+	JWKSFile string // used instead of JWKSURL when set
+	Refresh  time.Duration
+}
+
+// ServiceAccount identifies the workload a projected token was issued to
+type ServiceAccount struct {
+	Namespace string
+	Name      string
+	Pod       string
+}
+
+// String returns namespace/name
+func (sa *ServiceAccount) String() string {
+	return sa.Namespace + "/" + sa.Name
+}
+
+// ServiceAccountValidator verifies projected Kubernetes service account tokens
+// against the cluster's OIDC issuer keys
+type ServiceAccountValidator struct {
+	jwt *JWTValidator
+}
+
+// NewServiceAccountValidator loads the issuer keys. JWKS requests to the API
+// server authenticate with the pod's own service account token.
+func NewServiceAccountValidator(config ServiceAccountConfig) (*ServiceAccountValidator, error) {
+	if config.Audience == "" {
+		return nil, errors.New("a service account token audience is required")
+	}
+	if config.Refresh == 0 {
+		config.Refresh = time.Hour
+	}
+
+	ks := &KeySet{
+		file:    config.JWKSFile,
+		refresh: config.Refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		now:     time.Now,
+	}
+	if config.JWKSFile == "" {
+		ks.url = config.JWKSURL
+		if ks.url == "" {
+			ks.url = DefaultServiceAccountJWKSURL
+		}
+		if strings.HasPrefix(ks.url, "https://kubernetes.default.svc") {
+			client, err := inClusterClient()
+			if err != nil {
+				return nil, err
+			}
+			ks.client = client
+			ks.headers = inClusterHeaders
+		}
+	}
+	if err := ks.load(context.Background()); err != nil {
+		return nil, err
+	}
+
+	jwt, err := newJWTValidator(&JWTConfig{
+		Issuer:   config.Issuer,
+		Audience: []string{config.Audience},
+	}, ks)
+	if err != nil {
+		return nil, err
+	}
+	return &ServiceAccountValidator{jwt: jwt}, nil
+}
+
+// Validate verifies a token's signature, audience and expiry and returns the
+// service account named by its kubernetes.io claims
+func (v *ServiceAccountValidator) Validate(ctx context.Context, token string) (*ServiceAccount, error) {
+	identity, err := v.jwt.Validate(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Projected tokens carry {"kubernetes.io": {"namespace": ..., "serviceaccount": {"name": ...}}}
+	k8s, _ := identity.Claims["kubernetes.io"].(map[string]interface{})
+	sa := &ServiceAccount{
+		Namespace: claimString(k8s, "namespace"),
+		Name:      claimString(k8s, "serviceaccount.name"),
+		Pod:       claimString(k8s, "pod.name"),
+	}
+	if sa.Namespace == "" || sa.Name == "" {
+		return nil, fmt.Errorf("%w: not a projected service account token", ErrInvalidToken)
+	}
+	if identity.User != "system:serviceaccount:"+sa.Namespace+":"+sa.Name {
+		return nil, fmt.Errorf("%w: subject does not match the service account", ErrInvalidToken)
+	}
+	return sa, nil
+}
+
+// ServiceAccountAllowed reports whether namespace/name matches an allowlist
+// entry; entries are globs such as team-a/*
+func ServiceAccountAllowed(allowed []string, sa *ServiceAccount) bool {
+	return matchAny(allowed, sa.String())
+}
+
+// inClusterClient trusts the cluster CA for requests to the API server
+func inClusterClient() (*http.Client, error) {
+	pem, err := os.ReadFile(inClusterCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cluster CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("cluster CA contains no certificates")
+	}
+
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+		},
+	}, nil
+}
+
+// inClusterHeaders reads the pod's token on every fetch, as kubelet rotates it
+func inClusterHeaders() map[string]string {
+	token, err := os.ReadFile(inClusterTokenFile)
+	if err != nil {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + strings.TrimSpace(string(token))}
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func serviceAccountClaims(namespace, name, audience string) map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"iss": "https://kubernetes.default.svc.cluster.local",
+		"aud": []string{audience},
+		"sub": "system:serviceaccount:" + namespace + ":" + name,
+		"exp": now.Add(time.Hour).Unix(),
+		"nbf": now.Add(-time.Minute).Unix(),
+		"kubernetes.io": map[string]interface{}{
+			"namespace":      namespace,
+			"serviceaccount": map[string]interface{}{"name": name, "uid": "0b7e6c1e"},
+			"pod":            map[string]interface{}{"name": name + "-7d9f8", "uid": "a1f3"},
+		},
+	}
+}
+
+func TestServiceAccountValidator(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksFile, jwksDocument(map[string]interface{}{"k8s-1": &key.PublicKey}), 0600); err != nil {
+		t.Fatalf("Failed to write JWKS: %v", err)
+	}
+
+	validator, err := NewServiceAccountValidator(ServiceAccountConfig{
+		Issuer:   "https://kubernetes.default.svc.cluster.local",
+		Audience: "bedrock-proxy",
+		JWKSFile: jwksFile,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	ctx := context.Background()
+
+	t.Run("Valid", func(t *testing.T) {
+		token := signJWT(t, "RS256", "k8s-1", key, serviceAccountClaims("team-a", "worker", "bedrock-proxy"))
+		sa, err := validator.Validate(ctx, token)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if sa.String() != "team-a/worker" || sa.Pod != "worker-7d9f8" {
+			t.Errorf("Unexpected service account %+v", sa)
+		}
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		expired := serviceAccountClaims("team-a", "worker", "bedrock-proxy")
+		expired["exp"] = time.Now().Add(-time.Hour).Unix()
+		mismatched := serviceAccountClaims("team-a", "worker", "bedrock-proxy")
+		mismatched["sub"] = "system:serviceaccount:kube-system:admin"
+		notProjected := serviceAccountClaims("team-a", "worker", "bedrock-proxy")
+		delete(notProjected, "kubernetes.io")
+		otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+		tokens := map[string]string{
+			"wrong audience": signJWT(t, "RS256", "k8s-1", key, serviceAccountClaims("team-a", "worker", "kubernetes")),
+			"expired":        signJWT(t, "RS256", "k8s-1", key, expired),
+			"subject":        signJWT(t, "RS256", "k8s-1", key, mismatched),
+			"not projected":  signJWT(t, "RS256", "k8s-1", key, notProjected),
+			"wrong key":      signJWT(t, "RS256", "k8s-1", otherKey, serviceAccountClaims("team-a", "worker", "bedrock-proxy")),
+			"hs256":          signJWT(t, "HS256", "", []byte(testSecret), serviceAccountClaims("team-a", "worker", "bedrock-proxy")),
+		}
+		for name, token := range tokens {
+			if _, err := validator.Validate(ctx, token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("Allowlist", func(t *testing.T) {
+		allowed := []string{"team-a/*", "ops/deployer"}
+		tests := []struct {
+			sa   ServiceAccount
+			want bool
+		}{
+			{ServiceAccount{Namespace: "team-a", Name: "worker"}, true},
+			{ServiceAccount{Namespace: "ops", Name: "deployer"}, true},
+			{ServiceAccount{Namespace: "ops", Name: "debugger"}, false},
+			{ServiceAccount{Namespace: "team-ab", Name: "worker"}, false},
+		}
+		for _, tt := range tests {
+			if got := ServiceAccountAllowed(allowed, &tt.sa); got != tt.want {
+				t.Errorf("ServiceAccountAllowed(%s) = %v, want %v", tt.sa.String(), got, tt.want)
+			}
+		}
+	})
+
+	if _, err := NewServiceAccountValidator(ServiceAccountConfig{JWKSFile: jwksFile}); err == nil {
+		t.Error("Expected an error without an audience")
+	}
+}
@@ -7,10 +7,14 @@ import (
 	"crypto/rand"
 	"database/sql"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"time"
 )
 
+// SessionTokenPrefix starts every session token
+const SessionTokenPrefix = "bdrk_sess_"
+
 // SessionToken represents a temporary session token
 type SessionToken struct {
 	ID         int64
@@ -68,7 +72,7 @@ func (m *SessionManager) GenerateSessionToken(
 	}
 
 	// Create alphanumeric token with prefix
-	token := "bdrk_sess_" + base64.URLEncoding.EncodeToString(tokenBytes)
+	token := SessionTokenPrefix + base64.URLEncoding.EncodeToString(tokenBytes)
 
 	// Calculate expiration
 	expiresAt := time.Now().Add(duration)
@@ -99,7 +103,7 @@ func (m *SessionManager) ValidateSessionToken(token string) (*SessionToken, int6
 			ak.id as api_key_id
 		FROM session_tokens st
 		JOIN api_keys ak ON st.api_key_id = ak.id
-		WHERE st.token = ? AND st.is_active = 1
+		WHERE st.token = ? AND st.is_active = 1 AND ak.is_active = 1
 	`, token).Scan(
 		&session.ID, &session.Token, &session.APIKeyID,
 		&session.CreatedAt, &session.ExpiresAt, &lastUsed,
@@ -135,6 +139,21 @@ func (m *SessionManager) RevokeSessionToken(token string) error {
 	return err
 }
 
+// ErrSessionNotFound is returned when a session does not exist or belongs to another API key
+var ErrSessionNotFound = errors.New("session not found")
+
+// RevokeUserSession revokes one of an API key's sessions by ID
+func (m *SessionManager) RevokeUserSession(apiKeyID, sessionID int64) error {
+	result, err := m.db.Exec("UPDATE session_tokens SET is_active = 0 WHERE id = ? AND api_key_id = ? AND is_active = 1", sessionID, apiKeyID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
 // RevokeAllUserSessions revokes all sessions for a specific API key
 func (m *SessionManager) RevokeAllUserSessions(apiKeyID int64) error {
 	_, err := m.db.Exec("UPDATE session_tokens SET is_active = 0 WHERE api_key_id = ?", apiKeyID)
@@ -0,0 +1,75 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionManager(t *testing.T) {
+	db, err := NewAPIKeyDB(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	sessionManager := NewSessionManager(db.DB())
+	aliceID, _, _ := db.CreateAPIKey("Alice", "", "", nil, "[]", "{}")
+	bobID, _, _ := db.CreateAPIKey("Bob", "", "", nil, "[]", "{}")
+
+	t.Run("GenerateAndValidate", func(t *testing.T) {
+		token, err := sessionManager.GenerateSessionToken(aliceID, time.Hour, "127.0.0.1", "test")
+		if err != nil {
+			t.Fatalf("Failed to generate session token: %v", err)
+		}
+		if !strings.HasPrefix(token, SessionTokenPrefix) {
+			t.Errorf("Session token should start with %q, got %q", SessionTokenPrefix, token)
+		}
+
+		_, apiKeyID, err := sessionManager.ValidateSessionToken(token)
+		if err != nil {
+			t.Fatalf("Failed to validate session token: %v", err)
+		}
+		if apiKeyID != aliceID {
+			t.Errorf("Expected API key %d, got %d", aliceID, apiKeyID)
+		}
+	})
+
+	t.Run("RevokeUserSession", func(t *testing.T) {
+		token, _ := sessionManager.GenerateSessionToken(aliceID, time.Hour, "", "")
+		session, _, err := sessionManager.ValidateSessionToken(token)
+		if err != nil {
+			t.Fatalf("Failed to validate session token: %v", err)
+		}
+
+		// Another key cannot revoke Alice's session
+		if err := sessionManager.RevokeUserSession(bobID, session.ID); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("Expected ErrSessionNotFound, got %v", err)
+		}
+		if _, _, err := sessionManager.ValidateSessionToken(token); err != nil {
+			t.Errorf("Session should still be valid: %v", err)
+		}
+
+		if err := sessionManager.RevokeUserSession(aliceID, session.ID); err != nil {
+			t.Fatalf("Failed to revoke session: %v", err)
+		}
+		if _, _, err := sessionManager.ValidateSessionToken(token); err == nil {
+			t.Error("Revoked session should not validate")
+		}
+	})
+
+	t.Run("RevokedAPIKey", func(t *testing.T) {
+		token, _ := sessionManager.GenerateSessionToken(bobID, time.Hour, "", "")
+		if err := db.RevokeAPIKey(bobID); err != nil {
+			t.Fatalf("Failed to revoke API key: %v", err)
+		}
+		if _, _, err := sessionManager.ValidateSessionToken(token); err == nil {
+			t.Error("Sessions of a revoked API key should not validate")
+		}
+	})
+}
@@ -0,0 +1,278 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Budget periods
+const (
+	PeriodDaily   = "daily"
+	PeriodMonthly = "monthly"
+)
+
+// defaultThresholds are the spend fractions that trigger notifications
+var defaultThresholds = []float64{0.8, 1.0}
+
+// Limits are daily and monthly USD budgets. Zero means "not set here" and
+// falls through to the next source; a negative value means unlimited.
+type Limits struct {
+	DailyUSD   float64 `yaml:"daily_usd" json:"daily_usd"`
+	MonthlyUSD float64 `yaml:"monthly_usd" json:"monthly_usd"`
+}
+
+// Config is the budget configuration loaded from YAML
+type Config struct {
+	Enabled bool `yaml:"enabled"`
+
+	Global     Limits            `yaml:"global"`
+	Teams      map[string]Limits `yaml:"teams"`
+	APIKeys    map[string]Limits `yaml:"api_keys"` // by API key ID
+	Users      map[string]Limits `yaml:"users"`
+	DefaultKey Limits            `yaml:"default_key"` // for keys and users without their own budget
+
+	// Webhook notified when spend crosses a threshold fraction of a budget
+	WebhookURL string    `yaml:"webhook_url"`
+	Thresholds []float64 `yaml:"thresholds"`
+}
+
+// LoadConfig loads the budget configuration from a YAML file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var wrapper struct {
+		Budgets Config `yaml:"budgets"`
+	}
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), &wrapper); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	config := &wrapper.Budgets
+	if len(config.Thresholds) == 0 {
+		config.Thresholds = defaultThresholds
+	}
+	for _, threshold := range config.Thresholds {
+		if threshold <= 0 {
+			return nil, fmt.Errorf("budget thresholds must be positive, got %v", threshold)
+		}
+	}
+
+	return config, nil
+}
+
+// Subject identifies who a request is charged to
+type Subject struct {
+	KeyID    int64  // API key ID, 0 when not authenticated by a database key
+	User     string // authenticated user, or client IP
+	Metadata string // api_keys.metadata JSON; may set "team" and "budget"
+}
+
+// Scope is a budget a request is charged against
+type Scope struct {
+	Name   string // key:<id>, user:<name>, team:<name> or global
+	Limits Limits
+}
+
+// Denial explains why a request was refused
+type Denial struct {
+	Scope     string
+	Period    string
+	LimitUSD  float64
+	SpendUSD  float64 // with the reservations of requests in flight when Estimated is set
+	Estimated float64 // estimated maximum cost, 0 when the budget is already exhausted
+}
+
+// Message returns a client-facing description of the denial
+func (d *Denial) Message() string {
+	remaining := d.LimitUSD - d.SpendUSD
+	if d.Estimated > 0 && remaining > 0 {
+		return fmt.Sprintf("Estimated maximum cost $%.4f exceeds the remaining %s budget of $%.4f for %s. Lower max_tokens or try again later.",
+			d.Estimated, d.Period, remaining, d.Scope)
+	}
+	return fmt.Sprintf("The %s budget of $%.2f for %s has been exhausted.", d.Period, d.LimitUSD, d.Scope)
+}
+
+// PriceFunc returns a model's output price per 1M tokens, if known
+type PriceFunc func(ctx context.Context, model string) (float64, bool)
+
+// Enforcer checks requests against budgets and charges their cost
+type Enforcer struct {
+	config   *Config
+	tracker  *Tracker
+	notifier *Notifier
+	price    PriceFunc
+}
+
+// NewEnforcer creates an enforcer. price may be nil, in which case requests
+// are only refused once a budget is exhausted.
+func NewEnforcer(config *Config, tracker *Tracker, price PriceFunc) *Enforcer {
+	return &Enforcer{
+		config:   config,
+		tracker:  tracker,
+		notifier: NewNotifier(config.WebhookURL),
+		price:    price,
+	}
+}
+
+// Scopes resolves the budgets that apply to a subject. The key budget comes
+// from the key's metadata first, then the YAML api_keys, users and
+// default_key entries; the team comes from the key's metadata.
+func (e *Enforcer) Scopes(subject Subject) ([]Scope, error) {
+	var metadata struct {
+		Team   string  `json:"team"`
+		Budget *Limits `json:"budget"`
+	}
+	if subject.Metadata != "" {
+		if err := json.Unmarshal([]byte(subject.Metadata), &metadata); err != nil {
+			return nil, fmt.Errorf("invalid API key metadata: %w", err)
+		}
+	}
+
+	var sources []Limits
+	if metadata.Budget != nil {
+		sources = append(sources, *metadata.Budget)
+	}
+	name := "user:" + subject.User
+	if subject.KeyID != 0 {
+		name = "key:" + strconv.FormatInt(subject.KeyID, 10)
+		if limits, ok := e.config.APIKeys[strconv.FormatInt(subject.KeyID, 10)]; ok {
+			sources = append(sources, limits)
+		}
+	}
+	if limits, ok := e.config.Users[subject.User]; ok {
+		sources = append(sources, limits)
+	}
+	sources = append(sources, e.config.DefaultKey)
+
+	var scopes []Scope
+	if limits := resolve(sources); limits.set() {
+		scopes = append(scopes, Scope{Name: name, Limits: limits})
+	}
+	if metadata.Team != "" {
+		if limits, ok := e.config.Teams[metadata.Team]; ok && limits.set() {
+			scopes = append(scopes, Scope{Name: "team:" + metadata.Team, Limits: limits})
+		}
+	}
+	if e.config.Global.set() {
+		scopes = append(scopes, Scope{Name: "global", Limits: e.config.Global})
+	}
+	return scopes, nil
+}
+
+// resolve takes each limit from the first source that sets it
+func resolve(sources []Limits) Limits {
+	var resolved Limits
+	for _, source := range sources {
+		if resolved.DailyUSD == 0 {
+			resolved.DailyUSD = source.DailyUSD
+		}
+		if resolved.MonthlyUSD == 0 {
+			resolved.MonthlyUSD = source.MonthlyUSD
+		}
+	}
+	return resolved
+}
+
+// set reports whether any budget applies
+func (l Limits) set() bool {
+	return l.DailyUSD > 0 || l.MonthlyUSD > 0
+}
+
+// limit returns the budget for a period, or 0 when unlimited
+func (l Limits) limit(period string) float64 {
+	limit := l.DailyUSD
+	if period == PeriodMonthly {
+		limit = l.MonthlyUSD
+	}
+	if limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// EstimateMaxCost returns the cost of maxTokens output tokens of model, or 0
+// when the model's price is unknown
+func (e *Enforcer) EstimateMaxCost(ctx context.Context, model string, maxTokens int) float64 {
+	if e.price == nil || model == "" || maxTokens <= 0 {
+		return 0
+	}
+	outputPrice, ok := e.price(ctx, model)
+	if !ok {
+		return 0
+	}
+	return float64(maxTokens) / 1_000_000 * outputPrice
+}
+
+// Check refuses a request if any budget is exhausted or cannot cover the
+// request's estimated maximum cost on top of the estimates reserved by
+// requests in flight. When the request may proceed, its estimate is reserved
+// until the returned reservation is charged or released.
+func (e *Enforcer) Check(scopes []Scope, estimated float64) (*Reservation, *Denial, error) {
+	var limits []Limit
+	for _, scope := range scopes {
+		for _, period := range []string{PeriodDaily, PeriodMonthly} {
+			if limit := scope.Limits.limit(period); limit != 0 {
+				limits = append(limits, Limit{Scope: scope.Name, Period: period, USD: limit})
+			}
+		}
+	}
+
+	return e.tracker.Reserve(limits, estimated)
+}
+
+// Release returns a reservation made by Check for a request that did not
+// complete
+func (e *Enforcer) Release(reservation *Reservation) {
+	e.tracker.Release(reservation)
+}
+
+// Charge adds a request's actual cost to its budgets in place of its
+// reservation, and sends notifications for thresholds the spend crossed
+func (e *Enforcer) Charge(scopes []Scope, reservation *Reservation, cost float64) error {
+	// The reservation is released after the cost is added, so concurrent
+	// checks always count the request in one or the other
+	defer e.tracker.Release(reservation)
+	if cost <= 0 {
+		return nil
+	}
+
+	for _, scope := range scopes {
+		for _, period := range []string{PeriodDaily, PeriodMonthly} {
+			before, after, err := e.tracker.Add(scope.Name, period, cost)
+			if err != nil {
+				return err
+			}
+
+			limit := scope.Limits.limit(period)
+			if limit == 0 {
+				continue
+			}
+			for _, threshold := range e.config.Thresholds {
+				if before < threshold*limit && after >= threshold*limit {
+					e.notifier.Notify(Notification{
+						Event:     "budget.threshold",
+						Scope:     scope.Name,
+						Period:    period,
+						Threshold: threshold,
+						LimitUSD:  limit,
+						SpendUSD:  after,
+						Timestamp: time.Now().UTC(),
+					})
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package budget
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestScopes(t *testing.T) {
+	config := &Config{
+		Global:     Limits{MonthlyUSD: 1000},
+		Teams:      map[string]Limits{"research": {DailyUSD: 50}},
+		APIKeys:    map[string]Limits{"7": {DailyUSD: 10}},
+		Users:      map[string]Limits{"alice": {DailyUSD: -1}},
+		DefaultKey: Limits{DailyUSD: 1, MonthlyUSD: 20},
+	}
+	enforcer := NewEnforcer(config, nil, nil)
+
+	tests := []struct {
+		name     string
+		subject  Subject
+		expected map[string]Limits
+	}{
+		{
+			name:    "Default key budget",
+			subject: Subject{User: "bob"},
+			expected: map[string]Limits{
+				"user:bob": {DailyUSD: 1, MonthlyUSD: 20},
+				"global":   {MonthlyUSD: 1000},
+			},
+		},
+		{
+			name:    "API key budget inherits monthly default",
+			subject: Subject{KeyID: 7, User: "bob"},
+			expected: map[string]Limits{
+				"key:7":  {DailyUSD: 10, MonthlyUSD: 20},
+				"global": {MonthlyUSD: 1000},
+			},
+		},
+		{
+			name:    "Metadata budget and team",
+			subject: Subject{KeyID: 7, Metadata: `{"team":"research","budget":{"daily_usd":3}}`},
+			expected: map[string]Limits{
+				"key:7":         {DailyUSD: 3, MonthlyUSD: 20},
+				"team:research": {DailyUSD: 50},
+				"global":        {MonthlyUSD: 1000},
+			},
+		},
+		{
+			name:    "Unlimited daily budget",
+			subject: Subject{User: "alice"},
+			expected: map[string]Limits{
+				"user:alice": {DailyUSD: -1, MonthlyUSD: 20},
+				"global":     {MonthlyUSD: 1000},
+			},
+		},
+		{
+			name:    "Unknown team",
+			subject: Subject{User: "bob", Metadata: `{"team":"ops"}`},
+			expected: map[string]Limits{
+				"user:bob": {DailyUSD: 1, MonthlyUSD: 20},
+				"global":   {MonthlyUSD: 1000},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scopes, err := enforcer.Scopes(tt.subject)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(scopes) != len(tt.expected) {
+				t.Fatalf("Expected scopes %v, got %+v", tt.expected, scopes)
+			}
+			for _, scope := range scopes {
+				if expected, ok := tt.expected[scope.Name]; !ok || expected != scope.Limits {
+					t.Errorf("Unexpected scope %+v", scope)
+				}
+			}
+		})
+	}
+
+	if _, err := enforcer.Scopes(Subject{User: "bob", Metadata: "{"}); err == nil {
+		t.Error("Expected error for malformed metadata")
+	}
+}
+
+func TestEnforcer(t *testing.T) {
+	var mu sync.Mutex
+	var notifications []Notification
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n Notification
+		json.NewDecoder(r.Body).Decode(&n)
+		mu.Lock()
+		notifications = append(notifications, n)
+		mu.Unlock()
+	}))
+	defer webhook.Close()
+
+	config := &Config{
+		APIKeys:    map[string]Limits{"1": {DailyUSD: 1, MonthlyUSD: 100}},
+		WebhookURL: webhook.URL,
+		Thresholds: defaultThresholds,
+	}
+	tracker, _ := NewTracker(nil)
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+	price := func(ctx context.Context, model string) (float64, bool) {
+		return 15, model == "claude-3-opus" // $15 per 1M output tokens
+	}
+	enforcer := NewEnforcer(config, tracker, price)
+	scopes, _ := enforcer.Scopes(Subject{KeyID: 1})
+
+	t.Run("Estimate", func(t *testing.T) {
+		if cost := enforcer.EstimateMaxCost(context.Background(), "claude-3-opus", 4096); math.Abs(cost-0.06144) > 1e-9 {
+			t.Errorf("Unexpected estimate %v", cost)
+		}
+		if cost := enforcer.EstimateMaxCost(context.Background(), "unpriced", 4096); cost != 0 {
+			t.Errorf("Expected no estimate for unpriced model, got %v", cost)
+		}
+	})
+
+	t.Run("Thresholds notify once", func(t *testing.T) {
+		enforcer.Charge(scopes, nil, 0.5)
+		enforcer.Charge(scopes, nil, 0.35) // crosses 80% of the daily budget
+		enforcer.Charge(scopes, nil, 0.05)
+		enforcer.notifier.Wait()
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(notifications) != 1 {
+			t.Fatalf("Expected 1 notification, got %+v", notifications)
+		}
+		n := notifications[0]
+		if n.Scope != "key:1" || n.Period != PeriodDaily || n.Threshold != 0.8 || n.LimitUSD != 1 {
+			t.Errorf("Unexpected notification %+v", n)
+		}
+	})
+
+	t.Run("Estimate exceeds remaining budget", func(t *testing.T) {
+		_, denial, err := enforcer.Check(scopes, 0.2)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if denial == nil || denial.Period != PeriodDaily || denial.Estimated != 0.2 {
+			t.Fatalf("Expected daily denial for estimate, got %+v", denial)
+		}
+
+		reservation, denial, _ := enforcer.Check(scopes, 0.05)
+		if denial != nil {
+			t.Errorf("Expected request within budget to pass, got %+v", denial)
+		}
+		enforcer.Release(reservation)
+	})
+
+	t.Run("Exhausted budget", func(t *testing.T) {
+		enforcer.Charge(scopes, nil, 0.2) // crosses 100%
+		enforcer.notifier.Wait()
+
+		_, denial, _ := enforcer.Check(scopes, 0)
+		if denial == nil || denial.Estimated != 0 || denial.SpendUSD < 1 {
+			t.Fatalf("Expected exhausted denial, got %+v", denial)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(notifications) != 2 || notifications[1].Threshold != 1.0 {
+			t.Errorf("Expected 100%% notification, got %+v", notifications)
+		}
+	})
+
+	t.Run("New day resets daily budget", func(t *testing.T) {
+		now = now.Add(24 * time.Hour)
+
+		reservation, denial, _ := enforcer.Check(scopes, 0.5)
+		if denial != nil {
+			t.Errorf("Expected daily budget to reset, got %+v", denial)
+		}
+		enforcer.Release(reservation)
+		if spend, _ := tracker.Spend("key:1", PeriodMonthly); spend < 1 {
+			t.Errorf("Expected monthly spend to carry over, got %v", spend)
+		}
+	})
+}
+
+func TestConcurrentReservations(t *testing.T) {
+	config := &Config{APIKeys: map[string]Limits{"1": {DailyUSD: 1}}}
+	tracker, _ := NewTracker(nil)
+	enforcer := NewEnforcer(config, tracker, nil)
+	scopes, _ := enforcer.Scopes(Subject{KeyID: 1})
+
+	t.Run("Concurrent requests cannot overspend", func(t *testing.T) {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var admitted []*Reservation
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				reservation, denial, err := enforcer.Check(scopes, 0.3)
+				if err != nil || denial != nil {
+					return
+				}
+				mu.Lock()
+				admitted = append(admitted, reservation)
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		if len(admitted) != 3 {
+			t.Fatalf("Expected 3 of 50 requests estimated at $0.30 to fit a $1 budget, got %d", len(admitted))
+		}
+		for _, reservation := range admitted {
+			enforcer.Charge(scopes, reservation, 0.1)
+		}
+	})
+
+	t.Run("Charges replace reservations", func(t *testing.T) {
+		if spend, _ := tracker.Spend("key:1", PeriodDaily); math.Abs(spend-0.3) > 1e-9 {
+			t.Errorf("Expected spend 0.3, got %v", spend)
+		}
+		reservation, denial, _ := enforcer.Check(scopes, 0.6)
+		if denial != nil {
+			t.Fatalf("Expected the charged reservations to be released, got %+v", denial)
+		}
+
+		if _, denial, _ := enforcer.Check(scopes, 0.15); denial == nil || denial.Estimated != 0.15 {
+			t.Errorf("Expected the outstanding reservation to be counted, got %+v", denial)
+		}
+		enforcer.Release(reservation)
+		if _, denial, _ := enforcer.Check(scopes, 0.15); denial != nil {
+			t.Errorf("Expected a released reservation to free the budget, got %+v", denial)
+		}
+	})
+}
+
+func TestTrackerPersistence(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "budget.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	tracker, err := NewTracker(db)
+	if err != nil {
+		t.Fatalf("Failed to create tracker: %v", err)
+	}
+	tracker.Add("team:research", PeriodDaily, 1.25)
+	tracker.Add("team:research", PeriodDaily, 0.75)
+
+	// A new tracker (e.g. after a restart) reads spend from the database
+	restarted, _ := NewTracker(db)
+	spend, err := restarted.Spend("team:research", PeriodDaily)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if spend != 2 {
+		t.Errorf("Expected persisted spend 2, got %v", spend)
+	}
+
+	before, after, _ := restarted.Add("team:research", PeriodDaily, 1)
+	if before != 2 || after != 3 {
+		t.Errorf("Expected 2 -> 3, got %v -> %v", before, after)
+	}
+}
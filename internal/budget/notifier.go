@@ -0,0 +1,83 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package budget
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// webhookTimeout bounds a single notification delivery
+const webhookTimeout = 10 * time.Second
+
+// Notification is the JSON body posted to the budget webhook
+type Notification struct {
+	Event     string    `json:"event"`
+	Scope     string    `json:"scope"`
+	Period    string    `json:"period"`
+	Threshold float64   `json:"threshold"` // fraction of the budget, e.g. 0.8
+	LimitUSD  float64   `json:"limit_usd"`
+	SpendUSD  float64   `json:"spend_usd"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Notifier posts budget notifications to a webhook in the background
+type Notifier struct {
+	url        string
+	httpClient *http.Client
+	wg         sync.WaitGroup
+}
+
+// NewNotifier creates a notifier. Notifications are only logged when url is empty.
+func NewNotifier(url string) *Notifier {
+	return &Notifier{
+		url:        url,
+		httpClient: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+// Notify sends a notification without blocking the caller
+func (n *Notifier) Notify(notification Notification) {
+	log.Printf("Budget %s for %s reached %.0f%% ($%.4f of $%.2f)",
+		notification.Period, notification.Scope, notification.Threshold*100, notification.SpendUSD, notification.LimitUSD)
+	if n.url == "" {
+		return
+	}
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		if err := n.send(notification); err != nil {
+			log.Printf("Failed to send budget notification for %s: %v", notification.Scope, err)
+		}
+	}()
+}
+
+// Wait blocks until notifications in flight have been delivered
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+func (n *Notifier) send(notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.httpClient.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
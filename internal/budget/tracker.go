@@ -0,0 +1,184 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package budget
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Tracker keeps spend per scope and period. Totals are cached in memory and,
+// when a database is given, persisted so that spend survives restarts.
+// Reservations for requests in flight are kept in memory only.
+type Tracker struct {
+	mu       sync.Mutex
+	db       *sql.DB
+	spend    map[string]spendEntry // by scope and period
+	reserved map[string]float64    // by scope, period and period key
+	now      func() time.Time
+}
+
+// Limit is a scope's budget for one period
+type Limit struct {
+	Scope  string
+	Period string
+	USD    float64
+}
+
+// Reservation is an amount held against budgets until it is released
+type Reservation struct {
+	keys   []string
+	amount float64
+}
+
+// spendEntry is the cached spend of a scope in one day or month
+type spendEntry struct {
+	key   string // period key the spend belongs to
+	spend float64
+}
+
+// NewTracker creates a tracker. db may be nil for an in-memory tracker.
+func NewTracker(db *sql.DB) (*Tracker, error) {
+	if db != nil {
+		schema := `
+		CREATE TABLE IF NOT EXISTS budget_spend (
+			scope TEXT NOT NULL,
+			period TEXT NOT NULL,
+			spend REAL DEFAULT 0,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (scope, period)
+		);
+		`
+		if _, err := db.Exec(schema); err != nil {
+			return nil, fmt.Errorf("failed to create budget schema: %w", err)
+		}
+	}
+
+	return &Tracker{
+		db:       db,
+		spend:    make(map[string]spendEntry),
+		reserved: make(map[string]float64),
+		now:      time.Now,
+	}, nil
+}
+
+// periodKey identifies the current day (2006-01-02) or month (2006-01) in UTC
+func (t *Tracker) periodKey(period string) string {
+	now := t.now().UTC()
+	if period == PeriodMonthly {
+		return now.Format("2006-01")
+	}
+	return now.Format("2006-01-02")
+}
+
+// Spend returns the scope's spend in the current period
+func (t *Tracker) Spend(scope, period string) (float64, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.load(scope, period, t.periodKey(period))
+}
+
+// Reserve holds amount against every limit if each can cover it on top of
+// its spend and outstanding reservations. Otherwise nothing is reserved and
+// the first limit that cannot is described by the denial.
+func (t *Tracker) Reserve(limits []Limit, amount float64) (*Reservation, *Denial, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	reservation := &Reservation{amount: amount}
+	for _, limit := range limits {
+		key := t.periodKey(limit.Period)
+		spend, err := t.load(limit.Scope, limit.Period, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		reservedKey := limit.Scope + "|" + limit.Period + "|" + key
+		reserved := t.reserved[reservedKey]
+		if spend >= limit.USD {
+			return nil, &Denial{Scope: limit.Scope, Period: limit.Period, LimitUSD: limit.USD, SpendUSD: spend}, nil
+		}
+		if amount > limit.USD-spend-reserved {
+			return nil, &Denial{Scope: limit.Scope, Period: limit.Period, LimitUSD: limit.USD, SpendUSD: spend + reserved, Estimated: amount}, nil
+		}
+		reservation.keys = append(reservation.keys, reservedKey)
+	}
+
+	if amount > 0 {
+		for _, key := range reservation.keys {
+			t.reserved[key] += amount
+		}
+	}
+	return reservation, nil, nil
+}
+
+// Release returns a reservation's amount to its budgets. Releasing nil or an
+// already released reservation does nothing.
+func (t *Tracker) Release(reservation *Reservation) {
+	if reservation == nil || reservation.amount <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, key := range reservation.keys {
+		if remaining := t.reserved[key] - reservation.amount; remaining > 1e-12 {
+			t.reserved[key] = remaining
+		} else {
+			delete(t.reserved, key)
+		}
+	}
+	reservation.amount = 0
+}
+
+// Add adds amount to the scope's spend in the current period and returns the
+// spend before and after
+func (t *Tracker) Add(scope, period string, amount float64) (float64, float64, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := t.periodKey(period)
+	before, err := t.load(scope, period, key)
+	if err != nil {
+		return 0, 0, err
+	}
+	after := before + amount
+
+	if t.db != nil {
+		_, err := t.db.Exec(`
+			INSERT INTO budget_spend (scope, period, spend, updated_at)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT(scope, period) DO UPDATE SET
+				spend = spend + excluded.spend,
+				updated_at = excluded.updated_at
+		`, scope, key, amount, t.now().UTC())
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to record budget spend: %w", err)
+		}
+	}
+
+	t.spend[scope+"|"+period] = spendEntry{key: key, spend: after}
+	return before, after, nil
+}
+
+// load returns cached spend, reading it from the database on first use in a
+// period (caller holds mu)
+func (t *Tracker) load(scope, period, key string) (float64, error) {
+	if entry, ok := t.spend[scope+"|"+period]; ok && entry.key == key {
+		return entry.spend, nil
+	}
+
+	var spend float64
+	if t.db != nil {
+		err := t.db.QueryRow("SELECT spend FROM budget_spend WHERE scope = ? AND period = ?", scope, key).Scan(&spend)
+		if err != nil && err != sql.ErrNoRows {
+			return 0, fmt.Errorf("failed to read budget spend: %w", err)
+		}
+	}
+
+	t.spend[scope+"|"+period] = spendEntry{key: key, spend: spend}
+	return spend, nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/pkg/metrics"
+)
+
+const (
+	// defaultMaxBytes is the memory backend budget when max_bytes is not set
+	defaultMaxBytes = 256 << 20
+
+	// defaultTTL applies to models without their own TTL
+	defaultTTL = 10 * time.Minute
+)
+
+// errFlightAborted is returned to callers waiting on a computation that panicked
+var errFlightAborted = errors.New("cache computation aborted")
+
+// Config is the response cache configuration loaded from YAML
+type Config struct {
+	Backend  string `yaml:"backend"`   // memory or disk
+	MaxBytes int64  `yaml:"max_bytes"` // budget for cached entries
+	Dir      string `yaml:"dir"`       // disk backend directory
+
+	// TTL applies to models not listed in Models; a zero TTL in Models
+	// disables caching for that model
+	TTL    time.Duration            `yaml:"ttl"`
+	Models map[string]time.Duration `yaml:"models,omitempty"`
+
+	// Shared lets identical requests from different API keys and users hit
+	// the same entries; by default each identity has its own entries
+	Shared bool `yaml:"shared"`
+}
+
+// Backend stores cache entries. Implementations must be safe for concurrent use.
+type Backend interface {
+	// Get returns the value stored under key, if present and not expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set stores value under key until ttl elapses, evicting other entries
+	// to stay within the backend's budget
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Size returns the bytes held by the backend
+	Size() int64
+}
+
+// Cache is a keyed cache over a backend that coalesces concurrent misses
+// for the same key
+type Cache struct {
+	name    string // metrics label
+	config  Config
+	backend Backend
+
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is a computation in progress that concurrent callers wait for
+type flight struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+// New creates a cache over the backend selected by the configuration
+func New(name string, config Config) (*Cache, error) {
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = defaultMaxBytes
+	}
+	if config.TTL <= 0 {
+		config.TTL = defaultTTL
+	}
+
+	var backend Backend
+	switch config.Backend {
+	case "", "memory":
+		backend = NewMemoryBackend(config.MaxBytes)
+	case "disk":
+		if config.Dir == "" {
+			return nil, fmt.Errorf("disk cache backend requires dir")
+		}
+		disk, err := NewDiskBackend(config.Dir, config.MaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		backend = disk
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", config.Backend)
+	}
+
+	return NewWithBackend(name, config, backend), nil
+}
+
+// NewWithBackend creates a cache over a given backend
+func NewWithBackend(name string, config Config, backend Backend) *Cache {
+	if config.TTL <= 0 {
+		config.TTL = defaultTTL
+	}
+	metrics.SetCacheSize(name, backend.Size())
+	return &Cache{
+		name:    name,
+		config:  config,
+		backend: backend,
+		flights: make(map[string]*flight),
+	}
+}
+
+// Shared reports whether entries are shared across identities
+func (c *Cache) Shared() bool {
+	return c.config.Shared
+}
+
+// TTL returns how long responses for a model are cached; zero means the
+// model is not cached
+func (c *Cache) TTL(model string) time.Duration {
+	if ttl, ok := c.config.Models[model]; ok {
+		return ttl
+	}
+	return c.config.TTL
+}
+
+// Key hashes its parts into a cache key. Parts are length-prefixed so that
+// different splits of the same bytes give different keys.
+func Key(parts ...[]byte) string {
+	hash := sha256.New()
+	var length [8]byte
+	for _, part := range parts {
+		binary.BigEndian.PutUint64(length[:], uint64(len(part)))
+		hash.Write(length[:])
+		hash.Write(part)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Get returns the value cached under key
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	return c.backend.Get(ctx, key)
+}
+
+// Set caches value under key for ttl
+func (c *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	err := c.backend.Set(ctx, key, value, ttl)
+	metrics.SetCacheSize(c.name, c.backend.Size())
+	return err
+}
+
+// Do runs fn once for concurrent callers with the same key. Callers that
+// joined a computation started by another caller get its result with
+// shared set.
+func (c *Cache) Do(key string, fn func() ([]byte, error)) (value []byte, shared bool, err error) {
+	c.mu.Lock()
+	if f, ok := c.flights[key]; ok {
+		c.mu.Unlock()
+		<-f.done
+		return f.value, true, f.err
+	}
+	f := &flight{done: make(chan struct{}), err: errFlightAborted}
+	c.flights[key] = f
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.flights, key)
+		c.mu.Unlock()
+		close(f.done)
+	}()
+
+	f.value, f.err = fn()
+	return f.value, false, f.err
+}
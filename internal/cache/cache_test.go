@@ -0,0 +1,203 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryBackend(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	key := func(name string) string { return Key([]byte(name)) }
+
+	t.Run("Expiry", func(t *testing.T) {
+		b := NewMemoryBackend(1 << 20)
+		b.now = func() time.Time { return now }
+		b.Set(ctx, key("a"), []byte("value"), time.Minute)
+
+		if value, ok, _ := b.Get(ctx, key("a")); !ok || string(value) != "value" {
+			t.Fatalf("Expected a hit, got %q, %v", value, ok)
+		}
+		b.now = func() time.Time { return now.Add(time.Minute) }
+		if _, ok, _ := b.Get(ctx, key("a")); ok {
+			t.Error("Expected the entry to expire")
+		}
+		if b.Size() != 0 {
+			t.Errorf("Expected an empty backend, got %d bytes", b.Size())
+		}
+	})
+
+	t.Run("Byte budget evicts least recently used", func(t *testing.T) {
+		entrySize := int64(64 + 10) // hex key and value
+		b := NewMemoryBackend(2 * entrySize)
+		value := []byte("0123456789")
+		b.Set(ctx, key("a"), value, time.Hour)
+		b.Set(ctx, key("b"), value, time.Hour)
+		b.Get(ctx, key("a"))
+		b.Set(ctx, key("c"), value, time.Hour)
+
+		if _, ok, _ := b.Get(ctx, key("b")); ok {
+			t.Error("Expected the least recently used entry to be evicted")
+		}
+		for _, name := range []string{"a", "c"} {
+			if _, ok, _ := b.Get(ctx, key(name)); !ok {
+				t.Errorf("Expected %s to be kept", name)
+			}
+		}
+		if b.Size() != 2*entrySize {
+			t.Errorf("Size() = %d, want %d", b.Size(), 2*entrySize)
+		}
+
+		b.Set(ctx, key("big"), make([]byte, 3*entrySize), time.Hour)
+		if _, ok, _ := b.Get(ctx, key("big")); ok || b.Size() != 2*entrySize {
+			t.Error("Expected an entry larger than the budget not to be stored")
+		}
+	})
+}
+
+func TestDiskBackend(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	now := time.Unix(1700000000, 0)
+
+	b, err := NewDiskBackend(dir, 1<<20)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	b.now = func() time.Time { return now }
+	if err := b.Set(ctx, Key([]byte("a")), []byte("value"), time.Minute); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := b.Set(ctx, "../escape", []byte("value"), time.Minute); err == nil {
+		t.Error("Expected keys that are not digests to be refused")
+	}
+
+	// Entries survive a restart
+	reopened, err := NewDiskBackend(dir, 1<<20)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	reopened.now = func() time.Time { return now }
+	if value, ok, err := reopened.Get(ctx, Key([]byte("a"))); err != nil || !ok || string(value) != "value" {
+		t.Fatalf("Expected a hit after reopening, got %q, %v, %v", value, ok, err)
+	}
+	if reopened.Size() != int64(diskHeaderSize+len("value")) {
+		t.Errorf("Unexpected size %d", reopened.Size())
+	}
+
+	reopened.now = func() time.Time { return now.Add(time.Minute) }
+	if _, ok, _ := reopened.Get(ctx, Key([]byte("a"))); ok || reopened.Size() != 0 {
+		t.Error("Expected the entry to expire and be removed")
+	}
+
+	// Entries closest to expiry are evicted first
+	small, err := NewDiskBackend(t.TempDir(), 2*(diskHeaderSize+5))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	small.Set(ctx, Key([]byte("short")), []byte("value"), time.Minute)
+	small.Set(ctx, Key([]byte("long")), []byte("value"), time.Hour)
+	small.Set(ctx, Key([]byte("medium")), []byte("value"), 10*time.Minute)
+	if _, ok, _ := small.Get(ctx, Key([]byte("short"))); ok {
+		t.Error("Expected the entry closest to expiry to be evicted")
+	}
+	if _, ok, _ := small.Get(ctx, Key([]byte("long"))); !ok {
+		t.Error("Expected the longest-lived entry to be kept")
+	}
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	c := NewWithBackend("test", Config{
+		TTL:    time.Minute,
+		Models: map[string]time.Duration{"long": time.Hour, "uncached": 0},
+	}, NewMemoryBackend(1<<20))
+
+	t.Run("TTL", func(t *testing.T) {
+		for model, want := range map[string]time.Duration{"other": time.Minute, "long": time.Hour, "uncached": 0} {
+			if got := c.TTL(model); got != want {
+				t.Errorf("TTL(%q) = %v, want %v", model, got, want)
+			}
+		}
+	})
+
+	t.Run("Key", func(t *testing.T) {
+		if Key([]byte("ab"), []byte("c")) == Key([]byte("a"), []byte("bc")) {
+			t.Error("Expected different splits to give different keys")
+		}
+	})
+
+	t.Run("Zero TTL is not stored", func(t *testing.T) {
+		c.Set(ctx, Key([]byte("zero")), []byte("value"), 0)
+		if _, ok, _ := c.Get(ctx, Key([]byte("zero"))); ok {
+			t.Error("Expected nothing to be stored")
+		}
+	})
+
+	t.Run("Do coalesces concurrent calls", func(t *testing.T) {
+		var calls atomic.Int32
+		release := make(chan struct{})
+		started := make(chan struct{})
+		var startOnce sync.Once
+
+		var wg sync.WaitGroup
+		results := make([]bool, 5)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if i > 0 {
+					<-started
+				}
+				value, shared, err := c.Do("key", func() ([]byte, error) {
+					calls.Add(1)
+					startOnce.Do(func() { close(started) })
+					<-release
+					return []byte("value"), nil
+				})
+				if err != nil || string(value) != "value" {
+					t.Errorf("Unexpected result %q, %v", value, err)
+				}
+				results[i] = shared
+			}(i)
+		}
+
+		// Give the followers time to join the flight
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if calls.Load() != 1 {
+			t.Errorf("Expected one call, got %d", calls.Load())
+		}
+		if results[0] {
+			t.Error("Expected the first caller to run the computation")
+		}
+	})
+
+	t.Run("Do shares errors", func(t *testing.T) {
+		wantErr := errors.New("failed")
+		if _, _, err := c.Do("error", func() ([]byte, error) { return nil, wantErr }); !errors.Is(err, wantErr) {
+			t.Errorf("Expected the computation error, got %v", err)
+		}
+	})
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New("test", Config{Backend: "redis"}); err == nil {
+		t.Error("Expected an unknown backend to be refused")
+	}
+	if _, err := New("test", Config{Backend: "disk"}); err == nil {
+		t.Error("Expected the disk backend to require dir")
+	}
+	if _, err := New("test", Config{Backend: "disk", Dir: t.TempDir()}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// diskHeaderSize is the length of the expiry timestamp that precedes each value
+const diskHeaderSize = 8
+
+// DiskBackend keeps one file per entry in a directory, so entries survive
+// restarts and can be shared by replicas on the same volume. The oldest
+// entries are evicted when the byte budget is exceeded.
+type DiskBackend struct {
+	dir      string
+	maxBytes int64
+	now      func() time.Time
+
+	mu      sync.Mutex
+	size    int64
+	entries map[string]diskEntry // index of the files in dir
+}
+
+type diskEntry struct {
+	size    int64
+	expires time.Time
+}
+
+// NewDiskBackend creates a disk backend in dir, indexing the entries already there
+func NewDiskBackend(dir string, maxBytes int64) (*DiskBackend, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	b := &DiskBackend{
+		dir:      dir,
+		maxBytes: maxBytes,
+		now:      time.Now,
+		entries:  make(map[string]diskEntry),
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+	for _, file := range files {
+		if file.IsDir() || !validKey(file.Name()) {
+			continue
+		}
+		expires, size, err := b.readHeader(file.Name())
+		if err != nil {
+			continue
+		}
+		b.entries[file.Name()] = diskEntry{size: size, expires: expires}
+		b.size += size
+	}
+	b.evict()
+	return b, nil
+}
+
+// Get implements Backend
+func (b *DiskBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if !validKey(key) {
+		return nil, false, fmt.Errorf("invalid cache key %q", key)
+	}
+	data, err := os.ReadFile(filepath.Join(b.dir, key))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if len(data) < diskHeaderSize {
+		return nil, false, nil
+	}
+
+	expires := time.Unix(0, int64(binary.BigEndian.Uint64(data[:diskHeaderSize])))
+	if !b.now().Before(expires) {
+		b.mu.Lock()
+		b.remove(key)
+		b.mu.Unlock()
+		return nil, false, nil
+	}
+	return data[diskHeaderSize:], true, nil
+}
+
+// Set implements Backend. The file is written under a temporary name and
+// renamed, so readers never see a partial entry.
+func (b *DiskBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if !validKey(key) {
+		return fmt.Errorf("invalid cache key %q", key)
+	}
+	size := int64(diskHeaderSize + len(value))
+	if size > b.maxBytes {
+		return nil
+	}
+
+	expires := b.now().Add(ttl)
+	data := make([]byte, size)
+	binary.BigEndian.PutUint64(data[:diskHeaderSize], uint64(expires.UnixNano()))
+	copy(data[diskHeaderSize:], value)
+
+	tmp, err := os.CreateTemp(b.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := os.Rename(tmp.Name(), filepath.Join(b.dir, key)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to store cache file: %w", err)
+	}
+	if existing, ok := b.entries[key]; ok {
+		b.size -= existing.size
+	}
+	b.entries[key] = diskEntry{size: size, expires: expires}
+	b.size += size
+	b.evict()
+	return nil
+}
+
+// Size implements Backend
+func (b *DiskBackend) Size() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size
+}
+
+// evict removes expired entries, then the entries closest to expiry, until
+// the directory is within budget (caller holds mu or owns b)
+func (b *DiskBackend) evict() {
+	if b.size <= b.maxBytes {
+		return
+	}
+
+	keys := make([]string, 0, len(b.entries))
+	for key := range b.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return b.entries[keys[i]].expires.Before(b.entries[keys[j]].expires)
+	})
+	for _, key := range keys {
+		if b.size <= b.maxBytes {
+			return
+		}
+		b.remove(key)
+	}
+}
+
+// remove deletes an entry's file (caller holds mu)
+func (b *DiskBackend) remove(key string) {
+	os.Remove(filepath.Join(b.dir, key))
+	if entry, ok := b.entries[key]; ok {
+		b.size -= entry.size
+		delete(b.entries, key)
+	}
+}
+
+// readHeader reads the expiry and size of an entry's file
+func (b *DiskBackend) readHeader(key string) (time.Time, int64, error) {
+	file, err := os.Open(filepath.Join(b.dir, key))
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	var header [diskHeaderSize]byte
+	if _, err := file.Read(header[:]); err != nil {
+		return time.Time{}, 0, err
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(header[:]))), info.Size(), nil
+}
+
+// validKey reports whether key is a hex digest from Key, which keeps keys
+// usable as file names
+func validKey(key string) bool {
+	if len(key) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(key)
+	return err == nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryBackend keeps entries in process memory within a byte budget,
+// evicting the least recently used entries first. Entries are per replica.
+type MemoryBackend struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	entries  map[string]*list.Element
+	lru      *list.List // front is most recently used
+	now      func() time.Time
+}
+
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryBackend creates a memory backend holding at most maxBytes of keys and values
+func NewMemoryBackend(maxBytes int64) *MemoryBackend {
+	return &MemoryBackend{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		now:      time.Now,
+	}
+}
+
+// Get implements Backend
+func (b *MemoryBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	element, ok := b.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*memoryEntry)
+	if !b.now().Before(entry.expires) {
+		b.remove(element)
+		return nil, false, nil
+	}
+	b.lru.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+// Set implements Backend. Values larger than the whole budget are not stored.
+func (b *MemoryBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if element, ok := b.entries[key]; ok {
+		b.remove(element)
+	}
+	entrySize := int64(len(key) + len(value))
+	if entrySize > b.maxBytes {
+		return nil
+	}
+
+	b.entries[key] = b.lru.PushFront(&memoryEntry{key: key, value: value, expires: b.now().Add(ttl)})
+	b.size += entrySize
+	for b.size > b.maxBytes {
+		b.remove(b.lru.Back())
+	}
+	return nil
+}
+
+// Size implements Backend
+func (b *MemoryBackend) Size() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size
+}
+
+// remove drops an entry (caller holds mu)
+func (b *MemoryBackend) remove(element *list.Element) {
+	entry := b.lru.Remove(element).(*memoryEntry)
+	delete(b.entries, entry.key)
+	b.size -= int64(len(entry.key) + len(entry.value))
+}
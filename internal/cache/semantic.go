@@ -0,0 +1,221 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/pkg/metrics"
+)
+
+const (
+	defaultSemanticThreshold  = 0.95
+	defaultSemanticTTL        = time.Hour
+	defaultSemanticMaxEntries = 1000
+
+	// semanticSweepInterval is how often every namespace is checked for expired entries
+	semanticSweepInterval = time.Minute
+)
+
+// SemanticConfig is the semantic cache configuration loaded from YAML
+type SemanticConfig struct {
+	Enabled        bool   `yaml:"enabled"`
+	EmbeddingModel string `yaml:"embedding_model"` // model name routed like /v1/embeddings
+
+	// Threshold is the cosine similarity above which a cached answer is returned
+	Threshold  float64       `yaml:"threshold"`
+	TTL        time.Duration `yaml:"ttl"`
+	MaxEntries int           `yaml:"max_entries"` // per model and tenant
+
+	// Models limits the cache to these models; empty caches every model
+	Models []string `yaml:"models,omitempty"`
+}
+
+// SemanticMatch is a cached value similar to a query
+type SemanticMatch struct {
+	Value []byte
+	Score float64 // cosine similarity
+}
+
+// SemanticIndex is an in-process flat vector index of cached values, split
+// into namespaces (one per model and tenant). Within a namespace, an entry
+// only matches queries with the same context, such as the conversation
+// before the embedded message.
+type SemanticIndex struct {
+	name   string // metrics label
+	config SemanticConfig
+	now    func() time.Time
+
+	mu        sync.Mutex
+	spaces    map[string][]*semanticEntry // oldest first
+	size      int64
+	lastSweep time.Time
+}
+
+type semanticEntry struct {
+	context string
+	vector  []float32 // unit length
+	value   []byte
+	expires time.Time
+}
+
+// NewSemanticIndex creates an empty semantic index
+func NewSemanticIndex(name string, config SemanticConfig) *SemanticIndex {
+	if config.Threshold <= 0 {
+		config.Threshold = defaultSemanticThreshold
+	}
+	if config.TTL <= 0 {
+		config.TTL = defaultSemanticTTL
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = defaultSemanticMaxEntries
+	}
+	return &SemanticIndex{
+		name:   name,
+		config: config,
+		now:    time.Now,
+		spaces: make(map[string][]*semanticEntry),
+	}
+}
+
+// Caches reports whether responses for a model are cached
+func (i *SemanticIndex) Caches(model string) bool {
+	if len(i.config.Models) == 0 {
+		return true
+	}
+	for _, m := range i.config.Models {
+		if m == model {
+			return true
+		}
+	}
+	return false
+}
+
+// EmbeddingModel returns the model used to embed queries
+func (i *SemanticIndex) EmbeddingModel() string {
+	return i.config.EmbeddingModel
+}
+
+// Search returns the live entry in namespace with the same context that is
+// most similar to vector, if its similarity reaches the threshold
+func (i *SemanticIndex) Search(namespace, context string, vector []float64) (*SemanticMatch, bool) {
+	query := normalize(vector)
+	if query == nil {
+		return nil, false
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.expire(namespace)
+
+	var best *semanticEntry
+	bestScore := math.Inf(-1)
+	for _, entry := range i.spaces[namespace] {
+		if entry.context != context || len(entry.vector) != len(query) {
+			continue
+		}
+		if score := dot(entry.vector, query); score > bestScore {
+			best, bestScore = entry, score
+		}
+	}
+	if best == nil || bestScore < i.config.Threshold {
+		return nil, false
+	}
+	return &SemanticMatch{Value: best.value, Score: bestScore}, true
+}
+
+// Add stores value under vector, evicting the oldest entries of the
+// namespace beyond MaxEntries
+func (i *SemanticIndex) Add(namespace, context string, vector []float64, value []byte) {
+	unit := normalize(vector)
+	if unit == nil {
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.sweep()
+	i.expire(namespace)
+
+	entries := append(i.spaces[namespace], &semanticEntry{
+		context: context,
+		vector:  unit,
+		value:   value,
+		expires: i.now().Add(i.config.TTL),
+	})
+	i.size += entrySize(entries[len(entries)-1])
+	for len(entries) > i.config.MaxEntries {
+		i.size -= entrySize(entries[0])
+		entries = entries[1:]
+	}
+	i.spaces[namespace] = entries
+	metrics.SetCacheSize(i.name, i.size)
+}
+
+// expire drops the expired entries of a namespace (caller holds mu). All
+// entries share a TTL, so they expire in insertion order.
+func (i *SemanticIndex) expire(namespace string) {
+	entries := i.spaces[namespace]
+	now := i.now()
+	n := 0
+	for n < len(entries) && !now.Before(entries[n].expires) {
+		i.size -= entrySize(entries[n])
+		n++
+	}
+	if n == 0 {
+		return
+	}
+	if n == len(entries) {
+		delete(i.spaces, namespace)
+	} else {
+		i.spaces[namespace] = entries[n:]
+	}
+	metrics.SetCacheSize(i.name, i.size)
+}
+
+// sweep expires the entries of namespaces that are no longer searched (caller holds mu)
+func (i *SemanticIndex) sweep() {
+	now := i.now()
+	if now.Sub(i.lastSweep) < semanticSweepInterval {
+		return
+	}
+	i.lastSweep = now
+	for namespace := range i.spaces {
+		i.expire(namespace)
+	}
+}
+
+// entrySize approximates the memory held by an entry
+func entrySize(entry *semanticEntry) int64 {
+	return int64(len(entry.context) + 4*len(entry.vector) + len(entry.value))
+}
+
+// normalize returns vector scaled to unit length, or nil for a zero vector
+func normalize(vector []float64) []float32 {
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	if norm == 0 {
+		return nil
+	}
+	norm = math.Sqrt(norm)
+
+	unit := make([]float32, len(vector))
+	for j, v := range vector {
+		unit[j] = float32(v / norm)
+	}
+	return unit
+}
+
+// dot returns the dot product of two vectors of the same length
+func dot(a, b []float32) float64 {
+	var sum float64
+	for j := range a {
+		sum += float64(a[j]) * float64(b[j])
+	}
+	return sum
+}
@@ -0,0 +1,87 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSemanticIndex(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	newIndex := func(config SemanticConfig) *SemanticIndex {
+		index := NewSemanticIndex("test", config)
+		index.now = func() time.Time { return now }
+		return index
+	}
+
+	t.Run("Threshold", func(t *testing.T) {
+		index := newIndex(SemanticConfig{Threshold: 0.9})
+		index.Add("m/tenant", "ctx", []float64{1, 0, 0}, []byte("paris"))
+		index.Add("m/tenant", "ctx", []float64{0, 1, 0}, []byte("oslo"))
+
+		match, ok := index.Search("m/tenant", "ctx", []float64{2, 0.5, 0})
+		if !ok || string(match.Value) != "paris" {
+			t.Fatalf("Expected the closest entry, got %+v", match)
+		}
+		if want := 2 / math.Sqrt(4.25); math.Abs(match.Score-want) > 1e-6 {
+			t.Errorf("Score = %v, want %v", match.Score, want)
+		}
+
+		if _, ok := index.Search("m/tenant", "ctx", []float64{1, 1, 0}); ok {
+			t.Error("Expected no match below the threshold")
+		}
+	})
+
+	t.Run("Namespaces and contexts are isolated", func(t *testing.T) {
+		index := newIndex(SemanticConfig{})
+		index.Add("m/a", "ctx", []float64{1, 0}, []byte("a"))
+		if _, ok := index.Search("m/b", "ctx", []float64{1, 0}); ok {
+			t.Error("Expected other tenants not to match")
+		}
+		if _, ok := index.Search("m/a", "other", []float64{1, 0}); ok {
+			t.Error("Expected other contexts not to match")
+		}
+		if _, ok := index.Search("m/a", "ctx", []float64{1, 0, 0}); ok {
+			t.Error("Expected vectors of other dimensions not to match")
+		}
+	})
+
+	t.Run("TTL", func(t *testing.T) {
+		index := newIndex(SemanticConfig{TTL: time.Minute})
+		index.Add("ns", "ctx", []float64{1, 0}, []byte("a"))
+		index.now = func() time.Time { return now.Add(time.Minute) }
+		if _, ok := index.Search("ns", "ctx", []float64{1, 0}); ok {
+			t.Error("Expected the entry to expire")
+		}
+		if len(index.spaces) != 0 || index.size != 0 {
+			t.Errorf("Expected expired entries to be dropped, got %d namespaces and %d bytes", len(index.spaces), index.size)
+		}
+	})
+
+	t.Run("MaxEntries evicts the oldest", func(t *testing.T) {
+		index := newIndex(SemanticConfig{MaxEntries: 2})
+		for i := 0; i < 3; i++ {
+			index.Add("ns", "ctx", []float64{float64(i), 1}, []byte(fmt.Sprint(i)))
+		}
+		if len(index.spaces["ns"]) != 2 {
+			t.Fatalf("Expected 2 entries, got %d", len(index.spaces["ns"]))
+		}
+		if _, ok := index.Search("ns", "ctx", []float64{0, 1}); ok {
+			t.Error("Expected the oldest entry to be evicted")
+		}
+	})
+
+	t.Run("Models", func(t *testing.T) {
+		index := newIndex(SemanticConfig{Models: []string{"support-bot"}})
+		if !index.Caches("support-bot") || index.Caches("gpt-4o") {
+			t.Error("Expected only listed models to be cached")
+		}
+		if !newIndex(SemanticConfig{}).Caches("gpt-4o") {
+			t.Error("Expected every model to be cached without a list")
+		}
+	})
+}
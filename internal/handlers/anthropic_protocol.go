@@ -0,0 +1,187 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/internal/instance"
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+	"github.com/tosharewith/llmproxy_auth/internal/translator"
+	"github.com/tosharewith/llmproxy_auth/pkg/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// handleAnthropicProtocol serves the Anthropic Messages API
+// (POST /anthropic/{instance}/v1/messages) from any backend. Requests are
+// translated through the OpenAI format the instances are driven with, and
+// responses, streams and errors are returned in Anthropic's shapes.
+func (h *ProtocolHandler) handleAnthropicProtocol(
+	c *gin.Context,
+	provider providers.Provider,
+	instanceCfg *instance.InstanceConfig,
+	instanceName string,
+	startTime time.Time,
+) {
+	if !strings.HasSuffix(c.Request.URL.Path, "/messages") {
+		anthropicError(c, http.StatusNotFound, fmt.Sprintf("Unsupported endpoint %s; only /v1/messages is available", c.Request.URL.Path))
+		return
+	}
+
+	var req translator.AnthropicMessagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		anthropicError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	openaiReq, err := translator.TranslateAnthropicToOpenAI(&req)
+	if err != nil {
+		anthropicError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	messageID := "msg_" + strings.ReplaceAll(uuid.New().String(), "-", "")[:24]
+	providerReq, err := buildInstanceRequest(c.Request.Context(), instanceCfg, openaiReq)
+	if err != nil {
+		log.Printf("Translation error: %v", err)
+		anthropicError(c, http.StatusBadRequest, fmt.Sprintf("Failed to translate request: %v", err))
+		return
+	}
+
+	if req.Stream {
+		h.streamAnthropic(c, provider, instanceCfg, instanceName, providerReq, &req, openaiReq, messageID, startTime)
+		return
+	}
+
+	providerResp, err := provider.Invoke(c.Request.Context(), providerReq)
+	if err != nil {
+		log.Printf("Provider invocation error: %v", err)
+		anthropicProviderError(c, err)
+		return
+	}
+
+	openaiResp, err := parseInstanceResponse(instanceCfg, providerResp.Body, req.Model, messageID)
+	if err != nil {
+		log.Printf("Failed to parse provider response: %v", err)
+		anthropicError(c, http.StatusBadGateway, "Failed to parse provider response")
+		return
+	}
+	h.recordUsage(c, provider, openaiReq.Model, openaiResp.Usage, false)
+
+	if instanceCfg.Metrics.Enabled {
+		duration := time.Since(startTime)
+		metrics.RequestDuration.WithLabelValues("POST", "200").Observe(duration.Seconds())
+		metrics.RequestsTotal.WithLabelValues("POST", "200").Inc()
+	}
+
+	log.Printf("Protocol request completed: %s (status: 200, duration: %v)", instanceName, time.Since(startTime))
+
+	c.JSON(http.StatusOK, translator.TranslateOpenAIToAnthropic(openaiResp, req.Model, messageID))
+}
+
+// streamAnthropic relays a provider stream as Anthropic Messages events
+func (h *ProtocolHandler) streamAnthropic(
+	c *gin.Context,
+	provider providers.Provider,
+	instanceCfg *instance.InstanceConfig,
+	instanceName string,
+	providerReq *providers.ProviderRequest,
+	req *translator.AnthropicMessagesRequest,
+	openaiReq *translator.ChatCompletionRequest,
+	messageID string,
+	startTime time.Time,
+) {
+	stream := openStreamRelay(func() (io.ReadCloser, error) {
+		return provider.InvokeStreaming(c.Request.Context(), providerReq)
+	}, func(err error) {
+		log.Printf("Provider streaming error: %v", err)
+		anthropicProviderError(c, err)
+	}, openaiReq, startTime, instanceCfg.Metrics.Enabled, func(tokens *translator.Usage) {
+		h.recordUsage(c, provider, openaiReq.Model, tokens, true)
+	})
+	if stream == nil {
+		return
+	}
+	defer stream.Close()
+
+	// Bedrock Converse instances stream Converse events; all others stream OpenAI chunks
+	var next func() (*translator.ChatCompletionStreamResponse, error)
+	if respondsWithConverse(instanceCfg) {
+		next = translator.NewConverseStreamReader(stream, req.Model, messageID).Next
+	} else {
+		next = translator.NewChatCompletionStreamReader(stream).Next
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	writer := translator.NewAnthropicStreamWriter(c.Writer, messageID, req.Model)
+	var writeErr error
+	for {
+		chunk, err := next()
+		if err == io.EOF {
+			writeErr = writer.Close()
+			break
+		}
+		if err != nil {
+			// Headers are already sent, so report the error in-band
+			log.Printf("Stream error from %s: %v", instanceName, err)
+			statusCode := http.StatusBadGateway
+			var providerErr *providers.ProviderError
+			if errors.As(err, &providerErr) && providerErr.StatusCode != 0 {
+				statusCode = providerErr.StatusCode
+			}
+			stream.status = strconv.Itoa(statusCode)
+			writeErr = translator.WriteAnthropicStreamError(c.Writer, statusCode, err.Error())
+			break
+		}
+
+		stream.observe(chunk)
+		if err := writer.WriteChunk(chunk); err != nil {
+			// Client went away
+			log.Printf("Failed to write stream event: %v", err)
+			stream.status = "499"
+			return
+		}
+		c.Writer.Flush()
+	}
+	if writeErr != nil {
+		log.Printf("Failed to write stream event: %v", writeErr)
+	}
+	c.Writer.Flush()
+}
+
+// anthropicError writes an error in Anthropic's format
+func anthropicError(c *gin.Context, statusCode int, message string) {
+	c.JSON(statusCode, translator.NewAnthropicError(statusCode, message))
+}
+
+// anthropicProviderError converts provider errors to Anthropic's format
+func anthropicProviderError(c *gin.Context, err error) {
+	var providerErr *providers.ProviderError
+	if !errors.As(err, &providerErr) {
+		anthropicError(c, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	statusCode := providerErr.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusInternalServerError
+	}
+	if providerErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(providerErr.RetryAfter.Round(time.Second).Seconds())))
+	}
+	anthropicError(c, statusCode, providerErr.Message)
+}
@@ -0,0 +1,501 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// totpIssuer is shown by authenticator apps next to enrolled keys
+const totpIssuer = "Bedrock Proxy"
+
+// APIKeyHandler serves the API key admin endpoints under /admin/keys
+type APIKeyHandler struct {
+	apiKeyDB       *auth.APIKeyDB
+	totpManager    *auth.TOTPManager
+	sessionManager *auth.SessionManager
+}
+
+// NewAPIKeyHandler creates a new API key admin handler
+func NewAPIKeyHandler(
+	apiKeyDB *auth.APIKeyDB,
+	totpManager *auth.TOTPManager,
+	sessionManager *auth.SessionManager,
+) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyDB:       apiKeyDB,
+		totpManager:    totpManager,
+		sessionManager: sessionManager,
+	}
+}
+
+// APIKeyInfo is an API key as returned by the admin API. The key itself and
+// its hash are never returned.
+type APIKeyInfo struct {
+	ID          int64           `json:"id"`
+	PublicID    string          `json:"public_id"` // empty for legacy keys
+	Name        string          `json:"name"`
+	Email       string          `json:"email"`
+	Description string          `json:"description"`
+	IsActive    bool            `json:"is_active"`
+	CreatedAt   time.Time       `json:"created_at"`
+	LastUsedAt  *time.Time      `json:"last_used_at"`
+	ExpiresAt   *time.Time      `json:"expires_at"`
+	Permissions json.RawMessage `json:"permissions"`
+	Metadata    json.RawMessage `json:"metadata"`
+	TOTPEnabled bool            `json:"totp_enabled"`
+}
+
+// CreateAPIKeyRequest represents a request to create an API key
+type CreateAPIKeyRequest struct {
+	Name        string          `json:"name" binding:"required"`
+	Email       string          `json:"email"`
+	Description string          `json:"description"`
+	ExpiresAt   *time.Time      `json:"expires_at"`
+	ExpiresIn   string          `json:"expires_in"` // Go duration, e.g. "720h"
+	Permissions json.RawMessage `json:"permissions"`
+	Metadata    json.RawMessage `json:"metadata"`
+}
+
+// UpdateAPIKeyRequest represents a request to update an API key. Omitted
+// fields are left unchanged; "expires_at": null removes the expiry.
+type UpdateAPIKeyRequest struct {
+	Name        *string         `json:"name"`
+	Description *string         `json:"description"`
+	ExpiresAt   json.RawMessage `json:"expires_at"`
+	Permissions json.RawMessage `json:"permissions"`
+	Metadata    json.RawMessage `json:"metadata"`
+}
+
+// APIKeySecretResponse returns a newly issued key, shown only once
+type APIKeySecretResponse struct {
+	Key     APIKeyInfo `json:"key"`
+	APIKey  string     `json:"api_key"`
+	Message string     `json:"message"`
+}
+
+// TOTPEnrollmentResponse returns a new TOTP secret and backup codes
+type TOTPEnrollmentResponse struct {
+	Secret      string   `json:"secret"`
+	URL         string   `json:"url"` // otpauth:// URL for QR codes
+	BackupCodes []string `json:"backup_codes"`
+}
+
+// ListKeys handles GET /admin/keys
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	keys, err := h.apiKeyDB.ListAPIKeys()
+	if err != nil {
+		log.Printf("Failed to list API keys: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list API keys",
+		})
+		return
+	}
+
+	infos := make([]APIKeyInfo, 0, len(keys))
+	for i := range keys {
+		infos = append(infos, h.keyInfo(&keys[i]))
+	}
+
+	h.audit(c, 0, "admin_keys_listed", http.StatusOK, nil)
+	c.JSON(http.StatusOK, gin.H{
+		"keys":  infos,
+		"count": len(infos),
+	})
+}
+
+// CreateKey handles POST /admin/keys
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "Provide at least a name",
+		})
+		return
+	}
+
+	expiresAt := req.ExpiresAt
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || d <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request",
+				"message": "expires_in must be a positive duration such as 720h",
+			})
+			return
+		}
+		exp := time.Now().Add(d)
+		expiresAt = &exp
+	}
+
+	permissions, err := permissionsField(req.Permissions, "[]")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "message": err.Error()})
+		return
+	}
+	metadata, err := jsonField(req.Metadata, "{}", "{")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "message": "metadata must be a JSON object"})
+		return
+	}
+
+	id, apiKey, err := h.apiKeyDB.CreateAPIKey(req.Name, req.Email, req.Description, expiresAt, permissions, metadata)
+	if err != nil {
+		log.Printf("Failed to create API key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create API key",
+		})
+		return
+	}
+
+	key, err := h.apiKeyDB.GetAPIKey(id)
+	if err != nil {
+		log.Printf("Failed to read created API key %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create API key",
+		})
+		return
+	}
+
+	h.audit(c, id, "admin_key_created", http.StatusCreated, gin.H{"name": req.Name})
+	c.JSON(http.StatusCreated, APIKeySecretResponse{
+		Key:     h.keyInfo(key),
+		APIKey:  apiKey,
+		Message: "Store this API key securely. It will not be shown again.",
+	})
+}
+
+// GetKey handles GET /admin/keys/:id
+func (h *APIKeyHandler) GetKey(c *gin.Context) {
+	key, ok := h.loadKey(c)
+	if !ok {
+		return
+	}
+
+	h.audit(c, key.ID, "admin_key_viewed", http.StatusOK, nil)
+	c.JSON(http.StatusOK, h.keyInfo(key))
+}
+
+// UpdateKey handles PATCH /admin/keys/:id
+func (h *APIKeyHandler) UpdateKey(c *gin.Context) {
+	key, ok := h.loadKey(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "Provide a JSON object with the fields to change",
+		})
+		return
+	}
+
+	update := auth.APIKeyUpdate{Name: req.Name, Description: req.Description}
+	var changed []string
+	if req.Name != nil {
+		changed = append(changed, "name")
+	}
+	if req.Description != nil {
+		changed = append(changed, "description")
+	}
+	if len(req.ExpiresAt) > 0 {
+		if bytes.Equal(req.ExpiresAt, []byte("null")) {
+			update.ClearExpiresAt = true
+		} else {
+			var expiresAt time.Time
+			if err := json.Unmarshal(req.ExpiresAt, &expiresAt); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "message": "expires_at must be an RFC 3339 time or null"})
+				return
+			}
+			update.ExpiresAt = &expiresAt
+		}
+		changed = append(changed, "expires_at")
+	}
+	if len(req.Permissions) > 0 {
+		permissions, err := permissionsField(req.Permissions, "")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "message": err.Error()})
+			return
+		}
+		update.Permissions = &permissions
+		changed = append(changed, "permissions")
+	}
+	if len(req.Metadata) > 0 {
+		metadata, err := jsonField(req.Metadata, "", "{")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "message": "metadata must be a JSON object"})
+			return
+		}
+		update.Metadata = &metadata
+		changed = append(changed, "metadata")
+	}
+
+	if err := h.apiKeyDB.UpdateAPIKey(key.ID, update); err != nil {
+		log.Printf("Failed to update API key %d: %v", key.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update API key",
+		})
+		return
+	}
+
+	key, err := h.apiKeyDB.GetAPIKey(key.ID)
+	if err != nil {
+		log.Printf("Failed to read updated API key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update API key",
+		})
+		return
+	}
+
+	h.audit(c, key.ID, "admin_key_updated", http.StatusOK, gin.H{"fields": changed})
+	c.JSON(http.StatusOK, h.keyInfo(key))
+}
+
+// RotateKey handles POST /admin/keys/:id/rotate
+func (h *APIKeyHandler) RotateKey(c *gin.Context) {
+	key, ok := h.loadKey(c)
+	if !ok {
+		return
+	}
+	if !key.IsActive {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Cannot rotate a revoked API key",
+		})
+		return
+	}
+
+	apiKey, err := h.apiKeyDB.RotateAPIKey(key.ID)
+	if err != nil {
+		log.Printf("Failed to rotate API key %d: %v", key.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to rotate API key",
+		})
+		return
+	}
+
+	// Sessions opened with the old key end with it
+	h.sessionManager.RevokeAllUserSessions(key.ID)
+
+	// Rotation replaces the public ID, so report the key as it is now
+	key, err = h.apiKeyDB.GetAPIKey(key.ID)
+	if err != nil {
+		log.Printf("Failed to read rotated API key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to rotate API key",
+		})
+		return
+	}
+
+	h.audit(c, key.ID, "admin_key_rotated", http.StatusOK, nil)
+	c.JSON(http.StatusOK, APIKeySecretResponse{
+		Key:     h.keyInfo(key),
+		APIKey:  apiKey,
+		Message: "The previous key no longer works. Store this API key securely. It will not be shown again.",
+	})
+}
+
+// RevokeKey handles POST /admin/keys/:id/revoke
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	key, ok := h.loadKey(c)
+	if !ok {
+		return
+	}
+
+	if err := h.apiKeyDB.RevokeAPIKey(key.ID); err != nil {
+		log.Printf("Failed to revoke API key %d: %v", key.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revoke API key",
+		})
+		return
+	}
+	h.sessionManager.RevokeAllUserSessions(key.ID)
+
+	h.audit(c, key.ID, "admin_key_revoked", http.StatusOK, nil)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API key revoked",
+		"id":      key.ID,
+	})
+}
+
+// EnrollTOTP handles POST /admin/keys/:id/totp. Enrolling again replaces
+// the secret and backup codes.
+func (h *APIKeyHandler) EnrollTOTP(c *gin.Context) {
+	key, ok := h.loadKey(c)
+	if !ok {
+		return
+	}
+
+	accountName := key.Email
+	if accountName == "" {
+		accountName = key.Name
+	}
+
+	otpKey, backupCodes, err := h.totpManager.GenerateTOTP(key.ID, accountName, totpIssuer)
+	if err != nil {
+		log.Printf("Failed to enroll TOTP for API key %d: %v", key.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to enroll TOTP",
+		})
+		return
+	}
+
+	h.audit(c, key.ID, "admin_totp_enrolled", http.StatusOK, nil)
+	c.JSON(http.StatusOK, TOTPEnrollmentResponse{
+		Secret:      otpKey.Secret(),
+		URL:         otpKey.URL(),
+		BackupCodes: backupCodes,
+	})
+}
+
+// ResetTOTP handles DELETE /admin/keys/:id/totp, disabling 2FA so the key
+// can be enrolled again
+func (h *APIKeyHandler) ResetTOTP(c *gin.Context) {
+	key, ok := h.loadKey(c)
+	if !ok {
+		return
+	}
+
+	if err := h.totpManager.DisableTOTP(key.ID); err != nil {
+		log.Printf("Failed to reset TOTP for API key %d: %v", key.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to reset TOTP",
+		})
+		return
+	}
+
+	h.audit(c, key.ID, "admin_totp_reset", http.StatusOK, nil)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "TOTP disabled",
+		"id":      key.ID,
+	})
+}
+
+// loadKey reads the key named by the :id parameter, writing an error response if it fails
+func (h *APIKeyHandler) loadKey(c *gin.Context) (*auth.APIKey, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid API key ID",
+		})
+		return nil, false
+	}
+
+	key, err := h.apiKeyDB.GetAPIKey(id)
+	if errors.Is(err, auth.ErrAPIKeyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "API key not found",
+		})
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Failed to get API key %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get API key",
+		})
+		return nil, false
+	}
+
+	return key, true
+}
+
+// keyInfo converts a stored key for the admin API
+func (h *APIKeyHandler) keyInfo(key *auth.APIKey) APIKeyInfo {
+	totpEnabled, _ := h.totpManager.IsTOTPEnabled(key.ID)
+	return APIKeyInfo{
+		ID:          key.ID,
+		PublicID:    key.PublicID,
+		Name:        key.Name,
+		Email:       key.Email,
+		Description: key.Description,
+		IsActive:    key.IsActive,
+		CreatedAt:   key.CreatedAt,
+		LastUsedAt:  key.LastUsedAt,
+		ExpiresAt:   key.ExpiresAt,
+		Permissions: rawJSON(key.Permissions, "[]"),
+		Metadata:    rawJSON(key.Metadata, "{}"),
+		TOTPEnabled: totpEnabled,
+	}
+}
+
+// audit records an admin action against the target key in api_key_audit
+func (h *APIKeyHandler) audit(c *gin.Context, targetKeyID int64, action string, statusCode int, details gin.H) {
+	entry := gin.H{"method": c.Request.Method}
+	if adminID, ok := c.Get("api_key_id"); ok {
+		entry["admin_key_id"] = adminID
+	}
+	if admin, ok := c.Get("user"); ok {
+		entry["admin"] = admin
+	}
+	for k, v := range details {
+		entry[k] = v
+	}
+	metadata, _ := json.Marshal(entry)
+
+	err := h.apiKeyDB.LogAPIKeyUsage(
+		targetKeyID,
+		action,
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+		c.Request.URL.Path,
+		statusCode,
+		string(metadata),
+	)
+	if err != nil {
+		log.Printf("Failed to write audit log for %s: %v", action, err)
+	}
+}
+
+// jsonField validates a JSON value that must start with one of the bytes in
+// open ("[" or "{") and returns it compacted, or def when absent
+func jsonField(value json.RawMessage, def string, open string) (string, error) {
+	if len(value) == 0 || bytes.Equal(value, []byte("null")) {
+		if def == "" {
+			return "", errors.New("value required")
+		}
+		return def, nil
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, value); err != nil {
+		return "", err
+	}
+	if buf.Len() == 0 || !strings.ContainsRune(open, rune(buf.Bytes()[0])) {
+		return "", errors.New("unexpected JSON type")
+	}
+	return buf.String(), nil
+}
+
+// permissionsField validates a permissions value (array of scopes or policy
+// object) and returns it compacted, or def when absent
+func permissionsField(value json.RawMessage, def string) (string, error) {
+	permissions, err := jsonField(value, def, "[{")
+	if err != nil {
+		return "", errors.New("permissions must be a JSON array of scopes or a permissions object")
+	}
+	if _, err := auth.ParsePermissions(permissions); err != nil {
+		return "", err
+	}
+	return permissions, nil
+}
+
+// rawJSON returns stored JSON for embedding in a response, or def if it is invalid
+func rawJSON(value, def string) json.RawMessage {
+	if !json.Valid([]byte(value)) {
+		return json.RawMessage(def)
+	}
+	return json.RawMessage(value)
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/tosharewith/llmproxy_auth/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRotateKey(t *testing.T) {
+	db, err := auth.NewAPIKeyDB(filepath.Join(t.TempDir(), "apikeys.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+	h := NewAPIKeyHandler(db, auth.NewTOTPManager(db.DB()), auth.NewSessionManager(db.DB()))
+
+	rotate := func(t *testing.T, id int64) APIKeySecretResponse {
+		t.Helper()
+		c, w := newTestContext("/admin/keys/"+strconv.FormatInt(id, 10)+"/rotate", "")
+		c.Params = gin.Params{{Key: "id", Value: strconv.FormatInt(id, 10)}}
+		h.RotateKey(c)
+
+		var resp APIKeySecretResponse
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &resp) != nil {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		return resp
+	}
+
+	tests := []struct {
+		name   string
+		legacy bool
+	}{
+		{"Key with a public ID", false},
+		{"Legacy key is migrated", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, _, err := db.CreateAPIKey(tt.name, "", "", nil, "", "")
+			if err != nil {
+				t.Fatalf("Failed to create API key: %v", err)
+			}
+			if tt.legacy {
+				if _, err := db.DB().Exec("UPDATE api_keys SET public_id = NULL WHERE id = ?", id); err != nil {
+					t.Fatalf("Failed to clear public ID: %v", err)
+				}
+			}
+			before, _ := db.GetAPIKey(id)
+
+			resp := rotate(t, id)
+			after, err := db.ValidateAPIKey(resp.APIKey)
+			if err != nil {
+				t.Fatalf("Rotated key does not validate: %v", err)
+			}
+			if resp.Key.PublicID == "" || resp.Key.PublicID == before.PublicID || resp.Key.PublicID != after.PublicID {
+				t.Errorf("Expected the new public ID %q, got %q (previously %q)", after.PublicID, resp.Key.PublicID, before.PublicID)
+			}
+		})
+	}
+}
@@ -4,7 +4,9 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/tosharewith/llmproxy_auth/internal/auth"
@@ -199,7 +201,7 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		c.GetHeader("User-Agent"),
 		c.Request.URL.Path,
 		200,
-		`{"old_session_id":` + string(rune(session.ID)) + `}`,
+		`{"old_session_id":` + strconv.FormatInt(session.ID, 10) + `}`,
 	)
 
 	expiresAt := time.Now().Add(h.sessionDuration)
@@ -255,7 +257,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		c.GetHeader("User-Agent"),
 		c.Request.URL.Path,
 		200,
-		`{"session_id":` + string(rune(session.ID)) + `}`,
+		`{"session_id":` + strconv.FormatInt(session.ID, 10) + `}`,
 	)
 
 	c.JSON(http.StatusOK, gin.H{
@@ -341,7 +343,7 @@ func (h *AuthHandler) RevokeSession(c *gin.Context) {
 		}
 	}
 
-	_, _, err := h.sessionManager.ValidateSessionToken(currentToken)
+	_, apiKeyID, err := h.sessionManager.ValidateSessionToken(currentToken)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "Invalid session token",
@@ -349,8 +351,37 @@ func (h *AuthHandler) RevokeSession(c *gin.Context) {
 		return
 	}
 
-	// TODO: Verify session belongs to user before revoking
-	// For now, we'll add this in the session manager
+	id, err := strconv.ParseInt(sessionID, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid session ID",
+		})
+		return
+	}
+
+	// Only sessions of the caller's own API key can be revoked
+	if err := h.sessionManager.RevokeUserSession(apiKeyID, id); err != nil {
+		if errors.Is(err, auth.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Session not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revoke session",
+		})
+		return
+	}
+
+	h.apiKeyDB.LogAPIKeyUsage(
+		apiKeyID,
+		"session_revoked",
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+		c.Request.URL.Path,
+		200,
+		`{"session_id":`+strconv.FormatInt(id, 10)+`}`,
+	)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Session revoked successfully",
@@ -0,0 +1,208 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/internal/instance"
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+	"github.com/tosharewith/llmproxy_auth/internal/providers/bedrock"
+	"github.com/tosharewith/llmproxy_auth/internal/translator"
+	"github.com/tosharewith/llmproxy_auth/pkg/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// handleConverseProtocol serves the Bedrock Converse and ConverseStream APIs
+// (POST /converse/{instance}/model/{modelId}/converse[-stream]) from any
+// backend, so AWS SDK clients can switch providers by changing endpoint_url
+func (h *ProtocolHandler) handleConverseProtocol(
+	c *gin.Context,
+	provider providers.Provider,
+	instanceCfg *instance.InstanceConfig,
+	instanceName string,
+	startTime time.Time,
+) {
+	modelID, stream, ok := parseConversePath(c.Request.URL.Path)
+	if !ok {
+		converseError(c, http.StatusNotFound, fmt.Sprintf("Unsupported operation %s; only Converse and ConverseStream are available", c.Request.URL.Path))
+		return
+	}
+
+	var req translator.ConverseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		converseError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	openaiReq, err := translator.TranslateConverseRequestToOpenAI(&req, modelID, stream)
+	if err != nil {
+		converseError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if openaiReq.MaxTokens == 0 {
+		openaiReq.MaxTokens = 4096
+	}
+
+	providerReq, err := buildInstanceRequest(c.Request.Context(), instanceCfg, openaiReq)
+	if err != nil {
+		log.Printf("Translation error: %v", err)
+		converseError(c, http.StatusBadRequest, fmt.Sprintf("Failed to translate request: %v", err))
+		return
+	}
+	requestID := uuid.New().String()
+	c.Header("x-amzn-RequestId", requestID)
+
+	if stream {
+		h.streamConverse(c, provider, instanceCfg, instanceName, providerReq, openaiReq, requestID, startTime)
+		return
+	}
+
+	providerResp, err := provider.Invoke(c.Request.Context(), providerReq)
+	if err != nil {
+		log.Printf("Provider invocation error: %v", err)
+		converseProviderError(c, err)
+		return
+	}
+
+	openaiResp, err := parseInstanceResponse(instanceCfg, providerResp.Body, modelID, requestID)
+	if err != nil {
+		log.Printf("Failed to parse provider response: %v", err)
+		converseError(c, http.StatusBadGateway, "Failed to parse provider response")
+		return
+	}
+	h.recordUsage(c, provider, openaiReq.Model, openaiResp.Usage, false)
+
+	if instanceCfg.Metrics.Enabled {
+		duration := time.Since(startTime)
+		metrics.RequestDuration.WithLabelValues("POST", "200").Observe(duration.Seconds())
+		metrics.RequestsTotal.WithLabelValues("POST", "200").Inc()
+	}
+
+	log.Printf("Protocol request completed: %s (status: 200, duration: %v)", instanceName, time.Since(startTime))
+
+	c.JSON(http.StatusOK, translator.TranslateOpenAIToConverseResponse(openaiResp, time.Since(startTime)))
+}
+
+// streamConverse relays a provider stream as a ConverseStream event stream
+func (h *ProtocolHandler) streamConverse(
+	c *gin.Context,
+	provider providers.Provider,
+	instanceCfg *instance.InstanceConfig,
+	instanceName string,
+	providerReq *providers.ProviderRequest,
+	openaiReq *translator.ChatCompletionRequest,
+	requestID string,
+	startTime time.Time,
+) {
+	stream := openStreamRelay(func() (io.ReadCloser, error) {
+		return provider.InvokeStreaming(c.Request.Context(), providerReq)
+	}, func(err error) {
+		log.Printf("Provider streaming error: %v", err)
+		converseProviderError(c, err)
+	}, openaiReq, startTime, instanceCfg.Metrics.Enabled, func(tokens *translator.Usage) {
+		h.recordUsage(c, provider, openaiReq.Model, tokens, true)
+	})
+	if stream == nil {
+		return
+	}
+	defer stream.Close()
+
+	// Bedrock Converse instances stream Converse events; all others stream OpenAI chunks
+	var next func() (*translator.ChatCompletionStreamResponse, error)
+	if respondsWithConverse(instanceCfg) {
+		next = translator.NewConverseStreamReader(stream, openaiReq.Model, requestID).Next
+	} else {
+		next = translator.NewChatCompletionStreamReader(stream).Next
+	}
+
+	c.Header("Content-Type", "application/vnd.amazon.eventstream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	writer := translator.NewConverseStreamWriter(c.Writer)
+	var writeErr error
+	for {
+		chunk, err := next()
+		if err == io.EOF {
+			writeErr = writer.Close(time.Since(startTime))
+			break
+		}
+		if err != nil {
+			// Headers are already sent, so report the error in-band
+			log.Printf("Stream error from %s: %v", instanceName, err)
+			statusCode := http.StatusBadGateway
+			var providerErr *providers.ProviderError
+			if errors.As(err, &providerErr) && providerErr.StatusCode != 0 {
+				statusCode = providerErr.StatusCode
+			}
+			stream.status = strconv.Itoa(statusCode)
+			writeErr = writer.WriteException(statusCode, err.Error())
+			break
+		}
+
+		stream.observe(chunk)
+		if err := writer.WriteChunk(chunk); err != nil {
+			// Client went away
+			log.Printf("Failed to write stream event: %v", err)
+			stream.status = "499"
+			return
+		}
+		c.Writer.Flush()
+	}
+	if writeErr != nil {
+		log.Printf("Failed to write stream event: %v", writeErr)
+	}
+	c.Writer.Flush()
+}
+
+// parseConversePath extracts the model ID from .../model/{modelId}/converse
+// or .../model/{modelId}/converse-stream
+func parseConversePath(path string) (modelID string, stream bool, ok bool) {
+	_, rest, found := strings.Cut(path, "/model/")
+	if !found {
+		return "", false, false
+	}
+	if modelID, found = strings.CutSuffix(rest, "/converse-stream"); found {
+		return modelID, true, modelID != ""
+	}
+	if modelID, found = strings.CutSuffix(rest, "/converse"); found {
+		return modelID, false, modelID != ""
+	}
+	return "", false, false
+}
+
+// converseError writes an error the way Bedrock does: the exception name in
+// x-amzn-ErrorType and the message in the body
+func converseError(c *gin.Context, statusCode int, message string) {
+	c.Header("x-amzn-ErrorType", bedrock.ExceptionType(statusCode))
+	c.JSON(statusCode, gin.H{"message": message})
+}
+
+// converseProviderError converts provider errors to Bedrock's format
+func converseProviderError(c *gin.Context, err error) {
+	var providerErr *providers.ProviderError
+	if !errors.As(err, &providerErr) {
+		converseError(c, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	statusCode := providerErr.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusInternalServerError
+	}
+	if providerErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(providerErr.RetryAfter.Round(time.Second).Seconds())))
+	}
+	converseError(c, statusCode, providerErr.Message)
+}
@@ -0,0 +1,293 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+	"github.com/tosharewith/llmproxy_auth/internal/router"
+	"github.com/tosharewith/llmproxy_auth/internal/translator"
+	"github.com/tosharewith/llmproxy_auth/pkg/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Maximum number of inputs per embeddings call for each provider
+const (
+	openAIEmbeddingBatchSize = 2048
+	vertexEmbeddingBatchSize = 250
+	ibmEmbeddingBatchSize    = 1000
+	oracleEmbeddingBatchSize = 96
+)
+
+// Embeddings handles POST /v1/embeddings
+func (h *OpenAIHandler) Embeddings(c *gin.Context) {
+	startTime := time.Now()
+
+	var req translator.EmbeddingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, translator.ErrorResponse{
+			Error: translator.ErrorDetail{
+				Message: "Invalid request body",
+				Type:    "invalid_request_error",
+				Code:    "invalid_json",
+			},
+		})
+		return
+	}
+
+	if req.Model == "" {
+		c.JSON(http.StatusBadRequest, translator.ErrorResponse{
+			Error: translator.ErrorDetail{
+				Message: "Model is required",
+				Type:    "invalid_request_error",
+				Code:    "missing_model",
+			},
+		})
+		return
+	}
+
+	texts, err := translator.EmbeddingInputs(req.Input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, translator.ErrorResponse{
+			Error: translator.ErrorDetail{
+				Message: err.Error(),
+				Type:    "invalid_request_error",
+				Param:   "input",
+				Code:    "invalid_input",
+			},
+		})
+		return
+	}
+
+	switch req.EncodingFormat {
+	case "", "float", "base64":
+	default:
+		c.JSON(http.StatusBadRequest, translator.ErrorResponse{
+			Error: translator.ErrorDetail{
+				Message: fmt.Sprintf("Unsupported encoding_format %q; use float or base64", req.EncodingFormat),
+				Type:    "invalid_request_error",
+				Param:   "encoding_format",
+				Code:    "invalid_encoding_format",
+			},
+		})
+		return
+	}
+	if req.Dimensions < 0 {
+		c.JSON(http.StatusBadRequest, translator.ErrorResponse{
+			Error: translator.ErrorDetail{
+				Message: "dimensions must be positive",
+				Type:    "invalid_request_error",
+				Param:   "dimensions",
+				Code:    "invalid_dimensions",
+			},
+		})
+		return
+	}
+
+	// Inputs are split into batches the selected provider accepts; all
+	// batches go to the same provider so the vectors are comparable
+	ctx := c.Request.Context()
+	providerResps, result, err := h.router.InvokeBatch(ctx, req.Model, func(provider providers.Provider, modelInfo *router.ProviderModelInfo) ([]*providers.ProviderRequest, error) {
+		return buildEmbeddingRequests(ctx, provider.Name(), modelInfo, &req, texts)
+	})
+	if err != nil {
+		log.Printf("Embeddings invocation error for model %s: %v", req.Model, err)
+		h.handleInvocationError(c, req.Model, err)
+		return
+	}
+
+	providerName := result.Provider.Name()
+	log.Printf("Routed embeddings for model %s to provider %s (model: %s, inputs: %d, batches: %d)",
+		req.Model, providerName, result.ModelInfo.Model, len(texts), len(providerResps))
+
+	resp, err := mergeEmbeddingResponses(providerName, result.ModelInfo, providerResps)
+	if err != nil {
+		log.Printf("Failed to parse embeddings response: %v", err)
+		c.JSON(http.StatusInternalServerError, translator.ErrorResponse{
+			Error: translator.ErrorDetail{
+				Message: "Failed to parse provider response",
+				Type:    "internal_error",
+				Code:    "response_parse_error",
+			},
+		})
+		return
+	}
+	if len(resp.Data) != len(texts) {
+		log.Printf("Provider %s returned %d embeddings for %d inputs", providerName, len(resp.Data), len(texts))
+		c.JSON(http.StatusBadGateway, translator.ErrorResponse{
+			Error: translator.ErrorDetail{
+				Message: "Provider returned an unexpected number of embeddings",
+				Type:    "api_error",
+				Code:    "response_parse_error",
+			},
+		})
+		return
+	}
+
+	resp.Model = req.Model
+	if req.EncodingFormat == "base64" {
+		for i := range resp.Data {
+			resp.Data[i].Embedding.Base64 = true
+		}
+	}
+
+	// Budgets and rate limits charge from the recorded usage, so providers
+	// that report none are charged an estimate rather than nothing
+	tokens := &translator.Usage{
+		PromptTokens: resp.Usage.PromptTokens,
+		TotalTokens:  resp.Usage.TotalTokens,
+	}
+	if tokens.PromptTokens == 0 {
+		tokens.PromptTokens = estimateEmbeddingTokens(texts)
+		tokens.TotalTokens = tokens.PromptTokens
+	}
+
+	metadata := providerResps[0].Metadata
+	h.recordUsage(c, req.Model, result, &metadata, tokens, false)
+
+	duration := time.Since(startTime)
+	metrics.RequestDuration.WithLabelValues("POST", "200").Observe(duration.Seconds())
+	metrics.RequestsTotal.WithLabelValues("POST", "200").Inc()
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// buildEmbeddingRequests splits texts into the provider's batch size and
+// translates each batch into the request format the provider expects
+func buildEmbeddingRequests(ctx context.Context, providerName string, modelInfo *router.ProviderModelInfo, req *translator.EmbeddingRequest, texts []string) ([]*providers.ProviderRequest, error) {
+	// Address the provider's own model ID rather than the client-facing alias
+	model := req.Model
+	if modelInfo != nil && modelInfo.Model != "" {
+		model = modelInfo.Model
+	}
+	inputType := req.InputType
+	if inputType == "" && modelInfo != nil {
+		inputType = modelInfo.Metadata["input_type"]
+	}
+
+	var batchSize int
+	switch providerName {
+	case "bedrock":
+		batchSize = translator.BedrockEmbeddingBatchSize(model)
+	case "openai", "azure":
+		batchSize = openAIEmbeddingBatchSize
+	case "vertex":
+		batchSize = vertexEmbeddingBatchSize
+	case "ibm":
+		batchSize = ibmEmbeddingBatchSize
+	case "oracle":
+		batchSize = oracleEmbeddingBatchSize
+	default:
+		return nil, fmt.Errorf("provider %s does not support embeddings", providerName)
+	}
+
+	var requests []*providers.ProviderRequest
+	for start := 0; start < len(texts); start += batchSize {
+		batch := texts[start:min(start+batchSize, len(texts))]
+
+		providerReq, err := buildEmbeddingRequest(providerName, modelInfo, model, inputType, req, batch)
+		if err != nil {
+			return nil, err
+		}
+		providerReq.Context = ctx
+		requests = append(requests, providerReq)
+	}
+	return requests, nil
+}
+
+// buildEmbeddingRequest translates a single batch
+func buildEmbeddingRequest(providerName string, modelInfo *router.ProviderModelInfo, model, inputType string, req *translator.EmbeddingRequest, batch []string) (*providers.ProviderRequest, error) {
+	batchReq := translator.EmbeddingRequest{
+		Model:      model,
+		Input:      batch,
+		Dimensions: req.Dimensions,
+		User:       req.User,
+		InputType:  inputType,
+	}
+
+	if providerName == "bedrock" {
+		// Bedrock embedding models use InvokeModel with model-specific bodies
+		return translator.TranslateOpenAIToBedrockEmbeddings(&batchReq, model, batch)
+	}
+
+	path := providers.EmbeddingsPath
+	switch providerName {
+	case "openai", "azure":
+		// OpenAI-native APIs reject unknown parameters; floats are always
+		// requested so that batches can be merged and re-encoded
+		batchReq.InputType = ""
+		if providerName == "azure" && modelInfo != nil {
+			deployment := modelInfo.Deployment
+			if deployment == "" {
+				deployment = modelInfo.Model
+			}
+			path = fmt.Sprintf("/deployments/%s/embeddings", deployment)
+		}
+	}
+
+	body, err := json.Marshal(&batchReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	return &providers.ProviderRequest{
+		Method: "POST",
+		Path:   path,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: body,
+	}, nil
+}
+
+// mergeEmbeddingResponses combines batch responses into one OpenAI response,
+// renumbering embeddings by input position and summing usage
+func mergeEmbeddingResponses(providerName string, modelInfo *router.ProviderModelInfo, responses []*providers.ProviderResponse) (*translator.EmbeddingResponse, error) {
+	merged := &translator.EmbeddingResponse{Object: "list", Data: []translator.EmbeddingData{}}
+
+	for _, providerResp := range responses {
+		var batch *translator.EmbeddingResponse
+		if providerName == "bedrock" {
+			var err error
+			batch, err = translator.TranslateBedrockEmbeddingsToOpenAI(modelInfo.Model, providerResp.Body, providerResp.Headers)
+			if err != nil {
+				return nil, err
+			}
+		} else if err := json.Unmarshal(providerResp.Body, &batch); err != nil {
+			return nil, fmt.Errorf("failed to parse embeddings response: %w", err)
+		}
+
+		// Providers may return a batch out of order; index is relative to the batch
+		offset := len(merged.Data)
+		data := make([]translator.EmbeddingData, len(batch.Data))
+		for _, item := range batch.Data {
+			if item.Index < 0 || item.Index >= len(data) {
+				return nil, fmt.Errorf("embedding index %d out of range", item.Index)
+			}
+			item.Index += offset
+			item.Object = "embedding"
+			data[item.Index-offset] = item
+		}
+		merged.Data = append(merged.Data, data...)
+		merged.Usage.PromptTokens += batch.Usage.PromptTokens
+		merged.Usage.TotalTokens += batch.Usage.TotalTokens
+	}
+
+	return merged, nil
+}
+
+// estimateEmbeddingTokens approximates input tokens at four bytes per token
+func estimateEmbeddingTokens(texts []string) int {
+	tokens := 0
+	for _, text := range texts {
+		tokens += (len(text) + 3) / 4
+	}
+	return tokens
+}
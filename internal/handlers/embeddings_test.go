@@ -0,0 +1,51 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestEmbeddingsUsage(t *testing.T) {
+	const body = `{"model":"text-embedding","input":["first input","second"]}`
+	const data = `"data":[{"index":1,"embedding":[0.3,0.4]},{"index":0,"embedding":[0.1,0.2]}]`
+
+	t.Run("Reported usage is priced", func(t *testing.T) {
+		provider := &fakeProvider{name: "openai", embedding: `{"object":"list",` + data + `,"usage":{"prompt_tokens":6,"total_tokens":6}}`}
+		h := NewOpenAIHandler(newTestRouter(t, provider, "text-embedding"))
+		c, w := newTestContext("/v1/embeddings", body)
+		h.Embeddings(c)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		record := usageRecord(t, c)
+		if record.Model != "text-embedding" || record.InputTokens != 6 || record.OutputTokens != 0 || record.TotalTokens != 6 {
+			t.Errorf("Unexpected usage record %+v", record)
+		}
+		if want := 6 * 2 / 1e6; math.Abs(record.TotalCost-want) > 1e-12 {
+			t.Errorf("TotalCost = %v, want %v", record.TotalCost, want)
+		}
+		if total, _ := c.Get("total_tokens"); total != 6 {
+			t.Errorf("total_tokens = %v, want 6", total)
+		}
+	})
+
+	t.Run("Missing usage is estimated", func(t *testing.T) {
+		provider := &fakeProvider{name: "openai", embedding: `{"object":"list",` + data + `}`}
+		h := NewOpenAIHandler(newTestRouter(t, provider, "text-embedding"))
+		c, _ := newTestContext("/v1/embeddings", body)
+		h.Embeddings(c)
+
+		record := usageRecord(t, c)
+		if record.InputTokens != 5 || record.TotalCost == 0 {
+			t.Errorf("Expected an estimated and priced usage record, got %+v", record)
+		}
+		if total, _ := c.Get("total_tokens"); total != 5 {
+			t.Errorf("total_tokens = %v, want 5", total)
+		}
+	})
+}
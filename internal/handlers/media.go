@@ -0,0 +1,108 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/tosharewith/llmproxy_auth/internal/storage"
+	"github.com/tosharewith/llmproxy_auth/internal/translator"
+	"github.com/gin-gonic/gin"
+)
+
+// mediaContextKey holds the media resolved for a request
+type mediaContextKey struct{}
+
+// mediaError reports an image or document reference that could not be loaded
+type mediaError struct {
+	Kind string // image or document
+	Err  error
+}
+
+func (e *mediaError) Error() string {
+	return fmt.Sprintf("failed to load %s: %v", e.Kind, e.Err)
+}
+
+func (e *mediaError) Unwrap() error {
+	return e.Err
+}
+
+// SetMediaResolver enables fetching https:// and object storage image_url
+// and document references and inlining them before translation
+func (h *OpenAIHandler) SetMediaResolver(resolver *storage.MediaResolver) {
+	h.media = resolver
+}
+
+// resolveMedia inlines the remote images of a request as data URLs and its
+// documents as file parts, so every provider receives the same content, and
+// records each resolution in the request context and the gin context ("media").
+// Without a media resolver, only inline documents are accepted.
+func (h *OpenAIHandler) resolveMedia(c *gin.Context, req *translator.ChatCompletionRequest) (*translator.ChatCompletionRequest, error) {
+	if h.media == nil {
+		inlined, err := translator.InlineDocuments(req, nil)
+		if err != nil {
+			return nil, &mediaError{Kind: "document", Err: err}
+		}
+		return inlined, nil
+	}
+
+	ctx := c.Request.Context()
+	var resolved []storage.MediaInfo
+	dataURLs := make(map[string]string)
+	inlined, err := translator.InlineImageURLs(req, func(url string) (string, error) {
+		if dataURL, ok := dataURLs[url]; ok {
+			return dataURL, nil
+		}
+		media, err := h.media.Resolve(ctx, url)
+		if err != nil {
+			return "", err
+		}
+		log.Printf("Resolved image %s (%s, %d bytes, %dx%d) in %v",
+			media.URL, media.MediaType, media.Size, media.Width, media.Height, media.Duration)
+		resolved = append(resolved, media.MediaInfo)
+		dataURLs[url] = media.DataURL()
+		return dataURLs[url], nil
+	})
+	if err != nil {
+		return nil, &mediaError{Kind: "image", Err: err}
+	}
+
+	documents := make(map[string]*storage.Media)
+	fetchDocument := func(url string) (string, []byte, error) {
+		media, ok := documents[url]
+		if !ok {
+			var err error
+			media, err = h.media.ResolveDocument(ctx, url)
+			if err != nil {
+				return "", nil, err
+			}
+			log.Printf("Resolved document %s (%s, %d bytes) in %v",
+				media.URL, media.MediaType, media.Size, media.Duration)
+			resolved = append(resolved, media.MediaInfo)
+			documents[url] = media
+		}
+		return media.MediaType, media.Data, nil
+	}
+	inlined, err = translator.InlineDocuments(inlined, fetchDocument)
+	if err != nil {
+		return nil, &mediaError{Kind: "document", Err: err}
+	}
+
+	if len(resolved) > 0 {
+		c.Set("media", resolved)
+		c.Request = c.Request.WithContext(context.WithValue(ctx, mediaContextKey{}, resolved))
+	}
+	return inlined, nil
+}
+
+// requestMetadata returns the metadata attached to provider requests
+func requestMetadata(ctx context.Context) map[string]any {
+	resolved, ok := ctx.Value(mediaContextKey{}).([]storage.MediaInfo)
+	if !ok {
+		return nil
+	}
+	return map[string]any{"media": resolved}
+}
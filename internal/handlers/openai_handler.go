@@ -4,23 +4,41 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/tosharewith/llmproxy_auth/internal/cache"
 	"github.com/tosharewith/llmproxy_auth/internal/providers"
 	"github.com/tosharewith/llmproxy_auth/internal/router"
+	"github.com/tosharewith/llmproxy_auth/internal/storage"
 	"github.com/tosharewith/llmproxy_auth/internal/translator"
+	"github.com/tosharewith/llmproxy_auth/internal/usage"
 	"github.com/tosharewith/llmproxy_auth/pkg/metrics"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+// maxStructuredOutputRepairs bounds the retries made when output does not
+// match a json_schema response format
+const maxStructuredOutputRepairs = 1
+
+// errResponseParse marks a provider response that could not be parsed
+var errResponseParse = errors.New("failed to parse provider response")
+
 // OpenAIHandler handles OpenAI-compatible API requests
 type OpenAIHandler struct {
 	router *router.Router
+	media  *storage.MediaResolver // nil leaves image URLs to the providers
+	cache  *cache.Cache           // nil disables response caching
+
+	semantic *cache.SemanticIndex // nil disables semantic caching
 }
 
 // NewOpenAIHandler creates a new OpenAI handler
@@ -59,126 +77,188 @@ func (h *OpenAIHandler) ChatCompletions(c *gin.Context) {
 		return
 	}
 
-	// Generate request ID
-	requestID := fmt.Sprintf("chatcmpl-%s", uuid.New().String()[:8])
-
-	// Set default values
-	if req.MaxTokens == 0 {
-		req.MaxTokens = 4096
-	}
-	if req.Temperature == 0 {
-		req.Temperature = 1.0
+	if err := translator.ValidateResponseFormat(req.ResponseFormat); err != nil {
+		c.JSON(http.StatusBadRequest, translator.ErrorResponse{
+			Error: translator.ErrorDetail{
+				Message: err.Error(),
+				Type:    "invalid_request_error",
+				Param:   "response_format",
+				Code:    "invalid_response_format",
+			},
+		})
+		return
 	}
 
-	// Route to appropriate provider
-	provider, modelInfo, err := h.router.RouteRequest(c.Request.Context(), req.Model, "")
+	resolved, err := h.resolveMedia(c, &req)
 	if err != nil {
-		log.Printf("Routing error for model %s: %v", req.Model, err)
+		kind, code := "image", "invalid_image_url"
+		var mediaErr *mediaError
+		if errors.As(err, &mediaErr) && mediaErr.Kind == "document" {
+			kind, code = "document", "invalid_document"
+		}
 		c.JSON(http.StatusBadRequest, translator.ErrorResponse{
 			Error: translator.ErrorDetail{
-				Message: fmt.Sprintf("Model %q not found or not available", req.Model),
+				Message: fmt.Sprintf("Failed to load %s: %v", kind, errors.Unwrap(err)),
 				Type:    "invalid_request_error",
-				Code:    "model_not_found",
+				Code:    code,
 			},
 		})
 		return
 	}
+	req = *resolved
 
-	log.Printf("Routing model %s to provider %s (model: %s)", req.Model, provider.Name(), modelInfo.Model)
+	// Generate request ID
+	requestID := fmt.Sprintf("chatcmpl-%s", uuid.New().String()[:8])
+
+	// Set default values
+	if req.MaxTokens == 0 {
+		req.MaxTokens = 4096
+	}
+	if req.Temperature == 0 {
+		req.Temperature = 1.0
+	}
 
 	// Handle streaming vs non-streaming
 	if req.Stream {
-		h.handleStreamingRequest(c, provider, &req, modelInfo, requestID)
+		h.handleStreamingRequest(c, &req, requestID, startTime)
 	} else {
-		h.handleNonStreamingRequest(c, provider, &req, modelInfo, requestID, startTime)
+		h.handleNonStreamingRequest(c, &req, requestID, startTime)
 	}
 }
 
 // handleNonStreamingRequest handles non-streaming chat completion
 func (h *OpenAIHandler) handleNonStreamingRequest(
 	c *gin.Context,
-	provider providers.Provider,
 	req *translator.ChatCompletionRequest,
-	modelInfo *router.ProviderModelInfo,
 	requestID string,
 	startTime time.Time,
 ) {
-	// Translate OpenAI request to provider format
-	var providerReq *providers.ProviderRequest
-	var err error
+	if key, ttl, ok := h.responseCacheKey(c, req); ok {
+		h.handleCachedRequest(c, req, requestID, startTime, key, ttl)
+		return
+	}
 
-	providerName := provider.Name()
+	query, served := h.semanticLookup(c, req, requestID, startTime)
+	if served {
+		return
+	}
+	comp := h.complete(c.Request.Context(), req, requestID)
+	h.storeSemantic(c, query, comp)
+	if query != nil {
+		c.Header("X-Cache", "MISS")
+	}
+	h.writeCompletion(c, req, requestID, startTime, comp)
+	h.chargeEmbedding(c, query)
+}
 
-	if providerName == "bedrock" {
-		// Bedrock uses Converse API
-		providerReq, _, err = translator.TranslateOpenAIToConverseAPI(req)
-		if err != nil {
-			log.Printf("Translation error: %v", err)
-			c.JSON(http.StatusBadRequest, translator.ErrorResponse{
-				Error: translator.ErrorDetail{
-					Message: fmt.Sprintf("Failed to translate request: %v", err),
-					Type:    "invalid_request_error",
-					Code:    "translation_failed",
-				},
-			})
-			return
-		}
-	} else if providerName == "openai" || providerName == "azure" {
-		// OpenAI and Azure speak OpenAI natively - pass through
-		reqBody, err := json.Marshal(req)
-		if err != nil {
-			log.Printf("Failed to marshal request: %v", err)
-			c.JSON(http.StatusBadRequest, translator.ErrorResponse{
-				Error: translator.ErrorDetail{
-					Message: "Failed to marshal request",
-					Type:    "invalid_request_error",
-					Code:    "marshal_failed",
-				},
-			})
-			return
+// completion is the outcome of a non-streaming chat completion
+type completion struct {
+	resp         *translator.ChatCompletionResponse
+	providerResp *providers.ProviderResponse
+	result       *router.InvocationResult
+	err          error // set with resp when structured output failed validation
+}
+
+// complete invokes a chat completion and enforces a json_schema response
+// format, giving the model a bounded number of chances to repair output
+// that does not validate
+func (h *OpenAIHandler) complete(ctx context.Context, req *translator.ChatCompletionRequest, requestID string) *completion {
+	openaiResp, providerResp, result, err := h.invokeCompletion(ctx, req, requestID)
+	if err != nil {
+		return &completion{err: err}
+	}
+
+	tokens := openaiResp.Usage
+	for repairs := 0; ; repairs++ {
+		validationErr := translator.ValidateStructuredOutput(openaiResp, req)
+		if validationErr == nil {
+			break
 		}
-		providerReq = &providers.ProviderRequest{
-			Method: "POST",
-			Path:   "/chat/completions",
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-			Body:    reqBody,
-			Context: c.Request.Context(),
+		if repairs == maxStructuredOutputRepairs {
+			log.Printf("Structured output for model %s failed validation after %d repair attempts: %v", req.Model, repairs, validationErr)
+			openaiResp.Usage = tokens
+			return &completion{
+				resp:         openaiResp,
+				providerResp: providerResp,
+				result:       result,
+				err:          fmt.Errorf("%w (after %d repair attempts)", validationErr, repairs),
+			}
 		}
-	} else {
-		// Anthropic, Vertex, IBM, Oracle handle translation in their Invoke method
-		reqBody, err := json.Marshal(req)
+
+		log.Printf("Structured output for model %s failed validation, requesting a repair: %v", req.Model, validationErr)
+		repairReq := translator.StructuredOutputRepairRequest(req, openaiResp, validationErr)
+		openaiResp, providerResp, result, err = h.invokeCompletion(ctx, repairReq, requestID)
 		if err != nil {
-			log.Printf("Failed to marshal request: %v", err)
-			c.JSON(http.StatusBadRequest, translator.ErrorResponse{
-				Error: translator.ErrorDetail{
-					Message: "Failed to marshal request",
-					Type:    "invalid_request_error",
-					Code:    "marshal_failed",
-				},
-			})
-			return
+			return &completion{err: err}
 		}
-		providerReq = &providers.ProviderRequest{
-			Method: "POST",
-			Path:   "/chat/completions",
-			Headers: map[string]string{
-				"Content-Type": "application/json",
+		tokens = addUsage(tokens, openaiResp.Usage)
+	}
+	openaiResp.Usage = tokens
+
+	return &completion{resp: openaiResp, providerResp: providerResp, result: result}
+}
+
+// writeCompletion writes the response or error of a completion and records its usage
+func (h *OpenAIHandler) writeCompletion(
+	c *gin.Context,
+	req *translator.ChatCompletionRequest,
+	requestID string,
+	startTime time.Time,
+	comp *completion,
+) {
+	if comp.err != nil && comp.resp == nil {
+		h.handleCompletionError(c, req.Model, comp.err)
+		return
+	}
+	h.recordUsage(c, req.Model, comp.result, &comp.providerResp.Metadata, comp.resp.Usage, false)
+
+	if comp.err != nil {
+		metrics.RequestDuration.WithLabelValues("POST", "502").Observe(time.Since(startTime).Seconds())
+		metrics.RequestsTotal.WithLabelValues("POST", "502").Inc()
+		c.JSON(http.StatusBadGateway, translator.ErrorResponse{
+			Error: translator.ErrorDetail{
+				Message: comp.err.Error(),
+				Type:    "api_error",
+				Code:    "invalid_structured_output",
 			},
-			Body:    reqBody,
-			Context: c.Request.Context(),
-		}
+		})
+		return
 	}
 
-	// Invoke provider
-	providerResp, err := provider.Invoke(c.Request.Context(), providerReq)
+	// Set metadata
+	openaiResp := comp.resp
+	openaiResp.ID = requestID
+	openaiResp.Created = startTime.Unix()
+	openaiResp.Model = req.Model
+
+	// Record metrics
+	duration := time.Since(startTime)
+	metrics.RequestDuration.WithLabelValues("POST", "200").Observe(duration.Seconds())
+	metrics.RequestsTotal.WithLabelValues("POST", "200").Inc()
+
+	c.JSON(http.StatusOK, openaiResp)
+}
+
+// invokeCompletion runs one non-streaming completion with retries and
+// failover and returns the response in OpenAI format
+func (h *OpenAIHandler) invokeCompletion(
+	ctx context.Context,
+	req *translator.ChatCompletionRequest,
+	requestID string,
+) (*translator.ChatCompletionResponse, *providers.ProviderResponse, *router.InvocationResult, error) {
+	// The request is translated per provider
+	providerResp, result, err := h.router.Invoke(ctx, req.Model, func(provider providers.Provider, modelInfo *router.ProviderModelInfo) (*providers.ProviderRequest, error) {
+		return buildProviderRequest(ctx, provider.Name(), modelInfo, req)
+	})
 	if err != nil {
-		log.Printf("Provider invocation error: %v", err)
-		h.handleProviderError(c, err)
-		return
+		log.Printf("Provider invocation error for model %s: %v", req.Model, err)
+		return nil, nil, nil, err
 	}
 
+	providerName := result.Provider.Name()
+	log.Printf("Routed model %s to provider %s (model: %s, attempts: %d)",
+		req.Model, providerName, result.ModelInfo.Model, len(result.Attempts))
+
 	// Parse provider response and translate if needed
 	var openaiResp *translator.ChatCompletionResponse
 
@@ -187,85 +267,425 @@ func (h *OpenAIHandler) handleNonStreamingRequest(
 		var converseResp translator.ConverseResponse
 		if err := json.Unmarshal(providerResp.Body, &converseResp); err != nil {
 			log.Printf("Failed to parse Bedrock response: %v", err)
-			c.JSON(http.StatusInternalServerError, translator.ErrorResponse{
-				Error: translator.ErrorDetail{
-					Message: "Failed to parse provider response",
-					Type:    "internal_error",
-					Code:    "response_parse_error",
-				},
-			})
-			return
+			return nil, nil, nil, fmt.Errorf("%w: %v", errResponseParse, err)
 		}
 		openaiResp = translator.TranslateConverseToOpenAI(&converseResp, req.Model, requestID)
 	} else {
 		// OpenAI, Azure, Anthropic, Vertex, IBM, Oracle return OpenAI format (or already translated)
 		if err := json.Unmarshal(providerResp.Body, &openaiResp); err != nil {
 			log.Printf("Failed to parse provider response: %v", err)
-			c.JSON(http.StatusInternalServerError, translator.ErrorResponse{
-				Error: translator.ErrorDetail{
-					Message: "Failed to parse provider response",
-					Type:    "internal_error",
-					Code:    "response_parse_error",
-				},
-			})
-			return
+			return nil, nil, nil, fmt.Errorf("%w: %v", errResponseParse, err)
 		}
 	}
 
-	// Set metadata
-	openaiResp.ID = requestID
-	openaiResp.Created = startTime.Unix()
+	if emulatesTools(providerName, result.ModelInfo, req) {
+		if err := translator.ParseEmulatedToolCalls(openaiResp, req.Tools); err != nil {
+			log.Printf("Returning unparsed emulated tool call from %s as text: %v", providerName, err)
+		}
+	}
+	translator.UnwrapStructuredOutput(openaiResp, req)
 
-	// Record metrics
-	duration := time.Since(startTime)
-	metrics.RequestDuration.WithLabelValues("POST", "200").Observe(duration.Seconds())
-	metrics.RequestsTotal.WithLabelValues("POST", "200").Inc()
+	return openaiResp, providerResp, result, nil
+}
 
-	c.JSON(http.StatusOK, openaiResp)
+// handleCompletionError writes the error of a failed invokeCompletion
+func (h *OpenAIHandler) handleCompletionError(c *gin.Context, model string, err error) {
+	if errors.Is(err, errResponseParse) {
+		c.JSON(http.StatusInternalServerError, translator.ErrorResponse{
+			Error: translator.ErrorDetail{
+				Message: "Failed to parse provider response",
+				Type:    "internal_error",
+				Code:    "response_parse_error",
+			},
+		})
+		return
+	}
+	h.handleInvocationError(c, model, err)
+}
+
+// addUsage sums the token usage of two completions
+func addUsage(a, b *translator.Usage) *translator.Usage {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	return &translator.Usage{
+		PromptTokens:     a.PromptTokens + b.PromptTokens,
+		CompletionTokens: a.CompletionTokens + b.CompletionTokens,
+		TotalTokens:      a.TotalTokens + b.TotalTokens,
+	}
 }
 
 // handleStreamingRequest handles streaming chat completion
 func (h *OpenAIHandler) handleStreamingRequest(
 	c *gin.Context,
-	provider providers.Provider,
 	req *translator.ChatCompletionRequest,
-	modelInfo *router.ProviderModelInfo,
 	requestID string,
+	startTime time.Time,
 ) {
-	// TODO: Implement streaming support
-	c.JSON(http.StatusNotImplemented, translator.ErrorResponse{
-		Error: translator.ErrorDetail{
-			Message: "Streaming not yet implemented",
-			Type:    "not_implemented_error",
-			Code:    "streaming_not_implemented",
-		},
+	if !h.router.GetConfig().Features.Streaming {
+		c.JSON(http.StatusBadRequest, translator.ErrorResponse{
+			Error: translator.ErrorDetail{
+				Message: "Streaming is disabled on this gateway",
+				Type:    "invalid_request_error",
+				Code:    "streaming_disabled",
+			},
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	var result *router.InvocationResult
+	stream := openStreamRelay(func() (stream io.ReadCloser, err error) {
+		stream, result, err = h.router.InvokeStreaming(ctx, req.Model, func(provider providers.Provider, modelInfo *router.ProviderModelInfo) (*providers.ProviderRequest, error) {
+			return buildProviderRequest(ctx, provider.Name(), modelInfo, req)
+		})
+		return stream, err
+	}, func(err error) {
+		log.Printf("Provider streaming error for model %s: %v", req.Model, err)
+		h.handleInvocationError(c, req.Model, err)
+	}, req, startTime, true, func(tokens *translator.Usage) {
+		h.recordUsage(c, req.Model, result, &providers.ResponseMetadata{Latency: time.Since(startTime)}, tokens, true)
+	})
+	if stream == nil {
+		return
+	}
+	defer stream.Close()
+
+	providerName := result.Provider.Name()
+	log.Printf("Streaming model %s from provider %s (model: %s, attempts: %d)",
+		req.Model, providerName, result.ModelInfo.Model, len(result.Attempts))
+
+	// Bedrock streams Converse events; all other providers stream OpenAI chunks
+	var next func() (*translator.ChatCompletionStreamResponse, error)
+	if providerName == "bedrock" {
+		next = translator.NewConverseStreamReader(stream, req.Model, requestID).Next
+	} else {
+		next = translator.NewChatCompletionStreamReader(stream).Next
+	}
+	if emulatesTools(providerName, result.ModelInfo, req) {
+		next = translator.NewToolEmulationStreamReader(next, req.Tools).Next
+	}
+	if translator.StructuredOutputSchema(req) != nil {
+		next = translator.NewStructuredOutputStreamReader(next, req).Next
+	}
+
+	// Set SSE headers
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// Usage chunks requested on the client's behalf are not passed on
+	forwardUsage := streamOptions(providerName, req) == nil || (req.StreamOptions != nil && req.StreamOptions.IncludeUsage)
+	for {
+		chunk, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Headers are already sent, so report the error in-band
+			log.Printf("Stream error from %s: %v", providerName, err)
+			detail := translator.ErrorDetail{
+				Message: err.Error(),
+				Type:    "api_error",
+				Code:    "stream_error",
+			}
+			stream.status = "502"
+			var outputErr *translator.StructuredOutputError
+			if errors.As(err, &outputErr) {
+				detail.Code = "invalid_structured_output"
+			}
+			var providerErr *providers.ProviderError
+			if errors.As(err, &providerErr) {
+				detail.Type = providerErrorType(providerErr.Code)
+				if providerErr.Code != "" {
+					detail.Code = providerErr.Code
+				}
+				if providerErr.StatusCode != 0 {
+					stream.status = strconv.Itoa(providerErr.StatusCode)
+				}
+			}
+			translator.WriteStreamError(c.Writer, detail)
+			break
+		}
+
+		// Normalize chunk identity so clients see one consistent stream
+		chunk.ID = requestID
+		chunk.Object = "chat.completion.chunk"
+		chunk.Created = startTime.Unix()
+		chunk.Model = req.Model
+		stream.observe(chunk)
+		if chunk.Usage != nil && len(chunk.Choices) == 0 && !forwardUsage {
+			continue
+		}
+
+		if err := translator.WriteStreamChunk(c.Writer, chunk); err != nil {
+			// Client went away
+			log.Printf("Failed to write stream chunk: %v", err)
+			stream.status = "499"
+			return
+		}
+		c.Writer.Flush()
+	}
+
+	translator.WriteStreamDone(c.Writer)
+	c.Writer.Flush()
+}
+
+// streamOptions returns the stream_options to send a provider. OpenAI and
+// Azure only end a stream with a usage chunk when asked to; other providers
+// report usage unasked and do not take the field.
+func streamOptions(providerName string, req *translator.ChatCompletionRequest) *translator.StreamOptions {
+	if !req.Stream || (providerName != "openai" && providerName != "azure") {
+		return nil
+	}
+	return &translator.StreamOptions{IncludeUsage: true}
+}
+
+// estimateUsage approximates the usage of a streamed completion whose
+// provider reported none, at ~4 bytes per token like the rate limiter
+func estimateUsage(req *translator.ChatCompletionRequest, outputBytes int) *translator.Usage {
+	promptBytes := 0
+	if messages, err := json.Marshal(req.Messages); err == nil {
+		promptBytes = len(messages)
+	}
+	estimate := &translator.Usage{
+		PromptTokens:     (promptBytes + 3) / 4,
+		CompletionTokens: (outputBytes + 3) / 4,
+	}
+	estimate.TotalTokens = estimate.PromptTokens + estimate.CompletionTokens
+	return estimate
+}
+
+// deltaBytes returns the length of the text and tool call arguments in a chunk
+func deltaBytes(chunk *translator.ChatCompletionStreamResponse) int {
+	n := 0
+	for _, choice := range chunk.Choices {
+		n += len(choice.Delta.Content)
+		if choice.Delta.FunctionCall != nil {
+			n += len(choice.Delta.FunctionCall.Arguments)
+		}
+		for _, call := range choice.Delta.ToolCalls {
+			n += len(call.Function.Name) + len(call.Function.Arguments)
+		}
+	}
+	return n
+}
+
+// recordUsage prices a completion's token usage and exposes it to middleware
+// that runs after the handler (rate limiting and the usage ledger)
+func (h *OpenAIHandler) recordUsage(
+	c *gin.Context,
+	model string,
+	result *router.InvocationResult,
+	metadata *providers.ResponseMetadata,
+	tokens *translator.Usage,
+	streaming bool,
+) {
+	providerName := result.Provider.Name()
+	if metadata.ModelUsed == "" && result.ModelInfo != nil {
+		metadata.ModelUsed = result.ModelInfo.Model
+	}
+
+	if tokens != nil {
+		c.Set("prompt_tokens", tokens.PromptTokens)
+		c.Set("completion_tokens", tokens.CompletionTokens)
+		c.Set("total_tokens", tokens.TotalTokens)
+
+		pricing := h.router.ModelPricing(c.Request.Context(), result.Provider, result.ModelInfo)
+		metadata.SetUsage(tokens.PromptTokens, tokens.CompletionTokens, pricing)
+		metrics.RecordUsage(providerName, model, metadata.InputTokens, metadata.OutputTokens, metadata.TotalCost)
+	}
+
+	setUsageRecord(c, model, providerName, metadata, streaming)
+}
+
+// setUsageRecord leaves a priced completion for the usage ledger and budgets
+func setUsageRecord(c *gin.Context, model, providerName string, metadata *providers.ResponseMetadata, streaming bool) {
+	c.Set("usage_record", usage.Record{
+		Timestamp:     time.Now(),
+		Model:         model,
+		Provider:      providerName,
+		ProviderModel: metadata.ModelUsed,
+		InputTokens:   metadata.InputTokens,
+		OutputTokens:  metadata.OutputTokens,
+		TotalTokens:   metadata.TotalTokens,
+		InputCost:     metadata.InputCost,
+		OutputCost:    metadata.OutputCost,
+		TotalCost:     metadata.TotalCost,
+		Streaming:     streaming,
 	})
 }
 
+// buildProviderRequest translates an OpenAI request into the request format the provider expects
+func buildProviderRequest(ctx context.Context, providerName string, modelInfo *router.ProviderModelInfo, req *translator.ChatCompletionRequest) (*providers.ProviderRequest, error) {
+	// Address the provider's own model ID rather than the client-facing alias
+	providerModelReq := *req
+	if modelInfo != nil && modelInfo.Model != "" {
+		providerModelReq.Model = modelInfo.Model
+	}
+
+	if emulatesTools(providerName, modelInfo, req) {
+		providerModelReq = *translator.EmulateTools(&providerModelReq)
+	}
+	providerModelReq = *structuredOutputRequest(providerName, &providerModelReq)
+
+	// Documents the provider cannot take as document blocks are sent as text
+	documentReq, err := translator.DocumentsAsText(&providerModelReq, nativeDocumentType(providerName))
+	if err != nil {
+		return nil, err
+	}
+	providerModelReq = *documentReq
+	providerModelReq.StreamOptions = streamOptions(providerName, req)
+
+	if providerName == "bedrock" {
+		// Bedrock uses Converse API
+		providerReq, _, err := translator.TranslateOpenAIToConverseAPI(&providerModelReq)
+		if err != nil {
+			return nil, err
+		}
+		providerReq.Context = ctx
+		providerReq.Metadata = requestMetadata(ctx)
+		return providerReq, nil
+	}
+
+	// OpenAI and Azure speak OpenAI natively and are passed through;
+	// Anthropic, Vertex, IBM and Oracle handle translation in their Invoke method
+	reqBody, err := json.Marshal(&providerModelReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	path := "/chat/completions"
+	if providerName == "azure" && modelInfo != nil {
+		// Azure addresses models by deployment name
+		deployment := modelInfo.Deployment
+		if deployment == "" {
+			deployment = modelInfo.Model
+		}
+		path = fmt.Sprintf("/deployments/%s/chat/completions", deployment)
+	}
+
+	return &providers.ProviderRequest{
+		Method: "POST",
+		Path:   path,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body:     reqBody,
+		Metadata: requestMetadata(ctx),
+		Context:  ctx,
+	}, nil
+}
+
+// nativeDocumentType returns whether a provider accepts a document media type
+// as a document block; an empty type stands for an OpenAI file_id reference
+func nativeDocumentType(providerName string) func(mediaType string) bool {
+	return func(mediaType string) bool {
+		switch providerName {
+		case "bedrock":
+			return translator.IsConverseDocumentType(mediaType)
+		case "openai", "azure":
+			return mediaType == "application/pdf" || mediaType == ""
+		case "anthropic":
+			return mediaType == "application/pdf" || mediaType == "text/plain"
+		case "vertex":
+			return mediaType == "application/pdf"
+		default:
+			return false
+		}
+	}
+}
+
+// emulatesTools reports whether tool calling is emulated in the prompt for a
+// target; providers with native tool calling (Bedrock toolConfig, Vertex
+// functionDeclarations, OpenAI, Azure, Anthropic) always use it
+func emulatesTools(providerName string, modelInfo *router.ProviderModelInfo, req *translator.ChatCompletionRequest) bool {
+	if modelInfo == nil || !modelInfo.ToolEmulation {
+		return false
+	}
+	switch providerName {
+	case "ibm", "oracle":
+		return translator.UsesTools(req)
+	default:
+		return false
+	}
+}
+
+// structuredOutputRequest adapts a json_schema response format to a provider.
+// OpenAI and Azure accept it natively and Vertex maps it to responseSchema;
+// Bedrock and Anthropic are forced to call a tool with the schema as input.
+// Requests that offer their own tools, and other providers, describe the
+// schema in the prompt. The gateway validates the output in every case.
+func structuredOutputRequest(providerName string, req *translator.ChatCompletionRequest) *translator.ChatCompletionRequest {
+	if translator.StructuredOutputSchema(req) == nil {
+		return req
+	}
+	switch providerName {
+	case "openai", "azure":
+		return req
+	case "vertex", "bedrock", "anthropic":
+		if len(req.Tools) > 0 {
+			return translator.StructuredOutputPromptRequest(req)
+		}
+		if providerName == "vertex" {
+			return req
+		}
+		return translator.StructuredOutputToolRequest(req)
+	default:
+		return translator.StructuredOutputPromptRequest(req)
+	}
+}
+
+// handleInvocationError converts routing and provider errors to OpenAI error format
+func (h *OpenAIHandler) handleInvocationError(c *gin.Context, model string, err error) {
+	if errors.Is(err, router.ErrNoProvider) {
+		c.JSON(http.StatusBadRequest, translator.ErrorResponse{
+			Error: translator.ErrorDetail{
+				Message: fmt.Sprintf("Model %q not found or not available", model),
+				Type:    "invalid_request_error",
+				Code:    "model_not_found",
+			},
+		})
+		return
+	}
+
+	// No target could read a document in its format
+	var unsupported *translator.UnsupportedDocumentError
+	if errors.As(err, &unsupported) {
+		c.JSON(http.StatusBadRequest, translator.ErrorResponse{
+			Error: translator.ErrorDetail{
+				Message: unsupported.Error(),
+				Type:    "invalid_request_error",
+				Code:    "unsupported_document",
+			},
+		})
+		return
+	}
+
+	h.handleProviderError(c, err)
+}
+
 // handleProviderError converts provider errors to OpenAI error format
 func (h *OpenAIHandler) handleProviderError(c *gin.Context, err error) {
-	if providerErr, ok := err.(*providers.ProviderError); ok {
+	var providerErr *providers.ProviderError
+	if errors.As(err, &providerErr) {
 		statusCode := providerErr.StatusCode
 		if statusCode == 0 {
 			statusCode = http.StatusInternalServerError
 		}
-
-		errorType := "api_error"
-		switch providerErr.Code {
-		case providers.ErrCodeInvalidRequest:
-			errorType = "invalid_request_error"
-		case providers.ErrCodeAuthenticationFail:
-			errorType = "authentication_error"
-		case providers.ErrCodeRateLimitExceeded:
-			errorType = "rate_limit_error"
-		case providers.ErrCodeModelNotFound:
-			errorType = "invalid_request_error"
+		if providerErr.RetryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(providerErr.RetryAfter.Round(time.Second).Seconds())))
 		}
 
 		c.JSON(statusCode, translator.ErrorResponse{
 			Error: translator.ErrorDetail{
 				Message: providerErr.Message,
-				Type:    errorType,
+				Type:    providerErrorType(providerErr.Code),
 				Code:    providerErr.Code,
 			},
 		})
@@ -282,6 +702,24 @@ func (h *OpenAIHandler) handleProviderError(c *gin.Context, err error) {
 	})
 }
 
+// providerErrorType maps a provider error code to an OpenAI error type
+func providerErrorType(code string) string {
+	switch code {
+	case providers.ErrCodeInvalidRequest:
+		return "invalid_request_error"
+	case providers.ErrCodeAuthenticationFail:
+		return "authentication_error"
+	case providers.ErrCodeRateLimitExceeded:
+		return "rate_limit_error"
+	case providers.ErrCodeModelNotFound:
+		return "invalid_request_error"
+	case providers.ErrCodePermissionDenied:
+		return "permission_error"
+	default:
+		return "api_error"
+	}
+}
+
 // ListModels handles GET /v1/models
 func (h *OpenAIHandler) ListModels(c *gin.Context) {
 	models, err := h.router.ListModels(c.Request.Context())
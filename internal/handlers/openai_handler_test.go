@@ -0,0 +1,181 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+	"github.com/tosharewith/llmproxy_auth/internal/router"
+	"github.com/tosharewith/llmproxy_auth/internal/translator"
+	"github.com/tosharewith/llmproxy_auth/internal/usage"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeProvider answers every request with a canned body or stream and keeps
+// the request bodies it was sent
+type fakeProvider struct {
+	name      string
+	response  string
+	embedding string // response to embedding requests
+	stream    string
+	catalog   *providers.Model // returned by GetModelInfo when set
+	bodies    []string
+}
+
+func (p *fakeProvider) Name() string                          { return p.name }
+func (p *fakeProvider) HealthCheck(ctx context.Context) error { return nil }
+
+func (p *fakeProvider) Invoke(ctx context.Context, req *providers.ProviderRequest) (*providers.ProviderResponse, error) {
+	p.bodies = append(p.bodies, string(req.Body))
+	if req.Path == providers.EmbeddingsPath {
+		return &providers.ProviderResponse{StatusCode: http.StatusOK, Body: []byte(p.embedding)}, nil
+	}
+	return &providers.ProviderResponse{StatusCode: http.StatusOK, Body: []byte(p.response)}, nil
+}
+
+func (p *fakeProvider) InvokeStreaming(ctx context.Context, req *providers.ProviderRequest) (io.ReadCloser, error) {
+	p.bodies = append(p.bodies, string(req.Body))
+	return io.NopCloser(strings.NewReader(p.stream)), nil
+}
+
+func (p *fakeProvider) ListModels(ctx context.Context) ([]providers.Model, error) { return nil, nil }
+
+func (p *fakeProvider) GetModelInfo(ctx context.Context, modelID string) (*providers.Model, error) {
+	if p.catalog == nil {
+		return nil, errors.New("not found")
+	}
+	return p.catalog, nil
+}
+
+// newTestRouter routes models to provider at $2 input and $10 output per 1M tokens
+func newTestRouter(t *testing.T, provider *fakeProvider, models ...string) *router.Router {
+	t.Helper()
+
+	config := &router.Config{
+		ModelMappings: map[string]router.ModelMapping{},
+		Providers:     map[string]router.ProviderConfig{provider.name: {Enabled: true}},
+		Features:      router.FeatureFlags{Streaming: true},
+	}
+	for _, model := range models {
+		config.ModelMappings[model] = router.ModelMapping{DefaultProvider: provider.name, Providers: map[string]router.ProviderModelInfo{
+			provider.name: {Model: model + "-upstream", InputPrice: 2, OutputPrice: 10},
+		}}
+	}
+	r, err := router.NewRouter(config, map[string]providers.Provider{provider.name: provider})
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+	return r
+}
+
+// newTestContext creates a context for a JSON POST request
+func newTestContext(path, body string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	return c, w
+}
+
+// usageRecord returns the usage record a handler left on the context
+func usageRecord(t *testing.T, c *gin.Context) usage.Record {
+	t.Helper()
+	value, ok := c.Get("usage_record")
+	if !ok {
+		t.Fatal("Expected a usage_record")
+	}
+	return value.(usage.Record)
+}
+
+func TestStreamingUsage(t *testing.T) {
+	const chunks = "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hello there\"}}]}\n\n"
+
+	t.Run("Usage chunk is requested and recorded", func(t *testing.T) {
+		provider := &fakeProvider{name: "openai", stream: chunks +
+			"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":5,\"total_tokens\":15}}\n\n" +
+			"data: [DONE]\n\n"}
+		h := NewOpenAIHandler(newTestRouter(t, provider, "gpt-4o"))
+		c, w := newTestContext("/v1/chat/completions", `{"model":"gpt-4o","stream":true,"messages":[{"role":"user","content":"Hi"}]}`)
+		h.ChatCompletions(c)
+
+		var sent struct {
+			StreamOptions struct {
+				IncludeUsage bool `json:"include_usage"`
+			} `json:"stream_options"`
+		}
+		if len(provider.bodies) != 1 || json.Unmarshal([]byte(provider.bodies[0]), &sent) != nil || !sent.StreamOptions.IncludeUsage {
+			t.Fatalf("Expected stream_options.include_usage to be sent, got %v", provider.bodies)
+		}
+
+		record := usageRecord(t, c)
+		if record.InputTokens != 10 || record.OutputTokens != 5 || record.TotalTokens != 15 || !record.Streaming {
+			t.Errorf("Unexpected usage record %+v", record)
+		}
+		if want := (10*2 + 5*10) / 1e6; math.Abs(record.TotalCost-want) > 1e-12 {
+			t.Errorf("TotalCost = %v, want %v", record.TotalCost, want)
+		}
+		if total, _ := c.Get("total_tokens"); total != 15 {
+			t.Errorf("total_tokens = %v, want 15", total)
+		}
+		if strings.Contains(w.Body.String(), "prompt_tokens") {
+			t.Error("Expected the usage chunk not to be forwarded to a client that did not ask for it")
+		}
+	})
+
+	t.Run("Usage chunk is forwarded when the client asks", func(t *testing.T) {
+		provider := &fakeProvider{name: "azure", stream: chunks +
+			"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":5,\"total_tokens\":15}}\n\n" +
+			"data: [DONE]\n\n"}
+		h := NewOpenAIHandler(newTestRouter(t, provider, "gpt-4o"))
+		c, w := newTestContext("/v1/chat/completions", `{"model":"gpt-4o","stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"Hi"}]}`)
+		h.ChatCompletions(c)
+
+		if !strings.Contains(w.Body.String(), "prompt_tokens") {
+			t.Error("Expected the usage chunk to be forwarded")
+		}
+	})
+
+	t.Run("Missing usage is estimated", func(t *testing.T) {
+		provider := &fakeProvider{name: "openai", stream: chunks + "data: [DONE]\n\n"}
+		h := NewOpenAIHandler(newTestRouter(t, provider, "gpt-4o"))
+		c, _ := newTestContext("/v1/chat/completions", `{"model":"gpt-4o","stream":true,"messages":[{"role":"user","content":"Hi"}]}`)
+		h.ChatCompletions(c)
+
+		record := usageRecord(t, c)
+		if record.InputTokens == 0 || record.OutputTokens != 3 || record.TotalCost == 0 {
+			t.Errorf("Expected an estimated and priced usage record, got %+v", record)
+		}
+	})
+}
+
+func TestUnsupportedDocument(t *testing.T) {
+	provider := &fakeProvider{name: "ibm", response: `{"choices":[]}`}
+	h := NewOpenAIHandler(newTestRouter(t, provider, "granite"))
+	c, w := newTestContext("/v1/chat/completions", `{"model":"granite","messages":[{"role":"user","content":[`+
+		`{"type":"text","text":"Summarize."},`+
+		`{"type":"file","file":{"filename":"report.pdf","file_data":"data:application/pdf;base64,JVBERi0xLjc="}}]}]}`)
+	h.ChatCompletions(c)
+
+	var body translator.ErrorResponse
+	if w.Code != http.StatusBadRequest || json.Unmarshal(w.Body.Bytes(), &body) != nil {
+		t.Fatalf("Expected a 400 error, got %d %s", w.Code, w.Body.String())
+	}
+	if body.Error.Code != "unsupported_document" || !strings.Contains(body.Error.Message, "report.pdf") ||
+		!strings.Contains(body.Error.Message, "text is not extracted from application/pdf documents") {
+		t.Errorf("Unexpected error %+v", body.Error)
+	}
+	if len(provider.bodies) != 0 {
+		t.Errorf("Expected no provider call, got %v", provider.bodies)
+	}
+}
@@ -4,14 +4,17 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/tosharewith/llmproxy_auth/internal/instance"
 	"github.com/tosharewith/llmproxy_auth/internal/providers"
+	"github.com/tosharewith/llmproxy_auth/internal/router"
 	"github.com/tosharewith/llmproxy_auth/internal/translator"
 	"github.com/tosharewith/llmproxy_auth/pkg/metrics"
 	"github.com/gin-gonic/gin"
@@ -21,14 +24,16 @@ import (
 // ProtocolHandler handles protocol-based requests with transformations
 type ProtocolHandler struct {
 	providers map[string]providers.Provider
-	config    *instance.Config
+	config    *instance.Store
+	router    *router.Router // prices usage from the model catalog
 }
 
 // NewProtocolHandler creates a new protocol handler
-func NewProtocolHandler(providerRegistry map[string]providers.Provider, config *instance.Config) *ProtocolHandler {
+func NewProtocolHandler(providerRegistry map[string]providers.Provider, config *instance.Store, r *router.Router) *ProtocolHandler {
 	return &ProtocolHandler{
 		providers: providerRegistry,
 		config:    config,
+		router:    r,
 	}
 }
 
@@ -40,7 +45,7 @@ func (h *ProtocolHandler) HandleRequest(c *gin.Context) {
 	path := c.Request.URL.Path
 
 	// Find matching instance
-	instanceCfg, instanceName, err := h.config.GetInstanceByPath(path)
+	instanceCfg, instanceName, err := h.config.Load().GetInstanceByPath(path)
 	if err != nil {
 		log.Printf("No instance found for path %s: %v", path, err)
 		c.JSON(http.StatusNotFound, translator.ErrorResponse{
@@ -84,9 +89,14 @@ func (h *ProtocolHandler) HandleRequest(c *gin.Context) {
 	}
 
 	// Parse request based on protocol
-	if instanceCfg.Protocol == "openai" {
+	switch instanceCfg.Protocol {
+	case "openai":
 		h.handleOpenAIProtocol(c, provider, instanceCfg, instanceName, startTime)
-	} else {
+	case "anthropic":
+		h.handleAnthropicProtocol(c, provider, instanceCfg, instanceName, startTime)
+	case "converse":
+		h.handleConverseProtocol(c, provider, instanceCfg, instanceName, startTime)
+	default:
 		c.JSON(http.StatusNotImplemented, translator.ErrorResponse{
 			Error: translator.ErrorDetail{
 				Message: fmt.Sprintf("Protocol %s not yet implemented", instanceCfg.Protocol),
@@ -122,85 +132,7 @@ func (h *ProtocolHandler) handleOpenAIProtocol(
 	requestID := fmt.Sprintf("chatcmpl-%s", uuid.New().String()[:8])
 
 	// Apply transformation
-	var providerReq *providers.ProviderRequest
-	var err error
-
-	if instanceCfg.Transformation == nil {
-		// No transformation specified - treat as passthrough
-		reqBody, err := json.Marshal(req)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, translator.ErrorResponse{
-				Error: translator.ErrorDetail{
-					Message: "Failed to marshal request",
-					Type:    "internal_error",
-					Code:    "marshal_failed",
-				},
-			})
-			return
-		}
-		providerReq = &providers.ProviderRequest{
-			Method: "POST",
-			Path:   "/chat/completions",
-			Headers: map[string]string{
-				"Content-Type": "application/json",
-			},
-			Body:    reqBody,
-			Context: c.Request.Context(),
-		}
-	} else {
-		// Apply transformation based on configuration
-		transformTo := instanceCfg.Transformation.RequestTo
-
-		switch transformTo {
-		case "bedrock_converse":
-			providerReq, _, err = translator.TranslateOpenAIToConverseAPI(&req)
-		case "openai":
-			// Passthrough
-			reqBody, err := json.Marshal(req)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, translator.ErrorResponse{
-					Error: translator.ErrorDetail{
-						Message: "Failed to marshal request",
-						Type:    "internal_error",
-						Code:    "marshal_failed",
-					},
-				})
-				return
-			}
-			providerReq = &providers.ProviderRequest{
-				Method: "POST",
-				Path:   "/chat/completions",
-				Headers: map[string]string{
-					"Content-Type": "application/json",
-				},
-				Body:    reqBody,
-				Context: c.Request.Context(),
-			}
-		default:
-			// For other transformations, let provider handle it
-			reqBody, err := json.Marshal(req)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, translator.ErrorResponse{
-					Error: translator.ErrorDetail{
-						Message: "Failed to marshal request",
-						Type:    "internal_error",
-						Code:    "marshal_failed",
-					},
-				})
-				return
-			}
-			providerReq = &providers.ProviderRequest{
-				Method: "POST",
-				Path:   "/chat/completions",
-				Headers: map[string]string{
-					"Content-Type": "application/json",
-				},
-				Body:    reqBody,
-				Context: c.Request.Context(),
-			}
-		}
-	}
-
+	providerReq, err := buildInstanceRequest(c.Request.Context(), instanceCfg, &req)
 	if err != nil {
 		log.Printf("Translation error: %v", err)
 		c.JSON(http.StatusBadRequest, translator.ErrorResponse{
@@ -222,42 +154,25 @@ func (h *ProtocolHandler) handleOpenAIProtocol(
 	}
 
 	// Parse and translate response
-	var openaiResp *translator.ChatCompletionResponse
-
-	if instanceCfg.Transformation != nil && instanceCfg.Transformation.ResponseFrom == "bedrock_converse" {
-		// Translate from Bedrock Converse to OpenAI
-		var converseResp translator.ConverseResponse
-		if err := json.Unmarshal(providerResp.Body, &converseResp); err != nil {
-			log.Printf("Failed to parse Bedrock response: %v", err)
-			c.JSON(http.StatusInternalServerError, translator.ErrorResponse{
-				Error: translator.ErrorDetail{
-					Message: "Failed to parse provider response",
-					Type:    "internal_error",
-					Code:    "response_parse_error",
-				},
-			})
-			return
-		}
-		openaiResp = translator.TranslateConverseToOpenAI(&converseResp, req.Model, requestID)
-	} else {
-		// Response is already in OpenAI format or translated by provider
-		if err := json.Unmarshal(providerResp.Body, &openaiResp); err != nil {
-			log.Printf("Failed to parse provider response: %v", err)
-			c.JSON(http.StatusInternalServerError, translator.ErrorResponse{
-				Error: translator.ErrorDetail{
-					Message: "Failed to parse provider response",
-					Type:    "internal_error",
-					Code:    "response_parse_error",
-				},
-			})
-			return
-		}
+	openaiResp, err := parseInstanceResponse(instanceCfg, providerResp.Body, req.Model, requestID)
+	if err != nil {
+		log.Printf("Failed to parse provider response: %v", err)
+		c.JSON(http.StatusInternalServerError, translator.ErrorResponse{
+			Error: translator.ErrorDetail{
+				Message: "Failed to parse provider response",
+				Type:    "internal_error",
+				Code:    "response_parse_error",
+			},
+		})
+		return
 	}
 
 	// Set metadata
 	openaiResp.ID = requestID
 	openaiResp.Created = startTime.Unix()
 
+	h.recordUsage(c, provider, req.Model, openaiResp.Usage, false)
+
 	// Record metrics
 	if instanceCfg.Metrics.Enabled {
 		duration := time.Since(startTime)
@@ -270,6 +185,147 @@ func (h *ProtocolHandler) handleOpenAIProtocol(
 	c.JSON(http.StatusOK, openaiResp)
 }
 
+// recordUsage prices a protocol request's token usage from the provider's
+// model catalog and exposes it to middleware that runs after the handler
+// (rate limiting, budgets and the usage ledger)
+func (h *ProtocolHandler) recordUsage(c *gin.Context, provider providers.Provider, model string, tokens *translator.Usage, streaming bool) {
+	metadata := &providers.ResponseMetadata{ModelUsed: model}
+	if tokens != nil {
+		c.Set("prompt_tokens", tokens.PromptTokens)
+		c.Set("completion_tokens", tokens.CompletionTokens)
+		c.Set("total_tokens", tokens.TotalTokens)
+
+		pricing := h.router.ModelPricing(c.Request.Context(), provider, &router.ProviderModelInfo{Model: model})
+		metadata.SetUsage(tokens.PromptTokens, tokens.CompletionTokens, pricing)
+		metrics.RecordUsage(provider.Name(), model, metadata.InputTokens, metadata.OutputTokens, metadata.TotalCost)
+	}
+
+	setUsageRecord(c, model, provider.Name(), metadata, streaming)
+}
+
+// streamRelay is an upstream stream being relayed to a client. It tracks the
+// status and token usage of the relay for Close to record.
+type streamRelay struct {
+	io.ReadCloser
+	status      string // for metrics; "200" until the caller sets otherwise
+	usage       *translator.Usage
+	outputBytes int
+
+	req           *translator.ChatCompletionRequest
+	startTime     time.Time
+	recordMetrics bool
+	record        func(tokens *translator.Usage)
+}
+
+// openStreamRelay opens an upstream stream before committing to a streamed
+// response, so that errors reported to onError can still use a proper
+// status; it returns nil when opening failed. The upstream tokens are spent
+// from then on, so callers defer Close, which records usage on every exit
+// path, including clients that disconnect mid-stream.
+func openStreamRelay(
+	open func() (io.ReadCloser, error),
+	onError func(err error),
+	req *translator.ChatCompletionRequest,
+	startTime time.Time,
+	recordMetrics bool,
+	record func(tokens *translator.Usage),
+) *streamRelay {
+	stream, err := open()
+	if err != nil {
+		onError(err)
+		return nil
+	}
+	return &streamRelay{
+		ReadCloser:    stream,
+		status:        "200",
+		req:           req,
+		startTime:     startTime,
+		recordMetrics: recordMetrics,
+		record:        record,
+	}
+}
+
+// observe notes the usage and output of a chunk read from the stream
+func (r *streamRelay) observe(chunk *translator.ChatCompletionStreamResponse) {
+	if chunk.Usage != nil {
+		r.usage = chunk.Usage
+	}
+	r.outputBytes += deltaBytes(chunk)
+}
+
+// Close closes the upstream stream and records the relay's token usage,
+// estimated when the stream reported none, and request metrics
+func (r *streamRelay) Close() error {
+	err := r.ReadCloser.Close()
+
+	tokens := r.usage
+	if tokens == nil {
+		tokens = estimateUsage(r.req, r.outputBytes)
+	}
+	r.record(tokens)
+
+	if r.recordMetrics {
+		duration := time.Since(r.startTime)
+		metrics.RequestDuration.WithLabelValues("POST", r.status).Observe(duration.Seconds())
+		metrics.RequestsTotal.WithLabelValues("POST", r.status).Inc()
+	}
+	return err
+}
+
+// buildInstanceRequest translates an OpenAI request into the format the
+// instance's transformation targets. Providers other than Bedrock Converse
+// accept OpenAI requests and translate internally.
+func buildInstanceRequest(ctx context.Context, instanceCfg *instance.InstanceConfig, req *translator.ChatCompletionRequest) (*providers.ProviderRequest, error) {
+	if instanceCfg.Transformation != nil && instanceCfg.Transformation.RequestTo == "bedrock_converse" {
+		providerReq, _, err := translator.TranslateOpenAIToConverseAPI(req)
+		if err != nil {
+			return nil, err
+		}
+		providerReq.Context = ctx
+		return providerReq, nil
+	}
+
+	instanceReq := *req
+	instanceReq.StreamOptions = streamOptions(instanceCfg.Type, req)
+	reqBody, err := json.Marshal(&instanceReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return &providers.ProviderRequest{
+		Method: "POST",
+		Path:   "/chat/completions",
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body:    reqBody,
+		Context: ctx,
+	}, nil
+}
+
+// respondsWithConverse reports whether the instance returns Bedrock Converse responses
+func respondsWithConverse(instanceCfg *instance.InstanceConfig) bool {
+	return instanceCfg.Transformation != nil && instanceCfg.Transformation.ResponseFrom == "bedrock_converse"
+}
+
+// parseInstanceResponse parses a provider response into OpenAI format
+func parseInstanceResponse(instanceCfg *instance.InstanceConfig, body []byte, model, requestID string) (*translator.ChatCompletionResponse, error) {
+	if respondsWithConverse(instanceCfg) {
+		// Translate from Bedrock Converse to OpenAI
+		var converseResp translator.ConverseResponse
+		if err := json.Unmarshal(body, &converseResp); err != nil {
+			return nil, err
+		}
+		return translator.TranslateConverseToOpenAI(&converseResp, model, requestID), nil
+	}
+
+	// Response is already in OpenAI format or translated by provider
+	var openaiResp translator.ChatCompletionResponse
+	if err := json.Unmarshal(body, &openaiResp); err != nil {
+		return nil, err
+	}
+	return &openaiResp, nil
+}
+
 // handleProviderError converts provider errors to protocol error format
 func (h *ProtocolHandler) handleProviderError(c *gin.Context, err error) {
 	if providerErr, ok := err.(*providers.ProviderError); ok {
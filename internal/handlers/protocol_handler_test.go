@@ -0,0 +1,101 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tosharewith/llmproxy_auth/internal/instance"
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+)
+
+func TestProtocolUsage(t *testing.T) {
+	newHandler := func(provider *fakeProvider) *ProtocolHandler {
+		provider.catalog = &providers.Model{ID: "gpt-4o", InputPrice: 2, OutputPrice: 10}
+		store := instance.NewStore(&instance.Config{Instances: map[string]instance.InstanceConfig{
+			"openai_anthropic": {
+				Type:      "openai",
+				Mode:      "protocol",
+				Protocol:  "anthropic",
+				Endpoints: []instance.EndpointConfig{{Path: "/anthropic/openai"}},
+			},
+			"openai_converse": {
+				Type:      "openai",
+				Mode:      "protocol",
+				Protocol:  "converse",
+				Endpoints: []instance.EndpointConfig{{Path: "/converse/openai"}},
+			},
+			"openai_openai": {
+				Type:      "openai",
+				Mode:      "protocol",
+				Protocol:  "openai",
+				Endpoints: []instance.EndpointConfig{{Path: "/openai/openai"}},
+			},
+		}})
+		registry := map[string]providers.Provider{"openai": provider}
+		return NewProtocolHandler(registry, store, newTestRouter(t, provider, "other"))
+	}
+	const body = `{"model":"gpt-4o","max_tokens":100,"messages":[{"role":"user","content":"Hi"}]`
+
+	t.Run("Completion is priced from the catalog", func(t *testing.T) {
+		provider := &fakeProvider{name: "openai", response: `{"choices":[{"index":0,"message":{"role":"assistant","content":"Hello"},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`}
+		h := newHandler(provider)
+		c, _ := newTestContext("/anthropic/openai/v1/messages", body+"}")
+		h.HandleRequest(c)
+
+		record := usageRecord(t, c)
+		if record.Provider != "openai" || record.Model != "gpt-4o" || record.TotalTokens != 15 || record.Streaming {
+			t.Errorf("Unexpected usage record %+v", record)
+		}
+		if want := (10*2 + 5*10) / 1e6; math.Abs(record.TotalCost-want) > 1e-12 {
+			t.Errorf("TotalCost = %v, want %v", record.TotalCost, want)
+		}
+	})
+
+	t.Run("Stream usage is recorded", func(t *testing.T) {
+		provider := &fakeProvider{name: "openai", stream: "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hello\"}}]}\n\n" +
+			"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":5,\"total_tokens\":15}}\n\n" +
+			"data: [DONE]\n\n"}
+		h := newHandler(provider)
+		c, _ := newTestContext("/anthropic/openai/v1/messages", body+`,"stream":true}`)
+		h.HandleRequest(c)
+
+		record := usageRecord(t, c)
+		if record.TotalTokens != 15 || record.TotalCost == 0 || !record.Streaming {
+			t.Errorf("Unexpected usage record %+v", record)
+		}
+		if total, _ := c.Get("total_tokens"); total != 15 {
+			t.Errorf("total_tokens = %v, want 15", total)
+		}
+	})
+
+	t.Run("OpenAI protocol completion is priced", func(t *testing.T) {
+		provider := &fakeProvider{name: "openai", response: `{"choices":[{"index":0,"message":{"role":"assistant","content":"Hello"},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`}
+		h := newHandler(provider)
+		c, _ := newTestContext("/openai/openai/v1/chat/completions", `{"model":"gpt-4o","messages":[{"role":"user","content":"Hi"}]}`)
+		h.HandleRequest(c)
+
+		record := usageRecord(t, c)
+		if want := (10*2 + 5*10) / 1e6; record.TotalTokens != 15 || math.Abs(record.TotalCost-want) > 1e-12 {
+			t.Errorf("Unexpected usage record %+v", record)
+		}
+	})
+
+	t.Run("Converse stream usage is recorded", func(t *testing.T) {
+		provider := &fakeProvider{name: "openai", stream: "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hello\"}}]}\n\n" +
+			"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":5,\"total_tokens\":15}}\n\n" +
+			"data: [DONE]\n\n"}
+		h := newHandler(provider)
+		c, _ := newTestContext("/converse/openai/model/gpt-4o/converse-stream", `{"messages":[{"role":"user","content":[{"text":"Hi"}]}]}`)
+		h.HandleRequest(c)
+
+		record := usageRecord(t, c)
+		if record.TotalTokens != 15 || record.TotalCost == 0 || !record.Streaming {
+			t.Errorf("Unexpected usage record %+v", record)
+		}
+	})
+}
@@ -0,0 +1,207 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/internal/cache"
+	"github.com/tosharewith/llmproxy_auth/internal/translator"
+	"github.com/tosharewith/llmproxy_auth/internal/usage"
+	"github.com/tosharewith/llmproxy_auth/pkg/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// cachedCompletion is a response cache entry
+type cachedCompletion struct {
+	Response      *translator.ChatCompletionResponse `json:"response"`
+	Provider      string                             `json:"provider"`
+	ProviderModel string                             `json:"provider_model"`
+	StoredAt      time.Time                          `json:"stored_at"`
+}
+
+// SetResponseCache enables caching non-streaming chat completions when the
+// response_caching feature is on
+func (h *OpenAIHandler) SetResponseCache(responseCache *cache.Cache) {
+	h.cache = responseCache
+}
+
+// responseCacheKey returns the cache key and TTL of a request, or false when
+// the request is not cached. The key covers the canonical request (with
+// inlined media), the deployment that would serve it, and the caller's
+// identity unless entries are shared.
+func (h *OpenAIHandler) responseCacheKey(c *gin.Context, req *translator.ChatCompletionRequest) (string, time.Duration, bool) {
+	if h.cache == nil || !h.router.GetConfig().Features.ResponseCaching {
+		return "", 0, false
+	}
+	ttl := h.cache.TTL(req.Model)
+	if ttl <= 0 {
+		return "", 0, false
+	}
+
+	// Fields that do not change the completion are left out
+	canonical := *req
+	canonical.Stream = false
+	canonical.StreamOptions = nil
+	canonical.User = ""
+	body, err := json.Marshal(&canonical)
+	if err != nil {
+		return "", 0, false
+	}
+
+	target, _ := h.router.PrimaryTarget(c.Request.Context(), req.Model)
+	identity := ""
+	if !h.cache.Shared() {
+		identity = cacheIdentity(c)
+	}
+
+	return cache.Key([]byte(target.Provider), []byte(target.Model), []byte(identity), body), ttl, true
+}
+
+// cacheIdentity returns the API key, user or client address a request is
+// cached for
+func cacheIdentity(c *gin.Context) string {
+	if keyID, ok := c.Get("api_key_id"); ok {
+		return fmt.Sprintf("key:%v", keyID)
+	}
+	if user := c.GetString("user"); user != "" {
+		return "user:" + user
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// newCacheEntry encodes a successful completion as a cache entry
+func newCacheEntry(comp *completion) ([]byte, error) {
+	return json.Marshal(&cachedCompletion{
+		Response:      comp.resp,
+		Provider:      comp.result.Provider.Name(),
+		ProviderModel: comp.result.ModelInfo.Model,
+		StoredAt:      time.Now(),
+	})
+}
+
+// handleCachedRequest serves a cacheable completion from the response cache,
+// or completes it once for all concurrent identical requests and stores the
+// response. Cache-Control: no-cache skips the lookup and no-store skips storing.
+// Requests that miss are looked up in the semantic cache, if enabled.
+func (h *OpenAIHandler) handleCachedRequest(
+	c *gin.Context,
+	req *translator.ChatCompletionRequest,
+	requestID string,
+	startTime time.Time,
+	key string,
+	ttl time.Duration,
+) {
+	ctx := c.Request.Context()
+	noCache, noStore := cacheControl(c.GetHeader("Cache-Control"))
+	outcome := "miss"
+	if noCache {
+		outcome = "bypass"
+	}
+
+	if !noCache {
+		value, ok, err := h.cache.Get(ctx, key)
+		if err != nil {
+			log.Printf("Response cache lookup failed: %v", err)
+		}
+		if ok && h.writeCachedCompletion(c, req, requestID, startTime, value) {
+			metrics.RecordCacheLookup("response", req.Model, "hit")
+			return
+		}
+	}
+
+	query, served := h.semanticLookup(c, req, requestID, startTime)
+	if served {
+		metrics.RecordCacheLookup("response", req.Model, outcome)
+		return
+	}
+
+	var comp *completion
+	value, shared, err := h.cache.Do(key, func() ([]byte, error) {
+		comp = h.complete(ctx, req, requestID)
+		if comp.err != nil {
+			return nil, comp.err
+		}
+		value, err := newCacheEntry(comp)
+		if err != nil {
+			return nil, err
+		}
+		if !noStore {
+			if err := h.cache.Set(ctx, key, value, ttl); err != nil {
+				log.Printf("Failed to store response in cache: %v", err)
+			}
+		}
+		h.storeSemantic(c, query, comp)
+		return value, nil
+	})
+
+	if shared {
+		// Another request completed it; a failure there is retried here
+		if err == nil && h.writeCachedCompletion(c, req, requestID, startTime, value) {
+			metrics.RecordCacheLookup("response", req.Model, "coalesced")
+			return
+		}
+		comp = h.complete(ctx, req, requestID)
+		h.storeSemantic(c, query, comp)
+	}
+
+	metrics.RecordCacheLookup("response", req.Model, outcome)
+	c.Header("X-Cache", "MISS")
+	h.writeCompletion(c, req, requestID, startTime, comp)
+}
+
+// writeCachedCompletion serves a cache entry. Cache hits use no provider
+// tokens, so usage is recorded against the "cache" provider at zero cost.
+func (h *OpenAIHandler) writeCachedCompletion(
+	c *gin.Context,
+	req *translator.ChatCompletionRequest,
+	requestID string,
+	startTime time.Time,
+	value []byte,
+) bool {
+	var entry cachedCompletion
+	if err := json.Unmarshal(value, &entry); err != nil || entry.Response == nil {
+		log.Printf("Ignoring unreadable response cache entry: %v", err)
+		return false
+	}
+
+	resp := entry.Response
+	resp.ID = requestID
+	resp.Created = startTime.Unix()
+	resp.Model = req.Model
+
+	c.Set("usage_record", usage.Record{
+		Timestamp:     time.Now(),
+		Model:         req.Model,
+		Provider:      "cache",
+		ProviderModel: entry.ProviderModel,
+	})
+
+	metrics.RequestDuration.WithLabelValues("POST", "200").Observe(time.Since(startTime).Seconds())
+	metrics.RequestsTotal.WithLabelValues("POST", "200").Inc()
+
+	c.Header("X-Cache", "HIT")
+	c.Header("Age", strconv.Itoa(int(time.Since(entry.StoredAt).Seconds())))
+	c.JSON(http.StatusOK, resp)
+	return true
+}
+
+// cacheControl reads the no-cache and no-store request directives
+func cacheControl(header string) (noCache, noStore bool) {
+	for _, directive := range strings.Split(header, ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "no-cache":
+			noCache = true
+		case "no-store":
+			noStore = true
+		}
+	}
+	return noCache, noStore
+}
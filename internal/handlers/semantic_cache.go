@@ -0,0 +1,212 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/internal/cache"
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+	"github.com/tosharewith/llmproxy_auth/internal/router"
+	"github.com/tosharewith/llmproxy_auth/internal/translator"
+	"github.com/tosharewith/llmproxy_auth/internal/usage"
+	"github.com/tosharewith/llmproxy_auth/pkg/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// semanticQuery is a request's position in the semantic cache
+type semanticQuery struct {
+	namespace string // model and tenant
+	context   string // hash of the request without its final message
+	vector    []float64
+	embedding usage.Record // priced usage of embedding the query
+}
+
+// SetSemanticCache enables answering non-streaming chat completions from
+// earlier answers to similar questions
+func (h *OpenAIHandler) SetSemanticCache(index *cache.SemanticIndex) {
+	h.semantic = index
+}
+
+// semanticLookup embeds the final user message of a request and serves the
+// answer cached for the most similar earlier message, if it is similar
+// enough. It returns the query for storing the answer when nothing was
+// served, or nil when the request is not semantically cached. Embedding
+// failures are logged and the request proceeds uncached.
+func (h *OpenAIHandler) semanticLookup(
+	c *gin.Context,
+	req *translator.ChatCompletionRequest,
+	requestID string,
+	startTime time.Time,
+) (*semanticQuery, bool) {
+	if h.semantic == nil || !h.semantic.Caches(req.Model) {
+		return nil, false
+	}
+	text, ok := finalUserText(req)
+	if !ok {
+		return nil, false
+	}
+
+	// Earlier turns, the system prompt and the parameters must match exactly
+	previous := *req
+	previous.Messages = req.Messages[:len(req.Messages)-1]
+	previous.Stream = false
+	previous.StreamOptions = nil
+	previous.User = ""
+	body, err := json.Marshal(&previous)
+	if err != nil {
+		return nil, false
+	}
+
+	vector, embedding, err := h.embedText(c.Request.Context(), text)
+	if err != nil {
+		log.Printf("Semantic cache embedding with %s failed: %v", h.semantic.EmbeddingModel(), err)
+		return nil, false
+	}
+	query := &semanticQuery{
+		namespace: req.Model + "\x00" + cacheIdentity(c),
+		context:   cache.Key(body),
+		vector:    vector,
+		embedding: embedding,
+	}
+
+	if noCache, _ := cacheControl(c.GetHeader("Cache-Control")); noCache {
+		metrics.RecordCacheLookup("semantic", req.Model, "bypass")
+		return query, false
+	}
+	match, ok := h.semantic.Search(query.namespace, query.context, query.vector)
+	if ok {
+		c.Header("X-Cache-Similarity", strconv.FormatFloat(match.Score, 'f', 4, 64))
+		if h.writeCachedCompletion(c, req, requestID, startTime, match.Value) {
+			metrics.RecordCacheLookup("semantic", req.Model, "hit")
+			h.chargeEmbedding(c, query)
+			return nil, true
+		}
+		c.Writer.Header().Del("X-Cache-Similarity")
+	}
+	metrics.RecordCacheLookup("semantic", req.Model, "miss")
+	return query, false
+}
+
+// storeSemantic adds a successful completion to the semantic cache unless
+// the request sent Cache-Control: no-store
+func (h *OpenAIHandler) storeSemantic(c *gin.Context, query *semanticQuery, comp *completion) {
+	if query == nil || comp.err != nil {
+		return
+	}
+	if _, noStore := cacheControl(c.GetHeader("Cache-Control")); noStore {
+		return
+	}
+	value, err := newCacheEntry(comp)
+	if err != nil {
+		log.Printf("Failed to encode semantic cache entry: %v", err)
+		return
+	}
+	h.semantic.Add(query.namespace, query.context, query.vector, value)
+}
+
+// chargeEmbedding adds the tokens and cost of embedding a query to the usage
+// record of the request that triggered it, so that budgets, rate limits and
+// the usage ledger account for the lookup
+func (h *OpenAIHandler) chargeEmbedding(c *gin.Context, query *semanticQuery) {
+	if query == nil {
+		return
+	}
+	embedding := query.embedding
+	record := embedding
+	if value, ok := c.Get("usage_record"); ok {
+		record, _ = value.(usage.Record)
+		record.InputTokens += embedding.InputTokens
+		record.TotalTokens += embedding.TotalTokens
+		record.InputCost += embedding.InputCost
+		record.TotalCost += embedding.TotalCost
+	}
+	c.Set("usage_record", record)
+
+	c.Set("prompt_tokens", c.GetInt("prompt_tokens")+embedding.InputTokens)
+	c.Set("total_tokens", c.GetInt("total_tokens")+embedding.TotalTokens)
+}
+
+// embedText embeds a text with the semantic cache's embedding model through
+// the router and returns the priced usage of the call
+func (h *OpenAIHandler) embedText(ctx context.Context, text string) ([]float64, usage.Record, error) {
+	model := h.semantic.EmbeddingModel()
+	req := &translator.EmbeddingRequest{Model: model, Input: text}
+	providerResps, result, err := h.router.InvokeBatch(ctx, model, func(provider providers.Provider, modelInfo *router.ProviderModelInfo) ([]*providers.ProviderRequest, error) {
+		return buildEmbeddingRequests(ctx, provider.Name(), modelInfo, req, []string{text})
+	})
+	if err != nil {
+		return nil, usage.Record{}, err
+	}
+
+	resp, err := mergeEmbeddingResponses(result.Provider.Name(), result.ModelInfo, providerResps)
+	if err != nil {
+		return nil, usage.Record{}, err
+	}
+	if len(resp.Data) != 1 {
+		return nil, usage.Record{}, fmt.Errorf("provider returned %d embeddings for 1 input", len(resp.Data))
+	}
+
+	promptTokens := resp.Usage.PromptTokens
+	if promptTokens == 0 {
+		promptTokens = estimateEmbeddingTokens([]string{text})
+	}
+	metadata := providerResps[0].Metadata
+	if metadata.ModelUsed == "" && result.ModelInfo != nil {
+		metadata.ModelUsed = result.ModelInfo.Model
+	}
+	metadata.SetUsage(promptTokens, 0, h.router.ModelPricing(ctx, result.Provider, result.ModelInfo))
+	metrics.RecordUsage(result.Provider.Name(), model, metadata.InputTokens, 0, metadata.TotalCost)
+
+	return resp.Data[0].Embedding.Values, usage.Record{
+		Timestamp:     time.Now(),
+		Model:         model,
+		Provider:      result.Provider.Name(),
+		ProviderModel: metadata.ModelUsed,
+		InputTokens:   metadata.InputTokens,
+		TotalTokens:   metadata.TotalTokens,
+		InputCost:     metadata.InputCost,
+		TotalCost:     metadata.TotalCost,
+	}, nil
+}
+
+// finalUserText returns the text of a request's last message when it is a
+// user message made only of text
+func finalUserText(req *translator.ChatCompletionRequest) (string, bool) {
+	if len(req.Messages) == 0 {
+		return "", false
+	}
+	last := req.Messages[len(req.Messages)-1]
+	if last.Role != "user" {
+		return "", false
+	}
+
+	var text string
+	switch content := last.Content.(type) {
+	case string:
+		text = content
+	case []interface{}:
+		var texts []string
+		for _, part := range content {
+			partMap, ok := part.(map[string]interface{})
+			if !ok || partMap["type"] != "text" {
+				return "", false
+			}
+			partText, _ := partMap["text"].(string)
+			texts = append(texts, partText)
+		}
+		text = strings.Join(texts, "\n")
+	default:
+		return "", false
+	}
+
+	text = strings.TrimSpace(text)
+	return text, text != ""
+}
@@ -0,0 +1,62 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tosharewith/llmproxy_auth/internal/cache"
+)
+
+func TestSemanticCacheUsage(t *testing.T) {
+	provider := &fakeProvider{
+		name: "openai",
+		response: `{"choices":[{"index":0,"message":{"role":"assistant","content":"Hello"},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`,
+		embedding: `{"object":"list","data":[{"index":0,"embedding":[0.6,0.8]}],"usage":{"prompt_tokens":4,"total_tokens":4}}`,
+	}
+	h := NewOpenAIHandler(newTestRouter(t, provider, "gpt-4o", "text-embedding"))
+	h.SetSemanticCache(cache.NewSemanticIndex("test", cache.SemanticConfig{Enabled: true, EmbeddingModel: "text-embedding"}))
+	const body = `{"model":"gpt-4o","messages":[{"role":"user","content":"What is the capital of France?"}]}`
+	const embeddingCost = 4 * 2 / 1e6
+
+	t.Run("Miss charges the completion and the embedding", func(t *testing.T) {
+		c, w := newTestContext("/v1/chat/completions", body)
+		h.ChatCompletions(c)
+
+		if w.Header().Get("X-Cache") != "MISS" {
+			t.Fatalf("Expected a cache miss, got %q", w.Header().Get("X-Cache"))
+		}
+		record := usageRecord(t, c)
+		if record.Provider != "openai" || record.InputTokens != 14 || record.TotalTokens != 19 {
+			t.Errorf("Unexpected usage record %+v", record)
+		}
+		if want := (10*2+5*10)/1e6 + embeddingCost; math.Abs(record.TotalCost-want) > 1e-12 {
+			t.Errorf("TotalCost = %v, want %v", record.TotalCost, want)
+		}
+		if total, _ := c.Get("total_tokens"); total != 19 {
+			t.Errorf("total_tokens = %v, want 19", total)
+		}
+	})
+
+	t.Run("Hit charges the embedding", func(t *testing.T) {
+		c, w := newTestContext("/v1/chat/completions", body)
+		h.ChatCompletions(c)
+
+		if w.Header().Get("X-Cache") != "HIT" {
+			t.Fatalf("Expected a cache hit, got %q", w.Header().Get("X-Cache"))
+		}
+		record := usageRecord(t, c)
+		if record.Provider != "cache" || record.InputTokens != 4 || record.TotalTokens != 4 {
+			t.Errorf("Unexpected usage record %+v", record)
+		}
+		if math.Abs(record.TotalCost-embeddingCost) > 1e-12 {
+			t.Errorf("TotalCost = %v, want %v", record.TotalCost, embeddingCost)
+		}
+		if total, _ := c.Get("total_tokens"); total != 4 {
+			t.Errorf("total_tokens = %v, want 4", total)
+		}
+	})
+}
@@ -19,11 +19,11 @@ import (
 // This mode adds authentication and metrics but does not transform requests/responses
 type TransparentHandler struct {
 	providers map[string]providers.Provider
-	config    *instance.Config
+	config    *instance.Store
 }
 
 // NewTransparentHandler creates a new transparent handler
-func NewTransparentHandler(providerRegistry map[string]providers.Provider, config *instance.Config) *TransparentHandler {
+func NewTransparentHandler(providerRegistry map[string]providers.Provider, config *instance.Store) *TransparentHandler {
 	return &TransparentHandler{
 		providers: providerRegistry,
 		config:    config,
@@ -38,7 +38,7 @@ func (h *TransparentHandler) HandleRequest(c *gin.Context) {
 	path := c.Request.URL.Path
 
 	// Find matching instance
-	instanceCfg, instanceName, err := h.config.GetInstanceByPath(path)
+	instanceCfg, instanceName, err := h.config.Load().GetInstanceByPath(path)
 	if err != nil {
 		log.Printf("No instance found for path %s: %v", path, err)
 		c.JSON(http.StatusNotFound, gin.H{
@@ -0,0 +1,126 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/internal/usage"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultUsageDays is the range reported when no from date is given
+const defaultUsageDays = 30
+
+// UsageHandler serves the usage ledger admin API
+type UsageHandler struct {
+	ledger *usage.Ledger
+}
+
+// NewUsageHandler creates a new usage handler
+func NewUsageHandler(ledger *usage.Ledger) *UsageHandler {
+	return &UsageHandler{
+		ledger: ledger,
+	}
+}
+
+// UsageResponse represents the usage report
+type UsageResponse struct {
+	From   string         `json:"from"`
+	To     string         `json:"to"`
+	Data   []usage.Rollup `json:"data"`
+	Totals usage.Rollup   `json:"totals"`
+}
+
+// GetUsage handles GET /admin/usage
+// Query parameters: from and to (YYYY-MM-DD, inclusive, UTC), api_key_id, user,
+// model, provider and format=csv (or Accept: text/csv)
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	filter, err := parseUsageFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	rollups, err := h.ledger.DailyRollups(filter)
+	if err != nil {
+		log.Printf("Failed to query usage: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to query usage",
+		})
+		return
+	}
+
+	from := filter.From.Format(usage.DayFormat)
+	to := filter.To.Format(usage.DayFormat)
+
+	if c.Query("format") == "csv" || strings.Contains(c.GetHeader("Accept"), "text/csv") {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="usage_%s_%s.csv"`, from, to))
+		c.Status(http.StatusOK)
+		if err := usage.WriteCSV(c.Writer, rollups); err != nil {
+			log.Printf("Failed to write usage CSV: %v", err)
+		}
+		return
+	}
+
+	if rollups == nil {
+		rollups = []usage.Rollup{}
+	}
+	c.JSON(http.StatusOK, UsageResponse{
+		From:   from,
+		To:     to,
+		Data:   rollups,
+		Totals: usage.Sum(rollups),
+	})
+}
+
+// parseUsageFilter reads the report filter from query parameters. The range
+// defaults to the last 30 days ending today.
+func parseUsageFilter(c *gin.Context) (usage.Filter, error) {
+	var filter usage.Filter
+
+	filter.To = time.Now().UTC().Truncate(24 * time.Hour)
+	if to := c.Query("to"); to != "" {
+		day, err := time.Parse(usage.DayFormat, to)
+		if err != nil {
+			return filter, fmt.Errorf("to must be a date in YYYY-MM-DD format")
+		}
+		filter.To = day
+	}
+
+	filter.From = filter.To.AddDate(0, 0, -(defaultUsageDays - 1))
+	if from := c.Query("from"); from != "" {
+		day, err := time.Parse(usage.DayFormat, from)
+		if err != nil {
+			return filter, fmt.Errorf("from must be a date in YYYY-MM-DD format")
+		}
+		filter.From = day
+	}
+
+	if filter.From.After(filter.To) {
+		return filter, fmt.Errorf("from must not be after to")
+	}
+
+	if keyID := c.Query("api_key_id"); keyID != "" {
+		id, err := strconv.ParseInt(keyID, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("api_key_id must be an integer")
+		}
+		filter.APIKeyID = id
+	}
+	filter.User = c.Query("user")
+	filter.Model = c.Query("model")
+	filter.Provider = c.Query("provider")
+
+	return filter, nil
+}
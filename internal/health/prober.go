@@ -0,0 +1,102 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ProbeFunc checks a set of components and returns the result for each one
+type ProbeFunc func(ctx context.Context) map[string]error
+
+// ProbeResult is the cached outcome of probing a component
+type ProbeResult struct {
+	Healthy   bool      `json:"healthy"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Prober runs probes in the background so that readiness checks never block on
+// upstream calls
+type Prober struct {
+	probe    ProbeFunc
+	interval time.Duration
+	timeout  time.Duration
+
+	mu      sync.RWMutex
+	results map[string]ProbeResult
+	lastRun time.Time
+}
+
+// NewProber creates a prober that runs probe every interval, bounded by timeout
+func NewProber(probe ProbeFunc, interval, timeout time.Duration) *Prober {
+	return &Prober{
+		probe:    probe,
+		interval: interval,
+		timeout:  timeout,
+		results:  make(map[string]ProbeResult),
+	}
+}
+
+// Start runs the first probe synchronously, then keeps probing until ctx is done
+func (p *Prober) Start(ctx context.Context) {
+	p.RunOnce(ctx)
+
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.RunOnce(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// RunOnce probes all components and replaces the cached results
+func (p *Prober) RunOnce(ctx context.Context) {
+	probeCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	checkedAt := time.Now()
+	results := make(map[string]ProbeResult)
+	for name, err := range p.probe(probeCtx) {
+		result := ProbeResult{Healthy: err == nil, CheckedAt: checkedAt}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results[name] = result
+	}
+
+	p.mu.Lock()
+	p.results = results
+	p.lastRun = checkedAt
+	p.mu.Unlock()
+}
+
+// Results returns a copy of the cached probe results
+func (p *Prober) Results() map[string]ProbeResult {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	results := make(map[string]ProbeResult, len(p.results))
+	for name, result := range p.results {
+		results[name] = result
+	}
+	return results
+}
+
+// LastRun returns when the probes last completed
+func (p *Prober) LastRun() time.Time {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.lastRun
+}
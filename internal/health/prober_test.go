@@ -0,0 +1,48 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProber(t *testing.T) {
+	calls := 0
+	prober := NewProber(func(ctx context.Context) map[string]error {
+		calls++
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("Probe context should have a deadline")
+		}
+		return map[string]error{
+			"bedrock": nil,
+			"openai":  errors.New("connection refused"),
+		}
+	}, time.Hour, time.Second)
+
+	if len(prober.Results()) != 0 || !prober.LastRun().IsZero() {
+		t.Error("Prober should have no results before starting")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	prober.Start(ctx)
+
+	if calls != 1 {
+		t.Errorf("Expected Start to probe once synchronously, got %d calls", calls)
+	}
+
+	results := prober.Results()
+	if !results["bedrock"].Healthy {
+		t.Error("bedrock should be healthy")
+	}
+	if results["openai"].Healthy || results["openai"].Error != "connection refused" {
+		t.Errorf("Unexpected openai result: %+v", results["openai"])
+	}
+
+	// Results are served from cache
+	prober.Results()
+	if calls != 1 {
+		t.Errorf("Results should not trigger probes, got %d calls", calls)
+	}
+}
@@ -6,7 +6,9 @@ package instance
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"sync/atomic"
 
 	"gopkg.in/yaml.v3"
 )
@@ -105,6 +107,11 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	return ParseConfig(data)
+}
+
+// ParseConfig parses provider instances configuration from YAML
+func ParseConfig(data []byte) (*Config, error) {
 	// Expand environment variables
 	expanded := os.ExpandEnv(string(data))
 
@@ -192,3 +199,66 @@ func (c *Config) IsFeatureEnabled(featureName string) bool {
 	}
 	return feature.Enabled
 }
+
+// ValidateConfig performs validation on the loaded configuration
+func (c *Config) ValidateConfig() error {
+	var errors []string
+
+	names := c.ListInstances()
+	sort.Strings(names)
+	for _, name := range names {
+		instance := c.Instances[name]
+		if instance.Type == "" {
+			errors = append(errors, fmt.Sprintf("instance %q has no type", name))
+		}
+
+		switch instance.Mode {
+		case "transparent":
+		case "protocol":
+			if instance.Protocol == "" {
+				errors = append(errors, fmt.Sprintf("protocol instance %q has no protocol", name))
+			}
+		default:
+			errors = append(errors, fmt.Sprintf("instance %q has unknown mode %q", name, instance.Mode))
+		}
+
+		for _, endpoint := range instance.Endpoints {
+			if !strings.HasPrefix(endpoint.Path, "/") {
+				errors = append(errors, fmt.Sprintf("instance %q endpoint path %q must start with /", name, endpoint.Path))
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("configuration validation failed:\n  - %s", strings.Join(errors, "\n  - "))
+	}
+
+	return nil
+}
+
+// Store holds the current configuration so it can be replaced while
+// requests are being served
+type Store struct {
+	config atomic.Pointer[Config]
+}
+
+// NewStore creates a store holding config
+func NewStore(config *Config) *Store {
+	s := &Store{}
+	s.config.Store(config)
+	return s
+}
+
+// Load returns the current configuration
+func (s *Store) Load() *Config {
+	return s.config.Load()
+}
+
+// Update validates a configuration and swaps it in, returning the
+// configuration it replaced. Requests in flight keep the one they loaded.
+func (s *Store) Update(config *Config) (*Config, error) {
+	if err := config.ValidateConfig(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return s.config.Swap(config), nil
+}
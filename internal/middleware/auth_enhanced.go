@@ -94,6 +94,8 @@ func EnhancedAPIKeyAuth(apiKeyDB *auth.APIKeyDB, totpManager *auth.TOTPManager,
 		c.Set("user", keyInfo.Name)
 		c.Set("user_email", keyInfo.Email)
 		c.Set("api_key_id", keyInfo.ID)
+		c.Set("api_key_permissions", keyInfo.Permissions)
+		c.Set("api_key_metadata", keyInfo.Metadata)
 		c.Set("auth_method", "api_key_db")
 		c.Set("2fa_enabled", twoFAEnabled)
 
@@ -124,6 +126,25 @@ func EnhancedAPIKeyAuth(apiKeyDB *auth.APIKeyDB, totpManager *auth.TOTPManager,
 	}
 }
 
+// RequirePermission rejects requests whose API key's permissions do not
+// include permission. It must run after a database-backed auth middleware.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		permissions, _ := c.Get("api_key_permissions")
+		granted, _ := permissions.(string)
+		if !auth.HasPermission(granted, permission) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "Forbidden",
+				"message": "API key lacks the " + permission + " permission",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // AuditLogger logs all requests for compliance
 func AuditLogger(apiKeyDB *auth.APIKeyDB) gin.HandlerFunc {
 	return func(c *gin.Context) {
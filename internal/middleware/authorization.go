@@ -5,10 +5,12 @@ package middleware
 
 import (
 	"crypto/subtle"
+	"log"
 	"net/http"
 	"os"
 	"strings"
 
+	"github.com/tosharewith/llmproxy_auth/internal/auth"
 	"github.com/gin-gonic/gin"
 )
 
@@ -94,34 +96,36 @@ func BasicAuth(credentials map[string]string) gin.HandlerFunc {
 	}
 }
 
-// ServiceAccountAuth validates Kubernetes service account token
-func ServiceAccountAuth(allowedServiceAccounts []string) gin.HandlerFunc {
+// ServiceAccountAuth validates projected Kubernetes service account tokens
+// from Authorization: Bearer and checks namespace/name against the allowlist
+func ServiceAccountAuth(validator *auth.ServiceAccountValidator, allowedServiceAccounts []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get service account from header (injected by service mesh or app)
-		serviceAccount := c.GetHeader("X-Service-Account")
-		namespace := c.GetHeader("X-Namespace")
-
-		if serviceAccount == "" || namespace == "" {
+		authHeader := c.GetHeader("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
 			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Missing service account credentials",
+				"error":   "Missing service account credentials",
+				"message": "Provide a projected service account token via Authorization: Bearer <token>",
 			})
 			c.Abort()
 			return
 		}
 
-		// Validate against allowed list
-		fullSA := namespace + "/" + serviceAccount
-		allowed := false
-		for _, allowedSA := range allowedServiceAccounts {
-			if fullSA == allowedSA {
-				allowed = true
-				break
-			}
+		serviceAccount, err := validator.Validate(c.Request.Context(), strings.TrimPrefix(authHeader, "Bearer "))
+		if err != nil {
+			log.Printf("Service account token rejected from %s: %v", c.ClientIP(), err)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "Invalid service account token",
+				"message": err.Error(),
+			})
+			c.Abort()
+			return
 		}
 
-		if !allowed {
+		// Validate against allowed list
+		fullSA := serviceAccount.String()
+		if !auth.ServiceAccountAllowed(allowedServiceAccounts, serviceAccount) {
 			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Service account not authorized",
+				"error":           "Service account not authorized",
 				"service_account": fullSA,
 			})
 			c.Abort()
@@ -129,6 +133,7 @@ func ServiceAccountAuth(allowedServiceAccounts []string) gin.HandlerFunc {
 		}
 
 		c.Set("user", fullSA)
+		c.Set("service_account_namespace", serviceAccount.Namespace)
 		c.Set("auth_method", "service_account")
 		c.Next()
 	}
@@ -169,20 +174,3 @@ func LoadAPIKeysFromSecret(secretPath string) (map[string]string, error) {
 
 	return keys, nil
 }
-
-// RateLimitByUser provides per-user rate limiting
-func RateLimitByUser(requestsPerMinute int) gin.HandlerFunc {
-	// This is a placeholder - implement with Redis or in-memory store
-	return func(c *gin.Context) {
-		user, exists := c.Get("user")
-		if !exists {
-			user = c.ClientIP()
-		}
-
-		// TODO: Implement rate limiting logic
-		// Use Redis or in-memory cache to track requests per user
-		_ = user
-
-		c.Next()
-	}
-}
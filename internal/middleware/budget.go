@@ -0,0 +1,80 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/tosharewith/llmproxy_auth/internal/budget"
+	"github.com/tosharewith/llmproxy_auth/internal/usage"
+	"github.com/gin-gonic/gin"
+)
+
+// Budget refuses requests whose key, team or global budget is exhausted or
+// cannot cover the request's maximum cost, reserves that cost while the
+// request runs and charges the actual cost the handler reports in the
+// "usage_record" context key in its place.
+// If budget state cannot be read requests are let through.
+func Budget(enforcer *budget.Enforcer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subject := budget.Subject{User: c.ClientIP()}
+		if user, ok := c.Get("user"); ok {
+			if name, ok := user.(string); ok && name != "" {
+				subject.User = name
+			}
+		}
+		if keyID, ok := c.Get("api_key_id"); ok {
+			subject.KeyID, _ = keyID.(int64)
+		}
+		if metadata, ok := c.Get("api_key_metadata"); ok {
+			subject.Metadata, _ = metadata.(string)
+		}
+
+		scopes, err := enforcer.Scopes(subject)
+		if err != nil {
+			log.Printf("Budget scopes for %s: %v", subject.User, err)
+			c.Next()
+			return
+		}
+		if len(scopes) == 0 {
+			c.Next()
+			return
+		}
+
+		peek := peekRequest(c)
+		estimated := enforcer.EstimateMaxCost(c.Request.Context(), peek.Model, peek.completionBudget())
+
+		reservation, denial, err := enforcer.Check(scopes, estimated)
+		if err != nil {
+			log.Printf("Budget check failed, allowing request: %v", err)
+		}
+		if denial != nil {
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error": gin.H{
+					"message": denial.Message(),
+					"type":    "insufficient_quota",
+					"param":   nil,
+					"code":    "budget_exceeded",
+				},
+			})
+			c.Abort()
+			return
+		}
+		// Requests that report no usage, or panic, give their reservation back
+		defer enforcer.Release(reservation)
+
+		c.Next()
+
+		value, exists := c.Get("usage_record")
+		if !exists {
+			return
+		}
+		if rec, ok := value.(usage.Record); ok {
+			if err := enforcer.Charge(scopes, reservation, rec.TotalCost); err != nil {
+				log.Printf("Failed to charge budget: %v", err)
+			}
+		}
+	}
+}
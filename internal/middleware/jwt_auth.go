@@ -0,0 +1,51 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/tosharewith/llmproxy_auth/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// JWTAuth validates JWT/OIDC bearer tokens. The token's user, email and
+// groups claims become the caller's identity, and its groups select the
+// permissions enforced by Authorize.
+func JWTAuth(validator *auth.JWTValidator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "Missing bearer token",
+				"message": "Provide a JWT via Authorization: Bearer <token>",
+			})
+			c.Abort()
+			return
+		}
+
+		identity, err := validator.Validate(c.Request.Context(), strings.TrimPrefix(authHeader, "Bearer "))
+		if err != nil {
+			log.Printf("JWT rejected from %s: %v", c.ClientIP(), err)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "Invalid token",
+				"message": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("user", identity.User)
+		c.Set("user_email", identity.Email)
+		c.Set("jwt_groups", identity.Groups)
+		c.Set("auth_method", "jwt")
+		if permissions, ok := validator.Permissions(identity.Groups); ok {
+			c.Set("api_key_permissions", permissions)
+		}
+
+		c.Next()
+	}
+}
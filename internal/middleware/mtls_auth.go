@@ -0,0 +1,72 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/tosharewith/llmproxy_auth/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// ClientCertAuth authenticates callers by the TLS client certificate, which
+// the server has already verified against the client CA bundle and CRL. The
+// CRL is checked again per request, since connections outlive CRL reloads.
+//
+// Without next the certificate's identity is the caller. With next (e.g. API
+// key auth) the certificate only admits the request to next, which then
+// authenticates the caller, so credentials are only accepted over a
+// certificate from an allowed identity.
+func ClientCertAuth(verifier *auth.ClientCertVerifier, next gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.TLS == nil || len(c.Request.TLS.PeerCertificates) == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "Missing client certificate",
+				"message": "Connect over TLS with a client certificate issued by a trusted CA",
+			})
+			c.Abort()
+			return
+		}
+
+		cert := c.Request.TLS.PeerCertificates[0]
+
+		// The handshake checked the CRL, but a reloaded CRL must also end
+		// keep-alive connections from certificates revoked since
+		if err := verifier.CheckRevocation(cert); err != nil {
+			log.Printf("Client certificate rejected from %s: %v", c.ClientIP(), err)
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "Client certificate revoked",
+				"message": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		identity, err := verifier.Identify(cert)
+		if err != nil {
+			log.Printf("Client certificate rejected from %s: %v", c.ClientIP(), err)
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "Client certificate not authorized",
+				"message": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("client_cert_identity", identity.Name)
+		c.Set("client_cert_subject", identity.Subject)
+		if next != nil {
+			next(c)
+			return
+		}
+
+		c.Set("user", identity.Name)
+		c.Set("auth_method", "mtls")
+		if identity.Permissions != "" {
+			c.Set("api_key_permissions", identity.Permissions)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientCertAuthRevocation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Client CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	caDER, _ := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	ca, _ := x509.ParseCertificate(caDER)
+	clientKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	clientDER, _ := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(20),
+		Subject:      pkix.Name{CommonName: "billing-api"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, &clientKey.PublicKey, caKey)
+	client, _ := x509.ParseCertificate(clientDER)
+
+	caFile := filepath.Join(dir, "ca.crt")
+	crlFile := filepath.Join(dir, "ca.crl")
+	os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0600)
+	writeCRL := func(number int64, modTime time.Time, serials ...int64) {
+		var entries []x509.RevocationListEntry
+		for _, serial := range serials {
+			entries = append(entries, x509.RevocationListEntry{SerialNumber: big.NewInt(serial), RevocationTime: time.Now()})
+		}
+		der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:                    big.NewInt(number),
+			ThisUpdate:                time.Now(),
+			NextUpdate:                time.Now().Add(time.Hour),
+			RevokedCertificateEntries: entries,
+		}, ca, caKey)
+		if err != nil {
+			t.Fatalf("Failed to create CRL: %v", err)
+		}
+		os.WriteFile(crlFile, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), 0600)
+		os.Chtimes(crlFile, modTime, modTime)
+	}
+	writeCRL(1, time.Now())
+
+	verifier, err := auth.NewClientCertVerifier(&auth.MTLSConfig{
+		ClientCAFile: caFile,
+		CRLFile:      crlFile,
+		CRLRefresh:   time.Nanosecond,
+		Identities:   []auth.CertIdentityRule{{CN: "billing-*"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create verifier: %v", err)
+	}
+
+	engine := gin.New()
+	engine.Use(ClientCertAuth(verifier, nil))
+	engine.GET("/v1/models", func(c *gin.Context) { c.Status(http.StatusOK) })
+	send := func() int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}}
+		engine.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := send(); code != http.StatusOK {
+		t.Fatalf("Expected a trusted certificate to be accepted, got %d", code)
+	}
+
+	// The same connection state after the CRL is reloaded with the certificate revoked
+	writeCRL(2, time.Now().Add(time.Minute), 20)
+	if code := send(); code != http.StatusForbidden {
+		t.Errorf("Expected a certificate revoked after the handshake to be refused, got %d", code)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultMaxTokens is the completion budget assumed when a request sets none,
+// matching the chat completions handler default
+const defaultMaxTokens = 4096
+
+// requestPeek summarizes a JSON request body for middleware that runs
+// before the handler parses it
+type requestPeek struct {
+	Model     string
+	BodyBytes int
+	MaxTokens int // larger of max_tokens, max_completion_tokens and inferenceConfig.maxTokens
+}
+
+// peekRequest reads the model and token budget of a JSON request body without
+// consuming it. The result is cached on the context for later middleware.
+func peekRequest(c *gin.Context) requestPeek {
+	if cached, ok := c.Get("request_peek"); ok {
+		return cached.(requestPeek)
+	}
+
+	var peek requestPeek
+	defer func() { c.Set("request_peek", peek) }()
+
+	if c.Request.Method != http.MethodPost || c.Request.Body == nil ||
+		!strings.Contains(c.GetHeader("Content-Type"), "json") {
+		return peek
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return peek
+	}
+
+	var req struct {
+		Model               string `json:"model"`
+		MaxTokens           int    `json:"max_tokens"`
+		MaxCompletionTokens int    `json:"max_completion_tokens"`
+
+		// Bedrock Converse requests carry the limit in inferenceConfig
+		InferenceConfig struct {
+			MaxTokens int `json:"maxTokens"`
+		} `json:"inferenceConfig"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return peek
+	}
+
+	peek.Model = req.Model
+	peek.BodyBytes = len(body)
+	peek.MaxTokens = req.MaxTokens
+	if req.MaxCompletionTokens > peek.MaxTokens {
+		peek.MaxTokens = req.MaxCompletionTokens
+	}
+	if req.InferenceConfig.MaxTokens > peek.MaxTokens {
+		peek.MaxTokens = req.InferenceConfig.MaxTokens
+	}
+	return peek
+}
+
+// completionBudget returns the request's completion token limit, or the
+// handler default when it sets none
+func (p requestPeek) completionBudget() int {
+	if p.MaxTokens == 0 {
+		return defaultMaxTokens
+	}
+	return p.MaxTokens
+}
@@ -0,0 +1,196 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/tosharewith/llmproxy_auth/internal/auth"
+	"github.com/tosharewith/llmproxy_auth/internal/router"
+	"github.com/gin-gonic/gin"
+)
+
+// Authorize enforces the caller's API key permissions for a route group: the
+// scope, model allow/deny globs, provider allowlist and max_tokens ceiling.
+//
+// When aiRouter is set the model and provider rules are evaluated against the
+// deployments the router can send the model to, and the router is restricted
+// to the permitted ones. Otherwise provider names the upstream provider of the
+// request. Requests whose auth mode sets no permissions are not restricted.
+func Authorize(scope string, aiRouter *router.Router, provider func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("api_key_permissions")
+		if !exists {
+			c.Next()
+			return
+		}
+		raw, _ := value.(string)
+		perms, err := auth.ParsePermissions(raw)
+		if err != nil {
+			log.Printf("Refusing request with unreadable permissions: %v", err)
+			permissionDenied(c, "invalid_permissions", "Your API key's permissions could not be read")
+			return
+		}
+		c.Set("permissions", perms)
+
+		if !perms.AllowsScope(scope) {
+			permissionDenied(c, "scope_not_allowed", fmt.Sprintf("Your API key may not use the %s API", scope))
+			return
+		}
+
+		peek := peekRequest(c)
+		if perms.MaxTokens > 0 {
+			if peek.MaxTokens > perms.MaxTokens {
+				permissionDenied(c, "max_tokens_exceeded",
+					fmt.Sprintf("max_tokens %d exceeds your API key's limit of %d", peek.MaxTokens, perms.MaxTokens))
+				return
+			}
+			// Keep the handler's default from exceeding the ceiling; only
+			// completion requests take max_tokens (embeddings reject it)
+			if peek.MaxTokens == 0 && peek.Model != "" && scope == auth.ScopeOpenAI &&
+				strings.HasSuffix(c.Request.URL.Path, "/completions") {
+				capMaxTokens(c, perms.MaxTokens)
+			}
+		}
+
+		if aiRouter != nil {
+			filter := func(modelName, providerName, providerModel string) bool {
+				return perms.AllowsProvider(providerName) && perms.AllowsModel(modelName, providerModel)
+			}
+			c.Request = c.Request.WithContext(router.WithTargetFilter(c.Request.Context(), filter))
+
+			if peek.Model != "" {
+				if code, message := routedDenial(perms, aiRouter.ModelTargets(peek.Model), peek.Model); code != "" {
+					permissionDenied(c, code, message)
+					return
+				}
+			}
+			c.Next()
+			return
+		}
+
+		if provider != nil {
+			if name := provider(c); name != "" && !perms.AllowsProvider(name) {
+				permissionDenied(c, "provider_not_allowed", fmt.Sprintf("Your API key may not use provider %q", name))
+				return
+			}
+		}
+		model := peek.Model
+		if model == "" {
+			model = pathModel(c.Request.URL.Path)
+		}
+		if model != "" && !perms.AllowsModel(model) {
+			permissionDenied(c, "model_not_allowed", fmt.Sprintf("Your API key may not use model %q", model))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// PathProvider returns the provider named by a path segment, e.g. index 1 for
+// /transparent/{provider}/...
+func PathProvider(index int) func(c *gin.Context) string {
+	return func(c *gin.Context) string {
+		segments := strings.Split(strings.Trim(c.Request.URL.Path, "/"), "/")
+		if index < len(segments) {
+			return segments[index]
+		}
+		return ""
+	}
+}
+
+// routedDenial explains why none of a model's deployments is permitted, or
+// returns an empty code if one is. Unknown models are left to the handler
+// unless their name is denied.
+func routedDenial(perms *auth.Permissions, targets []router.Target, model string) (code, message string) {
+	if len(targets) == 0 {
+		if !perms.AllowsModel(model) {
+			return "model_not_allowed", fmt.Sprintf("Your API key may not use model %q", model)
+		}
+		return "", ""
+	}
+
+	providerAllowed := false
+	for _, target := range targets {
+		if !perms.AllowsProvider(target.Provider) {
+			continue
+		}
+		providerAllowed = true
+		if perms.AllowsModel(model, target.Model) {
+			return "", ""
+		}
+	}
+
+	if !providerAllowed {
+		return "provider_not_allowed", fmt.Sprintf("Your API key may not use any provider that serves model %q", model)
+	}
+	return "model_not_allowed", fmt.Sprintf("Your API key may not use model %q", model)
+}
+
+// pathModel extracts the model from native API paths such as
+// /model/{id}/invoke, /models/{id}:generateContent and /deployments/{name}/...
+func pathModel(path string) string {
+	segments := strings.Split(path, "/")
+	for i := 0; i < len(segments)-1; i++ {
+		switch segments[i] {
+		case "model", "models", "deployments":
+			model := segments[i+1]
+			// Strip Vertex actions (":generateContent") but keep Bedrock versions ("v1:0")
+			if j := strings.LastIndex(model, ":"); j >= 0 && j+1 < len(model) && unicode.IsLetter(rune(model[j+1])) {
+				model = model[:j]
+			}
+			return model
+		}
+	}
+	return ""
+}
+
+// capMaxTokens sets max_tokens on a JSON request body that has none
+func capMaxTokens(c *gin.Context, limit int) {
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return
+	}
+	fields["max_tokens"] = json.RawMessage(strconv.Itoa(limit))
+	capped, err := json.Marshal(fields)
+	if err != nil {
+		return
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewReader(capped))
+	c.Request.ContentLength = int64(len(capped))
+
+	peek := peekRequest(c)
+	peek.MaxTokens = limit
+	peek.BodyBytes = len(capped)
+	c.Set("request_peek", peek)
+}
+
+// permissionDenied aborts with an OpenAI-style 403
+func permissionDenied(c *gin.Context, code, message string) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": gin.H{
+			"message": message,
+			"type":    "permission_error",
+			"param":   nil,
+			"code":    code,
+		},
+	})
+	c.Abort()
+}
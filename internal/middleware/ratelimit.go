@@ -0,0 +1,113 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitByUser enforces per-minute request and token limits keyed by the
+// authenticated API key or user (or client IP), and optionally by model.
+// Token buckets are charged with an estimate up front and corrected with the
+// usage the handler reports in the "total_tokens" context key.
+// If the limit store is unreachable requests are let through.
+func RateLimitByUser(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subject := ratelimit.Subject{User: c.ClientIP()}
+		if user, ok := c.Get("user"); ok {
+			if name, ok := user.(string); ok && name != "" {
+				subject.User = name
+			}
+		}
+		if keyID, ok := c.Get("api_key_id"); ok {
+			subject.KeyID, _ = keyID.(int64)
+		}
+		if metadata, ok := c.Get("api_key_metadata"); ok {
+			subject.Metadata, _ = metadata.(string)
+		}
+
+		// Estimate ~4 bytes per prompt token plus the requested completion budget
+		peek := peekRequest(c)
+		subject.Model = peek.Model
+		estimatedTokens := (peek.BodyBytes + 3) / 4
+		if peek.Model != "" {
+			estimatedTokens += peek.completionBudget()
+		}
+
+		rules, err := limiter.Rules(subject)
+		if err != nil {
+			log.Printf("Rate limit rules for %s: %v", subject.User, err)
+			c.Next()
+			return
+		}
+
+		decision, err := limiter.Allow(c.Request.Context(), rules, estimatedTokens)
+		if err != nil {
+			log.Printf("Rate limit check failed, allowing request: %v", err)
+			c.Next()
+			return
+		}
+
+		setRateLimitHeaders(c, decision)
+
+		if !decision.Allowed {
+			retryAfter := int(decision.RetryAfter.Round(time.Second).Seconds())
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+
+			status := decision.Headers[decision.Denied.Dimension]
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error": gin.H{
+					"message": fmt.Sprintf("Rate limit reached for %s per minute: Limit %d, Remaining %d. Please try again in %s.",
+						decision.Denied.Dimension, decision.Denied.Limit, status.Remaining, formatReset(decision.RetryAfter)),
+					"type":  decision.Denied.Dimension,
+					"param": nil,
+					"code":  "rate_limit_exceeded",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		// Correct token buckets with actual usage; failed requests are refunded
+		delta := -estimatedTokens
+		if total, ok := c.Get("total_tokens"); ok {
+			if n, ok := total.(int); ok {
+				delta = n - estimatedTokens
+			}
+		}
+		if err := limiter.Adjust(context.Background(), rules, delta); err != nil {
+			log.Printf("Failed to adjust token rate limit: %v", err)
+		}
+	}
+}
+
+// setRateLimitHeaders sets OpenAI-style x-ratelimit-* headers
+func setRateLimitHeaders(c *gin.Context, decision *ratelimit.Decision) {
+	for dimension, status := range decision.Headers {
+		c.Header("x-ratelimit-limit-"+dimension, strconv.Itoa(status.Limit))
+		c.Header("x-ratelimit-remaining-"+dimension, strconv.Itoa(status.Remaining))
+		c.Header("x-ratelimit-reset-"+dimension, formatReset(status.ResetAfter))
+	}
+}
+
+// formatReset formats a duration the way OpenAI does (e.g. "1s", "6m0s", "20ms")
+func formatReset(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
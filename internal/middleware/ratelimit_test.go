@@ -0,0 +1,104 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tosharewith/llmproxy_auth/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitByUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := ratelimit.NewLimiter(&ratelimit.Config{
+		Default: ratelimit.Limits{RequestsPerMinute: 2, TokensPerMinute: 10000},
+	}, ratelimit.NewMemoryStore())
+
+	engine := gin.New()
+	engine.Use(RateLimitByUser(limiter))
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		c.Set("total_tokens", 100)
+		c.Status(http.StatusOK)
+	})
+
+	// 32 bytes is an 8 token prompt; the completion budget defaults to 4096
+	const body = `{"model":"gpt-4o","messages":[]}`
+	const estimated = 8 + defaultMaxTokens
+	send := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		engine.ServeHTTP(w, req)
+		return w
+	}
+	remainingTokens := func(w *httptest.ResponseRecorder) int {
+		n, err := strconv.Atoi(w.Header().Get("x-ratelimit-remaining-tokens"))
+		if err != nil {
+			t.Fatalf("Invalid x-ratelimit-remaining-tokens: %v", err)
+		}
+		return n
+	}
+
+	t.Run("Headers report the estimate", func(t *testing.T) {
+		w := send()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+		headers := map[string]string{
+			"x-ratelimit-limit-requests":     "2",
+			"x-ratelimit-remaining-requests": "1",
+			"x-ratelimit-limit-tokens":       "10000",
+			"x-ratelimit-remaining-tokens":   strconv.Itoa(10000 - estimated),
+		}
+		for name, want := range headers {
+			if got := w.Header().Get(name); got != want {
+				t.Errorf("%s = %q, want %q", name, got, want)
+			}
+		}
+		if w.Header().Get("x-ratelimit-reset-tokens") == "" {
+			t.Error("Expected x-ratelimit-reset-tokens to be set")
+		}
+	})
+
+	t.Run("Estimate is corrected to the reported usage", func(t *testing.T) {
+		w := send()
+		// The first request was charged its 100 reported tokens; refill over
+		// the test's runtime may add a token
+		if got, want := remainingTokens(w), 10000-100-estimated; got < want || got > want+1 {
+			t.Errorf("x-ratelimit-remaining-tokens = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("Exhausted limit returns 429", func(t *testing.T) {
+		w := send()
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected 429, got %d", w.Code)
+		}
+		if w.Header().Get("Retry-After") == "" {
+			t.Error("Expected a Retry-After header")
+		}
+
+		var resp struct {
+			Error struct {
+				Message string  `json:"message"`
+				Type    string  `json:"type"`
+				Param   *string `json:"param"`
+				Code    string  `json:"code"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Invalid error body: %v", err)
+		}
+		if resp.Error.Type != ratelimit.DimensionRequests || resp.Error.Code != "rate_limit_exceeded" || resp.Error.Param != nil ||
+			!strings.HasPrefix(resp.Error.Message, "Rate limit reached for requests per minute: Limit 2, Remaining 0.") {
+			t.Errorf("Unexpected error body %s", w.Body.String())
+		}
+	})
+}
@@ -68,6 +68,8 @@ func SessionTokenAuth(sessionManager *auth.SessionManager, apiKeyDB *auth.APIKey
 		c.Set("user", keyInfo.Name)
 		c.Set("user_email", keyInfo.Email)
 		c.Set("api_key_id", apiKeyID)
+		c.Set("api_key_permissions", keyInfo.Permissions)
+		c.Set("api_key_metadata", keyInfo.Metadata)
 		c.Set("session_id", session.ID)
 		c.Set("auth_method", "session_token")
 
@@ -106,12 +108,17 @@ func HybridAuth(
 		// If has session token, validate it
 		if sessionToken != "" {
 			session, apiKeyID, err := sessionManager.ValidateSessionToken(sessionToken)
+			var keyInfo *auth.APIKey
+			if err == nil {
+				keyInfo, err = apiKeyDB.GetAPIKeyByID(apiKeyID)
+			}
 			if err == nil {
 				// Valid session token - authenticated!
-				keyInfo, _ := apiKeyDB.GetAPIKeyByID(apiKeyID)
 				c.Set("user", keyInfo.Name)
 				c.Set("user_email", keyInfo.Email)
 				c.Set("api_key_id", apiKeyID)
+				c.Set("api_key_permissions", keyInfo.Permissions)
+				c.Set("api_key_metadata", keyInfo.Metadata)
 				c.Set("session_id", session.ID)
 				c.Set("auth_method", "session_token")
 				c.Next()
@@ -120,8 +127,12 @@ func HybridAuth(
 			// Session token invalid, fall through to API key + TOTP
 		}
 
-		// No valid session token, require API key + TOTP
+		// No valid session token, require API key + TOTP. OpenAI clients
+		// send the API key as a bearer token.
 		apiKey := c.GetHeader("X-API-Key")
+		if apiKey == "" && !strings.HasPrefix(sessionToken, auth.SessionTokenPrefix) {
+			apiKey = sessionToken
+		}
 		if apiKey == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error":   "Authentication required",
@@ -170,7 +181,10 @@ func HybridAuth(
 		c.Set("user", keyInfo.Name)
 		c.Set("user_email", keyInfo.Email)
 		c.Set("api_key_id", keyInfo.ID)
+		c.Set("api_key_permissions", keyInfo.Permissions)
+		c.Set("api_key_metadata", keyInfo.Metadata)
 		c.Set("auth_method", "api_key_totp")
+		c.Set("2fa_enabled", twoFAEnabled)
 
 		c.Next()
 	}
@@ -0,0 +1,39 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package middleware
+
+import (
+	"github.com/tosharewith/llmproxy_auth/internal/usage"
+	"github.com/gin-gonic/gin"
+)
+
+// UsageLedger writes the usage record a handler leaves in the "usage_record"
+// context key to the ledger, attributed to the authenticated key or user
+func UsageLedger(ledger *usage.Ledger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		value, exists := c.Get("usage_record")
+		if !exists {
+			return
+		}
+		rec, ok := value.(usage.Record)
+		if !ok {
+			return
+		}
+
+		if keyID, ok := c.Get("api_key_id"); ok {
+			rec.APIKeyID, _ = keyID.(int64)
+		}
+		if user, ok := c.Get("user"); ok {
+			rec.User, _ = user.(string)
+		}
+		if requestID, ok := c.Get("request_id"); ok {
+			rec.RequestID, _ = requestID.(string)
+		}
+		rec.StatusCode = c.Writer.Status()
+
+		ledger.Record(rec)
+	}
+}
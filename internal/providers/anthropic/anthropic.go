@@ -6,10 +6,12 @@ package anthropic
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/tosharewith/llmproxy_auth/internal/providers"
@@ -35,6 +37,8 @@ type AnthropicRequest struct {
 	Messages    []AnthropicMessage  `json:"messages"`
 	MaxTokens   int                 `json:"max_tokens"`
 	Temperature *float64            `json:"temperature,omitempty"`
+	TopP        *float64            `json:"top_p,omitempty"`
+	StopSequences []string          `json:"stop_sequences,omitempty"`
 	System      string              `json:"system,omitempty"`
 	Tools       []AnthropicTool     `json:"tools,omitempty"`
 	ToolChoice  interface{}         `json:"tool_choice,omitempty"`
@@ -174,6 +178,7 @@ func (p *AnthropicProvider) Invoke(ctx context.Context, request *providers.Provi
 			StatusCode: resp.StatusCode,
 			Message:    string(respBody),
 			Provider:   "anthropic",
+			RetryAfter: providers.ParseRetryAfter(resp.Header.Get("Retry-After")),
 		}
 	}
 
@@ -268,10 +273,14 @@ func (p *AnthropicProvider) InvokeStreaming(ctx context.Context, request *provid
 			StatusCode: resp.StatusCode,
 			Message:    string(body),
 			Provider:   "anthropic",
+			RetryAfter: providers.ParseRetryAfter(resp.Header.Get("Retry-After")),
 		}
 	}
 
-	return resp.Body, nil
+	// Translate Anthropic events into OpenAI chunks
+	return translator.PipeStream(resp.Body, func(w io.Writer) error {
+		return translateAnthropicStream(resp.Body, w, openaiReq.Model)
+	}), nil
 }
 
 // ListModels lists available Anthropic models
@@ -309,20 +318,43 @@ func translateOpenAIToAnthropic(req *translator.ChatCompletionRequest) *Anthropi
 	if req.Temperature > 0 {
 		anthropicReq.Temperature = &req.Temperature
 	}
+	if req.TopP > 0 {
+		anthropicReq.TopP = &req.TopP
+	}
+	anthropicReq.StopSequences = req.Stop
 
 	// Convert messages
+	var systemPrompts []string
 	for _, msg := range req.Messages {
 		if msg.Role == "system" {
 			// Extract system message
-			anthropicReq.System = extractTextContent(msg.Content)
-		} else {
-			// User and assistant messages
-			anthropicReq.Messages = append(anthropicReq.Messages, AnthropicMessage{
-				Role:    msg.Role,
-				Content: extractTextContent(msg.Content),
-			})
+			systemPrompts = append(systemPrompts, extractTextContent(msg.Content))
+			continue
+		}
+
+		// Tool results are returned to the model in a user turn
+		role := msg.Role
+		if role == "tool" {
+			role = "user"
+		}
+		blocks := convertMessageContent(msg)
+		if len(blocks) == 0 {
+			continue
 		}
+
+		// Anthropic requires alternating roles, so consecutive turns (such as
+		// several tool results) are merged into one message
+		if n := len(anthropicReq.Messages); n > 0 && anthropicReq.Messages[n-1].Role == role {
+			previous := anthropicReq.Messages[n-1].Content.([]map[string]interface{})
+			anthropicReq.Messages[n-1].Content = append(previous, blocks...)
+			continue
+		}
+		anthropicReq.Messages = append(anthropicReq.Messages, AnthropicMessage{
+			Role:    role,
+			Content: blocks,
+		})
 	}
+	anthropicReq.System = strings.Join(systemPrompts, "\n\n")
 
 	// Convert tools
 	if len(req.Tools) > 0 {
@@ -363,6 +395,115 @@ func translateOpenAIToAnthropic(req *translator.ChatCompletionRequest) *Anthropi
 	return anthropicReq
 }
 
+// convertMessageContent converts an OpenAI message into Anthropic content
+// blocks: text, images, tool calls and tool results
+func convertMessageContent(msg translator.ChatMessage) []map[string]interface{} {
+	if msg.Role == "tool" {
+		return []map[string]interface{}{{
+			"type":        "tool_result",
+			"tool_use_id": msg.ToolCallID,
+			"content":     extractTextContent(msg.Content),
+		}}
+	}
+
+	var blocks []map[string]interface{}
+	switch c := msg.Content.(type) {
+	case nil:
+	case string:
+		if c != "" {
+			blocks = append(blocks, map[string]interface{}{"type": "text", "text": c})
+		}
+	case []interface{}:
+		for _, part := range c {
+			partMap, ok := part.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch partMap["type"] {
+			case "text":
+				if text, ok := partMap["text"].(string); ok {
+					blocks = append(blocks, map[string]interface{}{"type": "text", "text": text})
+				}
+			case "image_url":
+				if block := convertImagePart(partMap); block != nil {
+					blocks = append(blocks, block)
+				}
+			case "file":
+				if block := convertFilePart(partMap); block != nil {
+					blocks = append(blocks, block)
+				}
+			}
+		}
+	default:
+		blocks = append(blocks, map[string]interface{}{"type": "text", "text": extractTextContent(c)})
+	}
+
+	for _, call := range msg.ToolCalls {
+		input := map[string]interface{}{}
+		if call.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &input); err != nil || input == nil {
+				input = map[string]interface{}{}
+			}
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type":  "tool_use",
+			"id":    call.ID,
+			"name":  call.Function.Name,
+			"input": input,
+		})
+	}
+	return blocks
+}
+
+// convertImagePart converts an OpenAI image_url part to an Anthropic image
+// block, inlining data URLs and passing other URLs by reference
+func convertImagePart(part map[string]interface{}) map[string]interface{} {
+	imageURL, _ := part["image_url"].(map[string]interface{})
+	url, _ := imageURL["url"].(string)
+	if url == "" {
+		return nil
+	}
+
+	source := map[string]interface{}{"type": "url", "url": url}
+	if rest, ok := strings.CutPrefix(url, "data:"); ok {
+		mediaType, data, found := strings.Cut(rest, ";base64,")
+		if !found {
+			return nil
+		}
+		source = map[string]interface{}{"type": "base64", "media_type": mediaType, "data": data}
+	}
+	return map[string]interface{}{"type": "image", "source": source}
+}
+
+// convertFilePart converts an OpenAI file part to an Anthropic document
+// block; PDFs are sent as base64 and plain text as text
+func convertFilePart(part map[string]interface{}) map[string]interface{} {
+	file, ok := translator.ParseFilePart(part)
+	if !ok {
+		return nil
+	}
+
+	var source map[string]interface{}
+	switch file.MediaType {
+	case "application/pdf":
+		source = map[string]interface{}{"type": "base64", "media_type": file.MediaType, "data": file.Data}
+	case "text/plain":
+		text, err := base64.StdEncoding.DecodeString(file.Data)
+		if err != nil {
+			return nil
+		}
+		source = map[string]interface{}{"type": "text", "media_type": file.MediaType, "data": string(text)}
+	default:
+		return nil
+	}
+
+	block := map[string]interface{}{"type": "document", "source": source}
+	if file.Name != "" {
+		block["title"] = file.Name
+	}
+	return block
+}
+
 // translateAnthropicToOpenAI converts Anthropic response to OpenAI format
 func translateAnthropicToOpenAI(resp *AnthropicResponse, model string) *translator.ChatCompletionResponse {
 	var content string
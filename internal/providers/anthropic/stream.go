@@ -0,0 +1,166 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package anthropic
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/internal/translator"
+)
+
+// AnthropicStreamEvent represents a Messages API streaming event
+type AnthropicStreamEvent struct {
+	Type         string                 `json:"type"`
+	Message      *AnthropicResponse     `json:"message,omitempty"`       // message_start
+	Index        int                    `json:"index"`                   // content_block_*
+	ContentBlock *AnthropicContentBlock `json:"content_block,omitempty"` // content_block_start
+	Delta        *AnthropicStreamDelta  `json:"delta,omitempty"`         // content_block_delta, message_delta
+	Usage        *AnthropicUsage        `json:"usage,omitempty"`         // message_delta
+	Error        *AnthropicError        `json:"error,omitempty"`         // error
+}
+
+// AnthropicStreamDelta represents the delta of a streaming event
+type AnthropicStreamDelta struct {
+	Type        string `json:"type,omitempty"` // text_delta or input_json_delta
+	Text        string `json:"text,omitempty"`
+	PartialJSON string `json:"partial_json,omitempty"`
+	StopReason  string `json:"stop_reason,omitempty"`
+}
+
+// AnthropicError represents an Anthropic API error
+type AnthropicError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// translateAnthropicStream converts an Anthropic SSE stream into OpenAI chunk SSE
+func translateAnthropicStream(r io.Reader, w io.Writer, model string) error {
+	sse := translator.NewSSEReader(r)
+	created := time.Now().Unix()
+	var id string
+	var usage translator.Usage
+	toolIndex := make(map[int]int)
+
+	write := func(delta translator.ChatMessageDelta, finishReason string) error {
+		return translator.WriteStreamChunk(w, translator.NewChatCompletionChunk(id, model, created, delta, finishReason))
+	}
+
+	for {
+		event, err := sse.Next()
+		if err == io.EOF {
+			return fmt.Errorf("anthropic stream ended before message_stop")
+		}
+		if err != nil {
+			return err
+		}
+		if event.Data == "" {
+			continue
+		}
+
+		var streamEvent AnthropicStreamEvent
+		if err := json.Unmarshal([]byte(event.Data), &streamEvent); err != nil {
+			return fmt.Errorf("failed to parse anthropic stream event: %w", err)
+		}
+
+		switch streamEvent.Type {
+		case "message_start":
+			if streamEvent.Message != nil {
+				id = streamEvent.Message.ID
+				usage.PromptTokens = streamEvent.Message.Usage.InputTokens
+			}
+			if err := write(translator.ChatMessageDelta{Role: "assistant"}, ""); err != nil {
+				return err
+			}
+
+		case "content_block_start":
+			block := streamEvent.ContentBlock
+			if block == nil || block.Type != "tool_use" {
+				continue
+			}
+			index := len(toolIndex)
+			toolIndex[streamEvent.Index] = index
+			if err := write(translator.ChatMessageDelta{
+				ToolCalls: []translator.ToolCall{{
+					Index:    &index,
+					ID:       block.ID,
+					Type:     "function",
+					Function: translator.FunctionCall{Name: block.Name},
+				}},
+			}, ""); err != nil {
+				return err
+			}
+
+		case "content_block_delta":
+			delta := streamEvent.Delta
+			if delta == nil {
+				continue
+			}
+			switch delta.Type {
+			case "text_delta":
+				if err := write(translator.ChatMessageDelta{Content: delta.Text}, ""); err != nil {
+					return err
+				}
+			case "input_json_delta":
+				index, ok := toolIndex[streamEvent.Index]
+				if !ok {
+					continue
+				}
+				if err := write(translator.ChatMessageDelta{
+					ToolCalls: []translator.ToolCall{{
+						Index:    &index,
+						Function: translator.FunctionCall{Arguments: delta.PartialJSON},
+					}},
+				}, ""); err != nil {
+					return err
+				}
+			}
+
+		case "message_delta":
+			if streamEvent.Usage != nil {
+				usage.CompletionTokens = streamEvent.Usage.OutputTokens
+			}
+			if streamEvent.Delta != nil && streamEvent.Delta.StopReason != "" {
+				if err := write(translator.ChatMessageDelta{}, mapAnthropicStopReason(streamEvent.Delta.StopReason)); err != nil {
+					return err
+				}
+			}
+
+		case "message_stop":
+			usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+			if err := translator.WriteStreamChunk(w, &translator.ChatCompletionStreamResponse{
+				ID:      id,
+				Object:  "chat.completion.chunk",
+				Created: created,
+				Model:   model,
+				Choices: []translator.ChatCompletionStreamChoice{},
+				Usage:   &usage,
+			}); err != nil {
+				return err
+			}
+			return translator.WriteStreamDone(w)
+
+		case "error":
+			message := "unknown stream error"
+			if streamEvent.Error != nil {
+				message = streamEvent.Error.Message
+			}
+			return fmt.Errorf("anthropic stream error: %s", message)
+		}
+	}
+}
+
+// mapAnthropicStopReason maps Anthropic stop reason to OpenAI finish reason
+func mapAnthropicStopReason(stopReason string) string {
+	switch stopReason {
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	default:
+		return "stop"
+	}
+}
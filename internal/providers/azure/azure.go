@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/tosharewith/llmproxy_auth/internal/providers"
@@ -85,7 +86,7 @@ func (p *AzureProvider) HealthCheck(ctx context.Context) error {
 // Invoke sends a request to Azure OpenAI
 func (p *AzureProvider) Invoke(ctx context.Context, request *providers.ProviderRequest) (*providers.ProviderResponse, error) {
 	// Azure uses deployment names instead of model names
-	// The path should be /openai/deployments/{deployment-id}/chat/completions
+	// The path should be /openai/deployments/{deployment-id}/{operation}
 	deploymentID := extractDeploymentID(request.Path)
 	if deploymentID == "" {
 		return nil, &providers.ProviderError{
@@ -96,8 +97,8 @@ func (p *AzureProvider) Invoke(ctx context.Context, request *providers.ProviderR
 	}
 
 	// Build Azure-specific URL
-	url := fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
-		p.endpoint, deploymentID, p.apiVersion)
+	url := fmt.Sprintf("%s/openai/deployments/%s/%s?api-version=%s",
+		p.endpoint, deploymentID, extractOperation(request.Path), p.apiVersion)
 
 	// Create HTTP request
 	httpReq, err := http.NewRequestWithContext(ctx, request.Method, url, bytes.NewReader(request.Body))
@@ -140,6 +141,7 @@ func (p *AzureProvider) Invoke(ctx context.Context, request *providers.ProviderR
 			StatusCode: resp.StatusCode,
 			Message:    string(body),
 			Provider:   "azure",
+			RetryAfter: providers.ParseRetryAfter(resp.Header.Get("Retry-After")),
 		}
 	}
 
@@ -200,6 +202,7 @@ func (p *AzureProvider) InvokeStreaming(ctx context.Context, request *providers.
 			StatusCode: resp.StatusCode,
 			Message:    string(body),
 			Provider:   "azure",
+			RetryAfter: providers.ParseRetryAfter(resp.Header.Get("Retry-After")),
 		}
 	}
 
@@ -295,9 +298,25 @@ func (p *AzureProvider) GetModelInfo(ctx context.Context, modelID string) (*prov
 
 // extractDeploymentID extracts the deployment ID from the request path or metadata
 func extractDeploymentID(path string) string {
-	// Path could be /v1/chat/completions with deployment in metadata
-	// Or could already contain deployment ID
-	// For now, we'll expect it in the path or return empty
-	// This will be populated by the router based on model mapping
-	return ""
+	// The handler sets the path to /deployments/{deployment-id}/chat/completions
+	// based on the model mapping; the /openai prefix is optional
+	path = strings.TrimPrefix(path, "/openai")
+	rest, ok := strings.CutPrefix(path, "/deployments/")
+	if !ok {
+		return ""
+	}
+	deploymentID, _, _ := strings.Cut(rest, "/")
+	return deploymentID
+}
+
+// extractOperation returns the operation after the deployment ID, such as
+// chat/completions or embeddings; chat completions are the default
+func extractOperation(path string) string {
+	path = strings.TrimPrefix(path, "/openai")
+	rest, _ := strings.CutPrefix(path, "/deployments/")
+	_, operation, _ := strings.Cut(rest, "/")
+	if operation == "" {
+		return "chat/completions"
+	}
+	return operation
 }
@@ -153,7 +153,7 @@ func (p *BedrockProvider) Invoke(ctx context.Context, request *providers.Provide
 
 	// Handle error responses
 	if resp.StatusCode >= 400 {
-		return nil, p.handleErrorResponse(resp.StatusCode, respBody)
+		return nil, p.handleErrorResponse(resp.StatusCode, resp.Header, respBody)
 	}
 
 	// Build response
@@ -224,7 +224,7 @@ func (p *BedrockProvider) InvokeStreaming(ctx context.Context, request *provider
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, p.handleErrorResponse(resp.StatusCode, body)
+		return nil, p.handleErrorResponse(resp.StatusCode, resp.Header, body)
 	}
 
 	// Return the response body as a ReadCloser
@@ -250,7 +250,7 @@ func (p *BedrockProvider) GetModelInfo(ctx context.Context, modelID string) (*pr
 }
 
 // handleErrorResponse converts Bedrock error responses to ProviderError
-func (p *BedrockProvider) handleErrorResponse(statusCode int, body []byte) error {
+func (p *BedrockProvider) handleErrorResponse(statusCode int, header http.Header, body []byte) error {
 	var code string
 	var message string
 
@@ -285,6 +285,7 @@ func (p *BedrockProvider) handleErrorResponse(statusCode int, body []byte) error
 		StatusCode: statusCode,
 		Code:       code,
 		Message:    message,
+		RetryAfter: providers.ParseRetryAfter(header.Get("Retry-After")),
 	}
 }
 
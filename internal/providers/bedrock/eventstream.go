@@ -0,0 +1,419 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bedrock
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+)
+
+// Event types emitted by ConverseStream
+const (
+	StreamEventMessageStart      = "messageStart"
+	StreamEventContentBlockStart = "contentBlockStart"
+	StreamEventContentBlockDelta = "contentBlockDelta"
+	StreamEventContentBlockStop  = "contentBlockStop"
+	StreamEventMessageStop       = "messageStop"
+	StreamEventMetadata          = "metadata"
+)
+
+// StreamEventChunk is the event type emitted by InvokeModelWithResponseStream
+const StreamEventChunk = "chunk"
+
+const (
+	eventStreamPreludeLength = 12
+	eventStreamCRCLength     = 4
+	// Bedrock messages are far smaller; this guards against corrupt lengths
+	eventStreamMaxMessageLength = 16 * 1024 * 1024
+)
+
+// Event stream header value types
+const (
+	headerTypeBoolTrue byte = iota
+	headerTypeBoolFalse
+	headerTypeByte
+	headerTypeShort
+	headerTypeInteger
+	headerTypeLong
+	headerTypeByteArray
+	headerTypeString
+	headerTypeTimestamp
+	headerTypeUUID
+)
+
+// ErrEventStreamChecksum is returned when a message fails CRC validation
+var ErrEventStreamChecksum = errors.New("event stream checksum mismatch")
+
+// EventStreamMessage is a single decoded application/vnd.amazon.eventstream message
+type EventStreamMessage struct {
+	Headers map[string]interface{}
+	Payload []byte
+}
+
+// StringHeader returns a string header value, or "" if absent
+func (m *EventStreamMessage) StringHeader(name string) string {
+	if v, ok := m.Headers[name].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// EventStreamDecoder decodes the AWS binary event stream used by Bedrock streaming APIs
+type EventStreamDecoder struct {
+	reader io.Reader
+}
+
+// NewEventStreamDecoder creates a decoder reading from r
+func NewEventStreamDecoder(r io.Reader) *EventStreamDecoder {
+	return &EventStreamDecoder{reader: r}
+}
+
+// ReadMessage reads and validates the next raw message. It returns io.EOF
+// when the stream ends cleanly between messages.
+func (d *EventStreamDecoder) ReadMessage() (*EventStreamMessage, error) {
+	// Prelude: total length, headers length, prelude CRC
+	prelude := make([]byte, eventStreamPreludeLength)
+	if _, err := io.ReadFull(d.reader, prelude); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("truncated event stream prelude")
+		}
+		return nil, err
+	}
+
+	totalLength := binary.BigEndian.Uint32(prelude[0:4])
+	headersLength := binary.BigEndian.Uint32(prelude[4:8])
+	preludeCRC := binary.BigEndian.Uint32(prelude[8:12])
+
+	if crc32.ChecksumIEEE(prelude[0:8]) != preludeCRC {
+		return nil, fmt.Errorf("invalid prelude: %w", ErrEventStreamChecksum)
+	}
+
+	minLength := uint32(eventStreamPreludeLength + eventStreamCRCLength)
+	if totalLength < minLength || totalLength > eventStreamMaxMessageLength {
+		return nil, fmt.Errorf("invalid event stream message length %d", totalLength)
+	}
+	if headersLength > totalLength-minLength {
+		return nil, fmt.Errorf("invalid event stream headers length %d", headersLength)
+	}
+
+	// Remainder: headers, payload, message CRC
+	rest := make([]byte, totalLength-eventStreamPreludeLength)
+	if _, err := io.ReadFull(d.reader, rest); err != nil {
+		return nil, fmt.Errorf("truncated event stream message: %w", err)
+	}
+
+	body := rest[:len(rest)-eventStreamCRCLength]
+	messageCRC := binary.BigEndian.Uint32(rest[len(rest)-eventStreamCRCLength:])
+
+	crc := crc32.NewIEEE()
+	crc.Write(prelude)
+	crc.Write(body)
+	if crc.Sum32() != messageCRC {
+		return nil, fmt.Errorf("invalid message: %w", ErrEventStreamChecksum)
+	}
+
+	headers, err := decodeEventStreamHeaders(body[:headersLength])
+	if err != nil {
+		return nil, err
+	}
+
+	return &EventStreamMessage{
+		Headers: headers,
+		Payload: body[headersLength:],
+	}, nil
+}
+
+// Next returns the next typed event. Exceptions sent by Bedrock are returned
+// as *providers.ProviderError, and io.EOF marks the end of the stream.
+func (d *EventStreamDecoder) Next() (*providers.StreamEvent, error) {
+	msg, err := d.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+
+	switch msg.StringHeader(":message-type") {
+	case "exception":
+		return nil, streamExceptionToProviderError(msg.StringHeader(":exception-type"), msg.Payload)
+	case "error":
+		return nil, streamExceptionToProviderError(msg.StringHeader(":error-code"), []byte(fmt.Sprintf(`{"message":%q}`, msg.StringHeader(":error-message"))))
+	}
+
+	event := &providers.StreamEvent{
+		Type: msg.StringHeader(":event-type"),
+		Data: msg.Payload,
+	}
+
+	switch event.Type {
+	case StreamEventMetadata:
+		// ConverseStream usage and latency
+		var metadata struct {
+			Usage struct {
+				InputTokens  int `json:"inputTokens"`
+				OutputTokens int `json:"outputTokens"`
+				TotalTokens  int `json:"totalTokens"`
+			} `json:"usage"`
+			Metrics struct {
+				LatencyMs int64 `json:"latencyMs"`
+			} `json:"metrics"`
+		}
+		if err := json.Unmarshal(msg.Payload, &metadata); err != nil {
+			return nil, fmt.Errorf("failed to parse metadata event: %w", err)
+		}
+		event.Metadata = &providers.ResponseMetadata{
+			Latency:      time.Duration(metadata.Metrics.LatencyMs) * time.Millisecond,
+			InputTokens:  metadata.Usage.InputTokens,
+			OutputTokens: metadata.Usage.OutputTokens,
+			TotalTokens:  metadata.Usage.TotalTokens,
+		}
+
+	case StreamEventChunk:
+		// InvokeModelWithResponseStream wraps the model-native JSON in base64
+		var chunk struct {
+			Bytes string `json:"bytes"`
+		}
+		if err := json.Unmarshal(msg.Payload, &chunk); err != nil {
+			return nil, fmt.Errorf("failed to parse chunk event: %w", err)
+		}
+		data, err := base64.StdEncoding.DecodeString(chunk.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode chunk bytes: %w", err)
+		}
+		event.Data = data
+
+		// The final chunk carries invocation metrics
+		var metrics struct {
+			InvocationMetrics *struct {
+				InputTokenCount   int   `json:"inputTokenCount"`
+				OutputTokenCount  int   `json:"outputTokenCount"`
+				InvocationLatency int64 `json:"invocationLatency"`
+			} `json:"amazon-bedrock-invocationMetrics"`
+		}
+		if json.Unmarshal(data, &metrics) == nil && metrics.InvocationMetrics != nil {
+			m := metrics.InvocationMetrics
+			event.Metadata = &providers.ResponseMetadata{
+				Latency:      time.Duration(m.InvocationLatency) * time.Millisecond,
+				InputTokens:  m.InputTokenCount,
+				OutputTokens: m.OutputTokenCount,
+				TotalTokens:  m.InputTokenCount + m.OutputTokenCount,
+			}
+		}
+	}
+
+	return event, nil
+}
+
+// EventStreamEncoder writes the AWS binary event stream, for serving
+// Bedrock-compatible streaming responses
+type EventStreamEncoder struct {
+	writer io.Writer
+}
+
+// NewEventStreamEncoder creates an encoder writing to w
+func NewEventStreamEncoder(w io.Writer) *EventStreamEncoder {
+	return &EventStreamEncoder{writer: w}
+}
+
+// WriteEvent writes a JSON event such as contentBlockDelta
+func (e *EventStreamEncoder) WriteEvent(eventType string, payload []byte) error {
+	return e.WriteMessage([][2]string{
+		{":event-type", eventType},
+		{":content-type", "application/json"},
+		{":message-type", "event"},
+	}, payload)
+}
+
+// WriteException writes a modeled exception such as throttlingException;
+// clients treat it as the end of the stream
+func (e *EventStreamEncoder) WriteException(exceptionType, message string) error {
+	payload, err := json.Marshal(map[string]string{"message": message})
+	if err != nil {
+		return err
+	}
+	return e.WriteMessage([][2]string{
+		{":exception-type", exceptionType},
+		{":content-type", "application/json"},
+		{":message-type", "exception"},
+	}, payload)
+}
+
+// WriteMessage frames a message with string headers
+func (e *EventStreamEncoder) WriteMessage(headers [][2]string, payload []byte) error {
+	var hdr []byte
+	for _, h := range headers {
+		if len(h[0]) > 255 || len(h[1]) > 65535 {
+			return fmt.Errorf("event stream header %q is too long", h[0])
+		}
+		hdr = append(hdr, byte(len(h[0])))
+		hdr = append(hdr, h[0]...)
+		hdr = append(hdr, headerTypeString)
+		hdr = binary.BigEndian.AppendUint16(hdr, uint16(len(h[1])))
+		hdr = append(hdr, h[1]...)
+	}
+
+	totalLength := eventStreamPreludeLength + len(hdr) + len(payload) + eventStreamCRCLength
+	if totalLength > eventStreamMaxMessageLength {
+		return fmt.Errorf("event stream message of %d bytes is too large", totalLength)
+	}
+
+	msg := make([]byte, 0, totalLength)
+	msg = binary.BigEndian.AppendUint32(msg, uint32(totalLength))
+	msg = binary.BigEndian.AppendUint32(msg, uint32(len(hdr)))
+	msg = binary.BigEndian.AppendUint32(msg, crc32.ChecksumIEEE(msg))
+	msg = append(msg, hdr...)
+	msg = append(msg, payload...)
+	msg = binary.BigEndian.AppendUint32(msg, crc32.ChecksumIEEE(msg))
+
+	_, err := e.writer.Write(msg)
+	return err
+}
+
+// ExceptionType returns the Bedrock exception name for an HTTP status code,
+// as sent in the x-amzn-ErrorType header. Streams use the same names starting
+// with a lower-case letter.
+func ExceptionType(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return "ValidationException"
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "AccessDeniedException"
+	case http.StatusNotFound:
+		return "ResourceNotFoundException"
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return "ModelTimeoutException"
+	case http.StatusTooManyRequests:
+		return "ThrottlingException"
+	case http.StatusServiceUnavailable:
+		return "ServiceUnavailableException"
+	default:
+		return "InternalServerException"
+	}
+}
+
+// decodeEventStreamHeaders parses the header section of a message
+func decodeEventStreamHeaders(b []byte) (map[string]interface{}, error) {
+	headers := make(map[string]interface{})
+
+	for len(b) > 0 {
+		nameLen := int(b[0])
+		if len(b) < 1+nameLen+1 {
+			return nil, fmt.Errorf("malformed event stream header name")
+		}
+		name := string(b[1 : 1+nameLen])
+		valueType := b[1+nameLen]
+		b = b[2+nameLen:]
+
+		var value interface{}
+		var size int
+
+		switch valueType {
+		case headerTypeBoolTrue:
+			value, size = true, 0
+		case headerTypeBoolFalse:
+			value, size = false, 0
+		case headerTypeByte:
+			size = 1
+			if len(b) >= size {
+				value = int8(b[0])
+			}
+		case headerTypeShort:
+			size = 2
+			if len(b) >= size {
+				value = int16(binary.BigEndian.Uint16(b))
+			}
+		case headerTypeInteger:
+			size = 4
+			if len(b) >= size {
+				value = int32(binary.BigEndian.Uint32(b))
+			}
+		case headerTypeLong:
+			size = 8
+			if len(b) >= size {
+				value = int64(binary.BigEndian.Uint64(b))
+			}
+		case headerTypeTimestamp:
+			size = 8
+			if len(b) >= size {
+				value = time.UnixMilli(int64(binary.BigEndian.Uint64(b))).UTC()
+			}
+		case headerTypeUUID:
+			size = 16
+			if len(b) >= size {
+				value = append([]byte(nil), b[:16]...)
+			}
+		case headerTypeByteArray, headerTypeString:
+			if len(b) < 2 {
+				return nil, fmt.Errorf("malformed event stream header %q", name)
+			}
+			valueLen := int(binary.BigEndian.Uint16(b))
+			b = b[2:]
+			size = valueLen
+			if len(b) >= size {
+				if valueType == headerTypeString {
+					value = string(b[:valueLen])
+				} else {
+					value = append([]byte(nil), b[:valueLen]...)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unknown event stream header type %d for %q", valueType, name)
+		}
+
+		if len(b) < size {
+			return nil, fmt.Errorf("malformed event stream header %q", name)
+		}
+		b = b[size:]
+		headers[name] = value
+	}
+
+	return headers, nil
+}
+
+// streamExceptionToProviderError converts an in-stream exception to a ProviderError
+func streamExceptionToProviderError(exceptionType string, payload []byte) error {
+	var body struct {
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(payload, &body)
+
+	message := body.Message
+	if message == "" {
+		message = "Stream exception"
+	}
+
+	statusCode := http.StatusInternalServerError
+	code := providers.ErrCodeInternalError
+
+	switch exceptionType {
+	case "throttlingException":
+		statusCode = http.StatusTooManyRequests
+		code = providers.ErrCodeRateLimitExceeded
+	case "validationException":
+		statusCode = http.StatusBadRequest
+		code = providers.ErrCodeInvalidRequest
+	case "serviceUnavailableException":
+		statusCode = http.StatusServiceUnavailable
+		code = providers.ErrCodeServiceUnavailable
+	case "modelTimeoutException":
+		statusCode = http.StatusGatewayTimeout
+		code = providers.ErrCodeServiceUnavailable
+	case "modelStreamErrorException":
+		statusCode = http.StatusBadGateway
+	}
+
+	return &providers.ProviderError{
+		Provider:   "bedrock",
+		StatusCode: statusCode,
+		Code:       code,
+		Message:    fmt.Sprintf("%s: %s", exceptionType, message),
+	}
+}
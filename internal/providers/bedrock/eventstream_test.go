@@ -0,0 +1,241 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package bedrock
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+)
+
+// encodeTestMessage builds an event stream message with string headers
+func encodeTestMessage(headers [][2]string, payload []byte) []byte {
+	var hdr bytes.Buffer
+	for _, h := range headers {
+		hdr.WriteByte(byte(len(h[0])))
+		hdr.WriteString(h[0])
+		hdr.WriteByte(headerTypeString)
+		binary.Write(&hdr, binary.BigEndian, uint16(len(h[1])))
+		hdr.WriteString(h[1])
+	}
+	return encodeTestRawMessage(hdr.Bytes(), payload)
+}
+
+// encodeTestRawMessage frames pre-encoded headers and a payload with valid CRCs
+func encodeTestRawMessage(headers, payload []byte) []byte {
+	var msg bytes.Buffer
+	binary.Write(&msg, binary.BigEndian, uint32(12+len(headers)+len(payload)+4))
+	binary.Write(&msg, binary.BigEndian, uint32(len(headers)))
+	binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	msg.Write(headers)
+	msg.Write(payload)
+	binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	return msg.Bytes()
+}
+
+func testEvent(eventType, payload string) []byte {
+	return encodeTestMessage([][2]string{
+		{":message-type", "event"},
+		{":event-type", eventType},
+		{":content-type", "application/json"},
+	}, []byte(payload))
+}
+
+func TestEventStreamDecoderConverse(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(testEvent(StreamEventContentBlockDelta, `{"contentBlockIndex":0,"delta":{"text":"Hi"}}`))
+	stream.Write(testEvent(StreamEventMessageStop, `{"stopReason":"end_turn"}`))
+	stream.Write(testEvent(StreamEventMetadata, `{"usage":{"inputTokens":12,"outputTokens":3,"totalTokens":15},"metrics":{"latencyMs":250}}`))
+
+	decoder := NewEventStreamDecoder(&stream)
+
+	event, err := decoder.Next()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if event.Type != StreamEventContentBlockDelta {
+		t.Errorf("Expected %s, got %s", StreamEventContentBlockDelta, event.Type)
+	}
+	if string(event.Data) != `{"contentBlockIndex":0,"delta":{"text":"Hi"}}` {
+		t.Errorf("Unexpected payload: %s", event.Data)
+	}
+
+	event, err = decoder.Next()
+	if err != nil || event.Type != StreamEventMessageStop {
+		t.Fatalf("Expected messageStop, got %v (err: %v)", event, err)
+	}
+
+	event, err = decoder.Next()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if event.Metadata == nil {
+		t.Fatal("Expected metadata on metadata event")
+	}
+	if event.Metadata.InputTokens != 12 || event.Metadata.OutputTokens != 3 || event.Metadata.TotalTokens != 15 {
+		t.Errorf("Unexpected usage: %+v", event.Metadata)
+	}
+	if event.Metadata.Latency.Milliseconds() != 250 {
+		t.Errorf("Expected latency 250ms, got %v", event.Metadata.Latency)
+	}
+
+	if _, err := decoder.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestEventStreamDecoderInvokeModel(t *testing.T) {
+	inner := `{"type":"message_stop","amazon-bedrock-invocationMetrics":{"inputTokenCount":7,"outputTokenCount":9,"invocationLatency":120}}`
+	payload := `{"bytes":"` + base64.StdEncoding.EncodeToString([]byte(inner)) + `"}`
+
+	decoder := NewEventStreamDecoder(bytes.NewReader(testEvent(StreamEventChunk, payload)))
+
+	event, err := decoder.Next()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if event.Type != StreamEventChunk {
+		t.Errorf("Expected chunk event, got %s", event.Type)
+	}
+	if string(event.Data) != inner {
+		t.Errorf("Expected decoded chunk bytes, got %s", event.Data)
+	}
+	if event.Metadata == nil || event.Metadata.TotalTokens != 16 {
+		t.Errorf("Expected invocation metrics with 16 total tokens, got %+v", event.Metadata)
+	}
+}
+
+func TestEventStreamDecoderException(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(testEvent(StreamEventContentBlockDelta, `{"contentBlockIndex":0,"delta":{"text":"Hi"}}`))
+	stream.Write(encodeTestMessage([][2]string{
+		{":message-type", "exception"},
+		{":exception-type", "throttlingException"},
+	}, []byte(`{"message":"Too many tokens"}`)))
+
+	decoder := NewEventStreamDecoder(&stream)
+	if _, err := decoder.Next(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	_, err := decoder.Next()
+	var providerErr *providers.ProviderError
+	if !errors.As(err, &providerErr) {
+		t.Fatalf("Expected ProviderError, got %v", err)
+	}
+	if providerErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429, got %d", providerErr.StatusCode)
+	}
+	if providerErr.Code != providers.ErrCodeRateLimitExceeded {
+		t.Errorf("Expected code %s, got %s", providers.ErrCodeRateLimitExceeded, providerErr.Code)
+	}
+}
+
+func TestEventStreamDecoderChecksums(t *testing.T) {
+	t.Run("Corrupt prelude", func(t *testing.T) {
+		msg := testEvent(StreamEventMessageStop, `{"stopReason":"end_turn"}`)
+		msg[3] ^= 0xFF
+
+		_, err := NewEventStreamDecoder(bytes.NewReader(msg)).ReadMessage()
+		if !errors.Is(err, ErrEventStreamChecksum) {
+			t.Errorf("Expected checksum error, got %v", err)
+		}
+	})
+
+	t.Run("Corrupt payload", func(t *testing.T) {
+		msg := testEvent(StreamEventMessageStop, `{"stopReason":"end_turn"}`)
+		msg[len(msg)-6] ^= 0xFF
+
+		_, err := NewEventStreamDecoder(bytes.NewReader(msg)).ReadMessage()
+		if !errors.Is(err, ErrEventStreamChecksum) {
+			t.Errorf("Expected checksum error, got %v", err)
+		}
+	})
+
+	t.Run("Truncated message", func(t *testing.T) {
+		msg := testEvent(StreamEventMessageStop, `{"stopReason":"end_turn"}`)
+
+		_, err := NewEventStreamDecoder(bytes.NewReader(msg[:len(msg)-5])).ReadMessage()
+		if err == nil || err == io.EOF {
+			t.Errorf("Expected truncation error, got %v", err)
+		}
+	})
+}
+
+func TestEventStreamHeaderTypes(t *testing.T) {
+	var hdr bytes.Buffer
+	writeName := func(name string, valueType byte) {
+		hdr.WriteByte(byte(len(name)))
+		hdr.WriteString(name)
+		hdr.WriteByte(valueType)
+	}
+
+	writeName("flag", headerTypeBoolTrue)
+	writeName("count", headerTypeInteger)
+	binary.Write(&hdr, binary.BigEndian, int32(42))
+	writeName("big", headerTypeLong)
+	binary.Write(&hdr, binary.BigEndian, int64(1<<40))
+	writeName("blob", headerTypeByteArray)
+	binary.Write(&hdr, binary.BigEndian, uint16(2))
+	hdr.Write([]byte{0xDE, 0xAD})
+
+	msg, err := NewEventStreamDecoder(bytes.NewReader(encodeTestRawMessage(hdr.Bytes(), nil))).ReadMessage()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if msg.Headers["flag"] != true {
+		t.Errorf("Expected flag=true, got %v", msg.Headers["flag"])
+	}
+	if msg.Headers["count"] != int32(42) {
+		t.Errorf("Expected count=42, got %v", msg.Headers["count"])
+	}
+	if msg.Headers["big"] != int64(1<<40) {
+		t.Errorf("Expected big=%d, got %v", int64(1<<40), msg.Headers["big"])
+	}
+	if blob, ok := msg.Headers["blob"].([]byte); !ok || !bytes.Equal(blob, []byte{0xDE, 0xAD}) {
+		t.Errorf("Unexpected blob header: %v", msg.Headers["blob"])
+	}
+}
+
+func TestEventStreamEncoder(t *testing.T) {
+	var stream bytes.Buffer
+	encoder := NewEventStreamEncoder(&stream)
+	if err := encoder.WriteEvent(StreamEventContentBlockDelta, []byte(`{"contentBlockIndex":0,"delta":{"text":"Hi"}}`)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := encoder.WriteException("throttlingException", "Too many tokens"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The encoded stream must decode to the same events
+	decoder := NewEventStreamDecoder(&stream)
+	event, err := decoder.Next()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if event.Type != StreamEventContentBlockDelta || string(event.Data) != `{"contentBlockIndex":0,"delta":{"text":"Hi"}}` {
+		t.Errorf("Unexpected event %s: %s", event.Type, event.Data)
+	}
+
+	_, err = decoder.Next()
+	var providerErr *providers.ProviderError
+	if !errors.As(err, &providerErr) || providerErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected a throttling ProviderError, got %v", err)
+	}
+	if _, err := decoder.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+
+	if got := ExceptionType(http.StatusTooManyRequests); got != "ThrottlingException" {
+		t.Errorf("Expected ThrottlingException, got %s", got)
+	}
+}
@@ -3,7 +3,11 @@
 
 package bedrock
 
-import "github.com/tosharewith/llmproxy_auth/internal/providers"
+import (
+	"strings"
+
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+)
 
 // BedrockModels defines all available Bedrock models
 var BedrockModels = []providers.Model{
@@ -176,11 +180,10 @@ var BedrockModelIDMap = map[string]string{
 // GetBedrockModelID returns the full Bedrock model ID for a friendly name
 func GetBedrockModelID(friendlyName string) (string, bool) {
 	// Check if it's already a full Bedrock model ID
-	if len(friendlyName) > 0 && (friendlyName[0:1] == "anthropic." ||
-		friendlyName[0:1] == "amazon." ||
-		friendlyName[0:1] == "meta." ||
-		friendlyName[0:1] == "mistral.") {
-		return friendlyName, true
+	for _, prefix := range []string{"anthropic.", "amazon.", "meta.", "mistral.", "cohere.", "ai21."} {
+		if strings.HasPrefix(friendlyName, prefix) {
+			return friendlyName, true
+		}
 	}
 
 	// Look up in map
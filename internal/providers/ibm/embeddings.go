@@ -0,0 +1,140 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package ibm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+	"github.com/tosharewith/llmproxy_auth/internal/translator"
+)
+
+// IBM watsonx.ai text embedding request/response types
+type IBMEmbeddingRequest struct {
+	Inputs    []string `json:"inputs"`
+	ModelID   string   `json:"model_id"`
+	ProjectID string   `json:"project_id"`
+}
+
+type IBMEmbeddingResponse struct {
+	ModelID string `json:"model_id"`
+	Results []struct {
+		Embedding []float64 `json:"embedding"`
+	} `json:"results"`
+	InputTokenCount int `json:"input_token_count"`
+}
+
+// invokeEmbeddings serves an OpenAI embeddings request with the text embeddings API
+func (p *IBMProvider) invokeEmbeddings(ctx context.Context, request *providers.ProviderRequest) (*providers.ProviderResponse, error) {
+	var openaiReq translator.EmbeddingRequest
+	if err := json.Unmarshal(request.Body, &openaiReq); err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("failed to parse request: %v", err),
+			Provider:   "ibm",
+		}
+	}
+	texts, err := translator.EmbeddingInputs(openaiReq.Input)
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+			Provider:   "ibm",
+		}
+	}
+	if openaiReq.Dimensions > 0 {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("model %s does not support dimensions", openaiReq.Model),
+			Provider:   "ibm",
+		}
+	}
+
+	body, err := json.Marshal(IBMEmbeddingRequest{
+		Inputs:    texts,
+		ModelID:   openaiReq.Model,
+		ProjectID: p.projectID,
+	})
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to marshal request: %v", err),
+			Provider:   "ibm",
+		}
+	}
+
+	url := fmt.Sprintf("%s/ml/v1/text/embeddings?version=2023-10-25", p.baseURL)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to create request: %v", err),
+			Provider:   "ibm",
+		}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	httpReq.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusServiceUnavailable,
+			Message:    fmt.Sprintf("request failed: %v", err),
+			Provider:   "ibm",
+		}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to read response: %v", err),
+			Provider:   "ibm",
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &providers.ProviderError{
+			StatusCode: resp.StatusCode,
+			Message:    string(respBody),
+			Provider:   "ibm",
+			RetryAfter: providers.ParseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	var ibmResp IBMEmbeddingResponse
+	if err := json.Unmarshal(respBody, &ibmResp); err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to parse response: %v", err),
+			Provider:   "ibm",
+		}
+	}
+
+	vectors := make([][]float64, len(ibmResp.Results))
+	for i, result := range ibmResp.Results {
+		vectors[i] = result.Embedding
+	}
+
+	openaiBody, err := json.Marshal(translator.NewEmbeddingResponse(openaiReq.Model, vectors, ibmResp.InputTokenCount))
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to marshal response: %v", err),
+			Provider:   "ibm",
+		}
+	}
+
+	return &providers.ProviderResponse{
+		StatusCode: resp.StatusCode,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       openaiBody,
+	}, nil
+}
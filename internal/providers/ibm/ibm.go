@@ -98,6 +98,10 @@ func (p *IBMProvider) HealthCheck(ctx context.Context) error {
 
 // Invoke sends a request to IBM watsonx.ai
 func (p *IBMProvider) Invoke(ctx context.Context, request *providers.ProviderRequest) (*providers.ProviderResponse, error) {
+	if request.Path == providers.EmbeddingsPath {
+		return p.invokeEmbeddings(ctx, request)
+	}
+
 	// Parse OpenAI request
 	var openaiReq translator.ChatCompletionRequest
 	if err := json.Unmarshal(request.Body, &openaiReq); err != nil {
@@ -164,6 +168,7 @@ func (p *IBMProvider) Invoke(ctx context.Context, request *providers.ProviderReq
 			StatusCode: resp.StatusCode,
 			Message:    string(respBody),
 			Provider:   "ibm",
+			RetryAfter: providers.ParseRetryAfter(resp.Header.Get("Retry-After")),
 		}
 	}
 
@@ -207,11 +212,144 @@ func (p *IBMProvider) Invoke(ctx context.Context, request *providers.ProviderReq
 
 // InvokeStreaming sends a streaming request to IBM watsonx.ai
 func (p *IBMProvider) InvokeStreaming(ctx context.Context, request *providers.ProviderRequest) (io.ReadCloser, error) {
-	return nil, &providers.ProviderError{
-		StatusCode: http.StatusNotImplemented,
-		Message:    "streaming not yet implemented for IBM provider",
-		Provider:   "ibm",
+	var openaiReq translator.ChatCompletionRequest
+	if err := json.Unmarshal(request.Body, &openaiReq); err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("failed to parse request: %v", err),
+			Provider:   "ibm",
+		}
 	}
+
+	ibmReq := translateOpenAIToIBM(&openaiReq, p.projectID)
+	body, err := json.Marshal(ibmReq)
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to marshal request: %v", err),
+			Provider:   "ibm",
+		}
+	}
+
+	url := fmt.Sprintf("%s/ml/v1/text/generation_stream?version=2023-05-29", p.baseURL)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to create request: %v", err),
+			Provider:   "ibm",
+		}
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusServiceUnavailable,
+			Message:    fmt.Sprintf("request failed: %v", err),
+			Provider:   "ibm",
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &providers.ProviderError{
+			StatusCode: resp.StatusCode,
+			Message:    string(body),
+			Provider:   "ibm",
+			RetryAfter: providers.ParseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	// Translate IBM generation events into OpenAI chunks
+	return translator.PipeStream(resp.Body, func(w io.Writer) error {
+		return translateIBMStream(resp.Body, w, openaiReq.Model)
+	}), nil
+}
+
+// translateIBMStream converts a generation_stream SSE stream into OpenAI chunk SSE
+func translateIBMStream(r io.Reader, w io.Writer, model string) error {
+	sse := translator.NewSSEReader(r)
+	id := fmt.Sprintf("ibm-%d", time.Now().UnixNano())
+	created := time.Now().Unix()
+	var promptTokens, completionTokens int
+	finishReason := ""
+
+	if err := translator.WriteStreamChunk(w, translator.NewChatCompletionChunk(id, model, created, translator.ChatMessageDelta{Role: "assistant"}, "")); err != nil {
+		return err
+	}
+
+	for {
+		event, err := sse.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if event.Event == "close" {
+			break
+		}
+		if event.Data == "" {
+			continue
+		}
+
+		var ibmResp IBMResponse
+		if err := json.Unmarshal([]byte(event.Data), &ibmResp); err != nil {
+			return fmt.Errorf("failed to parse ibm stream event: %w", err)
+		}
+
+		for _, result := range ibmResp.Results {
+			if result.GeneratedText != "" {
+				chunk := translator.NewChatCompletionChunk(id, model, created, translator.ChatMessageDelta{Content: result.GeneratedText}, "")
+				if err := translator.WriteStreamChunk(w, chunk); err != nil {
+					return err
+				}
+			}
+			if result.InputTokens > 0 {
+				promptTokens = result.InputTokens
+			}
+			if result.GeneratedTokens > 0 {
+				completionTokens = result.GeneratedTokens
+			}
+
+			// Map stop reason
+			switch result.StopReason {
+			case "eos_token", "stop_sequence":
+				finishReason = "stop"
+			case "max_tokens", "token_limit":
+				finishReason = "length"
+			}
+		}
+	}
+
+	if finishReason == "" {
+		finishReason = "stop"
+	}
+	if err := translator.WriteStreamChunk(w, translator.NewChatCompletionChunk(id, model, created, translator.ChatMessageDelta{}, finishReason)); err != nil {
+		return err
+	}
+
+	if err := translator.WriteStreamChunk(w, &translator.ChatCompletionStreamResponse{
+		ID:      id,
+		Object:  "chat.completion.chunk",
+		Created: created,
+		Model:   model,
+		Choices: []translator.ChatCompletionStreamChoice{},
+		Usage: &translator.Usage{
+			PromptTokens:     promptTokens,
+			CompletionTokens: completionTokens,
+			TotalTokens:      promptTokens + completionTokens,
+		},
+	}); err != nil {
+		return err
+	}
+
+	return translator.WriteStreamDone(w)
 }
 
 // ListModels lists available IBM watsonx.ai models
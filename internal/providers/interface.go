@@ -6,6 +6,8 @@ package providers
 import (
 	"context"
 	"io"
+	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -133,6 +135,9 @@ type StreamEvent struct {
 	// Event data (provider-specific format)
 	Data []byte
 
+	// Usage and latency, set on events that report them
+	Metadata *ResponseMetadata
+
 	// Error if the event represents an error
 	Error error
 }
@@ -154,6 +159,20 @@ func (m *Model) CalculateCost(inputTokens, outputTokens int) float64 {
 	return inputCost + outputCost
 }
 
+// SetUsage records token usage and prices it with the model's catalog prices.
+// Costs are left at zero when pricing is nil.
+func (m *ResponseMetadata) SetUsage(inputTokens, outputTokens int, pricing *Model) {
+	m.InputTokens = inputTokens
+	m.OutputTokens = outputTokens
+	m.TotalTokens = inputTokens + outputTokens
+
+	if pricing != nil {
+		m.InputCost = pricing.CalculateCost(inputTokens, 0)
+		m.OutputCost = pricing.CalculateCost(0, outputTokens)
+		m.TotalCost = pricing.CalculateCost(inputTokens, outputTokens)
+	}
+}
+
 // ProviderError represents a provider-specific error
 type ProviderError struct {
 	// Provider name
@@ -170,6 +189,9 @@ type ProviderError struct {
 
 	// Original error
 	Err error
+
+	// Delay requested by the provider before retrying (from Retry-After)
+	RetryAfter time.Duration
 }
 
 func (e *ProviderError) Error() string {
@@ -183,6 +205,29 @@ func (e *ProviderError) Unwrap() error {
 	return e.Err
 }
 
+// ParseRetryAfter parses a Retry-After header value given either in seconds
+// or as an HTTP date. It returns 0 if the value is absent or invalid.
+func ParseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
+
 // Common capability constants
 const (
 	CapabilityChat            = "chat"
@@ -194,12 +239,17 @@ const (
 	CapabilityJSON            = "json_mode"
 )
 
+// EmbeddingsPath is the request path for OpenAI-format embedding requests.
+// Providers that translate requests themselves serve it in Invoke.
+const EmbeddingsPath = "/embeddings"
+
 // Common error codes
 const (
 	ErrCodeInvalidRequest     = "invalid_request"
 	ErrCodeAuthenticationFail = "authentication_failed"
 	ErrCodeRateLimitExceeded  = "rate_limit_exceeded"
 	ErrCodeModelNotFound      = "model_not_found"
+	ErrCodePermissionDenied   = "permission_denied"
 	ErrCodeServiceUnavailable = "service_unavailable"
 	ErrCodeInternalError      = "internal_error"
 )
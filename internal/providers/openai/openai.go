@@ -127,6 +127,7 @@ func (p *OpenAIProvider) Invoke(ctx context.Context, request *providers.Provider
 			StatusCode: resp.StatusCode,
 			Message:    string(body),
 			Provider:   "openai",
+			RetryAfter: providers.ParseRetryAfter(resp.Header.Get("Retry-After")),
 		}
 	}
 
@@ -181,6 +182,7 @@ func (p *OpenAIProvider) InvokeStreaming(ctx context.Context, request *providers
 			StatusCode: resp.StatusCode,
 			Message:    string(body),
 			Provider:   "openai",
+			RetryAfter: providers.ParseRetryAfter(resp.Header.Get("Retry-After")),
 		}
 	}
 
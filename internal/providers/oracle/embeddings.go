@@ -0,0 +1,152 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package oracle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+	"github.com/tosharewith/llmproxy_auth/internal/translator"
+)
+
+// Oracle Generative AI embedText request/response types
+type OracleEmbedRequest struct {
+	CompartmentID string            `json:"compartmentId"`
+	ServingMode   OracleServingMode `json:"servingMode"`
+	Inputs        []string          `json:"inputs"`
+	InputType     string            `json:"inputType,omitempty"` // SEARCH_DOCUMENT, SEARCH_QUERY, CLASSIFICATION, CLUSTERING
+	Truncate      string            `json:"truncate,omitempty"`
+}
+
+type OracleEmbedResponse struct {
+	ID         string      `json:"id"`
+	Embeddings [][]float64 `json:"embeddings"`
+	ModelID    string      `json:"modelId"`
+	Usage      *struct {
+		PromptTokens int `json:"promptTokens"`
+		TotalTokens  int `json:"totalTokens"`
+	} `json:"usage,omitempty"`
+}
+
+// invokeEmbeddings serves an OpenAI embeddings request with the embedText API
+func (p *OracleProvider) invokeEmbeddings(ctx context.Context, request *providers.ProviderRequest) (*providers.ProviderResponse, error) {
+	var openaiReq translator.EmbeddingRequest
+	if err := json.Unmarshal(request.Body, &openaiReq); err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("failed to parse request: %v", err),
+			Provider:   "oracle",
+		}
+	}
+	texts, err := translator.EmbeddingInputs(openaiReq.Input)
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+			Provider:   "oracle",
+		}
+	}
+	if openaiReq.Dimensions > 0 {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("model %s does not support dimensions", openaiReq.Model),
+			Provider:   "oracle",
+		}
+	}
+
+	inputType := openaiReq.InputType
+	if inputType == "" {
+		inputType = "search_document"
+	}
+	body, err := json.Marshal(OracleEmbedRequest{
+		CompartmentID: p.compartmentID,
+		ServingMode: OracleServingMode{
+			ServingType: "ON_DEMAND",
+			ModelID:     openaiReq.Model,
+		},
+		Inputs:    texts,
+		InputType: strings.ToUpper(inputType),
+		Truncate:  "NONE",
+	})
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to marshal request: %v", err),
+			Provider:   "oracle",
+		}
+	}
+
+	url := fmt.Sprintf("%s/20231130/actions/embedText", p.endpoint)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to create request: %v", err),
+			Provider:   "oracle",
+		}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.authToken)
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusServiceUnavailable,
+			Message:    fmt.Sprintf("request failed: %v", err),
+			Provider:   "oracle",
+		}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to read response: %v", err),
+			Provider:   "oracle",
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &providers.ProviderError{
+			StatusCode: resp.StatusCode,
+			Message:    string(respBody),
+			Provider:   "oracle",
+			RetryAfter: providers.ParseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	var oracleResp OracleEmbedResponse
+	if err := json.Unmarshal(respBody, &oracleResp); err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to parse response: %v", err),
+			Provider:   "oracle",
+		}
+	}
+
+	promptTokens := 0
+	if oracleResp.Usage != nil {
+		promptTokens = oracleResp.Usage.PromptTokens
+	}
+	openaiBody, err := json.Marshal(translator.NewEmbeddingResponse(openaiReq.Model, oracleResp.Embeddings, promptTokens))
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to marshal response: %v", err),
+			Provider:   "oracle",
+		}
+	}
+
+	return &providers.ProviderResponse{
+		StatusCode: resp.StatusCode,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       openaiBody,
+	}, nil
+}
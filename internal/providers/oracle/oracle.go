@@ -52,6 +52,7 @@ type OracleChatRequest struct {
 	FrequencyPenalty *float64               `json:"frequencyPenalty,omitempty"`
 	PresencePenalty  *float64               `json:"presencePenalty,omitempty"`
 	Stop             []string               `json:"stop,omitempty"`
+	IsStream         bool                   `json:"isStream,omitempty"`
 }
 
 type OracleMessage struct {
@@ -82,6 +83,15 @@ type OracleChoice struct {
 	FinishReason string               `json:"finishReason"`
 }
 
+// OracleStreamEvent is a single event of a streamed chat response; generic
+// models send message deltas, Cohere models send text deltas
+type OracleStreamEvent struct {
+	Index        int            `json:"index"`
+	Message      *OracleMessage `json:"message,omitempty"`
+	Text         string         `json:"text,omitempty"`
+	FinishReason string         `json:"finishReason,omitempty"`
+}
+
 // NewOracleProvider creates a new Oracle Cloud AI provider
 func NewOracleProvider(config OracleConfig) (*OracleProvider, error) {
 	if config.Endpoint == "" {
@@ -117,6 +127,10 @@ func (p *OracleProvider) HealthCheck(ctx context.Context) error {
 
 // Invoke sends a request to Oracle Generative AI
 func (p *OracleProvider) Invoke(ctx context.Context, request *providers.ProviderRequest) (*providers.ProviderResponse, error) {
+	if request.Path == providers.EmbeddingsPath {
+		return p.invokeEmbeddings(ctx, request)
+	}
+
 	// Parse OpenAI request
 	var openaiReq translator.ChatCompletionRequest
 	if err := json.Unmarshal(request.Body, &openaiReq); err != nil {
@@ -182,6 +196,7 @@ func (p *OracleProvider) Invoke(ctx context.Context, request *providers.Provider
 			StatusCode: resp.StatusCode,
 			Message:    string(respBody),
 			Provider:   "oracle",
+			RetryAfter: providers.ParseRetryAfter(resp.Header.Get("Retry-After")),
 		}
 	}
 
@@ -225,10 +240,138 @@ func (p *OracleProvider) Invoke(ctx context.Context, request *providers.Provider
 
 // InvokeStreaming sends a streaming request to Oracle
 func (p *OracleProvider) InvokeStreaming(ctx context.Context, request *providers.ProviderRequest) (io.ReadCloser, error) {
-	return nil, &providers.ProviderError{
-		StatusCode: http.StatusNotImplemented,
-		Message:    "streaming not yet implemented for Oracle provider",
-		Provider:   "oracle",
+	var openaiReq translator.ChatCompletionRequest
+	if err := json.Unmarshal(request.Body, &openaiReq); err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("failed to parse request: %v", err),
+			Provider:   "oracle",
+		}
+	}
+
+	oracleReq := translateOpenAIToOracle(&openaiReq, p.compartmentID)
+	oracleReq.ChatRequest.IsStream = true
+
+	body, err := json.Marshal(oracleReq)
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to marshal request: %v", err),
+			Provider:   "oracle",
+		}
+	}
+
+	url := fmt.Sprintf("%s/20231130/actions/chat", p.endpoint)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to create request: %v", err),
+			Provider:   "oracle",
+		}
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.authToken)
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusServiceUnavailable,
+			Message:    fmt.Sprintf("request failed: %v", err),
+			Provider:   "oracle",
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &providers.ProviderError{
+			StatusCode: resp.StatusCode,
+			Message:    string(body),
+			Provider:   "oracle",
+			RetryAfter: providers.ParseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	// Translate Oracle stream events into OpenAI chunks
+	return translator.PipeStream(resp.Body, func(w io.Writer) error {
+		return translateOracleStream(resp.Body, w, openaiReq.Model)
+	}), nil
+}
+
+// translateOracleStream converts an OCI chat SSE stream into OpenAI chunk SSE
+func translateOracleStream(r io.Reader, w io.Writer, model string) error {
+	sse := translator.NewSSEReader(r)
+	id := fmt.Sprintf("oracle-%d", time.Now().UnixNano())
+	created := time.Now().Unix()
+	finishReason := ""
+
+	if err := translator.WriteStreamChunk(w, translator.NewChatCompletionChunk(id, model, created, translator.ChatMessageDelta{Role: "assistant"}, "")); err != nil {
+		return err
+	}
+
+	for {
+		event, err := sse.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if event.Data == "" {
+			continue
+		}
+
+		var streamEvent OracleStreamEvent
+		if err := json.Unmarshal([]byte(event.Data), &streamEvent); err != nil {
+			return fmt.Errorf("failed to parse oracle stream event: %w", err)
+		}
+
+		// The final event repeats the full text alongside the finish reason
+		if streamEvent.FinishReason != "" {
+			finishReason = mapOracleFinishReason(streamEvent.FinishReason)
+			continue
+		}
+
+		text := streamEvent.Text
+		if streamEvent.Message != nil {
+			for _, contentBlock := range streamEvent.Message.Content {
+				if contentBlock.Type == "TEXT" {
+					text += contentBlock.Text
+				}
+			}
+		}
+		if text == "" {
+			continue
+		}
+
+		chunk := translator.NewChatCompletionChunk(id, model, created, translator.ChatMessageDelta{Content: text}, "")
+		if err := translator.WriteStreamChunk(w, chunk); err != nil {
+			return err
+		}
+	}
+
+	if finishReason == "" {
+		finishReason = "stop"
+	}
+	if err := translator.WriteStreamChunk(w, translator.NewChatCompletionChunk(id, model, created, translator.ChatMessageDelta{}, finishReason)); err != nil {
+		return err
+	}
+
+	return translator.WriteStreamDone(w)
+}
+
+// mapOracleFinishReason maps Oracle finish reason to OpenAI finish reason
+func mapOracleFinishReason(reason string) string {
+	switch reason {
+	case "LENGTH", "MAX_TOKENS", "length":
+		return "length"
+	case "CONTENT_FILTER", "ERROR_TOXIC", "content_filter":
+		return "content_filter"
+	default:
+		return "stop"
 	}
 }
 
@@ -0,0 +1,162 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package vertex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+	"github.com/tosharewith/llmproxy_auth/internal/translator"
+)
+
+// Vertex AI text embedding request/response types
+type VertexEmbeddingRequest struct {
+	Instances  []VertexEmbeddingInstance `json:"instances"`
+	Parameters *VertexEmbeddingParams    `json:"parameters,omitempty"`
+}
+
+type VertexEmbeddingInstance struct {
+	Content  string `json:"content"`
+	TaskType string `json:"task_type,omitempty"` // RETRIEVAL_DOCUMENT, RETRIEVAL_QUERY, ...
+}
+
+type VertexEmbeddingParams struct {
+	OutputDimensionality int `json:"outputDimensionality,omitempty"`
+}
+
+type VertexEmbeddingResponse struct {
+	Predictions []struct {
+		Embeddings struct {
+			Values     []float64 `json:"values"`
+			Statistics struct {
+				TokenCount float64 `json:"token_count"`
+			} `json:"statistics"`
+		} `json:"embeddings"`
+	} `json:"predictions"`
+}
+
+// vertexTaskTypes maps Cohere-style input types to Vertex task types
+var vertexTaskTypes = map[string]string{
+	"search_document": "RETRIEVAL_DOCUMENT",
+	"search_query":    "RETRIEVAL_QUERY",
+	"classification":  "CLASSIFICATION",
+	"clustering":      "CLUSTERING",
+}
+
+// invokeEmbeddings serves an OpenAI embeddings request with the predict API
+func (p *VertexProvider) invokeEmbeddings(ctx context.Context, request *providers.ProviderRequest) (*providers.ProviderResponse, error) {
+	var openaiReq translator.EmbeddingRequest
+	if err := json.Unmarshal(request.Body, &openaiReq); err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("failed to parse request: %v", err),
+			Provider:   "vertex",
+		}
+	}
+	texts, err := translator.EmbeddingInputs(openaiReq.Input)
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+			Provider:   "vertex",
+		}
+	}
+
+	vertexReq := VertexEmbeddingRequest{}
+	for _, text := range texts {
+		vertexReq.Instances = append(vertexReq.Instances, VertexEmbeddingInstance{
+			Content:  text,
+			TaskType: vertexTaskTypes[openaiReq.InputType],
+		})
+	}
+	if openaiReq.Dimensions > 0 {
+		vertexReq.Parameters = &VertexEmbeddingParams{OutputDimensionality: openaiReq.Dimensions}
+	}
+
+	body, err := json.Marshal(vertexReq)
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to marshal request: %v", err),
+			Provider:   "vertex",
+		}
+	}
+
+	url := fmt.Sprintf("%s/publishers/google/models/%s:predict", p.baseURL, openaiReq.Model)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to create request: %v", err),
+			Provider:   "vertex",
+		}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.accessToken != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.accessToken)
+	}
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusServiceUnavailable,
+			Message:    fmt.Sprintf("request failed: %v", err),
+			Provider:   "vertex",
+		}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to read response: %v", err),
+			Provider:   "vertex",
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &providers.ProviderError{
+			StatusCode: resp.StatusCode,
+			Message:    string(respBody),
+			Provider:   "vertex",
+			RetryAfter: providers.ParseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	var vertexResp VertexEmbeddingResponse
+	if err := json.Unmarshal(respBody, &vertexResp); err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to parse response: %v", err),
+			Provider:   "vertex",
+		}
+	}
+
+	vectors := make([][]float64, len(vertexResp.Predictions))
+	promptTokens := 0
+	for i, prediction := range vertexResp.Predictions {
+		vectors[i] = prediction.Embeddings.Values
+		promptTokens += int(prediction.Embeddings.Statistics.TokenCount)
+	}
+
+	openaiBody, err := json.Marshal(translator.NewEmbeddingResponse(openaiReq.Model, vectors, promptTokens))
+	if err != nil {
+		return nil, &providers.ProviderError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    fmt.Sprintf("failed to marshal response: %v", err),
+			Provider:   "vertex",
+		}
+	}
+
+	return &providers.ProviderResponse{
+		StatusCode: resp.StatusCode,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       openaiBody,
+	}, nil
+}
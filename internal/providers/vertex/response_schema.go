@@ -0,0 +1,89 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package vertex
+
+// vertexSchemaKeywords are the JSON Schema keywords Gemini's responseSchema accepts
+var vertexSchemaKeywords = map[string]bool{
+	"type":             true,
+	"format":           true,
+	"description":      true,
+	"nullable":         true,
+	"enum":             true,
+	"properties":       true,
+	"required":         true,
+	"items":            true,
+	"anyOf":            true,
+	"minItems":         true,
+	"maxItems":         true,
+	"minimum":          true,
+	"maximum":          true,
+	"propertyOrdering": true,
+}
+
+// toVertexSchema converts a JSON Schema into the OpenAPI subset used by
+// responseSchema. Unsupported keywords such as additionalProperties are
+// dropped, since the gateway validates the output against the full schema,
+// and type unions with null become nullable.
+func toVertexSchema(schema map[string]interface{}) map[string]interface{} {
+	converted := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		switch key {
+		case "const":
+			converted["enum"] = []interface{}{value}
+			continue
+		case "oneOf":
+			key = "anyOf"
+		}
+		if !vertexSchemaKeywords[key] {
+			continue
+		}
+
+		switch key {
+		case "type":
+			converted["type"] = value
+			if types, ok := value.([]interface{}); ok {
+				var nonNull []interface{}
+				for _, t := range types {
+					if t == "null" {
+						converted["nullable"] = true
+					} else {
+						nonNull = append(nonNull, t)
+					}
+				}
+				if len(nonNull) == 1 {
+					converted["type"] = nonNull[0]
+				} else {
+					delete(converted, "type")
+				}
+			}
+		case "properties":
+			if properties, ok := value.(map[string]interface{}); ok {
+				convertedProperties := make(map[string]interface{}, len(properties))
+				for name, property := range properties {
+					if propertySchema, ok := property.(map[string]interface{}); ok {
+						convertedProperties[name] = toVertexSchema(propertySchema)
+					}
+				}
+				converted[key] = convertedProperties
+			}
+		case "items":
+			if items, ok := value.(map[string]interface{}); ok {
+				converted[key] = toVertexSchema(items)
+			}
+		case "anyOf":
+			if options, ok := value.([]interface{}); ok {
+				convertedOptions := make([]interface{}, 0, len(options))
+				for _, option := range options {
+					if optionSchema, ok := option.(map[string]interface{}); ok {
+						convertedOptions = append(convertedOptions, toVertexSchema(optionSchema))
+					}
+				}
+				converted[key] = convertedOptions
+			}
+		default:
+			converted[key] = value
+		}
+	}
+	return converted
+}
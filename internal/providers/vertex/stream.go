@@ -0,0 +1,135 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package vertex
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/internal/translator"
+)
+
+// translateVertexStream converts a streamGenerateContent SSE stream (alt=sse)
+// into OpenAI chunk SSE. Each event carries a partial VertexResponse.
+func translateVertexStream(r io.Reader, w io.Writer, model string) error {
+	sse := translator.NewSSEReader(r)
+	id := fmt.Sprintf("vertex-%d", time.Now().UnixNano())
+	created := time.Now().Unix()
+	var usage *translator.Usage
+	toolCount := 0
+	sentRole := false
+	finishReason := ""
+
+	write := func(delta translator.ChatMessageDelta, reason string) error {
+		return translator.WriteStreamChunk(w, translator.NewChatCompletionChunk(id, model, created, delta, reason))
+	}
+
+	for {
+		event, err := sse.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if event.Data == "" {
+			continue
+		}
+
+		var vertexResp VertexResponse
+		if err := json.Unmarshal([]byte(event.Data), &vertexResp); err != nil {
+			return fmt.Errorf("failed to parse vertex stream event: %w", err)
+		}
+
+		if !sentRole {
+			if err := write(translator.ChatMessageDelta{Role: "assistant"}, ""); err != nil {
+				return err
+			}
+			sentRole = true
+		}
+
+		if vertexResp.UsageMetadata != nil {
+			usage = &translator.Usage{
+				PromptTokens:     vertexResp.UsageMetadata.PromptTokenCount,
+				CompletionTokens: vertexResp.UsageMetadata.CandidatesTokenCount,
+				TotalTokens:      vertexResp.UsageMetadata.TotalTokenCount,
+			}
+		}
+
+		if len(vertexResp.Candidates) == 0 {
+			continue
+		}
+		candidate := vertexResp.Candidates[0]
+
+		for _, part := range candidate.Content.Parts {
+			if part.Text != "" {
+				if err := write(translator.ChatMessageDelta{Content: part.Text}, ""); err != nil {
+					return err
+				}
+			}
+			// Gemini delivers function calls whole rather than incrementally
+			if part.FunctionCall != nil {
+				argsJSON, _ := json.Marshal(part.FunctionCall.Args)
+				index := toolCount
+				toolCount++
+				if err := write(translator.ChatMessageDelta{
+					ToolCalls: []translator.ToolCall{{
+						Index: &index,
+						ID:    fmt.Sprintf("call_%d", index),
+						Type:  "function",
+						Function: translator.FunctionCall{
+							Name:      part.FunctionCall.Name,
+							Arguments: string(argsJSON),
+						},
+					}},
+				}, ""); err != nil {
+					return err
+				}
+			}
+		}
+
+		if candidate.FinishReason != "" {
+			finishReason = mapVertexFinishReason(candidate.FinishReason)
+		}
+	}
+
+	if finishReason == "" {
+		return fmt.Errorf("vertex stream ended without a finish reason")
+	}
+	if toolCount > 0 {
+		finishReason = "tool_calls"
+	}
+	if err := write(translator.ChatMessageDelta{}, finishReason); err != nil {
+		return err
+	}
+
+	if usage != nil {
+		if err := translator.WriteStreamChunk(w, &translator.ChatCompletionStreamResponse{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Model:   model,
+			Choices: []translator.ChatCompletionStreamChoice{},
+			Usage:   usage,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return translator.WriteStreamDone(w)
+}
+
+// mapVertexFinishReason maps Vertex finish reason to OpenAI finish reason
+func mapVertexFinishReason(reason string) string {
+	switch reason {
+	case "MAX_TOKENS":
+		return "length"
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII":
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
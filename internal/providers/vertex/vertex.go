@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/tosharewith/llmproxy_auth/internal/providers"
@@ -49,6 +50,13 @@ type VertexPart struct {
 	Text         string                 `json:"text,omitempty"`
 	FunctionCall *VertexFunctionCall    `json:"functionCall,omitempty"`
 	FunctionResponse *VertexFunctionResponse `json:"functionResponse,omitempty"`
+	InlineData       *VertexBlob             `json:"inlineData,omitempty"`
+}
+
+// VertexBlob is media sent inline with a request
+type VertexBlob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"` // base64 encoded
 }
 
 type VertexFunctionCall struct {
@@ -77,6 +85,9 @@ type GenerationConfig struct {
 	TopK            *int     `json:"topK,omitempty"`
 	MaxOutputTokens *int     `json:"maxOutputTokens,omitempty"`
 	StopSequences   []string `json:"stopSequences,omitempty"`
+
+	ResponseMimeType string                 `json:"responseMimeType,omitempty"`
+	ResponseSchema   map[string]interface{} `json:"responseSchema,omitempty"`
 }
 
 type VertexResponse struct {
@@ -132,6 +143,10 @@ func (p *VertexProvider) HealthCheck(ctx context.Context) error {
 
 // Invoke sends a request to Vertex AI
 func (p *VertexProvider) Invoke(ctx context.Context, request *providers.ProviderRequest) (*providers.ProviderResponse, error) {
+	if request.Path == providers.EmbeddingsPath {
+		return p.invokeEmbeddings(ctx, request)
+	}
+
 	// Parse OpenAI request
 	var openaiReq translator.ChatCompletionRequest
 	if err := json.Unmarshal(request.Body, &openaiReq); err != nil {
@@ -203,6 +218,7 @@ func (p *VertexProvider) Invoke(ctx context.Context, request *providers.Provider
 			StatusCode: resp.StatusCode,
 			Message:    string(respBody),
 			Provider:   "vertex",
+			RetryAfter: providers.ParseRetryAfter(resp.Header.Get("Retry-After")),
 		}
 	}
 
@@ -266,7 +282,7 @@ func (p *VertexProvider) InvokeStreaming(ctx context.Context, request *providers
 	}
 
 	modelID := openaiReq.Model
-	url := fmt.Sprintf("%s/publishers/google/models/%s:streamGenerateContent", p.baseURL, modelID)
+	url := fmt.Sprintf("%s/publishers/google/models/%s:streamGenerateContent?alt=sse", p.baseURL, modelID)
 
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
@@ -298,10 +314,14 @@ func (p *VertexProvider) InvokeStreaming(ctx context.Context, request *providers
 			StatusCode: resp.StatusCode,
 			Message:    string(body),
 			Provider:   "vertex",
+			RetryAfter: providers.ParseRetryAfter(resp.Header.Get("Retry-After")),
 		}
 	}
 
-	return resp.Body, nil
+	// Translate Vertex responses into OpenAI chunks
+	return translator.PipeStream(resp.Body, func(w io.Writer) error {
+		return translateVertexStream(resp.Body, w, openaiReq.Model)
+	}), nil
 }
 
 // ListModels lists available Vertex AI models
@@ -351,6 +371,15 @@ func translateOpenAIToVertex(req *translator.ChatCompletionRequest) *VertexGemin
 		vertexReq.GenerationConfig.StopSequences = req.Stop
 	}
 
+	// JSON mode; Gemini rejects a response schema alongside function calling
+	if req.ResponseFormat != nil && req.ResponseFormat.Type == "json_object" {
+		vertexReq.GenerationConfig.ResponseMimeType = "application/json"
+	}
+	if format := translator.StructuredOutputSchema(req); format != nil && len(req.Tools) == 0 {
+		vertexReq.GenerationConfig.ResponseMimeType = "application/json"
+		vertexReq.GenerationConfig.ResponseSchema = toVertexSchema(format.Schema)
+	}
+
 	// Convert messages
 	for _, msg := range req.Messages {
 		if msg.Role == "system" {
@@ -369,10 +398,8 @@ func translateOpenAIToVertex(req *translator.ChatCompletionRequest) *VertexGemin
 			}
 
 			vertexReq.Contents = append(vertexReq.Contents, VertexContent{
-				Role: role,
-				Parts: []VertexPart{
-					{Text: extractTextContent(msg.Content)},
-				},
+				Role:  role,
+				Parts: convertContentParts(msg.Content),
 			})
 		}
 	}
@@ -474,6 +501,45 @@ func translateVertexToOpenAI(resp *VertexResponse, model string) *translator.Cha
 	}
 }
 
+// convertContentParts converts OpenAI message content to Vertex parts,
+// inlining images given as data URLs and documents; other image URLs are dropped
+func convertContentParts(content interface{}) []VertexPart {
+	parts, ok := content.([]interface{})
+	if !ok {
+		return []VertexPart{{Text: extractTextContent(content)}}
+	}
+
+	var vertexParts []VertexPart
+	for _, part := range parts {
+		partMap, ok := part.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch partMap["type"] {
+		case "text":
+			if text, ok := partMap["text"].(string); ok {
+				vertexParts = append(vertexParts, VertexPart{Text: text})
+			}
+		case "image_url":
+			imageURL, _ := partMap["image_url"].(map[string]interface{})
+			url, _ := imageURL["url"].(string)
+			if rest, ok := strings.CutPrefix(url, "data:"); ok {
+				if mimeType, data, found := strings.Cut(rest, ";base64,"); found {
+					vertexParts = append(vertexParts, VertexPart{InlineData: &VertexBlob{MimeType: mimeType, Data: data}})
+				}
+			}
+		case "file":
+			if file, ok := translator.ParseFilePart(partMap); ok && file.FileID == "" {
+				vertexParts = append(vertexParts, VertexPart{InlineData: &VertexBlob{MimeType: file.MediaType, Data: file.Data}})
+			}
+		}
+	}
+	if len(vertexParts) == 0 {
+		return []VertexPart{{Text: ""}}
+	}
+	return vertexParts
+}
+
 // extractTextContent extracts text from content interface
 func extractTextContent(content interface{}) string {
 	switch c := content.(type) {
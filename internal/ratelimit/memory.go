@@ -0,0 +1,76 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps buckets in process memory. Limits are per replica.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+	bucket  Bucket
+}
+
+// sweepInterval is how often idle buckets are removed
+const sweepInterval = time.Minute
+
+// NewMemoryStore creates an in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*memoryBucket),
+		now:     time.Now,
+	}
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(ctx context.Context, key string, bucket Bucket, n float64, force bool) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	state, exists := s.buckets[key]
+	if !exists {
+		state = &memoryBucket{tokens: bucket.Capacity, updated: now}
+		s.buckets[key] = state
+	}
+	state.bucket = bucket
+
+	tokens, result := refill(bucket, state.tokens, now.Sub(state.updated), n, force)
+	state.tokens = tokens
+	state.updated = now
+
+	return result, nil
+}
+
+// sweep drops buckets that have refilled completely, since a missing bucket
+// is equivalent to a full one (caller holds mu)
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, state := range s.buckets {
+		if state.bucket.RefillPerSecond <= 0 {
+			continue
+		}
+		full := state.tokens + now.Sub(state.updated).Seconds()*state.bucket.RefillPerSecond
+		if full >= state.bucket.Capacity {
+			delete(s.buckets, key)
+		}
+	}
+}
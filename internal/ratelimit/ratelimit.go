@@ -0,0 +1,347 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Limit dimensions
+const (
+	DimensionRequests = "requests"
+	DimensionTokens   = "tokens"
+)
+
+// Bucket describes a token bucket that refills continuously up to its capacity
+type Bucket struct {
+	Capacity        float64
+	RefillPerSecond float64
+}
+
+// perMinute returns a bucket that allows limit units per minute with a one-minute burst
+func perMinute(limit int) Bucket {
+	return Bucket{Capacity: float64(limit), RefillPerSecond: float64(limit) / 60}
+}
+
+// Result is the outcome of taking tokens from a bucket
+type Result struct {
+	Allowed    bool
+	Remaining  float64
+	RetryAfter time.Duration // time until the request would fit, when denied
+	ResetAfter time.Duration // time until the bucket is full again
+}
+
+// Store holds bucket state. Implementations must apply each Take atomically.
+type Store interface {
+	// Take removes n tokens if they are available. With force the tokens are
+	// removed regardless, which may leave the bucket in debt; a negative n
+	// returns tokens to the bucket.
+	Take(ctx context.Context, key string, bucket Bucket, n float64, force bool) (Result, error)
+}
+
+// refill computes a bucket's level after elapsed time and applies a take.
+// It is shared by the store implementations so they behave identically.
+func refill(bucket Bucket, tokens float64, elapsed time.Duration, n float64, force bool) (float64, Result) {
+	if elapsed > 0 {
+		tokens = math.Min(bucket.Capacity, tokens+elapsed.Seconds()*bucket.RefillPerSecond)
+	}
+
+	result := Result{Allowed: force || n <= tokens}
+	if result.Allowed {
+		tokens = math.Min(bucket.Capacity, tokens-n)
+	} else if bucket.RefillPerSecond > 0 {
+		result.RetryAfter = secondsToDuration((n - tokens) / bucket.RefillPerSecond)
+	}
+
+	result.Remaining = math.Max(0, tokens)
+	if bucket.RefillPerSecond > 0 {
+		result.ResetAfter = secondsToDuration((bucket.Capacity - tokens) / bucket.RefillPerSecond)
+	}
+	return tokens, result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
+
+// Limits are per-minute request and token limits. Zero means "not set here"
+// and falls through to the next source; a negative value means unlimited.
+type Limits struct {
+	RequestsPerMinute int               `yaml:"requests_per_minute" json:"requests_per_minute"`
+	TokensPerMinute   int               `yaml:"tokens_per_minute" json:"tokens_per_minute"`
+	Models            map[string]Limits `yaml:"models,omitempty" json:"models,omitempty"`
+}
+
+// Config is the rate limiting configuration loaded from YAML
+type Config struct {
+	Enabled  bool   `yaml:"enabled"`
+	Store    string `yaml:"store"`     // memory or redis
+	RedisURL string `yaml:"redis_url"` // redis://[:password@]host:port[/db]
+
+	// PerModel gives each model its own buckets in addition to the identity-wide ones
+	PerModel bool `yaml:"per_model"`
+
+	Default Limits            `yaml:"default"`
+	APIKeys map[string]Limits `yaml:"api_keys"` // by API key ID
+	Users   map[string]Limits `yaml:"users"`
+	Models  map[string]Limits `yaml:"models"` // per-model limits applied to every identity
+}
+
+// LoadConfig loads the rate limiting configuration from a YAML file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var wrapper struct {
+		RateLimits Config `yaml:"rate_limits"`
+	}
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), &wrapper); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	config := &wrapper.RateLimits
+	if config.Store == "" {
+		config.Store = "memory"
+	}
+	if config.Store != "memory" && config.Store != "redis" {
+		return nil, fmt.Errorf("unknown rate limit store %q", config.Store)
+	}
+	if config.Store == "redis" && config.RedisURL == "" {
+		return nil, fmt.Errorf("redis rate limit store requires redis_url")
+	}
+
+	return config, nil
+}
+
+// NewStore creates the store selected by the configuration
+func NewStore(config *Config) (Store, error) {
+	if config.Store == "redis" {
+		return NewRedisStore(config.RedisURL)
+	}
+	return NewMemoryStore(), nil
+}
+
+// Subject identifies who a request is limited as
+type Subject struct {
+	KeyID    int64  // API key ID, 0 when not authenticated by a database key
+	User     string // authenticated user, or client IP
+	Model    string // requested model, empty for requests without one
+	Metadata string // api_keys.metadata JSON
+}
+
+// identity returns the bucket namespace for the subject
+func (s Subject) identity() string {
+	if s.KeyID != 0 {
+		return "key:" + strconv.FormatInt(s.KeyID, 10)
+	}
+	return "user:" + s.User
+}
+
+// Rule is a single bucket a request is checked against
+type Rule struct {
+	Dimension string // requests or tokens
+	Key       string
+	Limit     int
+}
+
+// Decision is the outcome of checking all of a request's rules
+type Decision struct {
+	Allowed bool
+
+	// Denied is the rule that rejected the request
+	Denied *Rule
+
+	// RetryAfter is how long to wait before retrying a denied request
+	RetryAfter time.Duration
+
+	// Headers holds the most restrictive state per dimension, for x-ratelimit-* headers
+	Headers map[string]Status
+}
+
+// Status is the state of a bucket after a check
+type Status struct {
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration
+}
+
+// Limiter applies configured limits to requests
+type Limiter struct {
+	config *Config
+	store  Store
+}
+
+// NewLimiter creates a limiter over a store
+func NewLimiter(config *Config, store Store) *Limiter {
+	return &Limiter{config: config, store: store}
+}
+
+// Rules resolves the buckets that apply to a subject. Limits come from the
+// key's metadata first, then the YAML api_keys, users and default entries.
+func (l *Limiter) Rules(subject Subject) ([]Rule, error) {
+	var metadataLimits *Limits
+	if subject.Metadata != "" {
+		var metadata struct {
+			RateLimits *Limits `json:"rate_limits"`
+		}
+		if err := json.Unmarshal([]byte(subject.Metadata), &metadata); err != nil {
+			return nil, fmt.Errorf("invalid API key metadata: %w", err)
+		}
+		metadataLimits = metadata.RateLimits
+	}
+
+	var sources []*Limits
+	if metadataLimits != nil {
+		sources = append(sources, metadataLimits)
+	}
+	if subject.KeyID != 0 {
+		if limits, ok := l.config.APIKeys[strconv.FormatInt(subject.KeyID, 10)]; ok {
+			sources = append(sources, &limits)
+		}
+	}
+	if limits, ok := l.config.Users[subject.User]; ok {
+		sources = append(sources, &limits)
+	}
+	sources = append(sources, &l.config.Default)
+
+	identity := subject.identity()
+	base := resolve(sources)
+	rules := base.rules("rl:" + identity)
+
+	if subject.Model == "" {
+		return rules, nil
+	}
+
+	// Model-specific overrides from the same sources, then the global models section
+	var modelSources []*Limits
+	for _, source := range sources {
+		if limits, ok := source.Models[subject.Model]; ok {
+			modelSources = append(modelSources, &limits)
+		}
+	}
+	if limits, ok := l.config.Models[subject.Model]; ok {
+		modelSources = append(modelSources, &limits)
+	}
+
+	if len(modelSources) == 0 && !l.config.PerModel {
+		return rules, nil
+	}
+	if l.config.PerModel {
+		modelSources = append(modelSources, &base)
+	}
+
+	return append(rules, resolve(modelSources).rules("rl:"+identity+":model:"+subject.Model)...), nil
+}
+
+// resolve takes each limit from the first source that sets it
+func resolve(sources []*Limits) Limits {
+	var resolved Limits
+	for _, source := range sources {
+		if resolved.RequestsPerMinute == 0 {
+			resolved.RequestsPerMinute = source.RequestsPerMinute
+		}
+		if resolved.TokensPerMinute == 0 {
+			resolved.TokensPerMinute = source.TokensPerMinute
+		}
+	}
+	return resolved
+}
+
+// rules returns the buckets for the positive limits under prefix
+func (lim Limits) rules(prefix string) []Rule {
+	var rules []Rule
+	if lim.RequestsPerMinute > 0 {
+		rules = append(rules, Rule{Dimension: DimensionRequests, Key: prefix + ":rpm", Limit: lim.RequestsPerMinute})
+	}
+	if lim.TokensPerMinute > 0 {
+		rules = append(rules, Rule{Dimension: DimensionTokens, Key: prefix + ":tpm", Limit: lim.TokensPerMinute})
+	}
+	return rules
+}
+
+// Allow charges one request and estimatedTokens against every rule. If any
+// rule denies the request, tokens already taken are returned.
+func (l *Limiter) Allow(ctx context.Context, rules []Rule, estimatedTokens int) (*Decision, error) {
+	decision := &Decision{Allowed: true, Headers: make(map[string]Status)}
+
+	var taken []Rule
+	for i := range rules {
+		rule := rules[i]
+		n := 1.0
+		if rule.Dimension == DimensionTokens {
+			n = float64(estimatedTokens)
+		}
+
+		result, err := l.store.Take(ctx, rule.Key, perMinute(rule.Limit), n, false)
+		if err != nil {
+			l.refund(ctx, taken, estimatedTokens)
+			return nil, err
+		}
+
+		decision.observe(rule, result)
+		if !result.Allowed {
+			decision.Allowed = false
+			decision.Denied = &rule
+			decision.RetryAfter = result.RetryAfter
+			l.refund(ctx, taken, estimatedTokens)
+			return decision, nil
+		}
+		taken = append(taken, rule)
+	}
+
+	return decision, nil
+}
+
+// Adjust corrects the token buckets once actual usage is known. A positive
+// delta charges more tokens than estimated; a negative delta refunds them.
+func (l *Limiter) Adjust(ctx context.Context, rules []Rule, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+
+	for _, rule := range rules {
+		if rule.Dimension != DimensionTokens {
+			continue
+		}
+		if _, err := l.store.Take(ctx, rule.Key, perMinute(rule.Limit), float64(delta), true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refund returns tokens taken for rules that were admitted before a denial
+func (l *Limiter) refund(ctx context.Context, rules []Rule, estimatedTokens int) {
+	for _, rule := range rules {
+		n := -1.0
+		if rule.Dimension == DimensionTokens {
+			n = -float64(estimatedTokens)
+		}
+		l.store.Take(ctx, rule.Key, perMinute(rule.Limit), n, true)
+	}
+}
+
+// observe keeps the most restrictive bucket state per dimension
+func (d *Decision) observe(rule Rule, result Result) {
+	status := Status{
+		Limit:      rule.Limit,
+		Remaining:  int(math.Floor(result.Remaining)),
+		ResetAfter: result.ResetAfter,
+	}
+
+	current, exists := d.Headers[rule.Dimension]
+	if !exists || status.Remaining < current.Remaining {
+		d.Headers[rule.Dimension] = status
+	}
+}
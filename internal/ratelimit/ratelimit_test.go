@@ -0,0 +1,203 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	bucket := perMinute(60) // 1 per second
+
+	ctx := context.Background()
+	for i := 0; i < 60; i++ {
+		if result, _ := store.Take(ctx, "k", bucket, 1, false); !result.Allowed {
+			t.Fatalf("Request %d should be allowed within burst", i+1)
+		}
+	}
+
+	result, _ := store.Take(ctx, "k", bucket, 1, false)
+	if result.Allowed {
+		t.Fatal("Request beyond capacity should be denied")
+	}
+	if result.RetryAfter != time.Second {
+		t.Errorf("Expected retry after 1s, got %v", result.RetryAfter)
+	}
+	if result.ResetAfter != time.Minute {
+		t.Errorf("Expected reset after 1m, got %v", result.ResetAfter)
+	}
+
+	now = now.Add(2 * time.Second)
+	result, _ = store.Take(ctx, "k", bucket, 1, false)
+	if !result.Allowed || result.Remaining != 1 {
+		t.Errorf("Expected refill of 2 tokens, got %+v", result)
+	}
+
+	// Forced charges can leave the bucket in debt
+	result, _ = store.Take(ctx, "k", bucket, 10, true)
+	if !result.Allowed || result.Remaining != 0 {
+		t.Errorf("Forced take should be allowed, got %+v", result)
+	}
+	now = now.Add(5 * time.Second)
+	if result, _ := store.Take(ctx, "k", bucket, 1, false); result.Allowed {
+		t.Error("Bucket in debt should deny until repaid")
+	}
+
+	// Refunds never exceed capacity
+	result, _ = store.Take(ctx, "k", bucket, -1000, true)
+	if result.Remaining != 60 {
+		t.Errorf("Expected refund capped at capacity, got %v", result.Remaining)
+	}
+}
+
+func TestLimiterRules(t *testing.T) {
+	config := &Config{
+		Default: Limits{RequestsPerMinute: 60, TokensPerMinute: 1000},
+		APIKeys: map[string]Limits{"7": {RequestsPerMinute: 600}},
+		Users:   map[string]Limits{"alice": {TokensPerMinute: -1}},
+		Models:  map[string]Limits{"gpt-4": {TokensPerMinute: 100}},
+	}
+	limiter := NewLimiter(config, NewMemoryStore())
+
+	tests := []struct {
+		name     string
+		subject  Subject
+		perModel bool
+		expected map[string]int
+	}{
+		{
+			name:     "Default limits",
+			subject:  Subject{User: "bob"},
+			expected: map[string]int{"rl:user:bob:rpm": 60, "rl:user:bob:tpm": 1000},
+		},
+		{
+			name:     "API key overrides requests, inherits tokens",
+			subject:  Subject{KeyID: 7, User: "bob"},
+			expected: map[string]int{"rl:key:7:rpm": 600, "rl:key:7:tpm": 1000},
+		},
+		{
+			name:     "Negative limit is unlimited",
+			subject:  Subject{User: "alice"},
+			expected: map[string]int{"rl:user:alice:rpm": 60},
+		},
+		{
+			name:     "Metadata takes precedence",
+			subject:  Subject{KeyID: 7, User: "bob", Metadata: `{"rate_limits":{"requests_per_minute":5}}`},
+			expected: map[string]int{"rl:key:7:rpm": 5, "rl:key:7:tpm": 1000},
+		},
+		{
+			name:    "Model limit from YAML",
+			subject: Subject{User: "bob", Model: "gpt-4"},
+			expected: map[string]int{
+				"rl:user:bob:rpm":             60,
+				"rl:user:bob:tpm":             1000,
+				"rl:user:bob:model:gpt-4:tpm": 100,
+			},
+		},
+		{
+			name:    "Model limit from metadata",
+			subject: Subject{KeyID: 7, Model: "claude-3-haiku", Metadata: `{"rate_limits":{"models":{"claude-3-haiku":{"requests_per_minute":3}}}}`},
+			expected: map[string]int{
+				"rl:key:7:rpm":                      600,
+				"rl:key:7:tpm":                      1000,
+				"rl:key:7:model:claude-3-haiku:rpm": 3,
+			},
+		},
+		{
+			name:     "Unlisted model shares identity buckets",
+			subject:  Subject{User: "bob", Model: "claude-3-haiku"},
+			expected: map[string]int{"rl:user:bob:rpm": 60, "rl:user:bob:tpm": 1000},
+		},
+		{
+			name:     "Per-model buckets",
+			subject:  Subject{User: "bob", Model: "gpt-4"},
+			perModel: true,
+			expected: map[string]int{
+				"rl:user:bob:rpm":             60,
+				"rl:user:bob:tpm":             1000,
+				"rl:user:bob:model:gpt-4:rpm": 60,
+				"rl:user:bob:model:gpt-4:tpm": 100,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.PerModel = tt.perModel
+			rules, err := limiter.Rules(tt.subject)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			got := make(map[string]int)
+			for _, rule := range rules {
+				got[rule.Key] = rule.Limit
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected rules %v, got %v", tt.expected, got)
+			}
+			for key, limit := range tt.expected {
+				if got[key] != limit {
+					t.Errorf("Expected %s=%d, got %v", key, limit, got)
+				}
+			}
+		})
+	}
+
+	if _, err := limiter.Rules(Subject{User: "bob", Metadata: "{"}); err == nil {
+		t.Error("Expected error for malformed metadata")
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	config := &Config{Default: Limits{RequestsPerMinute: 10, TokensPerMinute: 100}}
+	limiter := NewLimiter(config, NewMemoryStore())
+	ctx := context.Background()
+
+	rules, _ := limiter.Rules(Subject{User: "bob"})
+
+	decision, err := limiter.Allow(ctx, rules, 80)
+	if err != nil || !decision.Allowed {
+		t.Fatalf("Expected first request allowed, got %+v (err: %v)", decision, err)
+	}
+	if decision.Headers[DimensionTokens].Remaining != 20 || decision.Headers[DimensionRequests].Remaining != 9 {
+		t.Errorf("Unexpected header state: %+v", decision.Headers)
+	}
+
+	t.Run("Token limit denies and refunds requests", func(t *testing.T) {
+		decision, _ := limiter.Allow(ctx, rules, 50)
+		if decision.Allowed {
+			t.Fatal("Expected token limit to deny request")
+		}
+		if decision.Denied.Dimension != DimensionTokens {
+			t.Errorf("Expected tokens denial, got %s", decision.Denied.Dimension)
+		}
+		if decision.RetryAfter <= 0 {
+			t.Error("Expected positive retry after")
+		}
+
+		// The request slot taken before the denial was returned
+		decision, _ = limiter.Allow(ctx, rules, 0)
+		if decision.Headers[DimensionRequests].Remaining != 8 {
+			t.Errorf("Expected request refund, got %+v", decision.Headers[DimensionRequests])
+		}
+	})
+
+	t.Run("Adjust applies actual usage", func(t *testing.T) {
+		// The first request used 30 tokens rather than the 80 estimated
+		if err := limiter.Adjust(ctx, rules, 30-80); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		decision, _ := limiter.Allow(ctx, rules, 50)
+		if !decision.Allowed {
+			t.Errorf("Expected refunded tokens to admit request, got %+v", decision)
+		}
+	})
+}
@@ -0,0 +1,323 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	redisDialTimeout = 2 * time.Second
+	redisOpTimeout   = 2 * time.Second
+	redisPoolSize    = 16
+
+	// redisMaxTxnRetries bounds optimistic transaction retries under contention
+	redisMaxTxnRetries = 10
+)
+
+// errTxnConflict means a watched key changed before EXEC
+var errTxnConflict = errors.New("redis transaction conflict")
+
+// redisError is an error reply from the server
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+
+// RedisStore keeps buckets in any server speaking the Redis protocol so that
+// limits are shared across replicas. Updates use WATCH/MULTI/EXEC, so no
+// server-side scripting is required.
+type RedisStore struct {
+	addr     string
+	username string
+	password string
+	db       int
+	pool     chan *redisConn
+	now      func() time.Time
+}
+
+// NewRedisStore creates a store from a redis:// URL
+func NewRedisStore(rawURL string) (*RedisStore, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("unsupported redis URL scheme %q", u.Scheme)
+	}
+
+	store := &RedisStore{
+		addr: u.Host,
+		pool: make(chan *redisConn, redisPoolSize),
+		now:  time.Now,
+	}
+	if u.Port() == "" {
+		store.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		store.username = u.User.Username()
+		store.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if store.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid redis database %q", db)
+		}
+	}
+
+	return store, nil
+}
+
+// Take implements Store
+func (s *RedisStore) Take(ctx context.Context, key string, bucket Bucket, n float64, force bool) (Result, error) {
+	conn, err := s.get(ctx)
+	if err != nil {
+		return Result{}, fmt.Errorf("rate limit store: %w", err)
+	}
+
+	var result Result
+	for attempt := 0; attempt < redisMaxTxnRetries; attempt++ {
+		result, err = s.take(ctx, conn, key, bucket, n, force)
+		if err != errTxnConflict {
+			break
+		}
+	}
+	s.put(conn, err)
+
+	if err != nil {
+		return Result{}, fmt.Errorf("rate limit store: %w", err)
+	}
+	return result, nil
+}
+
+// take runs one optimistic read-modify-write of a bucket. Bucket state is
+// stored as "<tokens> <unix micros>" and expires once the bucket is full.
+func (s *RedisStore) take(ctx context.Context, conn *redisConn, key string, bucket Bucket, n float64, force bool) (Result, error) {
+	if _, err := conn.do(ctx, "WATCH", key); err != nil {
+		return Result{}, err
+	}
+
+	reply, err := conn.do(ctx, "GET", key)
+	if err != nil {
+		return Result{}, err
+	}
+
+	now := s.now()
+	tokens, updated := bucket.Capacity, now
+	if value, ok := reply.([]byte); ok {
+		tokens, updated, err = parseBucketState(value)
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	tokens, result := refill(bucket, tokens, now.Sub(updated), n, force)
+	if !result.Allowed {
+		// Nothing to write for a denied request
+		_, err := conn.do(ctx, "UNWATCH")
+		return result, err
+	}
+
+	ttl := time.Second
+	if bucket.RefillPerSecond > 0 {
+		ttl += secondsToDuration((bucket.Capacity - tokens) / bucket.RefillPerSecond)
+	}
+	state := strconv.FormatFloat(tokens, 'f', -1, 64) + " " + strconv.FormatInt(now.UnixMicro(), 10)
+
+	if _, err := conn.do(ctx, "MULTI"); err != nil {
+		return Result{}, err
+	}
+	if _, err := conn.do(ctx, "SET", key, state, "PX", strconv.FormatInt(ttl.Milliseconds(), 10)); err != nil {
+		return Result{}, err
+	}
+	exec, err := conn.do(ctx, "EXEC")
+	if err != nil {
+		return Result{}, err
+	}
+	if exec == nil {
+		return Result{}, errTxnConflict
+	}
+
+	return result, nil
+}
+
+// parseBucketState decodes a stored bucket
+func parseBucketState(value []byte) (float64, time.Time, error) {
+	fields := strings.Fields(string(value))
+	if len(fields) != 2 {
+		return 0, time.Time{}, fmt.Errorf("malformed bucket state %q", value)
+	}
+
+	tokens, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil || math.IsNaN(tokens) {
+		return 0, time.Time{}, fmt.Errorf("malformed bucket tokens %q", fields[0])
+	}
+	micros, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("malformed bucket timestamp %q", fields[1])
+	}
+
+	return tokens, time.UnixMicro(micros), nil
+}
+
+// get returns a pooled connection or dials a new one
+func (s *RedisStore) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-s.pool:
+		return conn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: redisDialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	conn := &redisConn{conn: netConn, reader: bufio.NewReader(netConn)}
+	if s.password != "" {
+		args := []string{"AUTH", s.password}
+		if s.username != "" {
+			args = []string{"AUTH", s.username, s.password}
+		}
+		if _, err := conn.do(ctx, args...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis authentication failed: %w", err)
+		}
+	}
+	if s.db != 0 {
+		if _, err := conn.do(ctx, "SELECT", strconv.Itoa(s.db)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to select redis database: %w", err)
+		}
+	}
+
+	return conn, nil
+}
+
+// put returns a healthy connection to the pool; connections that saw an
+// error are closed since they may be left mid-transaction
+func (s *RedisStore) put(conn *redisConn, err error) {
+	if err != nil && err != errTxnConflict {
+		conn.Close()
+		return
+	}
+
+	select {
+	case s.pool <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+// Close closes pooled connections
+func (s *RedisStore) Close() error {
+	for {
+		select {
+		case conn := <-s.pool:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// redisConn is a single RESP connection
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// do sends a command and reads its reply. Replies are decoded as string
+// (simple string), int64, []byte or nil (bulk string), []interface{} or nil
+// (array); error replies are returned as redisError.
+func (c *redisConn) do(ctx context.Context, args ...string) (interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > redisOpTimeout {
+		deadline = time.Now().Add(redisOpTimeout)
+	}
+	c.conn.SetDeadline(deadline)
+
+	var cmd strings.Builder
+	fmt.Fprintf(&cmd, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&cmd, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, cmd.String()); err != nil {
+		return nil, err
+	}
+
+	reply, err := readReply(c.reader)
+	if err != nil {
+		return nil, err
+	}
+	if replyErr, ok := reply.(redisError); ok {
+		return nil, replyErr
+	}
+	return reply, nil
+}
+
+// Close closes the connection
+func (c *redisConn) Close() error {
+	return c.conn.Close()
+}
+
+// readReply reads one RESP reply
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("malformed redis reply %q", line)
+	}
+	payload := line[1 : len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return payload, nil
+	case '-':
+		return redisError(payload), nil
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("malformed bulk length %q", payload)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:size], nil
+	case '*':
+		count, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("malformed array length %q", payload)
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unknown redis reply type %q", line[0])
+	}
+}
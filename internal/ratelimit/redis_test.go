@@ -0,0 +1,261 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is a minimal in-process server for the commands RedisStore uses
+type fakeRedis struct {
+	mu       sync.Mutex
+	values   map[string]string
+	versions map[string]int
+	password string
+
+	// beforeExec runs before each EXEC, for simulating concurrent writers
+	beforeExec func()
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{values: make(map[string]string), versions: make(map[string]int)}
+}
+
+// start serves connections until the test ends and returns the address
+func (f *fakeRedis) start(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func (f *fakeRedis) set(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[key] = value
+	f.versions[key]++
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	authenticated := f.password == ""
+	watched := make(map[string]int)
+	var queued [][]string
+	inMulti := false
+
+	for {
+		reply, err := readReply(reader)
+		if err != nil {
+			return
+		}
+		items, _ := reply.([]interface{})
+		args := make([]string, len(items))
+		for i, item := range items {
+			args[i] = string(item.([]byte))
+		}
+		if len(args) == 0 {
+			return
+		}
+
+		cmd := strings.ToUpper(args[0])
+		if !authenticated && cmd != "AUTH" {
+			io.WriteString(conn, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+		if inMulti && cmd != "EXEC" && cmd != "DISCARD" {
+			queued = append(queued, args)
+			io.WriteString(conn, "+QUEUED\r\n")
+			continue
+		}
+
+		switch cmd {
+		case "AUTH":
+			if args[len(args)-1] != f.password {
+				io.WriteString(conn, "-WRONGPASS invalid password\r\n")
+				continue
+			}
+			authenticated = true
+			io.WriteString(conn, "+OK\r\n")
+		case "SELECT":
+			io.WriteString(conn, "+OK\r\n")
+		case "WATCH":
+			f.mu.Lock()
+			for _, key := range args[1:] {
+				watched[key] = f.versions[key]
+			}
+			f.mu.Unlock()
+			io.WriteString(conn, "+OK\r\n")
+		case "UNWATCH":
+			watched = make(map[string]int)
+			io.WriteString(conn, "+OK\r\n")
+		case "GET":
+			f.mu.Lock()
+			value, ok := f.values[args[1]]
+			f.mu.Unlock()
+			if !ok {
+				io.WriteString(conn, "$-1\r\n")
+				continue
+			}
+			fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(value), value)
+		case "MULTI":
+			inMulti = true
+			io.WriteString(conn, "+OK\r\n")
+		case "DISCARD":
+			inMulti, queued = false, nil
+			watched = make(map[string]int)
+			io.WriteString(conn, "+OK\r\n")
+		case "EXEC":
+			if f.beforeExec != nil {
+				f.beforeExec()
+			}
+
+			f.mu.Lock()
+			conflict := false
+			for key, version := range watched {
+				if f.versions[key] != version {
+					conflict = true
+				}
+			}
+			if conflict {
+				f.mu.Unlock()
+				io.WriteString(conn, "*-1\r\n")
+			} else {
+				for _, queuedArgs := range queued {
+					f.values[queuedArgs[1]] = queuedArgs[2]
+					f.versions[queuedArgs[1]]++
+				}
+				f.mu.Unlock()
+				fmt.Fprintf(conn, "*%d\r\n", len(queued))
+				for range queued {
+					io.WriteString(conn, "+OK\r\n")
+				}
+			}
+			inMulti, queued = false, nil
+			watched = make(map[string]int)
+		default:
+			fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+		}
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	ctx := context.Background()
+	bucket := perMinute(60)
+
+	t.Run("Shares buckets across stores", func(t *testing.T) {
+		addr := newFakeRedis().start(t)
+		now := time.Now()
+
+		first, err := NewRedisStore("redis://" + addr)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		defer first.Close()
+		second, _ := NewRedisStore("redis://" + addr + "/2")
+		defer second.Close()
+		first.now = func() time.Time { return now }
+		second.now = first.now
+
+		for i := 0; i < 30; i++ {
+			first.Take(ctx, "k", bucket, 1, false)
+			second.Take(ctx, "k", bucket, 1, false)
+		}
+
+		result, err := first.Take(ctx, "k", bucket, 1, false)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.Allowed {
+			t.Error("Expected shared bucket to be exhausted")
+		}
+
+		now = now.Add(time.Second)
+		result, _ = second.Take(ctx, "k", bucket, 1, false)
+		if !result.Allowed {
+			t.Errorf("Expected refilled bucket to allow request, got %+v", result)
+		}
+	})
+
+	t.Run("Retries on transaction conflict", func(t *testing.T) {
+		server := newFakeRedis()
+		conflicts := 2
+		server.beforeExec = func() {
+			if conflicts > 0 {
+				conflicts--
+				server.set("k", fmt.Sprintf("10 %d", time.Now().UnixMicro()))
+			}
+		}
+		store, _ := NewRedisStore("redis://" + server.start(t))
+		defer store.Close()
+
+		result, err := store.Take(ctx, "k", bucket, 1, false)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !result.Allowed || result.Remaining >= 10 {
+			t.Errorf("Expected take from concurrently written bucket, got %+v", result)
+		}
+	})
+
+	t.Run("Authenticates", func(t *testing.T) {
+		server := newFakeRedis()
+		server.password = "secret"
+		addr := server.start(t)
+
+		store, _ := NewRedisStore("redis://:secret@" + addr)
+		defer store.Close()
+		if _, err := store.Take(ctx, "k", bucket, 1, false); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		wrong, _ := NewRedisStore("redis://:wrong@" + addr)
+		if _, err := wrong.Take(ctx, "k", bucket, 1, false); err == nil {
+			t.Error("Expected authentication error")
+		}
+	})
+
+	t.Run("Unreachable server", func(t *testing.T) {
+		listener, _ := net.Listen("tcp", "127.0.0.1:0")
+		addr := listener.Addr().String()
+		listener.Close()
+
+		store, _ := NewRedisStore("redis://" + addr)
+		if _, err := store.Take(ctx, "k", bucket, 1, false); err == nil {
+			t.Error("Expected connection error")
+		}
+	})
+
+	t.Run("Invalid URL", func(t *testing.T) {
+		for _, rawURL := range []string{"http://localhost", "redis://localhost/db"} {
+			if _, err := NewRedisStore(rawURL); err == nil {
+				t.Errorf("Expected error for %s", rawURL)
+			}
+		}
+	})
+}
@@ -0,0 +1,85 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reload
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Diff lists the settings that differ between two configurations as dotted
+// YAML paths, such as "model_mappings.gpt-4o added" or
+// "providers.azure.enabled changed". Values are left out because
+// configurations can hold credentials. Lists are compared as a whole.
+func Diff(before, after interface{}) ([]string, error) {
+	oldTree, err := toTree(before)
+	if err != nil {
+		return nil, err
+	}
+	newTree, err := toTree(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []string
+	diffTrees("", oldTree, newTree, &changes)
+	return changes, nil
+}
+
+// toTree converts a configuration into the maps and values of its YAML form
+func toTree(config interface{}) (interface{}, error) {
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	var tree interface{}
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+	return tree, nil
+}
+
+// diffTrees appends the paths under path that differ between two trees
+func diffTrees(path string, before, after interface{}, changes *[]string) {
+	oldMap, oldIsMap := before.(map[string]interface{})
+	newMap, newIsMap := after.(map[string]interface{})
+	if !oldIsMap || !newIsMap {
+		if !reflect.DeepEqual(before, after) {
+			*changes = append(*changes, path+" changed")
+		}
+		return
+	}
+
+	keys := make([]string, 0, len(oldMap)+len(newMap))
+	for key := range oldMap {
+		keys = append(keys, key)
+	}
+	for key := range newMap {
+		if _, exists := oldMap[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		child := key
+		if path != "" {
+			child = path + "." + key
+		}
+
+		oldValue, inOld := oldMap[key]
+		newValue, inNew := newMap[key]
+		switch {
+		case !inOld:
+			*changes = append(*changes, child+" added")
+		case !inNew:
+			*changes = append(*changes, child+" removed")
+		default:
+			diffTrees(child, oldValue, newValue, changes)
+		}
+	}
+}
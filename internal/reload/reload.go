@@ -0,0 +1,149 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reload
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/pkg/metrics"
+)
+
+// ApplyFunc parses, validates and swaps in a configuration file's contents,
+// returning a description of what changed. On error the current
+// configuration must be left in place.
+type ApplyFunc func(data []byte) (changes []string, err error)
+
+// Reloader reloads a configuration file when it changes on disk or the
+// process receives SIGHUP
+type Reloader struct {
+	name  string // metrics label
+	path  string
+	apply ApplyFunc
+
+	mu      sync.Mutex
+	current [sha256.Size]byte // contents in use
+	seen    [sha256.Size]byte // contents last read, applied or not
+}
+
+// New creates a reloader for a configuration file that is already loaded
+func New(name, path string, apply ApplyFunc) (*Reloader, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	r := &Reloader{name: name, path: path, apply: apply}
+	r.current = sha256.Sum256(data)
+	r.seen = r.current
+	metrics.SetConfigHash(name, r.current[:])
+	return r, nil
+}
+
+// Reload reads the file and applies it if its contents differ from the
+// configuration in use
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return r.failed(fmt.Errorf("failed to read config file: %w", err))
+	}
+	r.seen = sha256.Sum256(data)
+	return r.reload(data)
+}
+
+// check applies the file if its contents changed since it was last read, so
+// a file that failed validation is not retried until it is edited again
+func (r *Reloader) check() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		// Editors may briefly remove the file while saving
+		return nil
+	}
+	hash := sha256.Sum256(data)
+	if hash == r.seen {
+		return nil
+	}
+	r.seen = hash
+	return r.reload(data)
+}
+
+// reload applies data unless it is the configuration in use (caller holds mu)
+func (r *Reloader) reload(data []byte) error {
+	hash := sha256.Sum256(data)
+	if hash == r.current {
+		log.Printf("Configuration %s unchanged (%s)", r.name, r.path)
+		return nil
+	}
+
+	changes, err := r.apply(data)
+	if err != nil {
+		return r.failed(err)
+	}
+	r.current = hash
+	metrics.SetConfigHash(r.name, hash[:])
+	metrics.RecordConfigReload(r.name, "success")
+
+	if len(changes) == 0 {
+		log.Printf("✓ Configuration %s reloaded from %s (sha256 %x): no effective changes", r.name, r.path, hash[:6])
+	} else {
+		log.Printf("✓ Configuration %s reloaded from %s (sha256 %x):\n  - %s", r.name, r.path, hash[:6], strings.Join(changes, "\n  - "))
+	}
+	return nil
+}
+
+// failed records a failed reload (caller holds mu)
+func (r *Reloader) failed(err error) error {
+	metrics.RecordConfigReload(r.name, "failure")
+	log.Printf("Failed to reload configuration %s, keeping the current one: %v", r.name, err)
+	return err
+}
+
+// Watch checks the file for changes every interval until ctx is done
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.check()
+		}
+	}
+}
+
+// ReloadOnSIGHUP reloads every reloader each time the process receives
+// SIGHUP, until ctx is done
+func ReloadOnSIGHUP(ctx context.Context, reloaders ...*Reloader) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			log.Println("Received SIGHUP, reloading configuration")
+			for _, r := range reloaders {
+				r.Reload()
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reload
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReloader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+	}
+	write("version: 1")
+
+	var applied []string
+	r, err := New("test", path, func(data []byte) ([]string, error) {
+		if string(data) == "invalid" {
+			return nil, errors.New("validation failed")
+		}
+		applied = append(applied, string(data))
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	t.Run("Unchanged file is not applied", func(t *testing.T) {
+		if err := r.Reload(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(applied) != 0 {
+			t.Errorf("Expected nothing to be applied, got %v", applied)
+		}
+	})
+
+	t.Run("Failed reload is not retried until the file changes", func(t *testing.T) {
+		write("invalid")
+		if err := r.check(); err == nil {
+			t.Fatal("Expected the invalid config to be reported")
+		}
+		if err := r.check(); err != nil {
+			t.Errorf("Expected the same contents not to be applied again, got %v", err)
+		}
+		if err := r.Reload(); err == nil {
+			t.Error("Expected an explicit reload to apply the file again")
+		}
+	})
+
+	t.Run("Changed file is applied", func(t *testing.T) {
+		write("version: 2")
+		if err := r.check(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(applied, []string{"version: 2"}) {
+			t.Errorf("Expected the new contents to be applied, got %v", applied)
+		}
+	})
+
+	t.Run("Reverting to the applied file is a no-op", func(t *testing.T) {
+		write("invalid")
+		r.check()
+		write("version: 2")
+		if err := r.check(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(applied) != 1 {
+			t.Errorf("Expected the config in use not to be applied again, got %v", applied)
+		}
+	})
+}
+
+func TestDiff(t *testing.T) {
+	type provider struct {
+		Enabled bool   `yaml:"enabled"`
+		APIKey  string `yaml:"api_key"`
+	}
+	type config struct {
+		Providers map[string]provider `yaml:"providers"`
+		Fallback  []string            `yaml:"fallback"`
+	}
+
+	before := config{
+		Providers: map[string]provider{"a": {Enabled: true}, "b": {Enabled: true, APIKey: "secret"}},
+		Fallback:  []string{"a", "b"},
+	}
+	after := config{
+		Providers: map[string]provider{"b": {Enabled: false, APIKey: "secret"}, "c": {Enabled: true}},
+		Fallback:  []string{"b", "a"},
+	}
+
+	changes, err := Diff(before, after)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []string{
+		"fallback changed",
+		"providers.a removed",
+		"providers.b.enabled changed",
+		"providers.c added",
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Diff() = %v, want %v", changes, want)
+	}
+
+	if changes, _ := Diff(before, before); len(changes) != 0 {
+		t.Errorf("Expected no changes, got %v", changes)
+	}
+}
@@ -0,0 +1,349 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/pkg/metrics"
+)
+
+const (
+	defaultFailureThreshold    = 5
+	defaultOpenDuration        = 30 * time.Second
+	defaultHalfOpenMaxRequests = 1
+	defaultSuccessThreshold    = 1
+)
+
+// ErrCircuitOpen is returned when every candidate provider has an open circuit
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitState is the state of a circuit breaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets all requests through
+	CircuitClosed CircuitState = iota
+	// CircuitHalfOpen lets a limited number of trial requests through
+	CircuitHalfOpen
+	// CircuitOpen rejects all requests until the open duration elapses
+	CircuitOpen
+)
+
+// String returns the state name
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitHalfOpen:
+		return "half_open"
+	case CircuitOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker tracks consecutive failures for a provider or model deployment
+type CircuitBreaker struct {
+	provider string
+	model    string
+	config   CircuitBreakerConfig
+
+	mu        sync.Mutex
+	state     CircuitState
+	failures  int
+	successes int
+	inFlight  int
+	openedAt  time.Time
+	now       func() time.Time
+}
+
+// CircuitBreakerStatus is a point-in-time view of a circuit breaker
+type CircuitBreakerStatus struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model,omitempty"`
+	State    string `json:"state"`
+	Failures int    `json:"consecutive_failures"`
+}
+
+// newCircuitBreaker creates a closed circuit breaker
+func newCircuitBreaker(provider, model string, config CircuitBreakerConfig) *CircuitBreaker {
+	cb := &CircuitBreaker{
+		provider: provider,
+		model:    model,
+		config:   config,
+		now:      time.Now,
+	}
+	metrics.SetCircuitBreakerState(provider, model, int(CircuitClosed))
+	return cb
+}
+
+// State returns the current state, moving an expired open circuit to half-open
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.checkOpenExpired()
+	return cb.state
+}
+
+// Allow reports whether a request may be sent. Every allowed request must be
+// followed by RecordSuccess, RecordFailure or Release.
+func (cb *CircuitBreaker) Allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.checkOpenExpired()
+	switch cb.state {
+	case CircuitOpen:
+		return false
+	case CircuitHalfOpen:
+		if cb.inFlight >= cb.config.HalfOpenMaxRequests {
+			return false
+		}
+	}
+
+	cb.inFlight++
+	return true
+}
+
+// RecordSuccess records a successful request
+func (cb *CircuitBreaker) RecordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.release()
+	cb.failures = 0
+
+	if cb.state == CircuitHalfOpen {
+		cb.successes++
+		if cb.successes >= cb.config.SuccessThreshold {
+			cb.setState(CircuitClosed)
+		}
+	}
+}
+
+// RecordFailure records a failed request, opening the circuit once the
+// failure threshold is reached or if a half-open trial fails
+func (cb *CircuitBreaker) RecordFailure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.release()
+	cb.failures++
+
+	switch cb.state {
+	case CircuitHalfOpen:
+		cb.setState(CircuitOpen)
+	case CircuitClosed:
+		if cb.failures >= cb.config.FailureThreshold {
+			cb.setState(CircuitOpen)
+		}
+	}
+}
+
+// Release gives back an allowed request without recording an outcome
+// (e.g. the client canceled it)
+func (cb *CircuitBreaker) Release() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.release()
+}
+
+// Status returns a snapshot of the breaker
+func (cb *CircuitBreaker) Status() CircuitBreakerStatus {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.checkOpenExpired()
+	return CircuitBreakerStatus{
+		Provider: cb.provider,
+		Model:    cb.model,
+		State:    cb.state.String(),
+		Failures: cb.failures,
+	}
+}
+
+// release decrements the in-flight count (caller holds mu)
+func (cb *CircuitBreaker) release() {
+	if cb.inFlight > 0 {
+		cb.inFlight--
+	}
+}
+
+// checkOpenExpired moves an open circuit to half-open once the open duration has elapsed (caller holds mu)
+func (cb *CircuitBreaker) checkOpenExpired() {
+	if cb.state == CircuitOpen && cb.now().Sub(cb.openedAt) >= cb.config.OpenDuration {
+		cb.setState(CircuitHalfOpen)
+	}
+}
+
+// setState transitions the breaker and publishes the new state (caller holds mu)
+func (cb *CircuitBreaker) setState(state CircuitState) {
+	if cb.state == state {
+		return
+	}
+
+	cb.state = state
+	cb.successes = 0
+	switch state {
+	case CircuitOpen:
+		cb.openedAt = cb.now()
+	case CircuitClosed:
+		cb.failures = 0
+	}
+
+	metrics.SetCircuitBreakerState(cb.provider, cb.model, int(state))
+}
+
+// circuitBreakers holds the breakers for each provider and model deployment
+type circuitBreakers struct {
+	config CircuitBreakerConfig
+
+	mu       sync.Mutex
+	breakers map[string]*CircuitBreaker
+}
+
+// newCircuitBreakers creates a breaker registry, applying defaults for unset thresholds
+func newCircuitBreakers(config CircuitBreakerConfig) *circuitBreakers {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = defaultFailureThreshold
+	}
+	if config.OpenDuration <= 0 {
+		config.OpenDuration = defaultOpenDuration
+	}
+	if config.HalfOpenMaxRequests <= 0 {
+		config.HalfOpenMaxRequests = defaultHalfOpenMaxRequests
+	}
+	if config.SuccessThreshold <= 0 {
+		config.SuccessThreshold = defaultSuccessThreshold
+	}
+
+	return &circuitBreakers{
+		config:   config,
+		breakers: make(map[string]*CircuitBreaker),
+	}
+}
+
+// get returns the breaker for a provider, or for a model deployment when model is set
+func (b *circuitBreakers) get(provider, model string) *CircuitBreaker {
+	key := provider + "/" + model
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cb, exists := b.breakers[key]
+	if !exists {
+		cb = newCircuitBreaker(provider, model, b.config)
+		b.breakers[key] = cb
+	}
+	return cb
+}
+
+// available reports whether neither the provider nor the deployment circuit is open
+func (b *circuitBreakers) available(provider string, modelInfo *ProviderModelInfo) bool {
+	if !b.config.Enabled {
+		return true
+	}
+	return b.get(provider, "").State() != CircuitOpen &&
+		b.get(provider, deploymentName(modelInfo)).State() != CircuitOpen
+}
+
+// allow admits a request through both the provider and deployment breakers.
+// The returned done func must be called with the outcome.
+func (b *circuitBreakers) allow(provider string, modelInfo *ProviderModelInfo) (done func(outcome breakerOutcome), ok bool) {
+	if !b.config.Enabled {
+		return func(breakerOutcome) {}, true
+	}
+
+	providerBreaker := b.get(provider, "")
+	deploymentBreaker := b.get(provider, deploymentName(modelInfo))
+
+	if !providerBreaker.Allow() {
+		return nil, false
+	}
+	if !deploymentBreaker.Allow() {
+		providerBreaker.Release()
+		return nil, false
+	}
+
+	return func(outcome breakerOutcome) {
+		for _, cb := range []*CircuitBreaker{providerBreaker, deploymentBreaker} {
+			switch outcome {
+			case breakerSuccess:
+				cb.RecordSuccess()
+			case breakerFailure:
+				cb.RecordFailure()
+			default:
+				cb.Release()
+			}
+		}
+	}, true
+}
+
+// statuses returns a snapshot of all breakers sorted by provider and model
+func (b *circuitBreakers) statuses() []CircuitBreakerStatus {
+	b.mu.Lock()
+	breakers := make([]*CircuitBreaker, 0, len(b.breakers))
+	for _, cb := range b.breakers {
+		breakers = append(breakers, cb)
+	}
+	b.mu.Unlock()
+
+	statuses := make([]CircuitBreakerStatus, 0, len(breakers))
+	for _, cb := range breakers {
+		statuses = append(statuses, cb.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Provider != statuses[j].Provider {
+			return statuses[i].Provider < statuses[j].Provider
+		}
+		return statuses[i].Model < statuses[j].Model
+	})
+	return statuses
+}
+
+// breakerOutcome classifies an invocation result for the circuit breakers
+type breakerOutcome int
+
+const (
+	breakerSuccess breakerOutcome = iota
+	breakerFailure
+	breakerIgnored
+)
+
+// classifyOutcome decides whether an invocation error reflects provider health.
+// Transient and authentication errors count as failures; request errors show
+// the provider is responding.
+func classifyOutcome(err error) breakerOutcome {
+	if err == nil {
+		return breakerSuccess
+	}
+	if errors.Is(err, context.Canceled) {
+		return breakerIgnored
+	}
+	if isRetryable(err) {
+		return breakerFailure
+	}
+	switch errorStatusCode(err) {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return breakerFailure
+	}
+	return breakerSuccess
+}
+
+// deploymentName identifies the model deployment a target maps to
+func deploymentName(modelInfo *ProviderModelInfo) string {
+	if modelInfo.Deployment != "" {
+		return modelInfo.Deployment
+	}
+	return modelInfo.Model
+}
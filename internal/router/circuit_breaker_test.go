@@ -0,0 +1,183 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	config := CircuitBreakerConfig{
+		Enabled:             true,
+		FailureThreshold:    3,
+		OpenDuration:        time.Minute,
+		HalfOpenMaxRequests: 1,
+		SuccessThreshold:    2,
+	}
+
+	now := time.Now()
+	cb := newCircuitBreaker("test", "", config)
+	cb.now = func() time.Time { return now }
+
+	t.Run("Opens after consecutive failures", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			cb.Allow()
+			cb.RecordFailure()
+		}
+		if cb.State() != CircuitClosed {
+			t.Fatalf("Expected closed below threshold, got %s", cb.State())
+		}
+
+		cb.Allow()
+		cb.RecordFailure()
+		if cb.State() != CircuitOpen {
+			t.Fatalf("Expected open at threshold, got %s", cb.State())
+		}
+		if cb.Allow() {
+			t.Error("Open circuit should reject requests")
+		}
+	})
+
+	t.Run("Half-open limits trial requests", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		if cb.State() != CircuitHalfOpen {
+			t.Fatalf("Expected half-open after open duration, got %s", cb.State())
+		}
+
+		if !cb.Allow() {
+			t.Fatal("Half-open circuit should allow a trial request")
+		}
+		if cb.Allow() {
+			t.Error("Half-open circuit should allow only one concurrent trial")
+		}
+	})
+
+	t.Run("Failed trial reopens", func(t *testing.T) {
+		cb.RecordFailure()
+		if cb.State() != CircuitOpen {
+			t.Fatalf("Expected open after failed trial, got %s", cb.State())
+		}
+	})
+
+	t.Run("Successful trials close", func(t *testing.T) {
+		now = now.Add(time.Minute)
+
+		cb.Allow()
+		cb.RecordSuccess()
+		if cb.State() != CircuitHalfOpen {
+			t.Fatalf("Expected half-open until success threshold, got %s", cb.State())
+		}
+
+		cb.Allow()
+		cb.RecordSuccess()
+		if cb.State() != CircuitClosed {
+			t.Fatalf("Expected closed after success threshold, got %s", cb.State())
+		}
+		if status := cb.Status(); status.Failures != 0 {
+			t.Errorf("Expected failures reset on close, got %d", status.Failures)
+		}
+	})
+
+	t.Run("Success resets failure count", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			cb.Allow()
+			cb.RecordFailure()
+		}
+		cb.Allow()
+		cb.RecordSuccess()
+		cb.Allow()
+		cb.RecordFailure()
+
+		if cb.State() != CircuitClosed {
+			t.Errorf("Expected non-consecutive failures to keep circuit closed, got %s", cb.State())
+		}
+	})
+}
+
+func TestClassifyOutcome(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected breakerOutcome
+	}{
+		{"Success", nil, breakerSuccess},
+		{"Server error", &providers.ProviderError{StatusCode: http.StatusInternalServerError}, breakerFailure},
+		{"Throttled", &providers.ProviderError{StatusCode: http.StatusTooManyRequests}, breakerFailure},
+		{"Unauthorized", &providers.ProviderError{StatusCode: http.StatusUnauthorized}, breakerFailure},
+		{"Bad request", &providers.ProviderError{StatusCode: http.StatusBadRequest}, breakerSuccess},
+		{"Canceled", context.Canceled, breakerIgnored},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if outcome := classifyOutcome(tt.err); outcome != tt.expected {
+				t.Errorf("Expected outcome %d, got %d", tt.expected, outcome)
+			}
+		})
+	}
+}
+
+func TestRouteRequestSkipsOpenCircuits(t *testing.T) {
+	recordSleeps(t)
+	failure := &providers.ProviderError{Provider: "primary", StatusCode: http.StatusServiceUnavailable}
+	primary := &fakeProvider{name: "primary", errs: []error{failure, failure, failure}}
+	secondary := &fakeProvider{name: "secondary"}
+	r := newInvokerTestRouter(t, primary, secondary)
+	r.GetConfig().Providers["primary"] = ProviderConfig{Enabled: true}
+	r.breakers = newCircuitBreakers(CircuitBreakerConfig{
+		Enabled:          true,
+		FailureThreshold: 3,
+		OpenDuration:     time.Minute,
+	})
+
+	// Three failed requests trip the primary circuit
+	for i := 0; i < 3; i++ {
+		if _, _, err := r.Invoke(context.Background(), "test-model", modelBuilder); err != nil {
+			t.Fatalf("Expected failover to succeed, got %v", err)
+		}
+	}
+	if state := r.ProviderCircuitState("primary"); state != CircuitOpen {
+		t.Fatalf("Expected primary circuit open, got %s", state)
+	}
+
+	provider, _, err := r.RouteRequest(context.Background(), "test-model", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if provider.Name() != "secondary" {
+		t.Errorf("Expected routing to skip open primary, got %s", provider.Name())
+	}
+
+	calls := primary.calls
+	if _, _, err := r.Invoke(context.Background(), "test-model", modelBuilder); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if primary.calls != calls {
+		t.Error("Open circuit provider should not be invoked")
+	}
+
+	t.Run("All circuits open", func(t *testing.T) {
+		r.GetConfig().Routing.Fallback.Enabled = false
+
+		_, _, err := r.Invoke(context.Background(), "test-model", modelBuilder)
+		var providerErr *providers.ProviderError
+		if !errors.As(err, &providerErr) || providerErr.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("Expected 503 provider error, got %v", err)
+		}
+		if !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Expected ErrCircuitOpen, got %v", err)
+		}
+	})
+
+	statuses := r.CircuitBreakerStatuses()
+	if len(statuses) == 0 || statuses[0].Provider != "primary" || statuses[0].State != "open" {
+		t.Errorf("Unexpected breaker statuses: %+v", statuses)
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/tosharewith/llmproxy_auth/internal/cache"
+	"github.com/tosharewith/llmproxy_auth/internal/storage"
 	"gopkg.in/yaml.v3"
 )
 
@@ -19,6 +21,9 @@ type Config struct {
 	Routing       RoutingConfig           `yaml:"routing"`
 	Providers     map[string]ProviderConfig `yaml:"providers"`
 	Features      FeatureFlags            `yaml:"features"`
+	Media         storage.MediaConfig     `yaml:"media"`
+	ResponseCache cache.Config            `yaml:"response_cache"` // used when features.response_caching is set
+	SemanticCache cache.SemanticConfig    `yaml:"semantic_cache"`
 }
 
 // ModelMapping defines how a model name maps to different providers
@@ -35,6 +40,17 @@ type ProviderModelInfo struct {
 	Deployment string            `yaml:"deployment,omitempty"`
 	APIVersion string            `yaml:"api_version,omitempty"`
 	Metadata   map[string]string `yaml:"metadata,omitempty"`
+
+	// Load balancing weight relative to the model's other providers (default 1)
+	Weight int `yaml:"weight,omitempty"`
+
+	// Price overrides in USD per 1M tokens, used by the cost_optimized strategy
+	InputPrice  float64 `yaml:"input_price,omitempty"`
+	OutputPrice float64 `yaml:"output_price,omitempty"`
+
+	// Describe tools in the prompt and parse calls from the model's output
+	// for providers without native tool calling (IBM, Oracle)
+	ToolEmulation bool `yaml:"tool_emulation,omitempty"`
 }
 
 // RoutingConfig defines routing rules and fallback behavior
@@ -42,6 +58,7 @@ type RoutingConfig struct {
 	Patterns       []RoutingPattern `yaml:"patterns"`
 	Fallback       FallbackConfig   `yaml:"fallback"`
 	LoadBalancing  LoadBalancingConfig `yaml:"load_balancing"`
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker"`
 }
 
 // RoutingPattern defines a regex pattern for routing
@@ -65,6 +82,15 @@ type LoadBalancingConfig struct {
 	Strategy string `yaml:"strategy"` // round_robin, least_latency, random, cost_optimized
 }
 
+// CircuitBreakerConfig defines when provider and deployment circuits open and recover
+type CircuitBreakerConfig struct {
+	Enabled             bool          `yaml:"enabled"`
+	FailureThreshold    int           `yaml:"failure_threshold"`      // consecutive failures before opening
+	OpenDuration        time.Duration `yaml:"open_duration"`          // time before a half-open trial
+	HalfOpenMaxRequests int           `yaml:"half_open_max_requests"` // concurrent trial requests
+	SuccessThreshold    int           `yaml:"success_threshold"`      // trial successes before closing
+}
+
 // ProviderConfig contains provider-specific configuration
 type ProviderConfig struct {
 	Enabled     bool          `yaml:"enabled"`
@@ -96,6 +122,11 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	return ParseConfig(data)
+}
+
+// ParseConfig parses the router configuration from YAML
+func ParseConfig(data []byte) (*Config, error) {
 	// Expand environment variables
 	expanded := os.ExpandEnv(string(data))
 
@@ -231,6 +262,45 @@ func (c *Config) ValidateConfig() error {
 		}
 	}
 
+	// Check load balancing settings
+	if c.Routing.LoadBalancing.Enabled {
+		switch c.Routing.LoadBalancing.Strategy {
+		case "", StrategyRoundRobin, StrategyLeastLatency, StrategyRandom, StrategyCostOptimized:
+		default:
+			errors = append(errors, fmt.Sprintf("unknown load balancing strategy %q", c.Routing.LoadBalancing.Strategy))
+		}
+	}
+	for modelName, mapping := range c.ModelMappings {
+		for providerName, info := range mapping.Providers {
+			if info.Weight < 0 {
+				errors = append(errors, fmt.Sprintf("model %q provider %q has negative weight %d",
+					modelName, providerName, info.Weight))
+			}
+		}
+	}
+
+	// Check response cache settings
+	if c.Features.ResponseCaching {
+		switch c.ResponseCache.Backend {
+		case "", "memory":
+		case "disk":
+			if c.ResponseCache.Dir == "" {
+				errors = append(errors, "disk response cache requires dir")
+			}
+		default:
+			errors = append(errors, fmt.Sprintf("unknown response cache backend %q", c.ResponseCache.Backend))
+		}
+	}
+
+	if c.SemanticCache.Enabled {
+		if c.SemanticCache.EmbeddingModel == "" {
+			errors = append(errors, "semantic cache requires embedding_model")
+		}
+		if c.SemanticCache.Threshold < 0 || c.SemanticCache.Threshold > 1 {
+			errors = append(errors, fmt.Sprintf("semantic cache threshold %v must be between 0 and 1", c.SemanticCache.Threshold))
+		}
+	}
+
 	// Check fallback providers exist
 	if c.Routing.Fallback.Enabled {
 		for _, providerName := range c.Routing.Fallback.Providers {
@@ -0,0 +1,413 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+	"github.com/tosharewith/llmproxy_auth/pkg/metrics"
+)
+
+const (
+	// defaultRetryDelay is the base backoff when a provider sets no retry_delay
+	defaultRetryDelay = 500 * time.Millisecond
+
+	// maxRetryDelay caps the exponential backoff between attempts
+	maxRetryDelay = 10 * time.Second
+
+	// maxRetryAfter is the longest Retry-After we wait for; longer waits
+	// fail over to the next provider instead
+	maxRetryAfter = 30 * time.Second
+)
+
+// ErrNoProvider is returned when no provider can serve the requested model
+var ErrNoProvider = errors.New("no provider available")
+
+// RequestBuilder builds the provider-specific request for a routing target.
+// It is called once per target so that each provider gets its own translation.
+type RequestBuilder func(provider providers.Provider, modelInfo *ProviderModelInfo) (*providers.ProviderRequest, error)
+
+// Attempt records a single invocation attempt
+type Attempt struct {
+	Provider   string        `json:"provider"`
+	Model      string        `json:"model"`
+	Attempt    int           `json:"attempt"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Latency    time.Duration `json:"latency"`
+}
+
+// InvocationResult describes the target that served a request and the attempts made
+type InvocationResult struct {
+	Provider  providers.Provider
+	ModelInfo *ProviderModelInfo
+	Attempts  []Attempt
+}
+
+// invocationTarget is a provider/model pair to try
+type invocationTarget struct {
+	provider  providers.Provider
+	modelInfo *ProviderModelInfo
+}
+
+// sleepFunc waits for d or until ctx is done (replaced in tests)
+var sleepFunc = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Invoke routes a request for modelName and invokes it, retrying transient
+// failures and failing over to the configured fallback providers
+func (r *Router) Invoke(ctx context.Context, modelName string, build RequestBuilder) (*providers.ProviderResponse, *InvocationResult, error) {
+	var resp *providers.ProviderResponse
+
+	result, err := r.invoke(ctx, modelName, build, func(provider providers.Provider, req *providers.ProviderRequest) error {
+		var err error
+		resp, err = provider.Invoke(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, result, err
+	}
+
+	// Record attempts in response metadata
+	if resp.Metadata.ProviderMetadata == nil {
+		resp.Metadata.ProviderMetadata = make(map[string]any)
+	}
+	resp.Metadata.ProviderMetadata["provider"] = result.Provider.Name()
+	resp.Metadata.ProviderMetadata["attempts"] = result.Attempts
+
+	return resp, result, nil
+}
+
+// InvokeStreaming is like Invoke for streaming requests. Retries and failover
+// only happen before the stream is established.
+func (r *Router) InvokeStreaming(ctx context.Context, modelName string, build RequestBuilder) (io.ReadCloser, *InvocationResult, error) {
+	var stream io.ReadCloser
+
+	result, err := r.invoke(ctx, modelName, build, func(provider providers.Provider, req *providers.ProviderRequest) error {
+		var err error
+		stream, err = provider.InvokeStreaming(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, result, err
+	}
+
+	return stream, result, nil
+}
+
+// BatchBuilder builds the requests for a routing target when an operation has
+// to be split into several provider calls, such as large embedding batches
+type BatchBuilder func(provider providers.Provider, modelInfo *ProviderModelInfo) ([]*providers.ProviderRequest, error)
+
+// InvokeBatch is like Invoke for operations split into several requests. All
+// requests go to the same target so that results are consistent; retries
+// resume from the first request that has not succeeded, and failover starts
+// the batch over on the next target.
+func (r *Router) InvokeBatch(ctx context.Context, modelName string, build BatchBuilder) ([]*providers.ProviderResponse, *InvocationResult, error) {
+	var requests []*providers.ProviderRequest
+	var responses []*providers.ProviderResponse
+
+	result, err := r.invoke(ctx, modelName, func(provider providers.Provider, modelInfo *ProviderModelInfo) (*providers.ProviderRequest, error) {
+		batch, err := build(provider, modelInfo)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			return nil, fmt.Errorf("no requests to send")
+		}
+		requests, responses = batch, nil
+		return batch[0], nil
+	}, func(provider providers.Provider, _ *providers.ProviderRequest) error {
+		for len(responses) < len(requests) {
+			resp, err := provider.Invoke(ctx, requests[len(responses)])
+			if err != nil {
+				return err
+			}
+			responses = append(responses, resp)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, result, err
+	}
+
+	return responses, result, nil
+}
+
+// invoke runs call against each target in turn with retries
+func (r *Router) invoke(
+	ctx context.Context,
+	modelName string,
+	build RequestBuilder,
+	call func(providers.Provider, *providers.ProviderRequest) error,
+) (*InvocationResult, error) {
+	result := &InvocationResult{}
+
+	targets, err := r.invocationTargets(ctx, modelName)
+	if err != nil {
+		return result, err
+	}
+
+	var lastErr error
+	for i, target := range targets {
+		providerName := target.provider.Name()
+		if i > 0 {
+			log.Printf("Failing over model %q to provider %q", modelName, providerName)
+		}
+
+		// Translate the request for this provider
+		req, err := build(target.provider, target.modelInfo)
+		if err != nil {
+			lastErr = &providers.ProviderError{
+				Provider:   providerName,
+				StatusCode: http.StatusBadRequest,
+				Code:       providers.ErrCodeInvalidRequest,
+				Message:    fmt.Sprintf("Failed to translate request: %v", err),
+				Err:        err,
+			}
+			result.Attempts = append(result.Attempts, Attempt{
+				Provider:   providerName,
+				Model:      target.modelInfo.Model,
+				Attempt:    1,
+				StatusCode: http.StatusBadRequest,
+				Error:      lastErr.Error(),
+			})
+			continue
+		}
+
+		maxRetries := 0
+		retryDelay := defaultRetryDelay
+		if providerConfig, exists := r.GetConfig().Providers[providerName]; exists {
+			maxRetries = providerConfig.MaxRetries
+			if providerConfig.RetryDelay > 0 {
+				retryDelay = providerConfig.RetryDelay
+			}
+		}
+
+		for attempt := 0; ; attempt++ {
+			done, allowed := r.breakers.allow(providerName, target.modelInfo)
+			if !allowed {
+				lastErr = &providers.ProviderError{
+					Provider:   providerName,
+					StatusCode: http.StatusServiceUnavailable,
+					Code:       providers.ErrCodeServiceUnavailable,
+					Message:    fmt.Sprintf("Provider %q is unavailable", providerName),
+					Err:        ErrCircuitOpen,
+				}
+				result.Attempts = append(result.Attempts, Attempt{
+					Provider:   providerName,
+					Model:      target.modelInfo.Model,
+					Attempt:    attempt + 1,
+					StatusCode: http.StatusServiceUnavailable,
+					Error:      lastErr.Error(),
+				})
+				metrics.RecordProviderAttempt(providerName, "circuit_open")
+				break
+			}
+
+			start := time.Now()
+			err := call(target.provider, req)
+
+			outcome := classifyOutcome(err)
+			if ctx.Err() != nil {
+				outcome = breakerIgnored
+			}
+			done(outcome)
+
+			record := Attempt{
+				Provider: providerName,
+				Model:    target.modelInfo.Model,
+				Attempt:  attempt + 1,
+				Latency:  time.Since(start),
+			}
+			if err == nil {
+				record.StatusCode = http.StatusOK
+				result.Attempts = append(result.Attempts, record)
+				r.balancer.observeLatency(providerName, target.modelInfo, record.Latency)
+				result.Provider = target.provider
+				result.ModelInfo = target.modelInfo
+				metrics.RecordProviderAttempt(providerName, "success")
+				return result, nil
+			}
+
+			record.StatusCode = errorStatusCode(err)
+			record.Error = err.Error()
+			result.Attempts = append(result.Attempts, record)
+			lastErr = err
+
+			// Stop if the client went away
+			if ctx.Err() != nil {
+				metrics.RecordProviderAttempt(providerName, "canceled")
+				return result, err
+			}
+
+			if !isRetryable(err) || attempt >= maxRetries {
+				metrics.RecordProviderAttempt(providerName, "failed")
+				break
+			}
+
+			wait := backoffDelay(retryDelay, attempt)
+			if retryAfter := retryAfterDelay(err); retryAfter > 0 {
+				if retryAfter > maxRetryAfter {
+					// Not worth waiting; try the next provider
+					metrics.RecordProviderAttempt(providerName, "failed")
+					break
+				}
+				if retryAfter > wait {
+					wait = retryAfter
+				}
+			}
+
+			metrics.RecordProviderAttempt(providerName, "retry")
+			log.Printf("Provider %q attempt %d for model %q failed (%v), retrying in %v",
+				providerName, attempt+1, modelName, err, wait)
+
+			if err := sleepFunc(ctx, wait); err != nil {
+				return result, lastErr
+			}
+		}
+
+		if !shouldFailover(lastErr) {
+			break
+		}
+	}
+
+	return result, lastErr
+}
+
+// invocationTargets returns the routed provider followed by eligible fallback providers
+func (r *Router) invocationTargets(ctx context.Context, modelName string) ([]invocationTarget, error) {
+	provider, modelInfo, err := r.RouteRequest(ctx, modelName, "")
+	if err != nil {
+		if errors.Is(err, ErrTargetNotPermitted) {
+			return nil, &providers.ProviderError{
+				StatusCode: http.StatusForbidden,
+				Code:       providers.ErrCodePermissionDenied,
+				Message:    fmt.Sprintf("You are not allowed to use model %q through any of its providers", modelName),
+				Err:        err,
+			}
+		}
+		if errors.Is(err, ErrCircuitOpen) {
+			return nil, &providers.ProviderError{
+				StatusCode: http.StatusServiceUnavailable,
+				Code:       providers.ErrCodeServiceUnavailable,
+				Message:    fmt.Sprintf("All providers for model %q are unavailable", modelName),
+				Err:        err,
+			}
+		}
+		return nil, fmt.Errorf("%w: %v", ErrNoProvider, err)
+	}
+
+	targets := []invocationTarget{{provider: provider, modelInfo: modelInfo}}
+
+	config := r.GetConfig()
+	if !config.Features.AutoFallback || !config.Routing.Fallback.Enabled {
+		return targets, nil
+	}
+
+	seen := map[string]bool{provider.Name(): true}
+	for _, providerName := range config.GetFallbackProviders() {
+		if len(targets)-1 >= config.Routing.Fallback.MaxAttempts {
+			break
+		}
+		if seen[providerName] {
+			continue
+		}
+
+		// Only providers with a mapping for this model and a closed circuit can serve it
+		fallback, fallbackInfo, err := r.getAvailableProvider(ctx, modelName, providerName)
+		if err != nil {
+			continue
+		}
+
+		seen[providerName] = true
+		targets = append(targets, invocationTarget{provider: fallback, modelInfo: fallbackInfo})
+	}
+
+	return targets, nil
+}
+
+// backoffDelay returns the jittered exponential backoff for an attempt
+func backoffDelay(base time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 0; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	// Equal jitter: half fixed, half random
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// isRetryable reports whether an error is transient (429, 5xx or network)
+func isRetryable(err error) bool {
+	var providerErr *providers.ProviderError
+	if errors.As(err, &providerErr) {
+		switch providerErr.StatusCode {
+		case 0:
+			// No HTTP status means the request never completed
+			return providerErr.Code == providers.ErrCodeServiceUnavailable ||
+				providerErr.Code == providers.ErrCodeRateLimitExceeded
+		case http.StatusRequestTimeout, http.StatusTooManyRequests:
+			return true
+		case http.StatusNotImplemented:
+			return false
+		default:
+			return providerErr.StatusCode >= 500
+		}
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// shouldFailover reports whether another provider might succeed where this one
+// failed; malformed requests would fail everywhere
+func shouldFailover(err error) bool {
+	switch errorStatusCode(err) {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return false
+	default:
+		return true
+	}
+}
+
+// errorStatusCode extracts the HTTP status code from a provider error
+func errorStatusCode(err error) int {
+	var providerErr *providers.ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.StatusCode
+	}
+	return 0
+}
+
+// retryAfterDelay extracts the provider-requested retry delay
+func retryAfterDelay(err error) time.Duration {
+	var providerErr *providers.ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.RetryAfter
+	}
+	return 0
+}
@@ -0,0 +1,303 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package router
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+)
+
+// fakeProvider returns queued errors before succeeding
+type fakeProvider struct {
+	name   string
+	errs   []error
+	calls  int
+	models []string
+}
+
+func (p *fakeProvider) Name() string                          { return p.name }
+func (p *fakeProvider) HealthCheck(ctx context.Context) error { return nil }
+
+func (p *fakeProvider) Invoke(ctx context.Context, req *providers.ProviderRequest) (*providers.ProviderResponse, error) {
+	p.calls++
+	p.models = append(p.models, string(req.Body))
+	if len(p.errs) > 0 {
+		err := p.errs[0]
+		p.errs = p.errs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &providers.ProviderResponse{StatusCode: http.StatusOK, Body: []byte(`{}`)}, nil
+}
+
+func (p *fakeProvider) InvokeStreaming(ctx context.Context, req *providers.ProviderRequest) (io.ReadCloser, error) {
+	if _, err := p.Invoke(ctx, req); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader("data: [DONE]\n\n")), nil
+}
+
+func (p *fakeProvider) ListModels(ctx context.Context) ([]providers.Model, error) { return nil, nil }
+
+func (p *fakeProvider) GetModelInfo(ctx context.Context, modelID string) (*providers.Model, error) {
+	return nil, errors.New("not found")
+}
+
+func newInvokerTestRouter(t *testing.T, primary, secondary *fakeProvider) *Router {
+	t.Helper()
+
+	config := &Config{
+		ModelMappings: map[string]ModelMapping{
+			"test-model": {
+				DefaultProvider: primary.name,
+				Providers: map[string]ProviderModelInfo{
+					primary.name:   {Model: primary.name + "-model"},
+					secondary.name: {Model: secondary.name + "-model"},
+				},
+			},
+		},
+		Routing: RoutingConfig{
+			Fallback: FallbackConfig{
+				Enabled:     true,
+				Providers:   []string{primary.name, secondary.name},
+				MaxAttempts: 2,
+			},
+		},
+		Providers: map[string]ProviderConfig{
+			primary.name:   {Enabled: true, MaxRetries: 2, RetryDelay: time.Millisecond},
+			secondary.name: {Enabled: true, MaxRetries: 0},
+		},
+		Features: FeatureFlags{AutoFallback: true},
+	}
+
+	r, err := NewRouter(config, map[string]providers.Provider{
+		primary.name:   primary,
+		secondary.name: secondary,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+	return r
+}
+
+// recordSleeps replaces the backoff sleep for the duration of a test
+func recordSleeps(t *testing.T) *[]time.Duration {
+	t.Helper()
+
+	var sleeps []time.Duration
+	original := sleepFunc
+	sleepFunc = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	t.Cleanup(func() { sleepFunc = original })
+	return &sleeps
+}
+
+// modelBuilder encodes the target model in the request body
+func modelBuilder(provider providers.Provider, modelInfo *ProviderModelInfo) (*providers.ProviderRequest, error) {
+	return &providers.ProviderRequest{Method: "POST", Body: []byte(modelInfo.Model)}, nil
+}
+
+func TestInvokeRetries(t *testing.T) {
+	t.Run("Retries transient errors then succeeds", func(t *testing.T) {
+		sleeps := recordSleeps(t)
+		primary := &fakeProvider{name: "primary", errs: []error{
+			&providers.ProviderError{Provider: "primary", StatusCode: http.StatusServiceUnavailable},
+			&providers.ProviderError{Provider: "primary", StatusCode: http.StatusTooManyRequests, RetryAfter: 2 * time.Second},
+		}}
+		secondary := &fakeProvider{name: "secondary"}
+		r := newInvokerTestRouter(t, primary, secondary)
+
+		resp, result, err := r.Invoke(context.Background(), "test-model", modelBuilder)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if primary.calls != 3 || secondary.calls != 0 {
+			t.Errorf("Expected 3 primary and 0 secondary calls, got %d and %d", primary.calls, secondary.calls)
+		}
+		if result.Provider.Name() != "primary" {
+			t.Errorf("Expected primary to serve the request, got %s", result.Provider.Name())
+		}
+		if len(*sleeps) != 2 {
+			t.Fatalf("Expected 2 backoff sleeps, got %d", len(*sleeps))
+		}
+		if (*sleeps)[1] != 2*time.Second {
+			t.Errorf("Expected Retry-After of 2s to be honored, got %v", (*sleeps)[1])
+		}
+
+		attempts, ok := resp.Metadata.ProviderMetadata["attempts"].([]Attempt)
+		if !ok || len(attempts) != 3 {
+			t.Fatalf("Expected 3 attempts in metadata, got %v", resp.Metadata.ProviderMetadata["attempts"])
+		}
+		if attempts[0].StatusCode != http.StatusServiceUnavailable || attempts[2].StatusCode != http.StatusOK {
+			t.Errorf("Unexpected attempt status codes: %+v", attempts)
+		}
+	})
+
+	t.Run("Fails over with re-translated request", func(t *testing.T) {
+		recordSleeps(t)
+		unavailable := &providers.ProviderError{Provider: "primary", StatusCode: http.StatusBadGateway}
+		primary := &fakeProvider{name: "primary", errs: []error{unavailable, unavailable, unavailable}}
+		secondary := &fakeProvider{name: "secondary"}
+		r := newInvokerTestRouter(t, primary, secondary)
+
+		_, result, err := r.Invoke(context.Background(), "test-model", modelBuilder)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if primary.calls != 3 {
+			t.Errorf("Expected primary to be tried 1+2 times, got %d", primary.calls)
+		}
+		if result.Provider.Name() != "secondary" {
+			t.Errorf("Expected failover to secondary, got %s", result.Provider.Name())
+		}
+		if len(secondary.models) != 1 || secondary.models[0] != "secondary-model" {
+			t.Errorf("Expected secondary to receive its own model ID, got %v", secondary.models)
+		}
+		if len(result.Attempts) != 4 {
+			t.Errorf("Expected 4 attempts, got %d", len(result.Attempts))
+		}
+	})
+
+	t.Run("Does not retry or fail over client errors", func(t *testing.T) {
+		sleeps := recordSleeps(t)
+		primary := &fakeProvider{name: "primary", errs: []error{
+			&providers.ProviderError{Provider: "primary", StatusCode: http.StatusBadRequest},
+		}}
+		secondary := &fakeProvider{name: "secondary"}
+		r := newInvokerTestRouter(t, primary, secondary)
+
+		_, _, err := r.Invoke(context.Background(), "test-model", modelBuilder)
+		if err == nil {
+			t.Fatal("Expected error")
+		}
+		if primary.calls != 1 || secondary.calls != 0 || len(*sleeps) != 0 {
+			t.Errorf("Expected a single attempt, got primary=%d secondary=%d sleeps=%d",
+				primary.calls, secondary.calls, len(*sleeps))
+		}
+	})
+
+	t.Run("Long Retry-After fails over immediately", func(t *testing.T) {
+		sleeps := recordSleeps(t)
+		primary := &fakeProvider{name: "primary", errs: []error{
+			&providers.ProviderError{Provider: "primary", StatusCode: http.StatusTooManyRequests, RetryAfter: time.Hour},
+		}}
+		secondary := &fakeProvider{name: "secondary"}
+		r := newInvokerTestRouter(t, primary, secondary)
+
+		_, result, err := r.Invoke(context.Background(), "test-model", modelBuilder)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.Provider.Name() != "secondary" || len(*sleeps) != 0 {
+			t.Errorf("Expected immediate failover, got provider=%s sleeps=%d", result.Provider.Name(), len(*sleeps))
+		}
+	})
+
+	t.Run("Unknown model", func(t *testing.T) {
+		r := newInvokerTestRouter(t, &fakeProvider{name: "primary"}, &fakeProvider{name: "secondary"})
+
+		_, _, err := r.Invoke(context.Background(), "missing-model", modelBuilder)
+		if !errors.Is(err, ErrNoProvider) {
+			t.Errorf("Expected ErrNoProvider, got %v", err)
+		}
+	})
+}
+
+func TestInvokeStreamingFailover(t *testing.T) {
+	recordSleeps(t)
+	primary := &fakeProvider{name: "primary", errs: []error{
+		&providers.ProviderError{Provider: "primary", StatusCode: http.StatusUnauthorized},
+	}}
+	secondary := &fakeProvider{name: "secondary"}
+	r := newInvokerTestRouter(t, primary, secondary)
+
+	stream, result, err := r.InvokeStreaming(context.Background(), "test-model", modelBuilder)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer stream.Close()
+
+	if primary.calls != 1 {
+		t.Errorf("Expected authentication failure not to be retried, got %d calls", primary.calls)
+	}
+	if result.Provider.Name() != "secondary" {
+		t.Errorf("Expected failover to secondary, got %s", result.Provider.Name())
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	base := 100 * time.Millisecond
+	for attempt := 0; attempt < 10; attempt++ {
+		expected := base << attempt
+		if expected > maxRetryDelay {
+			expected = maxRetryDelay
+		}
+
+		delay := backoffDelay(base, attempt)
+		if delay < expected/2 || delay > expected {
+			t.Errorf("attempt %d: delay %v outside [%v, %v]", attempt, delay, expected/2, expected)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d := providers.ParseRetryAfter("5"); d != 5*time.Second {
+		t.Errorf("Expected 5s, got %v", d)
+	}
+	if d := providers.ParseRetryAfter(""); d != 0 {
+		t.Errorf("Expected 0 for empty header, got %v", d)
+	}
+
+	future := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	if d := providers.ParseRetryAfter(future); d <= 0 || d > 10*time.Second {
+		t.Errorf("Expected delay up to 10s for HTTP date, got %v", d)
+	}
+}
+
+func TestInvokeBatch(t *testing.T) {
+	sleeps := recordSleeps(t)
+	primary := &fakeProvider{name: "primary", errs: []error{
+		nil,
+		&providers.ProviderError{Provider: "primary", StatusCode: http.StatusTooManyRequests},
+	}}
+	secondary := &fakeProvider{name: "secondary"}
+	r := newInvokerTestRouter(t, primary, secondary)
+
+	build := func(provider providers.Provider, modelInfo *ProviderModelInfo) ([]*providers.ProviderRequest, error) {
+		var requests []*providers.ProviderRequest
+		for _, batch := range []string{"a", "b", "c"} {
+			requests = append(requests, &providers.ProviderRequest{Method: "POST", Body: []byte(modelInfo.Model + "/" + batch)})
+		}
+		return requests, nil
+	}
+
+	responses, result, err := r.InvokeBatch(context.Background(), "test-model", build)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(responses) != 3 || result.Provider.Name() != "primary" {
+		t.Fatalf("Expected 3 responses from primary, got %d from %s", len(responses), result.Provider.Name())
+	}
+
+	// The throttled second request is retried without resending the first
+	want := "primary-model/a,primary-model/b,primary-model/b,primary-model/c"
+	if got := strings.Join(primary.models, ","); got != want {
+		t.Errorf("Unexpected requests:\n got %s\nwant %s", got, want)
+	}
+	if len(*sleeps) != 1 || len(result.Attempts) != 2 {
+		t.Errorf("Expected 1 retry, got sleeps=%d attempts=%d", len(*sleeps), len(result.Attempts))
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package router
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+)
+
+// Load balancing strategies
+const (
+	StrategyRoundRobin    = "round_robin"
+	StrategyLeastLatency  = "least_latency"
+	StrategyRandom        = "random"
+	StrategyCostOptimized = "cost_optimized"
+)
+
+// latencyEWMAAlpha weights the most recent latency observation
+const latencyEWMAAlpha = 0.3
+
+// balanceTarget is a provider entry of a model mapping that can serve a request
+type balanceTarget struct {
+	name      string
+	provider  providers.Provider
+	modelInfo *ProviderModelInfo
+}
+
+// key identifies the target's provider deployment
+func (t balanceTarget) key() string {
+	return t.name + "/" + deploymentName(t.modelInfo)
+}
+
+// weight returns the configured weight, defaulting to 1
+func (t balanceTarget) weight() int {
+	if t.modelInfo.Weight > 0 {
+		return t.modelInfo.Weight
+	}
+	return 1
+}
+
+// loadBalancer spreads requests for a model across its provider entries
+type loadBalancer struct {
+	mu        sync.Mutex
+	current   map[string]int              // smooth weighted round robin state
+	latencies map[string]float64          // EWMA latency in milliseconds
+	prices    map[string]*providers.Model // cached pricing, nil when unknown
+}
+
+// newLoadBalancer creates an empty load balancer
+func newLoadBalancer() *loadBalancer {
+	return &loadBalancer{
+		current:   make(map[string]int),
+		latencies: make(map[string]float64),
+		prices:    make(map[string]*providers.Model),
+	}
+}
+
+// pick selects one of targets for modelName using strategy
+func (lb *loadBalancer) pick(ctx context.Context, strategy, modelName string, targets []balanceTarget) balanceTarget {
+	if len(targets) == 1 {
+		return targets[0]
+	}
+
+	switch strategy {
+	case StrategyLeastLatency:
+		return lb.pickLeastLatency(targets)
+	case StrategyRandom:
+		return pickWeightedRandom(targets)
+	case StrategyCostOptimized:
+		return lb.pickCheapest(ctx, targets)
+	default:
+		return lb.pickRoundRobin(modelName, targets)
+	}
+}
+
+// pickRoundRobin uses smooth weighted round robin so that heavier targets are
+// interleaved rather than picked in bursts
+func (lb *loadBalancer) pickRoundRobin(modelName string, targets []balanceTarget) balanceTarget {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	total := 0
+	best := -1
+	for i, target := range targets {
+		key := modelName + "/" + target.key()
+		lb.current[key] += target.weight()
+		total += target.weight()
+
+		if best < 0 || lb.current[key] > lb.current[modelName+"/"+targets[best].key()] {
+			best = i
+		}
+	}
+
+	lb.current[modelName+"/"+targets[best].key()] -= total
+	return targets[best]
+}
+
+// pickLeastLatency picks the target with the lowest weighted EWMA latency.
+// Targets without observations are tried first.
+func (lb *loadBalancer) pickLeastLatency(targets []balanceTarget) balanceTarget {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	best := 0
+	bestScore := math.Inf(1)
+	for i, target := range targets {
+		latency, observed := lb.latencies[target.key()]
+		if !observed {
+			return target
+		}
+
+		score := latency / float64(target.weight())
+		if score < bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return targets[best]
+}
+
+// pickCheapest picks the target with the lowest combined input and output
+// price, preferring higher weights on ties. Targets without pricing rank last.
+func (lb *loadBalancer) pickCheapest(ctx context.Context, targets []balanceTarget) balanceTarget {
+	best := 0
+	bestCost := math.Inf(1)
+	for i, target := range targets {
+		cost := math.Inf(1)
+		if pricing := lb.pricing(ctx, target); pricing != nil {
+			cost = pricing.InputPrice + pricing.OutputPrice
+		}
+
+		if cost < bestCost || (cost == bestCost && target.weight() > targets[best].weight()) {
+			best, bestCost = i, cost
+		}
+	}
+	return targets[best]
+}
+
+// pricing returns the target's prices, from model-mapping.yaml if set and
+// otherwise from the provider's model catalog
+func (lb *loadBalancer) pricing(ctx context.Context, target balanceTarget) *providers.Model {
+	if target.modelInfo.InputPrice > 0 || target.modelInfo.OutputPrice > 0 {
+		return &providers.Model{
+			ID:          target.modelInfo.Model,
+			Provider:    target.name,
+			InputPrice:  target.modelInfo.InputPrice,
+			OutputPrice: target.modelInfo.OutputPrice,
+		}
+	}
+
+	lb.mu.Lock()
+	pricing, cached := lb.prices[target.key()]
+	lb.mu.Unlock()
+	if cached {
+		return pricing
+	}
+
+	model, err := target.provider.GetModelInfo(ctx, target.modelInfo.Model)
+	if err != nil && ctx.Err() != nil {
+		// Don't cache a lookup abandoned by the caller
+		return nil
+	}
+	if err == nil && (model.InputPrice > 0 || model.OutputPrice > 0) {
+		pricing = model
+	}
+
+	lb.mu.Lock()
+	lb.prices[target.key()] = pricing
+	lb.mu.Unlock()
+	return pricing
+}
+
+// observeLatency folds a successful request's latency into the target's EWMA
+func (lb *loadBalancer) observeLatency(providerName string, modelInfo *ProviderModelInfo, latency time.Duration) {
+	key := balanceTarget{name: providerName, modelInfo: modelInfo}.key()
+	ms := float64(latency) / float64(time.Millisecond)
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	if previous, observed := lb.latencies[key]; observed {
+		ms = latencyEWMAAlpha*ms + (1-latencyEWMAAlpha)*previous
+	}
+	lb.latencies[key] = ms
+}
+
+// pickWeightedRandom picks a target with probability proportional to its weight
+func pickWeightedRandom(targets []balanceTarget) balanceTarget {
+	total := 0
+	for _, target := range targets {
+		total += target.weight()
+	}
+
+	n := rand.Intn(total)
+	for _, target := range targets {
+		n -= target.weight()
+		if n < 0 {
+			return target
+		}
+	}
+	return targets[len(targets)-1]
+}
+
+// balanceTargets returns the mapping's providers that can currently serve modelName,
+// in a stable order
+func (r *Router) balanceTargets(ctx context.Context, modelName string) []balanceTarget {
+	mapping, exists := r.GetConfig().ModelMappings[modelName]
+	if !exists {
+		return nil
+	}
+
+	names := make([]string, 0, len(mapping.Providers))
+	for name := range mapping.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var targets []balanceTarget
+	for _, name := range names {
+		provider, modelInfo, err := r.getAvailableProvider(ctx, modelName, name)
+		if err != nil {
+			continue
+		}
+		targets = append(targets, balanceTarget{name: name, provider: provider, modelInfo: modelInfo})
+	}
+	return targets
+}
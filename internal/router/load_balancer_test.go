@@ -0,0 +1,191 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package router
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+)
+
+// pricedProvider reports catalog prices for its models
+type pricedProvider struct {
+	fakeProvider
+	inputPrice  float64
+	outputPrice float64
+}
+
+func (p *pricedProvider) GetModelInfo(ctx context.Context, modelID string) (*providers.Model, error) {
+	if p.inputPrice == 0 && p.outputPrice == 0 {
+		return nil, errors.New("not found")
+	}
+	return &providers.Model{ID: modelID, InputPrice: p.inputPrice, OutputPrice: p.outputPrice}, nil
+}
+
+func newBalancedTestRouter(t *testing.T, strategy string, registry map[string]providers.Provider, infos map[string]ProviderModelInfo) *Router {
+	t.Helper()
+
+	providerConfigs := make(map[string]ProviderConfig)
+	for name := range registry {
+		providerConfigs[name] = ProviderConfig{Enabled: true}
+	}
+
+	config := &Config{
+		ModelMappings: map[string]ModelMapping{
+			"test-model": {DefaultProvider: "a", Providers: infos},
+		},
+		Routing: RoutingConfig{
+			LoadBalancing: LoadBalancingConfig{Enabled: true, Strategy: strategy},
+		},
+		Providers: providerConfigs,
+	}
+
+	r, err := NewRouter(config, registry)
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+	return r
+}
+
+// routeCounts routes n requests and counts the providers chosen
+func routeCounts(t *testing.T, r *Router, n int) map[string]int {
+	t.Helper()
+
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		provider, _, err := r.RouteRequest(context.Background(), "test-model", "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		counts[provider.Name()]++
+	}
+	return counts
+}
+
+func TestLoadBalancing(t *testing.T) {
+	registry := map[string]providers.Provider{
+		"a": &fakeProvider{name: "a"},
+		"b": &fakeProvider{name: "b"},
+	}
+
+	t.Run("Weighted round robin", func(t *testing.T) {
+		r := newBalancedTestRouter(t, StrategyRoundRobin, registry, map[string]ProviderModelInfo{
+			"a": {Model: "model-a", Weight: 3},
+			"b": {Model: "model-b"},
+		})
+
+		var sequence string
+		for i := 0; i < 8; i++ {
+			provider, _, _ := r.RouteRequest(context.Background(), "test-model", "")
+			sequence += provider.Name()
+		}
+		if sequence != "aabaaaba" {
+			t.Errorf("Expected smooth weighted sequence aabaaaba, got %s", sequence)
+		}
+	})
+
+	t.Run("Weighted random", func(t *testing.T) {
+		r := newBalancedTestRouter(t, StrategyRandom, registry, map[string]ProviderModelInfo{
+			"a": {Model: "model-a", Weight: 9},
+			"b": {Model: "model-b", Weight: 1},
+		})
+
+		counts := routeCounts(t, r, 2000)
+		if counts["a"] < 1600 || counts["b"] < 100 {
+			t.Errorf("Expected roughly 9:1 split, got %v", counts)
+		}
+	})
+
+	t.Run("Least latency", func(t *testing.T) {
+		r := newBalancedTestRouter(t, StrategyLeastLatency, registry, map[string]ProviderModelInfo{
+			"a": {Model: "model-a"},
+			"b": {Model: "model-b"},
+		})
+
+		// Unobserved targets are explored first
+		provider, modelInfo, _ := r.RouteRequest(context.Background(), "test-model", "")
+		if provider.Name() != "a" {
+			t.Fatalf("Expected first unobserved target a, got %s", provider.Name())
+		}
+		r.balancer.observeLatency("a", modelInfo, 500*time.Millisecond)
+
+		provider, modelInfo, _ = r.RouteRequest(context.Background(), "test-model", "")
+		if provider.Name() != "b" {
+			t.Fatalf("Expected unobserved target b, got %s", provider.Name())
+		}
+		r.balancer.observeLatency("b", modelInfo, 100*time.Millisecond)
+
+		if counts := routeCounts(t, r, 5); counts["b"] != 5 {
+			t.Errorf("Expected faster target b, got %v", counts)
+		}
+
+		// A run of slow responses moves traffic away from b
+		for i := 0; i < 5; i++ {
+			r.balancer.observeLatency("b", modelInfo, 2*time.Second)
+		}
+		if counts := routeCounts(t, r, 5); counts["a"] != 5 {
+			t.Errorf("Expected traffic to shift to a, got %v", counts)
+		}
+	})
+
+	t.Run("Cost optimized", func(t *testing.T) {
+		priced := map[string]providers.Provider{
+			"a": &pricedProvider{fakeProvider: fakeProvider{name: "a"}, inputPrice: 3, outputPrice: 15},
+			"b": &pricedProvider{fakeProvider: fakeProvider{name: "b"}},
+			"c": &pricedProvider{fakeProvider: fakeProvider{name: "c"}, inputPrice: 10, outputPrice: 30},
+		}
+		r := newBalancedTestRouter(t, StrategyCostOptimized, priced, map[string]ProviderModelInfo{
+			"a": {Model: "model-a"},
+			"b": {Model: "model-b", InputPrice: 0.25, OutputPrice: 1.25},
+			"c": {Model: "model-c"},
+		})
+
+		if counts := routeCounts(t, r, 3); counts["b"] != 3 {
+			t.Errorf("Expected cheapest target b (from config prices), got %v", counts)
+		}
+	})
+
+	t.Run("Skips open circuits", func(t *testing.T) {
+		r := newBalancedTestRouter(t, StrategyRoundRobin, registry, map[string]ProviderModelInfo{
+			"a": {Model: "model-a"},
+			"b": {Model: "model-b"},
+		})
+		r.breakers = newCircuitBreakers(CircuitBreakerConfig{Enabled: true, FailureThreshold: 1, OpenDuration: time.Minute})
+		r.breakers.get("a", "").Allow()
+		r.breakers.get("a", "").RecordFailure()
+
+		if counts := routeCounts(t, r, 4); counts["b"] != 4 {
+			t.Errorf("Expected all traffic on b, got %v", counts)
+		}
+	})
+}
+
+func TestValidateLoadBalancingConfig(t *testing.T) {
+	config := &Config{
+		ModelMappings: map[string]ModelMapping{
+			"test-model": {
+				DefaultProvider: "a",
+				Providers:       map[string]ProviderModelInfo{"a": {Model: "m", Weight: -1}},
+			},
+		},
+		Routing: RoutingConfig{
+			LoadBalancing: LoadBalancingConfig{Enabled: true, Strategy: "fastest"},
+		},
+		Providers: map[string]ProviderConfig{"a": {Enabled: true}},
+	}
+
+	err := config.ValidateConfig()
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+	for _, expected := range []string{"unknown load balancing strategy", "negative weight"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to mention %q, got %v", expected, err)
+		}
+	}
+}
@@ -5,16 +5,22 @@ package router
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
 
 	"github.com/tosharewith/llmproxy_auth/internal/providers"
 )
 
 // Router handles routing requests to appropriate providers
 type Router struct {
-	config    *Config
+	config    atomic.Pointer[Config] // replaced by UpdateConfig
 	providers map[string]providers.Provider
+	breakers  *circuitBreakers
+	balancer  *loadBalancer
 }
 
 // NewRouter creates a new router with the given configuration
@@ -24,48 +30,93 @@ func NewRouter(config *Config, providerRegistry map[string]providers.Provider) (
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	return &Router{
-		config:    config,
+	r := &Router{
 		providers: providerRegistry,
-	}, nil
+		breakers:  newCircuitBreakers(config.Routing.CircuitBreaker),
+		balancer:  newLoadBalancer(),
+	}
+	r.config.Store(config)
+	return r, nil
 }
 
 // RouteRequest determines which provider should handle a request
 func (r *Router) RouteRequest(ctx context.Context, modelName string, preferredProvider string) (providers.Provider, *ProviderModelInfo, error) {
+	config := r.GetConfig()
+
 	// If preferred provider is specified and valid, use it
 	if preferredProvider != "" {
-		if provider, modelInfo, err := r.getProviderForModel(modelName, preferredProvider); err == nil {
+		if provider, modelInfo, err := r.getAvailableProvider(ctx, modelName, preferredProvider); err == nil {
 			return provider, modelInfo, nil
 		}
 		log.Printf("Preferred provider %q not available for model %q, falling back to default", preferredProvider, modelName)
 	}
 
+	// Spread requests across the model's providers
+	if config.Routing.LoadBalancing.Enabled {
+		if targets := r.balanceTargets(ctx, modelName); len(targets) > 0 {
+			target := r.balancer.pick(ctx, config.Routing.LoadBalancing.Strategy, modelName, targets)
+			return target.provider, target.modelInfo, nil
+		}
+	}
+
 	// Get default provider for the model
-	defaultProvider := r.config.GetDefaultProvider(modelName)
+	defaultProvider := config.GetDefaultProvider(modelName)
 	if defaultProvider == "" {
 		return nil, nil, fmt.Errorf("no provider found for model %q", modelName)
 	}
 
 	// Try default provider
-	provider, modelInfo, err := r.getProviderForModel(modelName, defaultProvider)
+	provider, modelInfo, err := r.getAvailableProvider(ctx, modelName, defaultProvider)
 	if err == nil {
 		return provider, modelInfo, nil
 	}
 
+	// The caller may not use the default provider; use another of the model's providers
+	if errors.Is(err, ErrTargetNotPermitted) {
+		if targets := r.balanceTargets(ctx, modelName); len(targets) > 0 {
+			return targets[0].provider, targets[0].modelInfo, nil
+		}
+	}
+
 	// If auto-fallback is disabled, return the error
-	if !r.config.Features.AutoFallback || !r.config.Routing.Fallback.Enabled {
+	if !config.Features.AutoFallback || !config.Routing.Fallback.Enabled {
 		return nil, nil, fmt.Errorf("provider %q failed for model %q: %w", defaultProvider, modelName, err)
 	}
 
 	// Try fallback providers
 	log.Printf("Default provider %q failed for model %q, attempting fallback", defaultProvider, modelName)
-	return r.tryFallbackProviders(ctx, modelName, defaultProvider)
+	provider, modelInfo, fallbackErr := r.tryFallbackProviders(ctx, modelName, defaultProvider)
+	if fallbackErr != nil && errors.Is(err, ErrCircuitOpen) {
+		return nil, nil, fmt.Errorf("%v: %w", fallbackErr, err)
+	}
+	return provider, modelInfo, fallbackErr
+}
+
+// getAvailableProvider gets a provider for a model, skipping open circuits and
+// targets the request's TargetFilter refuses
+func (r *Router) getAvailableProvider(ctx context.Context, modelName, providerName string) (providers.Provider, *ProviderModelInfo, error) {
+	provider, modelInfo, err := r.getProviderForModel(modelName, providerName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !targetPermitted(ctx, modelName, providerName, modelInfo) {
+		return nil, nil, fmt.Errorf("%w: provider %q model %q", ErrTargetNotPermitted, providerName, modelInfo.Model)
+	}
+
+	if !r.breakers.available(providerName, modelInfo) {
+		return nil, nil, fmt.Errorf("%w for provider %q model %q", ErrCircuitOpen, providerName, deploymentName(modelInfo))
+	}
+
+	return provider, modelInfo, nil
 }
 
 // getProviderForModel gets a specific provider for a model
 func (r *Router) getProviderForModel(modelName, providerName string) (providers.Provider, *ProviderModelInfo, error) {
+	config := r.GetConfig()
+
 	// Check if provider is enabled
-	if !r.config.IsProviderEnabled(providerName) {
+	if !config.IsProviderEnabled(providerName) {
 		return nil, nil, fmt.Errorf("provider %q is disabled", providerName)
 	}
 
@@ -76,7 +127,7 @@ func (r *Router) getProviderForModel(modelName, providerName string) (providers.
 	}
 
 	// Get model info for this provider
-	modelInfo, err := r.config.GetProviderModelInfo(modelName, providerName)
+	modelInfo, err := config.GetProviderModelInfo(modelName, providerName)
 	if err != nil {
 		return nil, nil, fmt.Errorf("model %q not available on provider %q: %w", modelName, providerName, err)
 	}
@@ -86,9 +137,11 @@ func (r *Router) getProviderForModel(modelName, providerName string) (providers.
 
 // tryFallbackProviders attempts to find an alternative provider
 func (r *Router) tryFallbackProviders(ctx context.Context, modelName, excludeProvider string) (providers.Provider, *ProviderModelInfo, error) {
-	fallbackProviders := r.config.GetFallbackProviders()
+	config := r.GetConfig()
+	fallbackProviders := config.GetFallbackProviders()
 	attempts := 0
-	maxAttempts := r.config.Routing.Fallback.MaxAttempts
+	maxAttempts := config.Routing.Fallback.MaxAttempts
+	circuitOpen := false
 
 	for _, providerName := range fallbackProviders {
 		// Skip the failed provider
@@ -103,21 +156,27 @@ func (r *Router) tryFallbackProviders(ctx context.Context, modelName, excludePro
 		attempts++
 
 		// Try this fallback provider
-		provider, modelInfo, err := r.getProviderForModel(modelName, providerName)
+		provider, modelInfo, err := r.getAvailableProvider(ctx, modelName, providerName)
 		if err == nil {
 			log.Printf("Successfully failed over to provider %q for model %q", providerName, modelName)
 			return provider, modelInfo, nil
 		}
+		if errors.Is(err, ErrCircuitOpen) {
+			circuitOpen = true
+		}
 
 		log.Printf("Fallback provider %q also failed for model %q: %v", providerName, modelName, err)
 	}
 
+	if circuitOpen {
+		return nil, nil, fmt.Errorf("all fallback providers exhausted for model %q: %w", modelName, ErrCircuitOpen)
+	}
 	return nil, nil, fmt.Errorf("all fallback providers exhausted for model %q", modelName)
 }
 
 // GetProvider gets a provider by name
 func (r *Router) GetProvider(providerName string) (providers.Provider, error) {
-	if !r.config.IsProviderEnabled(providerName) {
+	if !r.GetConfig().IsProviderEnabled(providerName) {
 		return nil, fmt.Errorf("provider %q is disabled", providerName)
 	}
 
@@ -129,14 +188,21 @@ func (r *Router) GetProvider(providerName string) (providers.Provider, error) {
 	return provider, nil
 }
 
-// ListModels lists all available models across all enabled providers
+// ListModels lists all available models across all enabled providers that
+// the request's TargetFilter allows
 func (r *Router) ListModels(ctx context.Context) ([]providers.Model, error) {
 	var allModels []providers.Model
 
 	// Get models from configuration
-	for modelName, mapping := range r.config.ModelMappings {
+	config := r.GetConfig()
+	for modelName, mapping := range config.ModelMappings {
 		// Only include models whose default provider is enabled
-		if !r.config.IsProviderEnabled(mapping.DefaultProvider) {
+		if !config.IsProviderEnabled(mapping.DefaultProvider) {
+			continue
+		}
+
+		// Only include models the caller may use
+		if !r.ModelPermitted(ctx, modelName) {
 			continue
 		}
 
@@ -168,8 +234,8 @@ func (r *Router) ListModels(ctx context.Context) ([]providers.Model, error) {
 // GetModelInfo gets information about a specific model
 func (r *Router) GetModelInfo(ctx context.Context, modelName string) (*providers.Model, error) {
 	// Get default provider for the model
-	defaultProvider := r.config.GetDefaultProvider(modelName)
-	if defaultProvider == "" {
+	defaultProvider := r.GetConfig().GetDefaultProvider(modelName)
+	if defaultProvider == "" || !r.ModelPermitted(ctx, modelName) {
 		return nil, fmt.Errorf("model %q not found", modelName)
 	}
 
@@ -183,25 +249,140 @@ func (r *Router) GetModelInfo(ctx context.Context, modelName string) (*providers
 	return provider.GetModelInfo(ctx, modelName)
 }
 
-// HealthCheck performs health checks on all enabled providers
+// HealthCheck performs health checks on all enabled providers concurrently
 func (r *Router) HealthCheck(ctx context.Context) map[string]error {
 	results := make(map[string]error)
 
+	config := r.GetConfig()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
 	for name, provider := range r.providers {
-		if !r.config.IsProviderEnabled(name) {
+		if !config.IsProviderEnabled(name) {
 			continue
 		}
 
-		err := provider.HealthCheck(ctx)
-		results[name] = err
+		wg.Add(1)
+		go func(name string, provider providers.Provider) {
+			defer wg.Done()
+
+			err := provider.HealthCheck(ctx)
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}(name, provider)
 	}
+	wg.Wait()
 
 	return results
 }
 
-// GetConfig returns the router configuration
+// CircuitBreakerStatuses returns the state of every provider and deployment circuit breaker
+func (r *Router) CircuitBreakerStatuses() []CircuitBreakerStatus {
+	return r.breakers.statuses()
+}
+
+// ProviderCircuitState returns the state of a provider-level circuit breaker
+func (r *Router) ProviderCircuitState(providerName string) CircuitState {
+	if !r.breakers.config.Enabled {
+		return CircuitClosed
+	}
+	return r.breakers.get(providerName, "").State()
+}
+
+// ModelPricing returns the prices of a provider deployment, from model-mapping.yaml
+// if set and otherwise from the provider's model catalog. It returns nil when
+// the price is unknown.
+func (r *Router) ModelPricing(ctx context.Context, provider providers.Provider, modelInfo *ProviderModelInfo) *providers.Model {
+	if modelInfo == nil {
+		return nil
+	}
+	return r.balancer.pricing(ctx, balanceTarget{name: provider.Name(), provider: provider, modelInfo: modelInfo})
+}
+
+// ModelOutputPrice returns the lowest known output price per 1M tokens among
+// the providers that can currently serve modelName
+func (r *Router) ModelOutputPrice(ctx context.Context, modelName string) (float64, bool) {
+	var lowest float64
+	found := false
+	for _, target := range r.balanceTargets(ctx, modelName) {
+		pricing := r.balancer.pricing(ctx, target)
+		if pricing == nil {
+			continue
+		}
+		if !found || pricing.OutputPrice < lowest {
+			lowest, found = pricing.OutputPrice, true
+		}
+	}
+	return lowest, found
+}
+
+// ModelTargets returns the deployments configured for a model on enabled
+// providers, default provider first
+func (r *Router) ModelTargets(modelName string) []Target {
+	config := r.GetConfig()
+	mapping, exists := config.ModelMappings[modelName]
+	if !exists {
+		return nil
+	}
+
+	var targets []Target
+	for name, info := range mapping.Providers {
+		if !config.IsProviderEnabled(name) {
+			continue
+		}
+		targets = append(targets, Target{Provider: name, Model: info.Model})
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if (targets[i].Provider == mapping.DefaultProvider) != (targets[j].Provider == mapping.DefaultProvider) {
+			return targets[i].Provider == mapping.DefaultProvider
+		}
+		return targets[i].Provider < targets[j].Provider
+	})
+	return targets
+}
+
+// PrimaryTarget returns the first of a model's deployments that the
+// request's TargetFilter allows, which serves the request unless it fails
+func (r *Router) PrimaryTarget(ctx context.Context, modelName string) (Target, bool) {
+	filter, filtered := ctx.Value(targetFilterKey{}).(TargetFilter)
+	for _, target := range r.ModelTargets(modelName) {
+		if !filtered || filter(modelName, target.Provider, target.Model) {
+			return target, true
+		}
+	}
+	return Target{}, false
+}
+
+// ModelPermitted reports whether the request's TargetFilter allows at least
+// one of the model's deployments
+func (r *Router) ModelPermitted(ctx context.Context, modelName string) bool {
+	filter, ok := ctx.Value(targetFilterKey{}).(TargetFilter)
+	if !ok {
+		return true
+	}
+	for _, target := range r.ModelTargets(modelName) {
+		if filter(modelName, target.Provider, target.Model) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetConfig returns the current router configuration. Callers should read
+// it once per request so a concurrent UpdateConfig does not mix two versions.
 func (r *Router) GetConfig() *Config {
-	return r.config
+	return r.config.Load()
+}
+
+// UpdateConfig validates a configuration and swaps it in for requests routed
+// from then on, returning the configuration it replaced. Requests in flight
+// finish with the configuration they started with. Circuit breaker settings
+// only take effect on restart.
+func (r *Router) UpdateConfig(config *Config) (*Config, error) {
+	if err := config.ValidateConfig(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return r.config.Swap(config), nil
 }
 
 // RegisterProvider registers a new provider (useful for testing)
@@ -0,0 +1,62 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package router
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+)
+
+func TestUpdateConfig(t *testing.T) {
+	registry := map[string]providers.Provider{
+		"a": &fakeProvider{name: "a"},
+		"b": &fakeProvider{name: "b"},
+	}
+	newConfig := func(defaultProvider string) *Config {
+		return &Config{
+			ModelMappings: map[string]ModelMapping{
+				"test-model": {DefaultProvider: defaultProvider, Providers: map[string]ProviderModelInfo{
+					"a": {Model: "model-a"},
+					"b": {Model: "model-b"},
+				}},
+			},
+			Providers: map[string]ProviderConfig{
+				"a": {Enabled: true},
+				"b": {Enabled: true},
+			},
+		}
+	}
+	initial := newConfig("a")
+	r, err := NewRouter(initial, registry)
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+
+	t.Run("Invalid config is rejected", func(t *testing.T) {
+		if _, err := r.UpdateConfig(newConfig("missing")); err == nil {
+			t.Fatal("Expected an invalid config to be rejected")
+		}
+		if r.GetConfig() != initial {
+			t.Error("Expected the current config to be kept")
+		}
+	})
+
+	t.Run("Valid config is swapped in", func(t *testing.T) {
+		updated := newConfig("b")
+		previous, err := r.UpdateConfig(updated)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if previous != initial || r.GetConfig() != updated {
+			t.Fatal("Expected the new config to replace the initial one")
+		}
+
+		provider, _, err := r.RouteRequest(context.Background(), "test-model", "")
+		if err != nil || provider.Name() != "b" {
+			t.Errorf("Expected requests to route to the new default provider, got %v, %v", provider, err)
+		}
+	})
+}
@@ -0,0 +1,39 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package router
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTargetNotPermitted is returned when the request's TargetFilter refuses a provider deployment
+var ErrTargetNotPermitted = errors.New("target not permitted")
+
+// Target is a provider deployment that can serve a model
+type Target struct {
+	Provider string
+	Model    string // provider model ID
+}
+
+// TargetFilter reports whether a request for modelName may be served by
+// provider's deployment providerModel
+type TargetFilter func(modelName, provider, providerModel string) bool
+
+type targetFilterKey struct{}
+
+// WithTargetFilter returns a context whose requests are only routed to
+// targets the filter allows
+func WithTargetFilter(ctx context.Context, filter TargetFilter) context.Context {
+	return context.WithValue(ctx, targetFilterKey{}, filter)
+}
+
+// targetPermitted applies the context's TargetFilter, if any
+func targetPermitted(ctx context.Context, modelName, provider string, modelInfo *ProviderModelInfo) bool {
+	filter, ok := ctx.Value(targetFilterKey{}).(TargetFilter)
+	if !ok {
+		return true
+	}
+	return filter(modelName, provider, modelInfo.Model)
+}
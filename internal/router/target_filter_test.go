@@ -0,0 +1,103 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+)
+
+func TestTargetFilter(t *testing.T) {
+	registry := map[string]providers.Provider{
+		"a": &fakeProvider{name: "a"},
+		"b": &fakeProvider{name: "b"},
+	}
+	config := &Config{
+		ModelMappings: map[string]ModelMapping{
+			"test-model": {DefaultProvider: "a", Providers: map[string]ProviderModelInfo{
+				"a": {Model: "model-a"},
+				"b": {Model: "model-b"},
+			}},
+			"other-model": {DefaultProvider: "a", Providers: map[string]ProviderModelInfo{
+				"a": {Model: "other-a"},
+			}},
+		},
+		Providers: map[string]ProviderConfig{
+			"a": {Enabled: true},
+			"b": {Enabled: true},
+		},
+	}
+	r, err := NewRouter(config, registry)
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+
+	onlyB := WithTargetFilter(context.Background(), func(modelName, provider, providerModel string) bool {
+		return provider == "b"
+	})
+
+	t.Run("ModelTargets lists default first", func(t *testing.T) {
+		targets := r.ModelTargets("test-model")
+		if len(targets) != 2 || targets[0] != (Target{Provider: "a", Model: "model-a"}) || targets[1].Provider != "b" {
+			t.Errorf("Unexpected targets %+v", targets)
+		}
+	})
+
+	t.Run("PrimaryTarget applies the filter", func(t *testing.T) {
+		if target, ok := r.PrimaryTarget(context.Background(), "test-model"); !ok || target.Provider != "a" {
+			t.Errorf("Expected provider a, got %+v", target)
+		}
+		if target, ok := r.PrimaryTarget(onlyB, "test-model"); !ok || target != (Target{Provider: "b", Model: "model-b"}) {
+			t.Errorf("Expected provider b, got %+v", target)
+		}
+		if _, ok := r.PrimaryTarget(onlyB, "other-model"); ok {
+			t.Error("Expected no permitted target")
+		}
+	})
+
+	t.Run("Routes around a refused default provider", func(t *testing.T) {
+		provider, modelInfo, err := r.RouteRequest(onlyB, "test-model", "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if provider.Name() != "b" || modelInfo.Model != "model-b" {
+			t.Errorf("Expected provider b, got %s (%s)", provider.Name(), modelInfo.Model)
+		}
+	})
+
+	t.Run("No permitted target", func(t *testing.T) {
+		_, _, err := r.Invoke(onlyB, "other-model", func(provider providers.Provider, modelInfo *ProviderModelInfo) (*providers.ProviderRequest, error) {
+			return &providers.ProviderRequest{}, nil
+		})
+
+		var providerErr *providers.ProviderError
+		if !errors.As(err, &providerErr) || providerErr.StatusCode != http.StatusForbidden || providerErr.Code != providers.ErrCodePermissionDenied {
+			t.Fatalf("Expected 403 permission error, got %v", err)
+		}
+		if !errors.Is(err, ErrTargetNotPermitted) {
+			t.Errorf("Expected ErrTargetNotPermitted, got %v", err)
+		}
+	})
+
+	t.Run("ListModels and GetModelInfo", func(t *testing.T) {
+		models, err := r.ListModels(onlyB)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(models) != 1 {
+			t.Errorf("Expected only test-model to be listed, got %+v", models)
+		}
+
+		if _, err := r.GetModelInfo(onlyB, "other-model"); err == nil {
+			t.Error("Expected refused model to be reported as not found")
+		}
+		if !r.ModelPermitted(context.Background(), "other-model") {
+			t.Error("Models should be permitted without a filter")
+		}
+	})
+}
@@ -4,9 +4,11 @@
 package storage
 
 import (
+	"container/list"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,22 +16,43 @@ import (
 	"time"
 )
 
+// ErrDocumentTooLarge is returned when a document exceeds the fetcher's size limit
+var ErrDocumentTooLarge = errors.New("document exceeds the size limit")
+
 // DocumentFetcher fetches and caches documents for RAG
 type DocumentFetcher struct {
 	httpClient *http.Client
 	cache      *DocumentCache
+	maxSize    int64 // 0 means unlimited
 }
 
 // NewDocumentFetcher creates a new document fetcher
 func NewDocumentFetcher(cacheTTL time.Duration) *DocumentFetcher {
+	return NewDocumentFetcherWithClient(&http.Client{
+		Timeout: 30 * time.Second,
+	}, cacheTTL)
+}
+
+// NewDocumentFetcherWithClient creates a document fetcher that uses the given HTTP client
+func NewDocumentFetcherWithClient(client *http.Client, cacheTTL time.Duration) *DocumentFetcher {
 	return &DocumentFetcher{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		cache: NewDocumentCache(cacheTTL),
+		httpClient: client,
+		cache:      NewDocumentCache(cacheTTL),
 	}
 }
 
+// SetMaxSize limits the size of fetched documents; larger documents fail
+// with ErrDocumentTooLarge
+func (f *DocumentFetcher) SetMaxSize(maxSize int64) {
+	f.maxSize = maxSize
+}
+
+// SetCacheMaxBytes limits the total size of cached documents, evicting the
+// least recently used ones first
+func (f *DocumentFetcher) SetCacheMaxBytes(maxBytes int64) {
+	f.cache.SetMaxBytes(maxBytes)
+}
+
 // FetchDocument retrieves a document from a URL (typically a presigned URL)
 func (f *DocumentFetcher) FetchDocument(ctx context.Context, url string) (*Document, error) {
 	// Check cache first
@@ -53,11 +76,21 @@ func (f *DocumentFetcher) FetchDocument(ctx context.Context, url string) (*Docum
 		return nil, fmt.Errorf("failed to fetch document: HTTP %d", resp.StatusCode)
 	}
 
-	// Read document content
-	content, err := io.ReadAll(resp.Body)
+	// Read document content, stopping once the size limit is exceeded
+	body := io.Reader(resp.Body)
+	if f.maxSize > 0 {
+		if resp.ContentLength > f.maxSize {
+			return nil, ErrDocumentTooLarge
+		}
+		body = io.LimitReader(resp.Body, f.maxSize+1)
+	}
+	content, err := io.ReadAll(body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read document: %w", err)
 	}
+	if f.maxSize > 0 && int64(len(content)) > f.maxSize {
+		return nil, ErrDocumentTooLarge
+	}
 
 	// Create document
 	doc := &Document{
@@ -88,14 +121,19 @@ type Document struct {
 	FetchedAt   time.Time
 }
 
-// DocumentCache caches fetched documents
+// DocumentCache caches fetched documents, optionally within a byte budget
+// that evicts the least recently used documents first
 type DocumentCache struct {
-	mu      sync.RWMutex
-	entries map[string]*cacheEntry
-	ttl     time.Duration
+	mu       sync.Mutex
+	entries  map[string]*list.Element
+	lru      *list.List // front is most recently used
+	ttl      time.Duration
+	maxBytes int64 // 0 means unlimited
+	size     int64
 }
 
 type cacheEntry struct {
+	url       string
 	document  *Document
 	expiresAt time.Time
 }
@@ -103,7 +141,8 @@ type cacheEntry struct {
 // NewDocumentCache creates a new document cache
 func NewDocumentCache(ttl time.Duration) *DocumentCache {
 	cache := &DocumentCache{
-		entries: make(map[string]*cacheEntry),
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
 		ttl:     ttl,
 	}
 
@@ -113,33 +152,56 @@ func NewDocumentCache(ttl time.Duration) *DocumentCache {
 	return cache
 }
 
+// SetMaxBytes limits the total content size of cached documents
+func (c *DocumentCache) SetMaxBytes(maxBytes int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.maxBytes = maxBytes
+	c.evict()
+}
+
 // Get retrieves a document from cache
 func (c *DocumentCache) Get(url string) *Document {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	entry, exists := c.entries[url]
+	element, exists := c.entries[url]
 	if !exists {
 		return nil
 	}
 
 	// Check if expired
+	entry := element.Value.(*cacheEntry)
 	if time.Now().After(entry.expiresAt) {
+		c.remove(element)
 		return nil
 	}
 
+	c.lru.MoveToFront(element)
 	return entry.document
 }
 
-// Set stores a document in cache
+// Set stores a document in cache. Documents larger than the whole budget are
+// not stored.
 func (c *DocumentCache) Set(url string, doc *Document) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.entries[url] = &cacheEntry{
+	if element, exists := c.entries[url]; exists {
+		c.remove(element)
+	}
+	if c.maxBytes > 0 && doc.Size > c.maxBytes {
+		return
+	}
+
+	c.entries[url] = c.lru.PushFront(&cacheEntry{
+		url:       url,
 		document:  doc,
 		expiresAt: time.Now().Add(c.ttl),
-	}
+	})
+	c.size += doc.Size
+	c.evict()
 }
 
 // Delete removes a document from cache
@@ -147,7 +209,9 @@ func (c *DocumentCache) Delete(url string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	delete(c.entries, url)
+	if element, exists := c.entries[url]; exists {
+		c.remove(element)
+	}
 }
 
 // Clear removes all documents from cache
@@ -155,17 +219,42 @@ func (c *DocumentCache) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.entries = make(map[string]*cacheEntry)
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.size = 0
 }
 
 // Size returns the number of cached documents
 func (c *DocumentCache) Size() int {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	return len(c.entries)
 }
 
+// Bytes returns the total content size of cached documents
+func (c *DocumentCache) Bytes() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.size
+}
+
+// evict drops the least recently used documents until the cache fits its
+// budget (caller holds mu)
+func (c *DocumentCache) evict() {
+	for c.maxBytes > 0 && c.size > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove drops an entry (caller holds mu)
+func (c *DocumentCache) remove(element *list.Element) {
+	entry := c.lru.Remove(element).(*cacheEntry)
+	delete(c.entries, entry.url)
+	c.size -= entry.document.Size
+}
+
 // cleanupLoop periodically removes expired entries
 func (c *DocumentCache) cleanupLoop() {
 	ticker := time.NewTicker(5 * time.Minute)
@@ -182,9 +271,9 @@ func (c *DocumentCache) cleanup() {
 	defer c.mu.Unlock()
 
 	now := time.Now()
-	for url, entry := range c.entries {
-		if now.After(entry.expiresAt) {
-			delete(c.entries, url)
+	for _, element := range c.entries {
+		if now.After(element.Value.(*cacheEntry).expiresAt) {
+			c.remove(element)
 		}
 	}
 }
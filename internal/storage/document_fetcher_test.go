@@ -0,0 +1,46 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDocumentCacheEviction(t *testing.T) {
+	document := func(url string, size int) *Document {
+		return &Document{URL: url, Content: make([]byte, size), Size: int64(size)}
+	}
+
+	cache := NewDocumentCache(time.Minute)
+	cache.SetMaxBytes(100)
+	cache.Set("a", document("a", 40))
+	cache.Set("b", document("b", 40))
+	cache.Get("a") // b is now the least recently used
+	cache.Set("c", document("c", 40))
+
+	if cache.Get("b") != nil {
+		t.Error("Expected the least recently used document to be evicted")
+	}
+	if cache.Get("a") == nil || cache.Get("c") == nil {
+		t.Error("Expected the recently used documents to be kept")
+	}
+	if cache.Bytes() != 80 || cache.Size() != 2 {
+		t.Errorf("Bytes() = %d, Size() = %d, want 80 and 2", cache.Bytes(), cache.Size())
+	}
+
+	cache.Set("large", document("large", 101))
+	if cache.Get("large") != nil || cache.Bytes() != 80 {
+		t.Error("Expected a document larger than the budget not to be cached")
+	}
+
+	cache.Set("a", document("a", 10))
+	if cache.Bytes() != 50 {
+		t.Errorf("Bytes() = %d after replacing a document, want 50", cache.Bytes())
+	}
+	cache.Clear()
+	if cache.Bytes() != 0 || cache.Size() != 0 {
+		t.Errorf("Bytes() = %d, Size() = %d after Clear, want 0", cache.Bytes(), cache.Size())
+	}
+}
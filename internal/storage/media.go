@@ -0,0 +1,358 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // register GIF for image.DecodeConfig
+	_ "image/jpeg" // register JPEG for image.DecodeConfig
+	_ "image/png"  // register PNG for image.DecodeConfig
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Media limits default to the strictest provider (Bedrock: 3.75 MB and 8000px
+// per side for images, 4.5 MB for documents)
+const (
+	defaultMediaMaxBytes     = 3932160
+	defaultDocumentMaxBytes  = 4718592
+	defaultMediaMaxDimension = 8000
+	defaultMediaFetchTimeout = 10 * time.Second
+	defaultMediaCacheTTL     = 5 * time.Minute
+	defaultMediaCacheBytes   = 64 << 20
+)
+
+// supportedImageTypes are the image formats every multimodal provider accepts
+var supportedImageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// MediaConfig controls how image and document references are fetched and inlined
+type MediaConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	MaxBytes         int64         `yaml:"max_bytes"` // per image
+	MaxWidth         int           `yaml:"max_width"`
+	MaxHeight        int           `yaml:"max_height"`
+	MaxDocumentBytes int64         `yaml:"max_document_bytes"`
+	FetchTimeout     time.Duration `yaml:"fetch_timeout"`
+	CacheTTL         time.Duration `yaml:"cache_ttl"`
+	CacheMaxBytes    int64         `yaml:"cache_max_bytes"` // fetched https content kept across requests
+
+	// Allow https:// URLs that resolve to loopback, private or link-local addresses
+	AllowPrivateNetworks bool `yaml:"allow_private_networks"`
+
+	// Object storage locations clients may reference, as scheme://bucket/prefix
+	// (s3://uploads/images/). Objects are read with the gateway's own
+	// credentials, so references outside these locations are refused; with no
+	// entries, object storage references are refused altogether.
+	AllowedObjects []string `yaml:"allowed_objects,omitempty"`
+
+	// Region of the S3 client used for s3:// references
+	S3Region string `yaml:"s3_region,omitempty"`
+}
+
+// MediaInfo describes a resolved media reference
+type MediaInfo struct {
+	Kind      string        `json:"kind"` // image or document
+	URL       string        `json:"url"`  // without query string, which may hold credentials
+	Scheme    string        `json:"scheme"`
+	MediaType string        `json:"media_type"`
+	Size      int64         `json:"size"`
+	Width     int           `json:"width"`
+	Height    int           `json:"height"`
+	SHA256    string        `json:"sha256"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// Media is a resolved media reference with its content
+type Media struct {
+	MediaInfo
+	Data []byte
+}
+
+// DataURL returns the media as a base64 data URL
+func (m *Media) DataURL() string {
+	return "data:" + m.MediaType + ";base64," + base64.StdEncoding.EncodeToString(m.Data)
+}
+
+// MediaResolver fetches images and documents referenced by https:// URLs or
+// object storage URLs (s3://bucket/key), checks their type and limits, and
+// returns their content for inlining
+type MediaResolver struct {
+	config  MediaConfig
+	fetcher *DocumentFetcher
+	storage map[string]StorageProvider // keyed by URL scheme
+}
+
+// NewMediaResolver creates a media resolver; storage providers are keyed by
+// the URL scheme they serve (s3)
+func NewMediaResolver(config MediaConfig, storageProviders map[string]StorageProvider) *MediaResolver {
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = defaultMediaMaxBytes
+	}
+	if config.MaxWidth <= 0 {
+		config.MaxWidth = defaultMediaMaxDimension
+	}
+	if config.MaxHeight <= 0 {
+		config.MaxHeight = defaultMediaMaxDimension
+	}
+	if config.MaxDocumentBytes <= 0 {
+		config.MaxDocumentBytes = defaultDocumentMaxBytes
+	}
+	if config.FetchTimeout <= 0 {
+		config.FetchTimeout = defaultMediaFetchTimeout
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = defaultMediaCacheTTL
+	}
+	if config.CacheMaxBytes <= 0 {
+		config.CacheMaxBytes = defaultMediaCacheBytes
+	}
+
+	dialer := &net.Dialer{Timeout: config.FetchTimeout}
+	if !config.AllowPrivateNetworks {
+		dialer.Control = publicAddressOnly
+	}
+	client := &http.Client{
+		Timeout:   config.FetchTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: config.FetchTimeout},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "https" {
+				return fmt.Errorf("refusing redirect to %s URL", req.URL.Scheme)
+			}
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			return nil
+		},
+	}
+	fetcher := NewDocumentFetcherWithClient(client, config.CacheTTL)
+	fetcher.SetMaxSize(max(config.MaxBytes, config.MaxDocumentBytes))
+	fetcher.SetCacheMaxBytes(config.CacheMaxBytes)
+
+	return &MediaResolver{
+		config:  config,
+		fetcher: fetcher,
+		storage: storageProviders,
+	}
+}
+
+// Resolve fetches an image reference and validates it
+func (r *MediaResolver) Resolve(ctx context.Context, rawURL string) (*Media, error) {
+	start := time.Now()
+	u, data, _, err := r.fetch(ctx, rawURL, r.config.MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	mediaType := http.DetectContentType(data)
+	if !supportedImageTypes[mediaType] {
+		return nil, fmt.Errorf("unsupported image type %s; expected PNG, JPEG, GIF or WebP", mediaType)
+	}
+	width, height, err := imageDimensions(data, mediaType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s image: %w", mediaType, err)
+	}
+	if width > r.config.MaxWidth || height > r.config.MaxHeight {
+		return nil, fmt.Errorf("image is %dx%d pixels; the limit is %dx%d", width, height, r.config.MaxWidth, r.config.MaxHeight)
+	}
+
+	media := newMedia("image", u, mediaType, data, start)
+	media.Width = width
+	media.Height = height
+	return media, nil
+}
+
+// ResolveDocument fetches a document reference. Its media type is the one
+// reported by the server or object store, or sniffed from the content.
+func (r *MediaResolver) ResolveDocument(ctx context.Context, rawURL string) (*Media, error) {
+	start := time.Now()
+	u, data, contentType, err := r.fetch(ctx, rawURL, r.config.MaxDocumentBytes)
+	if err != nil {
+		return nil, err
+	}
+	if contentType == "" || contentType == "application/octet-stream" || contentType == "binary/octet-stream" {
+		contentType = http.DetectContentType(data)
+	}
+	return newMedia("document", u, contentType, data, start), nil
+}
+
+// fetch reads a reference over https or from the storage provider for its
+// scheme, enforcing maxBytes, and returns the reported content type
+func (r *MediaResolver) fetch(ctx context.Context, rawURL string, maxBytes int64) (*url.URL, []byte, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("invalid URL: %w", err)
+	}
+
+	var data []byte
+	var contentType string
+	switch u.Scheme {
+	case "https":
+		doc, err := r.fetcher.FetchDocument(ctx, rawURL)
+		if err != nil {
+			return nil, nil, "", sizeError(err, maxBytes)
+		}
+		data, contentType = doc.Content, doc.ContentType
+	case "http":
+		return nil, nil, "", errors.New("URLs must use https")
+	default:
+		provider, ok := r.storage[u.Scheme]
+		if !ok {
+			return nil, nil, "", fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+		}
+		data, contentType, err = r.getObject(ctx, provider, u, maxBytes)
+		if err != nil {
+			return nil, nil, "", err
+		}
+	}
+
+	if int64(len(data)) > maxBytes {
+		return nil, nil, "", sizeError(ErrDocumentTooLarge, maxBytes)
+	}
+	return u, data, contentType, nil
+}
+
+// newMedia describes fetched content, dropping credentials from its URL
+func newMedia(kind string, u *url.URL, mediaType string, data []byte, start time.Time) *Media {
+	hash := sha256.Sum256(data)
+	redacted := *u
+	redacted.RawQuery = ""
+	redacted.Fragment = ""
+	redacted.User = nil
+	return &Media{
+		MediaInfo: MediaInfo{
+			Kind:      kind,
+			URL:       redacted.String(),
+			Scheme:    u.Scheme,
+			MediaType: mediaType,
+			Size:      int64(len(data)),
+			SHA256:    hex.EncodeToString(hash[:]),
+			Duration:  time.Since(start),
+		},
+		Data: data,
+	}
+}
+
+// getObject reads an object referenced as scheme://bucket/key
+func (r *MediaResolver) getObject(ctx context.Context, provider StorageProvider, u *url.URL, maxBytes int64) ([]byte, string, error) {
+	bucket := u.Host
+	key := u.Path
+	if len(key) > 0 && key[0] == '/' {
+		key = key[1:]
+	}
+	if bucket == "" || key == "" {
+		return nil, "", fmt.Errorf("URL must have the form %s://bucket/key", u.Scheme)
+	}
+	if !r.objectAllowed(u.Scheme, bucket, key) {
+		return nil, "", fmt.Errorf("%s://%s/%s is not in an allowed object storage location", u.Scheme, bucket, key)
+	}
+
+	resp, err := provider.GetObject(ctx, &GetObjectRequest{Bucket: bucket, Key: key})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get %s://%s/%s: %w", u.Scheme, bucket, key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.ContentLength > maxBytes {
+		return nil, "", sizeError(ErrDocumentTooLarge, maxBytes)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s://%s/%s: %w", u.Scheme, bucket, key, err)
+	}
+	return data, resp.ContentType, nil
+}
+
+// objectAllowed reports whether an object lies under one of the configured
+// allowed locations. An entry without a key prefix allows the whole bucket.
+func (r *MediaResolver) objectAllowed(scheme, bucket, key string) bool {
+	for _, entry := range r.config.AllowedObjects {
+		allowed, err := url.Parse(entry)
+		if err != nil || allowed.Scheme != scheme || allowed.Host != bucket {
+			continue
+		}
+		if strings.HasPrefix(key, strings.TrimPrefix(allowed.Path, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// sizeError adds the limit to size limit errors
+func sizeError(err error, maxBytes int64) error {
+	if errors.Is(err, ErrDocumentTooLarge) {
+		return fmt.Errorf("content exceeds the %d byte limit", maxBytes)
+	}
+	return err
+}
+
+// imageDimensions returns the width and height of a sniffed image
+func imageDimensions(data []byte, mediaType string) (int, int, error) {
+	if mediaType == "image/webp" {
+		return webpDimensions(data)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, err
+	}
+	return config.Width, config.Height, nil
+}
+
+// webpDimensions reads the canvas size from a WebP header (VP8, VP8L or VP8X)
+func webpDimensions(data []byte) (int, int, error) {
+	if len(data) < 30 {
+		return 0, 0, errors.New("truncated WebP header")
+	}
+	chunk := data[12:]
+	switch string(chunk[:4]) {
+	case "VP8 ":
+		// Lossy: 14-bit dimensions after the frame tag and start code
+		width := int(binary.LittleEndian.Uint16(chunk[14:16]) & 0x3fff)
+		height := int(binary.LittleEndian.Uint16(chunk[16:18]) & 0x3fff)
+		return width, height, nil
+	case "VP8L":
+		// Lossless: 14-bit width-1 and height-1 after the signature byte
+		bits := binary.LittleEndian.Uint32(chunk[9:13])
+		return int(bits&0x3fff) + 1, int((bits>>14)&0x3fff) + 1, nil
+	case "VP8X":
+		// Extended: 24-bit canvas width-1 and height-1
+		width := int(chunk[12]) | int(chunk[13])<<8 | int(chunk[14])<<16
+		height := int(chunk[15]) | int(chunk[16])<<8 | int(chunk[17])<<16
+		return width + 1, height + 1, nil
+	default:
+		return 0, 0, fmt.Errorf("unknown WebP chunk %q", chunk[:4])
+	}
+}
+
+// publicAddressOnly refuses connections to loopback, private, link-local and
+// unspecified addresses, so image URLs cannot reach internal services
+func publicAddressOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return fmt.Errorf("refusing to fetch from non-public address %s", host)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeStorage serves every object with the same content and records the keys read
+type fakeStorage struct {
+	StorageProvider
+	content []byte
+	reads   []string
+}
+
+func (s *fakeStorage) GetObject(ctx context.Context, req *GetObjectRequest) (*GetObjectResponse, error) {
+	s.reads = append(s.reads, req.Bucket+"/"+req.Key)
+	return &GetObjectResponse{
+		Body:          io.NopCloser(bytes.NewReader(s.content)),
+		ContentType:   "text/plain",
+		ContentLength: int64(len(s.content)),
+	}, nil
+}
+
+func TestResolveObjectAllowList(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		url     string
+		valid   bool
+	}{
+		{"No allowed locations", nil, "s3://uploads/report.txt", false},
+		{"Allowed prefix", []string{"s3://uploads/docs/"}, "s3://uploads/docs/report.txt", true},
+		{"Outside prefix", []string{"s3://uploads/docs/"}, "s3://uploads/secrets/report.txt", false},
+		{"Whole bucket", []string{"s3://uploads"}, "s3://uploads/secrets/report.txt", true},
+		{"Bucket name prefix", []string{"s3://uploads"}, "s3://uploads-private/report.txt", false},
+		{"Other bucket", []string{"s3://uploads/docs/"}, "s3://config/docs/report.txt", false},
+		{"Other scheme", []string{"gs://uploads/docs/"}, "s3://uploads/docs/report.txt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakeStorage{content: []byte("quarterly report")}
+			r := NewMediaResolver(MediaConfig{AllowedObjects: tt.allowed}, map[string]StorageProvider{"s3": provider})
+			media, err := r.ResolveDocument(context.Background(), tt.url)
+			if (err == nil) != tt.valid {
+				t.Fatalf("ResolveDocument(%s) = %v, want valid=%v", tt.url, err, tt.valid)
+			}
+			if !tt.valid {
+				if len(provider.reads) != 0 {
+					t.Errorf("Expected no object reads, got %v", provider.reads)
+				}
+				if !strings.Contains(err.Error(), "not in an allowed object storage location") {
+					t.Errorf("Unexpected error %v", err)
+				}
+				return
+			}
+			if string(media.Data) != "quarterly report" || media.MediaType != "text/plain" {
+				t.Errorf("Unexpected media %+v", media.MediaInfo)
+			}
+		})
+	}
+}
+
+// newTestResolver creates a resolver that trusts server's certificate
+func newTestResolver(t *testing.T, server *httptest.Server, config MediaConfig) *MediaResolver {
+	t.Helper()
+	r := NewMediaResolver(config, nil)
+	transport := r.fetcher.httpClient.Transport.(*http.Transport)
+	transport.TLSClientConfig = server.Client().Transport.(*http.Transport).TLSClientConfig
+	return r
+}
+
+func TestResolveLimits(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	bodies := map[string][]byte{
+		"/small.png": encoded.Bytes(),
+		"/medium":    bytes.Repeat([]byte("a"), 2000),
+		"/large":     bytes.Repeat([]byte("a"), 5000),
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://"+r.Host+"/small.png", http.StatusFound)
+			return
+		}
+		w.Write(bodies[r.URL.Path])
+	}))
+	defer server.Close()
+
+	config := MediaConfig{MaxBytes: 1024, MaxDocumentBytes: 4096, AllowPrivateNetworks: true}
+	tests := []struct {
+		name     string
+		document bool
+		path     string
+		config   MediaConfig
+		err      string
+	}{
+		{"Image within the limit", false, "/small.png", config, ""},
+		{"Image over max_bytes", false, "/medium", config, "exceeds the 1024 byte limit"},
+		{"Document over max_bytes", true, "/medium", config, ""},
+		{"Document over max_document_bytes", true, "/large", config, "exceeds the 4096 byte limit"},
+		{"Redirect to http", false, "/redirect", config, "refusing redirect to http URL"},
+		{"Private network", false, "/small.png", MediaConfig{}, "refusing to fetch from non-public address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestResolver(t, server, tt.config)
+			var media *Media
+			var err error
+			if tt.document {
+				media, err = r.ResolveDocument(context.Background(), server.URL+tt.path)
+			} else {
+				media, err = r.Resolve(context.Background(), server.URL+tt.path)
+			}
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if !bytes.Equal(media.Data, bodies[tt.path]) {
+					t.Errorf("Resolved %d bytes, want %d", len(media.Data), len(bodies[tt.path]))
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+
+	t.Run("Object over max_bytes", func(t *testing.T) {
+		provider := &fakeStorage{content: bodies["/medium"]}
+		r := NewMediaResolver(MediaConfig{MaxBytes: 1024, AllowedObjects: []string{"s3://uploads"}}, map[string]StorageProvider{"s3": provider})
+		if _, err := r.Resolve(context.Background(), "s3://uploads/medium"); err == nil || !strings.Contains(err.Error(), "exceeds the 1024 byte limit") {
+			t.Errorf("Expected a size limit error, got %v", err)
+		}
+	})
+}
+
+func TestPublicAddressOnly(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"127.0.0.1:443", false},
+		{"10.1.2.3:443", false},
+		{"172.16.0.1:443", false},
+		{"192.168.1.1:443", false},
+		{"169.254.169.254:80", false},
+		{"0.0.0.0:443", false},
+		{"[::1]:443", false},
+		{"[fe80::1]:443", false},
+		{"[fd00::1]:443", false},
+		{"[::ffff:127.0.0.1]:443", false},
+		{"[::ffff:10.0.0.1]:443", false},
+		{"[::ffff:169.254.169.254]:80", false},
+		{"localhost:443", false},
+		{"93.184.216.34:443", true},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", true},
+		{"[::ffff:93.184.216.34]:443", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			if err := publicAddressOnly("tcp", tt.address, nil); (err == nil) != tt.allowed {
+				t.Errorf("publicAddressOnly(%s) = %v, want allowed=%v", tt.address, err, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestWebPDimensions(t *testing.T) {
+	// webp builds a RIFF header around a chunk whose payload is padded to the
+	// 30 bytes the parser needs
+	webp := func(chunk string, payload ...byte) []byte {
+		data := append([]byte("RIFF\x00\x00\x00\x00WEBP"+chunk+"\x00\x00\x00\x00"), payload...)
+		for len(data) < 30 {
+			data = append(data, 0)
+		}
+		return data
+	}
+	le16 := func(v uint16) []byte { return binary.LittleEndian.AppendUint16(nil, v) }
+	le32 := func(v uint32) []byte { return binary.LittleEndian.AppendUint32(nil, v) }
+
+	// Lossy: frame tag, start code, then 14-bit dimensions with 2 scale bits
+	vp8 := append([]byte{0, 0, 0, 0x9d, 0x01, 0x2a}, append(le16(640|0xc000), le16(480)...)...)
+	// Lossless: signature, then 14-bit width-1 and height-1
+	vp8l := append([]byte{0x2f}, le32(799|599<<14)...)
+	// Extended: flags and reserved bytes, then 24-bit width-1 and height-1
+	vp8x := []byte{0, 0, 0, 0, 0x3f, 0x1f, 0, 0xff, 0x0f, 0}
+
+	tests := []struct {
+		name          string
+		data          []byte
+		width, height int
+		valid         bool
+	}{
+		{"VP8", webp("VP8 ", vp8...), 640, 480, true},
+		{"VP8L", webp("VP8L", vp8l...), 800, 600, true},
+		{"VP8X", webp("VP8X", vp8x...), 8000, 4096, true},
+		{"Unknown chunk", webp("ALPH"), 0, 0, false},
+		{"Truncated", webp("VP8X", vp8x...)[:29], 0, 0, false},
+		{"Empty", nil, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height, err := webpDimensions(tt.data)
+			if (err == nil) != tt.valid {
+				t.Fatalf("webpDimensions() = %v, want valid=%v", err, tt.valid)
+			}
+			if width != tt.width || height != tt.height {
+				t.Errorf("webpDimensions() = %dx%d, want %dx%d", width, height, tt.width, tt.height)
+			}
+		})
+	}
+}
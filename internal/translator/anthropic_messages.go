@@ -0,0 +1,408 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Anthropic Messages API types, accepted as an inbound protocol and
+// translated to and from the OpenAI types the backends are driven with
+
+// AnthropicMessagesRequest represents an Anthropic Messages API request
+type AnthropicMessagesRequest struct {
+	Model         string               `json:"model"`
+	Messages      []AnthropicMessage   `json:"messages"`
+	System        json.RawMessage      `json:"system,omitempty"` // string or text blocks
+	MaxTokens     int                  `json:"max_tokens"`
+	Temperature   *float64             `json:"temperature,omitempty"`
+	TopP          *float64             `json:"top_p,omitempty"`
+	TopK          *int                 `json:"top_k,omitempty"`
+	StopSequences []string             `json:"stop_sequences,omitempty"`
+	Stream        bool                 `json:"stream,omitempty"`
+	Tools         []AnthropicTool      `json:"tools,omitempty"`
+	ToolChoice    *AnthropicToolChoice `json:"tool_choice,omitempty"`
+	Metadata      *AnthropicMetadata   `json:"metadata,omitempty"`
+}
+
+// AnthropicMessage represents a message in the conversation
+type AnthropicMessage struct {
+	Role    string          `json:"role"`    // user or assistant
+	Content json.RawMessage `json:"content"` // string or content blocks
+}
+
+// AnthropicContentBlock represents a text, image, tool_use or tool_result block
+type AnthropicContentBlock struct {
+	Type   string                `json:"type"`
+	Text   string                `json:"text,omitempty"`
+	Source *AnthropicImageSource `json:"source,omitempty"` // image
+
+	ID    string          `json:"id,omitempty"`    // tool_use
+	Name  string          `json:"name,omitempty"`  // tool_use
+	Input json.RawMessage `json:"input,omitempty"` // tool_use
+
+	ToolUseID string          `json:"tool_use_id,omitempty"` // tool_result
+	Content   json.RawMessage `json:"content,omitempty"`     // tool_result: string or blocks
+	IsError   bool            `json:"is_error,omitempty"`    // tool_result
+}
+
+// AnthropicImageSource represents an inline or linked image
+type AnthropicImageSource struct {
+	Type      string `json:"type"` // base64 or url
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// AnthropicTool represents a tool definition
+type AnthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+// AnthropicToolChoice represents how the model should use tools
+type AnthropicToolChoice struct {
+	Type string `json:"type"` // auto, any, tool or none
+	Name string `json:"name,omitempty"`
+}
+
+// AnthropicMetadata represents request metadata
+type AnthropicMetadata struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+// AnthropicMessagesResponse represents an Anthropic Messages API response
+type AnthropicMessagesResponse struct {
+	ID           string                  `json:"id"`
+	Type         string                  `json:"type"` // message
+	Role         string                  `json:"role"` // assistant
+	Content      []AnthropicContentBlock `json:"content"`
+	Model        string                  `json:"model"`
+	StopReason   *string                 `json:"stop_reason"`
+	StopSequence *string                 `json:"stop_sequence"`
+	Usage        AnthropicUsage          `json:"usage"`
+}
+
+// AnthropicUsage represents token usage
+type AnthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// AnthropicErrorResponse represents an Anthropic API error
+type AnthropicErrorResponse struct {
+	Type  string               `json:"type"` // error
+	Error AnthropicErrorDetail `json:"error"`
+}
+
+// AnthropicErrorDetail contains error details
+type AnthropicErrorDetail struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// NewAnthropicError builds an error of the type Anthropic uses for the status code
+func NewAnthropicError(statusCode int, message string) AnthropicErrorResponse {
+	return AnthropicErrorResponse{
+		Type:  "error",
+		Error: AnthropicErrorDetail{Type: AnthropicErrorType(statusCode), Message: message},
+	}
+}
+
+// AnthropicErrorType maps an HTTP status code to an Anthropic error type
+func AnthropicErrorType(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return "invalid_request_error"
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusPaymentRequired, http.StatusForbidden:
+		return "permission_error"
+	case http.StatusNotFound:
+		return "not_found_error"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case http.StatusServiceUnavailable, 529:
+		return "overloaded_error"
+	default:
+		return "api_error"
+	}
+}
+
+// TranslateAnthropicToOpenAI converts an Anthropic Messages request to OpenAI format
+func TranslateAnthropicToOpenAI(req *AnthropicMessagesRequest) (*ChatCompletionRequest, error) {
+	if req.Model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+	if req.MaxTokens <= 0 {
+		return nil, fmt.Errorf("max_tokens must be greater than zero")
+	}
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("messages must not be empty")
+	}
+
+	openaiReq := &ChatCompletionRequest{
+		Model:     req.Model,
+		MaxTokens: req.MaxTokens,
+		Stop:      req.StopSequences,
+		Stream:    req.Stream,
+	}
+	if req.Temperature != nil {
+		openaiReq.Temperature = *req.Temperature
+	}
+	if req.TopP != nil {
+		openaiReq.TopP = *req.TopP
+	}
+	if req.Metadata != nil {
+		openaiReq.User = req.Metadata.UserID
+	}
+
+	if len(req.System) > 0 {
+		blocks, err := parseAnthropicContent(req.System)
+		if err != nil {
+			return nil, fmt.Errorf("invalid system: %w", err)
+		}
+		if system := anthropicText(blocks); system != "" {
+			openaiReq.Messages = append(openaiReq.Messages, ChatMessage{Role: "system", Content: system})
+		}
+	}
+
+	for i, msg := range req.Messages {
+		blocks, err := parseAnthropicContent(msg.Content)
+		if err != nil {
+			return nil, fmt.Errorf("messages[%d]: %w", i, err)
+		}
+
+		switch msg.Role {
+		case "user":
+			messages, err := translateAnthropicUserMessage(blocks)
+			if err != nil {
+				return nil, fmt.Errorf("messages[%d]: %w", i, err)
+			}
+			openaiReq.Messages = append(openaiReq.Messages, messages...)
+		case "assistant":
+			openaiReq.Messages = append(openaiReq.Messages, translateAnthropicAssistantMessage(blocks))
+		default:
+			return nil, fmt.Errorf("messages[%d]: unsupported role %q", i, msg.Role)
+		}
+	}
+
+	for _, tool := range req.Tools {
+		openaiReq.Tools = append(openaiReq.Tools, Tool{
+			Type: "function",
+			Function: Function{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.InputSchema,
+			},
+		})
+	}
+
+	if req.ToolChoice != nil {
+		switch req.ToolChoice.Type {
+		case "auto", "none":
+			openaiReq.ToolChoice = req.ToolChoice.Type
+		case "any":
+			openaiReq.ToolChoice = "required"
+		case "tool":
+			openaiReq.ToolChoice = map[string]interface{}{
+				"type":     "function",
+				"function": map[string]interface{}{"name": req.ToolChoice.Name},
+			}
+		default:
+			return nil, fmt.Errorf("unsupported tool_choice type %q", req.ToolChoice.Type)
+		}
+	}
+
+	return openaiReq, nil
+}
+
+// translateAnthropicUserMessage splits a user turn into OpenAI tool result
+// messages followed by a user message with the remaining content
+func translateAnthropicUserMessage(blocks []AnthropicContentBlock) ([]ChatMessage, error) {
+	var messages []ChatMessage
+	var parts []interface{}
+
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			parts = append(parts, map[string]interface{}{"type": "text", "text": block.Text})
+		case "image":
+			url, err := anthropicImageURL(block.Source)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, map[string]interface{}{
+				"type":      "image_url",
+				"image_url": map[string]interface{}{"url": url},
+			})
+		case "tool_result":
+			result, err := parseAnthropicContent(block.Content)
+			if err != nil {
+				return nil, fmt.Errorf("invalid tool_result content: %w", err)
+			}
+			content := anthropicText(result)
+			if block.IsError && content == "" {
+				content = "error"
+			}
+			messages = append(messages, ChatMessage{
+				Role:       "tool",
+				Content:    content,
+				ToolCallID: block.ToolUseID,
+			})
+		default:
+			return nil, fmt.Errorf("unsupported content block type %q in user message", block.Type)
+		}
+	}
+
+	if len(parts) == 1 {
+		if part := parts[0].(map[string]interface{}); part["type"] == "text" {
+			return append(messages, ChatMessage{Role: "user", Content: part["text"]}), nil
+		}
+	}
+	if len(parts) > 0 {
+		messages = append(messages, ChatMessage{Role: "user", Content: parts})
+	}
+	return messages, nil
+}
+
+// translateAnthropicAssistantMessage converts text and tool_use blocks; other
+// block types such as thinking are not replayed to the backend
+func translateAnthropicAssistantMessage(blocks []AnthropicContentBlock) ChatMessage {
+	msg := ChatMessage{Role: "assistant"}
+	var text strings.Builder
+
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			arguments := string(block.Input)
+			if arguments == "" || arguments == "null" {
+				arguments = "{}"
+			}
+			msg.ToolCalls = append(msg.ToolCalls, ToolCall{
+				ID:       block.ID,
+				Type:     "function",
+				Function: FunctionCall{Name: block.Name, Arguments: arguments},
+			})
+		}
+	}
+
+	if text.Len() > 0 || len(msg.ToolCalls) == 0 {
+		msg.Content = text.String()
+	}
+	return msg
+}
+
+// TranslateOpenAIToAnthropic converts an OpenAI chat completion to an Anthropic message
+func TranslateOpenAIToAnthropic(resp *ChatCompletionResponse, model, messageID string) *AnthropicMessagesResponse {
+	message := &AnthropicMessagesResponse{
+		ID:      messageID,
+		Type:    "message",
+		Role:    "assistant",
+		Content: []AnthropicContentBlock{},
+		Model:   model,
+	}
+
+	if len(resp.Choices) > 0 {
+		choice := resp.Choices[0]
+		if choice.Message.Content != nil {
+			if text := extractTextContent(choice.Message.Content); text != "" {
+				message.Content = append(message.Content, AnthropicContentBlock{Type: "text", Text: text})
+			}
+		}
+		for _, call := range choice.Message.ToolCalls {
+			message.Content = append(message.Content, AnthropicContentBlock{
+				Type:  "tool_use",
+				ID:    call.ID,
+				Name:  call.Function.Name,
+				Input: toolInput(call.Function.Arguments),
+			})
+		}
+		stopReason := MapFinishReasonToAnthropic(choice.FinishReason)
+		message.StopReason = &stopReason
+	}
+
+	if resp.Usage != nil {
+		message.Usage = AnthropicUsage{
+			InputTokens:  resp.Usage.PromptTokens,
+			OutputTokens: resp.Usage.CompletionTokens,
+		}
+	}
+	return message
+}
+
+// MapFinishReasonToAnthropic maps an OpenAI finish reason to an Anthropic stop reason
+func MapFinishReasonToAnthropic(finishReason string) string {
+	switch finishReason {
+	case "length":
+		return "max_tokens"
+	case "tool_calls", "function_call":
+		return "tool_use"
+	case "content_filter":
+		return "refusal"
+	default:
+		return "end_turn"
+	}
+}
+
+// parseAnthropicContent accepts content given as a plain string or as blocks
+func parseAnthropicContent(raw json.RawMessage) ([]AnthropicContentBlock, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return []AnthropicContentBlock{{Type: "text", Text: text}}, nil
+	}
+
+	var blocks []AnthropicContentBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return nil, fmt.Errorf("content must be a string or an array of content blocks")
+	}
+	return blocks, nil
+}
+
+// anthropicText joins the text blocks of content
+func anthropicText(blocks []AnthropicContentBlock) string {
+	var texts []string
+	for _, block := range blocks {
+		if block.Type == "text" {
+			texts = append(texts, block.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// anthropicImageURL converts an image source to an OpenAI image_url
+func anthropicImageURL(source *AnthropicImageSource) (string, error) {
+	if source == nil {
+		return "", fmt.Errorf("image block has no source")
+	}
+	switch source.Type {
+	case "base64":
+		return fmt.Sprintf("data:%s;base64,%s", source.MediaType, source.Data), nil
+	case "url":
+		return source.URL, nil
+	default:
+		return "", fmt.Errorf("unsupported image source type %q", source.Type)
+	}
+}
+
+// toolInput returns tool call arguments as a JSON object, falling back to an
+// empty object when the model produced invalid JSON
+func toolInput(arguments string) json.RawMessage {
+	if json.Valid([]byte(arguments)) && strings.HasPrefix(strings.TrimSpace(arguments), "{") {
+		return json.RawMessage(arguments)
+	}
+	return json.RawMessage("{}")
+}
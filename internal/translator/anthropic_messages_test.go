@@ -0,0 +1,205 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const anthropicToolConversation = `{
+	"model": "claude-3-5-sonnet",
+	"max_tokens": 1024,
+	"system": [{"type": "text", "text": "You are terse."}],
+	"stop_sequences": ["END"],
+	"tools": [{"name": "get_weather", "description": "Weather lookup", "input_schema": {"type": "object", "properties": {"city": {"type": "string"}}}}],
+	"tool_choice": {"type": "tool", "name": "get_weather"},
+	"messages": [
+		{"role": "user", "content": [
+			{"type": "text", "text": "Weather in Paris and Rome?"},
+			{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "iVBORw0KGgo="}}
+		]},
+		{"role": "assistant", "content": [
+			{"type": "text", "text": "Checking."},
+			{"type": "tool_use", "id": "toolu_1", "name": "get_weather", "input": {"city": "Paris"}},
+			{"type": "tool_use", "id": "toolu_2", "name": "get_weather", "input": {"city": "Rome"}}
+		]},
+		{"role": "user", "content": [
+			{"type": "tool_result", "tool_use_id": "toolu_1", "content": "18C"},
+			{"type": "tool_result", "tool_use_id": "toolu_2", "content": [{"type": "text", "text": "24C"}]},
+			{"type": "text", "text": "Which is warmer?"}
+		]}
+	]
+}`
+
+func TestTranslateAnthropicToOpenAI(t *testing.T) {
+	var req AnthropicMessagesRequest
+	if err := json.Unmarshal([]byte(anthropicToolConversation), &req); err != nil {
+		t.Fatalf("Failed to parse request: %v", err)
+	}
+
+	openaiReq, err := TranslateAnthropicToOpenAI(&req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	roles := make([]string, len(openaiReq.Messages))
+	for i, msg := range openaiReq.Messages {
+		roles[i] = msg.Role
+	}
+	if got := strings.Join(roles, ","); got != "system,user,assistant,tool,tool,user" {
+		t.Fatalf("Unexpected message roles %s", got)
+	}
+
+	image := openaiReq.Messages[1].Content.([]interface{})[1].(map[string]interface{})
+	if url := image["image_url"].(map[string]interface{})["url"]; url != "data:image/png;base64,iVBORw0KGgo=" {
+		t.Errorf("Unexpected image URL %v", url)
+	}
+	assistant := openaiReq.Messages[2]
+	if assistant.Content != "Checking." || len(assistant.ToolCalls) != 2 || assistant.ToolCalls[1].Function.Arguments != `{"city": "Rome"}` {
+		t.Errorf("Unexpected assistant message %+v", assistant)
+	}
+	if result := openaiReq.Messages[4]; result.ToolCallID != "toolu_2" || result.Content != "24C" {
+		t.Errorf("Unexpected tool result %+v", result)
+	}
+	if openaiReq.Stop[0] != "END" || openaiReq.Tools[0].Function.Name != "get_weather" {
+		t.Errorf("Stop sequences or tools not translated: %+v", openaiReq)
+	}
+	choice, _ := openaiReq.ToolChoice.(map[string]interface{})
+	if choice["type"] != "function" {
+		t.Errorf("Unexpected tool_choice %v", openaiReq.ToolChoice)
+	}
+
+	t.Run("Converse", func(t *testing.T) {
+		providerReq, _, err := TranslateOpenAIToConverseAPI(openaiReq)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var converseReq ConverseRequest
+		json.Unmarshal(providerReq.Body, &converseReq)
+
+		// Tool results and the follow-up question share one user turn
+		if len(converseReq.Messages) != 3 {
+			t.Fatalf("Expected 3 alternating messages, got %d", len(converseReq.Messages))
+		}
+		assistant := converseReq.Messages[1].Content
+		if len(assistant) != 3 || assistant[1].ToolUse == nil || assistant[1].ToolUse.Input["city"] != "Paris" {
+			t.Errorf("Unexpected assistant content %+v", assistant)
+		}
+		user := converseReq.Messages[2].Content
+		if len(user) != 3 || user[0].ToolResult == nil || user[1].ToolResult.ToolUseId != "toolu_2" || *user[2].Text != "Which is warmer?" {
+			t.Errorf("Unexpected user content %+v", user)
+		}
+		if converseReq.Messages[0].Content[1].Image == nil {
+			t.Error("Expected the image to be forwarded")
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		requests := []string{
+			`{"model": "m", "messages": [{"role": "user", "content": "hi"}]}`,
+			`{"model": "m", "max_tokens": 10, "messages": [{"role": "system", "content": "hi"}]}`,
+			`{"model": "m", "max_tokens": 10, "messages": [{"role": "user", "content": 42}]}`,
+			`{"model": "m", "max_tokens": 10, "messages": [{"role": "user", "content": "hi"}], "tool_choice": {"type": "sometimes"}}`,
+		}
+		for _, body := range requests {
+			var req AnthropicMessagesRequest
+			json.Unmarshal([]byte(body), &req)
+			if _, err := TranslateAnthropicToOpenAI(&req); err == nil {
+				t.Errorf("Expected an error for %s", body)
+			}
+		}
+	})
+}
+
+func TestTranslateOpenAIToAnthropic(t *testing.T) {
+	resp := &ChatCompletionResponse{
+		Choices: []ChatCompletionChoice{{
+			Message: ChatMessage{
+				Role:      "assistant",
+				ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}}},
+			},
+			FinishReason: "tool_calls",
+		}},
+		Usage: &Usage{PromptTokens: 12, CompletionTokens: 5, TotalTokens: 17},
+	}
+
+	message := TranslateOpenAIToAnthropic(resp, "claude-3-5-sonnet", "msg_1")
+	data, _ := json.Marshal(message)
+	want := `{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"tool_use","id":"call_1","name":"get_weather","input":{"city":"Paris"}}],"model":"claude-3-5-sonnet","stop_reason":"tool_use","stop_sequence":null,"usage":{"input_tokens":12,"output_tokens":5}}`
+	if string(data) != want {
+		t.Errorf("Unexpected message:\n got %s\nwant %s", data, want)
+	}
+
+	if got := NewAnthropicError(429, "slow down"); got.Error.Type != "rate_limit_error" || got.Type != "error" {
+		t.Errorf("Unexpected error shape %+v", got)
+	}
+}
+
+func TestAnthropicStreamWriter(t *testing.T) {
+	zero, one := 0, 1
+	finish := "tool_calls"
+	chunks := []*ChatCompletionStreamResponse{
+		NewChatCompletionChunk("x", "m", 0, ChatMessageDelta{Role: "assistant"}, ""),
+		NewChatCompletionChunk("x", "m", 0, ChatMessageDelta{Content: "Let me "}, ""),
+		NewChatCompletionChunk("x", "m", 0, ChatMessageDelta{Content: "check."}, ""),
+		NewChatCompletionChunk("x", "m", 0, ChatMessageDelta{ToolCalls: []ToolCall{{Index: &zero, ID: "call_1", Function: FunctionCall{Name: "a"}}}}, ""),
+		NewChatCompletionChunk("x", "m", 0, ChatMessageDelta{ToolCalls: []ToolCall{{Index: &zero, Function: FunctionCall{Arguments: `{"x":`}}}}, ""),
+		NewChatCompletionChunk("x", "m", 0, ChatMessageDelta{ToolCalls: []ToolCall{{Index: &zero, Function: FunctionCall{Arguments: `1}`}}}}, ""),
+		NewChatCompletionChunk("x", "m", 0, ChatMessageDelta{ToolCalls: []ToolCall{{Index: &one, ID: "call_2", Function: FunctionCall{Name: "b", Arguments: "{}"}}}}, ""),
+		NewChatCompletionChunk("x", "m", 0, ChatMessageDelta{}, finish),
+		{Choices: []ChatCompletionStreamChoice{}, Usage: &Usage{PromptTokens: 9, CompletionTokens: 4}},
+	}
+
+	var buf bytes.Buffer
+	writer := NewAnthropicStreamWriter(&buf, "msg_1", "claude-3-5-sonnet")
+	for _, chunk := range chunks {
+		if err := writer.WriteChunk(chunk); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	reader := NewSSEReader(&buf)
+	var events []string
+	var last map[string]interface{}
+	for {
+		event, err := reader.Next()
+		if err != nil {
+			break
+		}
+		var payload map[string]interface{}
+		if err := json.Unmarshal([]byte(event.Data), &payload); err != nil || payload["type"] != event.Event {
+			t.Fatalf("Event %s has mismatched data %s", event.Event, event.Data)
+		}
+		name := event.Event
+		if index, ok := payload["index"]; ok {
+			name = fmt.Sprintf("%s/%v", name, index)
+		}
+		events = append(events, name)
+		if event.Event == "message_delta" {
+			last = payload
+		}
+	}
+
+	want := "message_start,ping," +
+		"content_block_start/0,content_block_delta/0,content_block_delta/0,content_block_stop/0," +
+		"content_block_start/1,content_block_delta/1,content_block_delta/1,content_block_stop/1," +
+		"content_block_start/2,content_block_delta/2,content_block_stop/2," +
+		"message_delta,message_stop"
+	if got := strings.Join(events, ","); got != want {
+		t.Errorf("Unexpected events:\n got %s\nwant %s", got, want)
+	}
+
+	delta := last["delta"].(map[string]interface{})
+	usage := last["usage"].(map[string]interface{})
+	if delta["stop_reason"] != "tool_use" || usage["output_tokens"] != float64(4) {
+		t.Errorf("Unexpected message_delta %v", last)
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// AnthropicStreamWriter writes OpenAI chunks as an Anthropic Messages event
+// stream: message_start, one content block per text run or tool call,
+// message_delta with the stop reason and usage, then message_stop.
+type AnthropicStreamWriter struct {
+	w     io.Writer
+	id    string
+	model string
+
+	started    bool
+	blockOpen  bool
+	blockIndex int    // index of the open block, or of the next one when none is open
+	blockType  string // text or tool_use
+	toolCall   int    // OpenAI index of the tool call in the open tool_use block
+	stopReason string
+	usage      AnthropicUsage
+}
+
+// NewAnthropicStreamWriter creates a writer for message id
+func NewAnthropicStreamWriter(w io.Writer, id, model string) *AnthropicStreamWriter {
+	return &AnthropicStreamWriter{w: w, id: id, model: model}
+}
+
+// WriteChunk translates a single OpenAI chunk
+func (s *AnthropicStreamWriter) WriteChunk(chunk *ChatCompletionStreamResponse) error {
+	if err := s.start(); err != nil {
+		return err
+	}
+
+	if chunk.Usage != nil {
+		s.usage = AnthropicUsage{
+			InputTokens:  chunk.Usage.PromptTokens,
+			OutputTokens: chunk.Usage.CompletionTokens,
+		}
+	}
+	if len(chunk.Choices) == 0 {
+		return nil
+	}
+
+	choice := chunk.Choices[0]
+	if choice.Delta.Content != "" {
+		if s.blockType != "text" {
+			if err := s.openBlock(AnthropicContentBlock{Type: "text", Text: ""}); err != nil {
+				return err
+			}
+			s.blockType = "text"
+		}
+		if err := s.writeDelta(map[string]interface{}{"type": "text_delta", "text": choice.Delta.Content}); err != nil {
+			return err
+		}
+	}
+
+	for _, call := range choice.Delta.ToolCalls {
+		index := s.toolCall
+		if call.Index != nil {
+			index = *call.Index
+		}
+
+		// A call with an ID (or a new index) starts a new tool_use block
+		if s.blockType != "tool_use" || call.ID != "" || index != s.toolCall {
+			if err := s.openBlock(AnthropicContentBlock{
+				Type:  "tool_use",
+				ID:    call.ID,
+				Name:  call.Function.Name,
+				Input: json.RawMessage("{}"),
+			}); err != nil {
+				return err
+			}
+			s.blockType = "tool_use"
+			s.toolCall = index
+		}
+		if call.Function.Arguments != "" {
+			if err := s.writeDelta(map[string]interface{}{"type": "input_json_delta", "partial_json": call.Function.Arguments}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if choice.FinishReason != nil && *choice.FinishReason != "" {
+		s.stopReason = MapFinishReasonToAnthropic(*choice.FinishReason)
+	}
+	return nil
+}
+
+// Close ends the open block and writes the closing message events
+func (s *AnthropicStreamWriter) Close() error {
+	if err := s.start(); err != nil {
+		return err
+	}
+	if err := s.closeBlock(); err != nil {
+		return err
+	}
+
+	stopReason := s.stopReason
+	if stopReason == "" {
+		stopReason = "end_turn"
+	}
+	if err := writeSSEEvent(s.w, "message_delta", map[string]interface{}{
+		"type":  "message_delta",
+		"delta": map[string]interface{}{"stop_reason": stopReason, "stop_sequence": nil},
+		"usage": s.usage,
+	}); err != nil {
+		return err
+	}
+	return writeSSEEvent(s.w, "message_stop", map[string]string{"type": "message_stop"})
+}
+
+// start writes message_start before the first event
+func (s *AnthropicStreamWriter) start() error {
+	if s.started {
+		return nil
+	}
+	s.started = true
+
+	message := AnthropicMessagesResponse{
+		ID:      s.id,
+		Type:    "message",
+		Role:    "assistant",
+		Content: []AnthropicContentBlock{},
+		Model:   s.model,
+	}
+	if err := writeSSEEvent(s.w, "message_start", map[string]interface{}{"type": "message_start", "message": message}); err != nil {
+		return err
+	}
+	return writeSSEEvent(s.w, "ping", map[string]string{"type": "ping"})
+}
+
+// openBlock closes the open block, if any, and starts a new one
+func (s *AnthropicStreamWriter) openBlock(block AnthropicContentBlock) error {
+	if err := s.closeBlock(); err != nil {
+		return err
+	}
+	s.blockOpen = true
+	return writeSSEEvent(s.w, "content_block_start", map[string]interface{}{
+		"type":          "content_block_start",
+		"index":         s.blockIndex,
+		"content_block": anthropicStreamBlock(block),
+	})
+}
+
+func (s *AnthropicStreamWriter) closeBlock() error {
+	if !s.blockOpen {
+		return nil
+	}
+	s.blockOpen = false
+	s.blockType = ""
+	index := s.blockIndex
+	s.blockIndex++
+	return writeSSEEvent(s.w, "content_block_stop", map[string]interface{}{"type": "content_block_stop", "index": index})
+}
+
+func (s *AnthropicStreamWriter) writeDelta(delta map[string]interface{}) error {
+	return writeSSEEvent(s.w, "content_block_delta", map[string]interface{}{
+		"type":  "content_block_delta",
+		"index": s.blockIndex,
+		"delta": delta,
+	})
+}
+
+// anthropicStreamBlock renders a block start, which always carries text or
+// input even when empty
+func anthropicStreamBlock(block AnthropicContentBlock) map[string]interface{} {
+	if block.Type == "text" {
+		return map[string]interface{}{"type": "text", "text": block.Text}
+	}
+	return map[string]interface{}{"type": block.Type, "id": block.ID, "name": block.Name, "input": block.Input}
+}
+
+// WriteAnthropicStreamError writes an error event; Anthropic streams end after it
+func WriteAnthropicStreamError(w io.Writer, statusCode int, message string) error {
+	return writeSSEEvent(w, "error", NewAnthropicError(statusCode, message))
+}
+
+// writeSSEEvent writes a named SSE event with a JSON payload
+func writeSSEEvent(w io.Writer, event string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", event, err)
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(event) + len(data) + 16)
+	buf.WriteString("event: ")
+	buf.WriteString(event)
+	buf.WriteString("\ndata: ")
+	buf.Write(data)
+	buf.WriteString("\n\n")
+	_, err = w.Write(buf.Bytes())
+	return err
+}
@@ -36,6 +36,7 @@ type ContentBlock struct {
 	Document *DocumentBlock `json:"document,omitempty"`
 	ToolUse  *ToolUseBlock `json:"toolUse,omitempty"`
 	ToolResult *ToolResultBlock `json:"toolResult,omitempty"`
+	JSON     interface{}  `json:"json,omitempty"` // tool result content only
 }
 
 // ImageBlock represents an image
@@ -169,19 +170,49 @@ func TranslateOpenAIToConverseAPI(openaiReq *ChatCompletionRequest) (*providers.
 			continue
 		}
 
-		// Skip function/tool messages for now (can be added later)
-		if msg.Role == "function" || msg.Role == "tool" {
+		// Legacy function results carry no call ID to answer
+		if msg.Role == "function" {
 			continue
 		}
 
-		// Convert message content
-		contentBlocks := convertToContentBlocks(msg.Content)
+		role := msg.Role
+		var contentBlocks []ContentBlock
+		if msg.Role == "tool" {
+			// Tool results are returned to the model in a user turn
+			role = "user"
+			result := extractTextContent(msg.Content)
+			contentBlocks = append(contentBlocks, ContentBlock{
+				ToolResult: &ToolResultBlock{
+					ToolUseId: msg.ToolCallID,
+					Content:   []ContentBlock{{Text: &result}},
+				},
+			})
+		} else {
+			if text, ok := msg.Content.(string); msg.Content != nil && (!ok || text != "") {
+				contentBlocks = convertToContentBlocks(msg.Content)
+			}
+			for _, call := range msg.ToolCalls {
+				contentBlocks = append(contentBlocks, ContentBlock{
+					ToolUse: &ToolUseBlock{
+						ToolUseId: call.ID,
+						Name:      call.Function.Name,
+						Input:     toolUseInput(call.Function.Arguments),
+					},
+				})
+			}
+		}
 		if len(contentBlocks) == 0 {
 			continue
 		}
 
+		// Converse requires alternating roles, so consecutive turns (such as
+		// several tool results) are merged into one message
+		if n := len(converseMessages); n > 0 && converseMessages[n-1].Role == role {
+			converseMessages[n-1].Content = append(converseMessages[n-1].Content, contentBlocks...)
+			continue
+		}
 		converseMessages = append(converseMessages, ConverseMessage{
-			Role:    msg.Role,
+			Role:    role,
 			Content: contentBlocks,
 		})
 	}
@@ -236,6 +267,9 @@ func TranslateOpenAIToConverseAPI(openaiReq *ChatCompletionRequest) (*providers.
 		},
 		Body: body,
 	}
+	if openaiReq.Stream {
+		providerReq.Headers["Accept"] = "application/vnd.amazon.eventstream"
+	}
 
 	return providerReq, bedrockModelID, nil
 }
@@ -371,11 +405,27 @@ func convertContentPartToBlock(part map[string]interface{}) *ContentBlock {
 				}
 			}
 		}
+
+	case "file":
+		if file, ok := ParseFilePart(part); ok {
+			return converseDocumentBlock(file)
+		}
 	}
 
 	return nil
 }
 
+// toolUseInput parses tool call arguments; Converse requires an object
+func toolUseInput(arguments string) map[string]interface{} {
+	input := map[string]interface{}{}
+	if arguments != "" {
+		if err := json.Unmarshal([]byte(arguments), &input); err != nil || input == nil {
+			return map[string]interface{}{}
+		}
+	}
+	return input
+}
+
 // extractImageFormat extracts image format from data URL prefix
 func extractImageFormat(prefix string) string {
 	// prefix format: "data:image/jpeg;base64"
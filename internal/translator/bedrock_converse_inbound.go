@@ -0,0 +1,247 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TranslateConverseRequestToOpenAI converts a Bedrock Converse request, as sent
+// by boto3 and the AWS SDKs to /model/{modelId}/converse, to OpenAI format so
+// that it can be served by any provider
+func TranslateConverseRequestToOpenAI(req *ConverseRequest, model string, stream bool) (*ChatCompletionRequest, error) {
+	if model == "" {
+		return nil, fmt.Errorf("modelId is required")
+	}
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("messages must not be empty")
+	}
+
+	openaiReq := &ChatCompletionRequest{
+		Model:  model,
+		Stream: stream,
+	}
+
+	if cfg := req.InferenceConfig; cfg != nil {
+		if cfg.MaxTokens != nil {
+			openaiReq.MaxTokens = *cfg.MaxTokens
+		}
+		if cfg.Temperature != nil {
+			openaiReq.Temperature = *cfg.Temperature
+		}
+		if cfg.TopP != nil {
+			openaiReq.TopP = *cfg.TopP
+		}
+		openaiReq.Stop = cfg.StopSequences
+	}
+
+	var system []string
+	for _, block := range req.System {
+		if block.Text != "" {
+			system = append(system, block.Text)
+		}
+	}
+	if len(system) > 0 {
+		openaiReq.Messages = append(openaiReq.Messages, ChatMessage{Role: "system", Content: strings.Join(system, "\n")})
+	}
+
+	for i, msg := range req.Messages {
+		var messages []ChatMessage
+		var err error
+		switch msg.Role {
+		case "user":
+			messages, err = translateConverseUserMessage(msg.Content)
+		case "assistant":
+			messages = []ChatMessage{translateConverseAssistantMessage(msg.Content)}
+		default:
+			err = fmt.Errorf("unsupported role %q", msg.Role)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("messages.%d: %w", i, err)
+		}
+		openaiReq.Messages = append(openaiReq.Messages, messages...)
+	}
+
+	if req.ToolConfig != nil {
+		for _, tool := range req.ToolConfig.Tools {
+			if tool.ToolSpec == nil {
+				continue
+			}
+			var parameters map[string]interface{}
+			if tool.ToolSpec.InputSchema != nil {
+				parameters = tool.ToolSpec.InputSchema.JSON
+			}
+			openaiReq.Tools = append(openaiReq.Tools, Tool{
+				Type: "function",
+				Function: Function{
+					Name:        tool.ToolSpec.Name,
+					Description: tool.ToolSpec.Description,
+					Parameters:  parameters,
+				},
+			})
+		}
+
+		if choice := req.ToolConfig.ToolChoice; choice != nil {
+			switch {
+			case choice.Tool != nil:
+				openaiReq.ToolChoice = map[string]interface{}{
+					"type":     "function",
+					"function": map[string]interface{}{"name": choice.Tool.Name},
+				}
+			case choice.Any != nil:
+				openaiReq.ToolChoice = "required"
+			case choice.Auto != nil:
+				openaiReq.ToolChoice = "auto"
+			}
+		}
+	}
+
+	return openaiReq, nil
+}
+
+// translateConverseUserMessage splits a user turn into OpenAI tool result
+// messages followed by a user message with the remaining content
+func translateConverseUserMessage(blocks []ContentBlock) ([]ChatMessage, error) {
+	var messages []ChatMessage
+	var parts []interface{}
+
+	for _, block := range blocks {
+		switch {
+		case block.Text != nil:
+			parts = append(parts, map[string]interface{}{"type": "text", "text": *block.Text})
+		case block.Image != nil:
+			parts = append(parts, map[string]interface{}{
+				"type": "image_url",
+				"image_url": map[string]interface{}{
+					"url": fmt.Sprintf("data:image/%s;base64,%s", block.Image.Format, block.Image.Source.Bytes),
+				},
+			})
+		case block.ToolResult != nil:
+			messages = append(messages, ChatMessage{
+				Role:       "tool",
+				Content:    converseToolResultText(block.ToolResult),
+				ToolCallID: block.ToolResult.ToolUseId,
+			})
+		case block.Document != nil:
+			parts = append(parts, map[string]interface{}{
+				"type": "file",
+				"file": map[string]interface{}{
+					"filename":  block.Document.Name,
+					"file_data": fmt.Sprintf("data:%s;base64,%s", converseDocumentMediaType(block.Document.Format), block.Document.Source.Bytes),
+				},
+			})
+		}
+	}
+
+	if len(parts) == 1 {
+		if part := parts[0].(map[string]interface{}); part["type"] == "text" {
+			return append(messages, ChatMessage{Role: "user", Content: part["text"]}), nil
+		}
+	}
+	if len(parts) > 0 {
+		messages = append(messages, ChatMessage{Role: "user", Content: parts})
+	}
+	return messages, nil
+}
+
+// translateConverseAssistantMessage converts text and toolUse blocks
+func translateConverseAssistantMessage(blocks []ContentBlock) ChatMessage {
+	msg := ChatMessage{Role: "assistant"}
+	var text strings.Builder
+
+	for _, block := range blocks {
+		switch {
+		case block.Text != nil:
+			text.WriteString(*block.Text)
+		case block.ToolUse != nil:
+			input := block.ToolUse.Input
+			if input == nil {
+				input = map[string]interface{}{}
+			}
+			arguments, _ := json.Marshal(input)
+			msg.ToolCalls = append(msg.ToolCalls, ToolCall{
+				ID:       block.ToolUse.ToolUseId,
+				Type:     "function",
+				Function: FunctionCall{Name: block.ToolUse.Name, Arguments: string(arguments)},
+			})
+		}
+	}
+
+	if text.Len() > 0 || len(msg.ToolCalls) == 0 {
+		msg.Content = text.String()
+	}
+	return msg
+}
+
+// converseToolResultText flattens tool result content; JSON results are
+// passed on as their encoding
+func converseToolResultText(result *ToolResultBlock) string {
+	var texts []string
+	for _, block := range result.Content {
+		if block.Text != nil {
+			texts = append(texts, *block.Text)
+		} else if block.JSON != nil {
+			data, _ := json.Marshal(block.JSON)
+			texts = append(texts, string(data))
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// TranslateOpenAIToConverseResponse converts an OpenAI chat completion to a
+// Bedrock Converse response
+func TranslateOpenAIToConverseResponse(resp *ChatCompletionResponse, latency time.Duration) *ConverseResponse {
+	message := &ConverseMessage{Role: "assistant", Content: []ContentBlock{}}
+	stopReason := "end_turn"
+
+	if len(resp.Choices) > 0 {
+		choice := resp.Choices[0]
+		if choice.Message.Content != nil {
+			if text := extractTextContent(choice.Message.Content); text != "" {
+				message.Content = append(message.Content, ContentBlock{Text: &text})
+			}
+		}
+		for _, call := range choice.Message.ToolCalls {
+			message.Content = append(message.Content, ContentBlock{
+				ToolUse: &ToolUseBlock{
+					ToolUseId: call.ID,
+					Name:      call.Function.Name,
+					Input:     toolUseInput(call.Function.Arguments),
+				},
+			})
+		}
+		stopReason = MapFinishReasonToConverse(choice.FinishReason)
+	}
+
+	converseResp := &ConverseResponse{
+		Output:     ConverseOutput{Message: message},
+		StopReason: stopReason,
+		Metrics:    &ConverseMetrics{LatencyMs: latency.Milliseconds()},
+	}
+	if resp.Usage != nil {
+		converseResp.Usage = ConverseUsage{
+			InputTokens:  resp.Usage.PromptTokens,
+			OutputTokens: resp.Usage.CompletionTokens,
+			TotalTokens:  resp.Usage.PromptTokens + resp.Usage.CompletionTokens,
+		}
+	}
+	return converseResp
+}
+
+// MapFinishReasonToConverse maps an OpenAI finish reason to a Converse stop reason
+func MapFinishReasonToConverse(finishReason string) string {
+	switch finishReason {
+	case "length":
+		return "max_tokens"
+	case "tool_calls", "function_call":
+		return "tool_use"
+	case "content_filter":
+		return "content_filtered"
+	default:
+		return "end_turn"
+	}
+}
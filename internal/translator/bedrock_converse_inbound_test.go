@@ -0,0 +1,154 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+)
+
+const converseToolConversation = `{
+	"system": [{"text": "You are terse."}],
+	"inferenceConfig": {"maxTokens": 512, "temperature": 0.2, "stopSequences": ["END"]},
+	"toolConfig": {
+		"tools": [{"toolSpec": {"name": "get_weather", "description": "Weather lookup", "inputSchema": {"json": {"type": "object"}}}}],
+		"toolChoice": {"any": {}}
+	},
+	"messages": [
+		{"role": "user", "content": [{"text": "Weather in Paris?"}, {"image": {"format": "png", "source": {"bytes": "iVBORw0KGgo="}}}]},
+		{"role": "assistant", "content": [{"toolUse": {"toolUseId": "tooluse_1", "name": "get_weather", "input": {"city": "Paris"}}}]},
+		{"role": "user", "content": [{"toolResult": {"toolUseId": "tooluse_1", "content": [{"json": {"celsius": 18}}]}}]}
+	]
+}`
+
+func TestTranslateConverseRequestToOpenAI(t *testing.T) {
+	var req ConverseRequest
+	if err := json.Unmarshal([]byte(converseToolConversation), &req); err != nil {
+		t.Fatalf("Failed to parse request: %v", err)
+	}
+
+	openaiReq, err := TranslateConverseRequestToOpenAI(&req, "gpt-4o", true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	roles := make([]string, len(openaiReq.Messages))
+	for i, msg := range openaiReq.Messages {
+		roles[i] = msg.Role
+	}
+	if got := strings.Join(roles, ","); got != "system,user,assistant,tool" {
+		t.Fatalf("Unexpected message roles %s", got)
+	}
+	if openaiReq.Model != "gpt-4o" || !openaiReq.Stream || openaiReq.MaxTokens != 512 || openaiReq.Stop[0] != "END" {
+		t.Errorf("Request parameters not translated: %+v", openaiReq)
+	}
+	if openaiReq.ToolChoice != "required" || openaiReq.Tools[0].Function.Name != "get_weather" {
+		t.Errorf("Tools not translated: %+v %v", openaiReq.Tools, openaiReq.ToolChoice)
+	}
+
+	image := openaiReq.Messages[1].Content.([]interface{})[1].(map[string]interface{})
+	if url := image["image_url"].(map[string]interface{})["url"]; url != "data:image/png;base64,iVBORw0KGgo=" {
+		t.Errorf("Unexpected image URL %v", url)
+	}
+	if call := openaiReq.Messages[2].ToolCalls[0]; call.ID != "tooluse_1" || call.Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("Unexpected tool call %+v", call)
+	}
+	if result := openaiReq.Messages[3]; result.ToolCallID != "tooluse_1" || result.Content != `{"celsius":18}` {
+		t.Errorf("Unexpected tool result %+v", result)
+	}
+
+	if _, err := TranslateConverseRequestToOpenAI(&ConverseRequest{}, "gpt-4o", false); err == nil {
+		t.Error("Expected an error without messages")
+	}
+}
+
+func TestTranslateOpenAIToConverseResponse(t *testing.T) {
+	content := "Sunny."
+	resp := &ChatCompletionResponse{
+		Choices: []ChatCompletionChoice{{
+			Message: ChatMessage{
+				Role:      "assistant",
+				Content:   content,
+				ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Rome"}`}}},
+			},
+			FinishReason: "tool_calls",
+		}},
+		Usage: &Usage{PromptTokens: 20, CompletionTokens: 7, TotalTokens: 27},
+	}
+
+	converseResp := TranslateOpenAIToConverseResponse(resp, 250*time.Millisecond)
+	data, _ := json.Marshal(converseResp)
+	want := `{"output":{"message":{"role":"assistant","content":[{"text":"Sunny."},{"toolUse":{"toolUseId":"call_1","name":"get_weather","input":{"city":"Rome"}}}]}},"stopReason":"tool_use","usage":{"inputTokens":20,"outputTokens":7,"totalTokens":27},"metrics":{"latencyMs":250}}`
+	if string(data) != want {
+		t.Errorf("Unexpected response:\n got %s\nwant %s", data, want)
+	}
+}
+
+func TestConverseStreamWriter(t *testing.T) {
+	zero := 0
+	chunks := []*ChatCompletionStreamResponse{
+		NewChatCompletionChunk("x", "m", 0, ChatMessageDelta{Role: "assistant", Content: "Checking"}, ""),
+		NewChatCompletionChunk("x", "m", 0, ChatMessageDelta{ToolCalls: []ToolCall{{Index: &zero, ID: "call_1", Function: FunctionCall{Name: "get_weather"}}}}, ""),
+		NewChatCompletionChunk("x", "m", 0, ChatMessageDelta{ToolCalls: []ToolCall{{Index: &zero, Function: FunctionCall{Arguments: `{"city":"Rome"}`}}}}, ""),
+		NewChatCompletionChunk("x", "m", 0, ChatMessageDelta{}, "tool_calls"),
+		{Choices: []ChatCompletionStreamChoice{}, Usage: &Usage{PromptTokens: 9, CompletionTokens: 4}},
+	}
+
+	var stream bytes.Buffer
+	writer := NewConverseStreamWriter(&stream)
+	for _, chunk := range chunks {
+		if err := writer.WriteChunk(chunk); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := writer.Close(time.Second); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	writer.WriteException(http.StatusTooManyRequests, "slow down")
+
+	// Reading the stream back as Bedrock output must reproduce the chunks
+	reader := NewConverseStreamReader(&stream, "m", "x")
+	var text, arguments, finish string
+	var usage *Usage
+	for {
+		chunk, err := reader.Next()
+		if err != nil {
+			var providerErr *providers.ProviderError
+			if !errors.As(err, &providerErr) || providerErr.StatusCode != http.StatusTooManyRequests {
+				t.Errorf("Expected the throttling exception, got %v", err)
+			}
+			break
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			text += choice.Delta.Content
+			for _, call := range choice.Delta.ToolCalls {
+				arguments += call.Function.Arguments
+			}
+			if choice.FinishReason != nil {
+				finish = *choice.FinishReason
+			}
+		}
+	}
+
+	if text != "Checking" || arguments != `{"city":"Rome"}` || finish != "tool_calls" {
+		t.Errorf("Unexpected round trip: text=%q arguments=%q finish=%q", text, arguments, finish)
+	}
+	if usage == nil || usage.TotalTokens != 13 {
+		t.Errorf("Unexpected usage %+v", usage)
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
@@ -0,0 +1,330 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+	"github.com/tosharewith/llmproxy_auth/internal/providers/bedrock"
+)
+
+// Bedrock ConverseStream event types
+
+// ConverseStreamMessageStart is sent when the assistant message begins
+type ConverseStreamMessageStart struct {
+	Role string `json:"role"`
+}
+
+// ConverseStreamContentBlockStart is sent when a tool use block begins
+type ConverseStreamContentBlockStart struct {
+	ContentBlockIndex int `json:"contentBlockIndex"`
+	Start             struct {
+		ToolUse *struct {
+			ToolUseId string `json:"toolUseId"`
+			Name      string `json:"name"`
+		} `json:"toolUse,omitempty"`
+	} `json:"start"`
+}
+
+// ConverseStreamContentBlockDelta carries incremental text or tool input
+type ConverseStreamContentBlockDelta struct {
+	ContentBlockIndex int `json:"contentBlockIndex"`
+	Delta             struct {
+		Text    *string `json:"text,omitempty"`
+		ToolUse *struct {
+			Input string `json:"input"`
+		} `json:"toolUse,omitempty"`
+	} `json:"delta"`
+}
+
+// ConverseStreamMessageStop is sent when the assistant message ends
+type ConverseStreamMessageStop struct {
+	StopReason string `json:"stopReason"`
+}
+
+// ConverseStreamReader translates a Bedrock ConverseStream response into OpenAI chunks
+type ConverseStreamReader struct {
+	decoder   *bedrock.EventStreamDecoder
+	id        string
+	model     string
+	toolIndex map[int]int
+}
+
+// NewConverseStreamReader creates a reader over a Bedrock event stream body
+func NewConverseStreamReader(r io.Reader, model, requestID string) *ConverseStreamReader {
+	return &ConverseStreamReader{
+		decoder:   bedrock.NewEventStreamDecoder(r),
+		id:        requestID,
+		model:     model,
+		toolIndex: make(map[int]int),
+	}
+}
+
+// Next returns the next chunk, or io.EOF when the stream ends. Exceptions
+// raised by Bedrock mid-stream are returned as *providers.ProviderError.
+func (r *ConverseStreamReader) Next() (*ChatCompletionStreamResponse, error) {
+	for {
+		event, err := r.decoder.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		chunk, err := r.translateEvent(event)
+		if err != nil {
+			return nil, err
+		}
+		if chunk != nil {
+			return chunk, nil
+		}
+	}
+}
+
+// translateEvent converts a single ConverseStream event; it returns nil for
+// events that carry nothing for the client
+func (r *ConverseStreamReader) translateEvent(streamEvent *providers.StreamEvent) (*ChatCompletionStreamResponse, error) {
+	eventType, payload := streamEvent.Type, streamEvent.Data
+
+	switch eventType {
+	case bedrock.StreamEventMessageStart:
+		var event ConverseStreamMessageStart
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, fmt.Errorf("failed to parse %s event: %w", eventType, err)
+		}
+		return r.chunk(ChatMessageDelta{Role: "assistant"}, ""), nil
+
+	case bedrock.StreamEventContentBlockStart:
+		var event ConverseStreamContentBlockStart
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, fmt.Errorf("failed to parse %s event: %w", eventType, err)
+		}
+		if event.Start.ToolUse == nil {
+			return nil, nil
+		}
+
+		index := len(r.toolIndex)
+		r.toolIndex[event.ContentBlockIndex] = index
+		return r.chunk(ChatMessageDelta{
+			ToolCalls: []ToolCall{{
+				Index: &index,
+				ID:    event.Start.ToolUse.ToolUseId,
+				Type:  "function",
+				Function: FunctionCall{
+					Name: event.Start.ToolUse.Name,
+				},
+			}},
+		}, ""), nil
+
+	case bedrock.StreamEventContentBlockDelta:
+		var event ConverseStreamContentBlockDelta
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, fmt.Errorf("failed to parse %s event: %w", eventType, err)
+		}
+
+		if event.Delta.Text != nil {
+			return r.chunk(ChatMessageDelta{Content: *event.Delta.Text}, ""), nil
+		}
+		if event.Delta.ToolUse != nil {
+			index, ok := r.toolIndex[event.ContentBlockIndex]
+			if !ok {
+				return nil, nil
+			}
+			return r.chunk(ChatMessageDelta{
+				ToolCalls: []ToolCall{{
+					Index:    &index,
+					Function: FunctionCall{Arguments: event.Delta.ToolUse.Input},
+				}},
+			}, ""), nil
+		}
+		return nil, nil
+
+	case bedrock.StreamEventMessageStop:
+		var event ConverseStreamMessageStop
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, fmt.Errorf("failed to parse %s event: %w", eventType, err)
+		}
+		return r.chunk(ChatMessageDelta{}, mapConverseStopReason(event.StopReason)), nil
+
+	case bedrock.StreamEventMetadata:
+		usage := streamEvent.Metadata
+		if usage == nil {
+			return nil, nil
+		}
+		return &ChatCompletionStreamResponse{
+			ID:      r.id,
+			Object:  "chat.completion.chunk",
+			Model:   r.model,
+			Choices: []ChatCompletionStreamChoice{},
+			Usage: &Usage{
+				PromptTokens:     usage.InputTokens,
+				CompletionTokens: usage.OutputTokens,
+				TotalTokens:      usage.TotalTokens,
+			},
+		}, nil
+
+	default:
+		// contentBlockStop and unknown events carry nothing for the client
+		return nil, nil
+	}
+}
+
+// chunk builds a chunk for this stream
+func (r *ConverseStreamReader) chunk(delta ChatMessageDelta, finishReason string) *ChatCompletionStreamResponse {
+	return NewChatCompletionChunk(r.id, r.model, 0, delta, finishReason)
+}
+
+// ConverseStreamWriter writes OpenAI chunks as a Bedrock ConverseStream event
+// stream: messageStart, content block deltas (with contentBlockStart for tool
+// use), contentBlockStop, messageStop and a closing metadata event.
+type ConverseStreamWriter struct {
+	encoder *bedrock.EventStreamEncoder
+
+	started    bool
+	blockOpen  bool
+	blockIndex int    // index of the open block, or of the next one when none is open
+	blockType  string // text or toolUse
+	toolCall   int    // OpenAI index of the tool call in the open toolUse block
+	stopReason string
+	usage      ConverseUsage
+}
+
+// NewConverseStreamWriter creates a writer over w
+func NewConverseStreamWriter(w io.Writer) *ConverseStreamWriter {
+	return &ConverseStreamWriter{encoder: bedrock.NewEventStreamEncoder(w)}
+}
+
+// WriteChunk translates a single OpenAI chunk
+func (s *ConverseStreamWriter) WriteChunk(chunk *ChatCompletionStreamResponse) error {
+	if err := s.start(); err != nil {
+		return err
+	}
+
+	if chunk.Usage != nil {
+		s.usage = ConverseUsage{
+			InputTokens:  chunk.Usage.PromptTokens,
+			OutputTokens: chunk.Usage.CompletionTokens,
+			TotalTokens:  chunk.Usage.PromptTokens + chunk.Usage.CompletionTokens,
+		}
+	}
+	if len(chunk.Choices) == 0 {
+		return nil
+	}
+
+	choice := chunk.Choices[0]
+	if choice.Delta.Content != "" {
+		// Text blocks have no start event
+		if s.blockType != "text" {
+			if err := s.closeBlock(); err != nil {
+				return err
+			}
+			s.blockOpen = true
+			s.blockType = "text"
+		}
+		if err := s.writeDelta(map[string]interface{}{"text": choice.Delta.Content}); err != nil {
+			return err
+		}
+	}
+
+	for _, call := range choice.Delta.ToolCalls {
+		index := s.toolCall
+		if call.Index != nil {
+			index = *call.Index
+		}
+
+		// A call with an ID (or a new index) starts a new toolUse block
+		if s.blockType != "toolUse" || call.ID != "" || index != s.toolCall {
+			if err := s.closeBlock(); err != nil {
+				return err
+			}
+			s.blockOpen = true
+			s.blockType = "toolUse"
+			s.toolCall = index
+			if err := s.writeEvent(bedrock.StreamEventContentBlockStart, map[string]interface{}{
+				"contentBlockIndex": s.blockIndex,
+				"start": map[string]interface{}{
+					"toolUse": map[string]string{"toolUseId": call.ID, "name": call.Function.Name},
+				},
+			}); err != nil {
+				return err
+			}
+		}
+		if call.Function.Arguments != "" {
+			if err := s.writeDelta(map[string]interface{}{"toolUse": map[string]string{"input": call.Function.Arguments}}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if choice.FinishReason != nil && *choice.FinishReason != "" {
+		s.stopReason = MapFinishReasonToConverse(*choice.FinishReason)
+	}
+	return nil
+}
+
+// Close ends the open block and writes messageStop and metadata
+func (s *ConverseStreamWriter) Close(latency time.Duration) error {
+	if err := s.start(); err != nil {
+		return err
+	}
+	if err := s.closeBlock(); err != nil {
+		return err
+	}
+
+	stopReason := s.stopReason
+	if stopReason == "" {
+		stopReason = "end_turn"
+	}
+	if err := s.writeEvent(bedrock.StreamEventMessageStop, ConverseStreamMessageStop{StopReason: stopReason}); err != nil {
+		return err
+	}
+	return s.writeEvent(bedrock.StreamEventMetadata, map[string]interface{}{
+		"usage":   s.usage,
+		"metrics": ConverseMetrics{LatencyMs: latency.Milliseconds()},
+	})
+}
+
+// WriteException writes a stream exception for an HTTP status code
+func (s *ConverseStreamWriter) WriteException(statusCode int, message string) error {
+	exceptionType := bedrock.ExceptionType(statusCode)
+	return s.encoder.WriteException(strings.ToLower(exceptionType[:1])+exceptionType[1:], message)
+}
+
+// start writes messageStart before the first event
+func (s *ConverseStreamWriter) start() error {
+	if s.started {
+		return nil
+	}
+	s.started = true
+	return s.writeEvent(bedrock.StreamEventMessageStart, ConverseStreamMessageStart{Role: "assistant"})
+}
+
+func (s *ConverseStreamWriter) closeBlock() error {
+	if !s.blockOpen {
+		return nil
+	}
+	s.blockOpen = false
+	s.blockType = ""
+	index := s.blockIndex
+	s.blockIndex++
+	return s.writeEvent(bedrock.StreamEventContentBlockStop, map[string]int{"contentBlockIndex": index})
+}
+
+func (s *ConverseStreamWriter) writeDelta(delta map[string]interface{}) error {
+	return s.writeEvent(bedrock.StreamEventContentBlockDelta, map[string]interface{}{
+		"contentBlockIndex": s.blockIndex,
+		"delta":             delta,
+	})
+}
+
+func (s *ConverseStreamWriter) writeEvent(eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", eventType, err)
+	}
+	return s.encoder.WriteEvent(eventType, data)
+}
@@ -0,0 +1,133 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tosharewith/llmproxy_auth/internal/providers"
+)
+
+// Bedrock embedding models take one text (Titan) or up to 96 texts (Cohere) per call
+const (
+	titanEmbeddingBatchSize  = 1
+	cohereEmbeddingBatchSize = 96
+)
+
+// TitanEmbeddingRequest is the InvokeModel body for Amazon Titan text embeddings
+type TitanEmbeddingRequest struct {
+	InputText  string `json:"inputText"`
+	Dimensions int    `json:"dimensions,omitempty"` // v2 only: 256, 512 or 1024
+	Normalize  *bool  `json:"normalize,omitempty"`  // v2 only
+}
+
+// TitanEmbeddingResponse is the InvokeModel response for Amazon Titan text embeddings
+type TitanEmbeddingResponse struct {
+	Embedding           []float64 `json:"embedding"`
+	InputTextTokenCount int       `json:"inputTextTokenCount"`
+}
+
+// CohereEmbeddingRequest is the InvokeModel body for Cohere Embed on Bedrock
+type CohereEmbeddingRequest struct {
+	Texts     []string `json:"texts"`
+	InputType string   `json:"input_type"`
+	Truncate  string   `json:"truncate,omitempty"`
+}
+
+// CohereEmbeddingResponse is the InvokeModel response for Cohere Embed on Bedrock
+type CohereEmbeddingResponse struct {
+	ID         string      `json:"id"`
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+// BedrockEmbeddingBatchSize returns how many texts a Bedrock embedding model accepts per call
+func BedrockEmbeddingBatchSize(modelID string) int {
+	if isCohereEmbedModel(modelID) {
+		return cohereEmbeddingBatchSize
+	}
+	return titanEmbeddingBatchSize
+}
+
+// TranslateOpenAIToBedrockEmbeddings builds an InvokeModel request embedding
+// texts, which must fit in a single batch for the model
+func TranslateOpenAIToBedrockEmbeddings(req *EmbeddingRequest, modelID string, texts []string) (*providers.ProviderRequest, error) {
+	if len(texts) > BedrockEmbeddingBatchSize(modelID) {
+		return nil, fmt.Errorf("model %s accepts at most %d inputs per call", modelID, BedrockEmbeddingBatchSize(modelID))
+	}
+
+	var body interface{}
+	switch {
+	case isCohereEmbedModel(modelID):
+		if req.Dimensions != 0 {
+			return nil, fmt.Errorf("model %s does not support dimensions", modelID)
+		}
+		inputType := req.InputType
+		if inputType == "" {
+			inputType = "search_document"
+		}
+		body = CohereEmbeddingRequest{Texts: texts, InputType: inputType, Truncate: "NONE"}
+	case strings.Contains(modelID, "titan-embed"):
+		titanReq := TitanEmbeddingRequest{InputText: texts[0]}
+		if req.Dimensions != 0 {
+			if !strings.Contains(modelID, "titan-embed-text-v2") {
+				return nil, fmt.Errorf("model %s does not support dimensions", modelID)
+			}
+			switch req.Dimensions {
+			case 256, 512, 1024:
+			default:
+				return nil, fmt.Errorf("dimensions must be 256, 512 or 1024 for %s", modelID)
+			}
+			titanReq.Dimensions = req.Dimensions
+		}
+		body = titanReq
+	default:
+		return nil, fmt.Errorf("model %s is not a supported Bedrock embedding model", modelID)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embedding request: %w", err)
+	}
+
+	return &providers.ProviderRequest{
+		Method: "POST",
+		Path:   fmt.Sprintf("/model/%s/invoke", modelID),
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+			"Accept":       "application/json",
+		},
+		Body: data,
+	}, nil
+}
+
+// TranslateBedrockEmbeddingsToOpenAI converts an InvokeModel embedding
+// response. Cohere does not report tokens in the body, so the input token
+// count header is used when present.
+func TranslateBedrockEmbeddingsToOpenAI(modelID string, body []byte, headers map[string]string) (*EmbeddingResponse, error) {
+	promptTokens, _ := strconv.Atoi(headers["X-Amzn-Bedrock-Input-Token-Count"])
+
+	if isCohereEmbedModel(modelID) {
+		var cohereResp CohereEmbeddingResponse
+		if err := json.Unmarshal(body, &cohereResp); err != nil {
+			return nil, fmt.Errorf("failed to parse Cohere embedding response: %w", err)
+		}
+		return NewEmbeddingResponse(modelID, cohereResp.Embeddings, promptTokens), nil
+	}
+
+	var titanResp TitanEmbeddingResponse
+	if err := json.Unmarshal(body, &titanResp); err != nil {
+		return nil, fmt.Errorf("failed to parse Titan embedding response: %w", err)
+	}
+	if titanResp.InputTextTokenCount > 0 {
+		promptTokens = titanResp.InputTextTokenCount
+	}
+	return NewEmbeddingResponse(modelID, [][]float64{titanResp.Embedding}, promptTokens), nil
+}
+
+func isCohereEmbedModel(modelID string) bool {
+	return strings.Contains(modelID, "cohere.embed")
+}
@@ -0,0 +1,274 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Documents are attached to chat messages as OpenAI file content parts:
+//
+//	{"type": "file", "file": {"filename": "report.pdf", "file_data": "data:application/pdf;base64,..."}}
+//
+// Two extensions reference documents by URL (https:// presigned URLs or
+// storage URIs such as s3://bucket/key) for the gateway to fetch:
+//
+//	{"type": "file", "file": {"filename": "report.pdf", "file_url": "s3://bucket/report.pdf"}}
+//	{"type": "document", "name": "report.pdf", "source": {"type": "url", "url": "https://..."}}
+//
+// The document part also takes {"type": "base64", "media_type": ..., "data": ...}
+// sources. InlineDocuments rewrites all of these to file parts with file_data.
+
+// documentFormats maps document media types to Bedrock Converse document formats
+var documentFormats = map[string]string{
+	"application/pdf":    "pdf",
+	"text/csv":           "csv",
+	"application/msword": "doc",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": "docx",
+	"application/vnd.ms-excel": "xls",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": "xlsx",
+	"text/html":     "html",
+	"text/plain":    "txt",
+	"text/markdown": "md",
+}
+
+// textDocumentTypes are non-text/* media types that can be inlined as text
+var textDocumentTypes = map[string]bool{
+	"application/json":   true,
+	"application/xml":    true,
+	"application/yaml":   true,
+	"application/x-yaml": true,
+}
+
+// UnsupportedDocumentError reports a binary document sent to a provider that
+// reads documents only as text. Text is not extracted from binary formats
+// such as PDF, Word or Excel.
+type UnsupportedDocumentError struct {
+	Name      string
+	MediaType string
+}
+
+func (e *UnsupportedDocumentError) Error() string {
+	return fmt.Sprintf("document %s is not supported by this provider: it reads documents only as text, and text is not extracted from %s documents",
+		e.Name, e.MediaType)
+}
+
+// FilePart is a document attached to a message as a file content part
+type FilePart struct {
+	Name      string
+	MediaType string
+	Data      string // base64 encoded
+	FileID    string // OpenAI Files API reference instead of data
+}
+
+// ParseFilePart reads an OpenAI file content part with inline file_data or a file_id
+func ParseFilePart(part map[string]interface{}) (FilePart, bool) {
+	if part["type"] != "file" {
+		return FilePart{}, false
+	}
+	file, _ := part["file"].(map[string]interface{})
+	name, _ := file["filename"].(string)
+	if fileID, _ := file["file_id"].(string); fileID != "" {
+		return FilePart{Name: name, FileID: fileID}, true
+	}
+
+	fileData, _ := file["file_data"].(string)
+	rest, ok := strings.CutPrefix(fileData, "data:")
+	if !ok {
+		return FilePart{}, false
+	}
+	mediaType, data, found := strings.Cut(rest, ";base64,")
+	if !found {
+		return FilePart{}, false
+	}
+	return FilePart{Name: name, MediaType: mediaType, Data: data}, true
+}
+
+// newFilePart builds a file content part with inline data
+func newFilePart(name, mediaType string, data []byte) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "file",
+		"file": map[string]interface{}{
+			"filename":  name,
+			"file_data": "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data),
+		},
+	}
+}
+
+// documentMediaType picks a document's media type from its file extension,
+// falling back to the type reported by its source
+func documentMediaType(name, reported string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".md", ".markdown":
+		return "text/markdown"
+	case ".csv":
+		return "text/csv"
+	case ".txt":
+		return "text/plain"
+	case ".html", ".htm":
+		return "text/html"
+	case ".pdf":
+		return "application/pdf"
+	case ".doc":
+		return "application/msword"
+	case ".docx":
+		return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	case ".xls":
+		return "application/vnd.ms-excel"
+	case ".xlsx":
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case ".json":
+		return "application/json"
+	}
+	if mediaType, _, err := mime.ParseMediaType(reported); err == nil {
+		return mediaType
+	}
+	return "application/octet-stream"
+}
+
+// InlineDocuments returns a copy of req in which every document reference is
+// a file part with inline file_data. fetch loads documents referenced by URL
+// and returns the media type reported by their source; when fetch is nil, URL
+// references are rejected. The request is returned unchanged when it has no
+// document parts to rewrite.
+func InlineDocuments(req *ChatCompletionRequest, fetch func(url string) (string, []byte, error)) (*ChatCompletionRequest, error) {
+	return rewriteParts(req, func(part map[string]interface{}) (interface{}, error) {
+		var name, url, mediaType, data string
+		switch part["type"] {
+		case "file":
+			file, _ := part["file"].(map[string]interface{})
+			url, _ = file["file_url"].(string)
+			if url == "" {
+				return nil, nil
+			}
+			name, _ = file["filename"].(string)
+		case "document":
+			name, _ = part["name"].(string)
+			if name == "" {
+				name, _ = part["title"].(string)
+			}
+			source, _ := part["source"].(map[string]interface{})
+			switch source["type"] {
+			case "url":
+				url, _ = source["url"].(string)
+			case "base64":
+				mediaType, _ = source["media_type"].(string)
+				data, _ = source["data"].(string)
+			case "text":
+				mediaType = "text/plain"
+				text, _ := source["data"].(string)
+				data = base64.StdEncoding.EncodeToString([]byte(text))
+			default:
+				return nil, fmt.Errorf("unsupported document source type %v", source["type"])
+			}
+		default:
+			return nil, nil
+		}
+
+		if name == "" && url != "" {
+			name = path.Base(strings.SplitN(strings.SplitN(url, "?", 2)[0], "#", 2)[0])
+		}
+		if name == "" || name == "." || name == "/" {
+			name = "document"
+		}
+
+		if url == "" {
+			content, err := base64.StdEncoding.DecodeString(data)
+			if err != nil {
+				return nil, fmt.Errorf("invalid base64 document data: %w", err)
+			}
+			return newFilePart(name, documentMediaType(name, mediaType), content), nil
+		}
+
+		if fetch == nil {
+			return nil, fmt.Errorf("document URLs are not enabled on this gateway")
+		}
+		reported, content, err := fetch(url)
+		if err != nil {
+			return nil, err
+		}
+		return newFilePart(name, documentMediaType(name, reported), content), nil
+	})
+}
+
+// DocumentsAsText returns a copy of req in which file parts whose media type
+// the provider does not accept natively are replaced by text parts holding
+// the document's content. native reports whether a media type is accepted;
+// it is called with an empty type for file_id references. Binary documents
+// that are neither native nor text fail with *UnsupportedDocumentError.
+func DocumentsAsText(req *ChatCompletionRequest, native func(mediaType string) bool) (*ChatCompletionRequest, error) {
+	return rewriteParts(req, func(part map[string]interface{}) (interface{}, error) {
+		file, ok := ParseFilePart(part)
+		if !ok || native(file.MediaType) {
+			return nil, nil
+		}
+		if file.FileID != "" {
+			return nil, fmt.Errorf("file_id references are not supported by this provider")
+		}
+		if !strings.HasPrefix(file.MediaType, "text/") && !textDocumentTypes[file.MediaType] {
+			return nil, &UnsupportedDocumentError{Name: file.Name, MediaType: file.MediaType}
+		}
+
+		content, err := base64.StdEncoding.DecodeString(file.Data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 document data: %w", err)
+		}
+		if !utf8.Valid(content) {
+			return nil, fmt.Errorf("document %s is not valid UTF-8 text", file.Name)
+		}
+		return map[string]interface{}{
+			"type": "text",
+			"text": fmt.Sprintf("<document name=%q>\n%s\n</document>", file.Name, content),
+		}, nil
+	})
+}
+
+// IsConverseDocumentType reports whether Bedrock Converse accepts a document media type
+func IsConverseDocumentType(mediaType string) bool {
+	_, ok := documentFormats[mediaType]
+	return ok
+}
+
+// converseDocumentNameInvalid matches characters Converse document names may not contain
+var converseDocumentNameInvalid = regexp.MustCompile(`[^A-Za-z0-9\s\-()\[\]]+`)
+
+// converseDocumentBlock converts a file part to a Converse document block
+func converseDocumentBlock(file FilePart) *ContentBlock {
+	format, ok := documentFormats[file.MediaType]
+	if !ok || file.FileID != "" {
+		return nil
+	}
+
+	// Names allow alphanumerics, single spaces, hyphens, parentheses and brackets
+	name := strings.TrimSuffix(file.Name, path.Ext(file.Name))
+	name = converseDocumentNameInvalid.ReplaceAllString(name, " ")
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		name = "document"
+	}
+
+	return &ContentBlock{
+		Document: &DocumentBlock{
+			Format: format,
+			Name:   name,
+			Source: DocumentSource{Bytes: file.Data},
+		},
+	}
+}
+
+// converseDocumentMediaType maps a Converse document format to a media type
+func converseDocumentMediaType(format string) string {
+	for mediaType, f := range documentFormats {
+		if f == format {
+			return mediaType
+		}
+	}
+	return "application/octet-stream"
+}
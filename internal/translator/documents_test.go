@@ -0,0 +1,149 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func documentRequest(parts ...interface{}) *ChatCompletionRequest {
+	return &ChatCompletionRequest{
+		Model:    "claude-3-5-sonnet",
+		Messages: []ChatMessage{{Role: "user", Content: append([]interface{}{map[string]interface{}{"type": "text", "text": "Summarize."}}, parts...)}},
+	}
+}
+
+func TestInlineDocuments(t *testing.T) {
+	req := documentRequest(
+		map[string]interface{}{"type": "file", "file": map[string]interface{}{"filename": "notes.md", "file_url": "s3://bucket/notes.md"}},
+		map[string]interface{}{"type": "document", "source": map[string]interface{}{"type": "url", "url": "https://example.com/report.pdf?X-Amz-Signature=abc"}},
+		map[string]interface{}{"type": "document", "name": "memo", "source": map[string]interface{}{"type": "text", "data": "hello"}},
+	)
+
+	t.Run("Resolved", func(t *testing.T) {
+		var urls []string
+		inlined, err := InlineDocuments(req, func(url string) (string, []byte, error) {
+			urls = append(urls, url)
+			return "application/octet-stream", []byte("data"), nil
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(urls) != 2 {
+			t.Errorf("Expected two fetches, got %v", urls)
+		}
+
+		want := []FilePart{
+			{Name: "notes.md", MediaType: "text/markdown", Data: "ZGF0YQ=="},
+			{Name: "report.pdf", MediaType: "application/pdf", Data: "ZGF0YQ=="},
+			{Name: "memo", MediaType: "text/plain", Data: "aGVsbG8="},
+		}
+		parts := inlined.Messages[0].Content.([]interface{})
+		for i, w := range want {
+			file, ok := ParseFilePart(parts[i+1].(map[string]interface{}))
+			if !ok || file != w {
+				t.Errorf("Part %d = %+v, want %+v", i+1, file, w)
+			}
+		}
+		if req.Messages[0].Content.([]interface{})[1].(map[string]interface{})["file"].(map[string]interface{})["file_url"] == nil {
+			t.Error("Expected the original request to be unchanged")
+		}
+	})
+
+	t.Run("Fetch disabled", func(t *testing.T) {
+		if _, err := InlineDocuments(req, nil); err == nil || !strings.Contains(err.Error(), "messages[0].content[1]") {
+			t.Errorf("Expected an error for the first URL, got %v", err)
+		}
+	})
+
+	t.Run("Fetch error", func(t *testing.T) {
+		fetchErr := errors.New("access denied")
+		_, err := InlineDocuments(req, func(url string) (string, []byte, error) {
+			return "", nil, fetchErr
+		})
+		if !errors.Is(err, fetchErr) {
+			t.Errorf("Expected the fetch error, got %v", err)
+		}
+	})
+
+	t.Run("No documents", func(t *testing.T) {
+		plain := documentRequest()
+		inlined, err := InlineDocuments(plain, nil)
+		if err != nil || inlined != plain {
+			t.Errorf("Expected the request to be returned unchanged, got %v", err)
+		}
+	})
+}
+
+func TestDocumentsAsText(t *testing.T) {
+	req := documentRequest(
+		newFilePart("report.pdf", "application/pdf", []byte("%PDF-1.7")),
+		newFilePart("data.csv", "text/csv", []byte("a,b\n1,2")),
+	)
+
+	t.Run("Native", func(t *testing.T) {
+		converted, err := DocumentsAsText(req, IsConverseDocumentType)
+		if err != nil || converted != req {
+			t.Errorf("Expected native documents to be kept, got %v", err)
+		}
+	})
+
+	t.Run("Text fallback", func(t *testing.T) {
+		converted, err := DocumentsAsText(req, func(mediaType string) bool { return mediaType == "application/pdf" })
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		parts := converted.Messages[0].Content.([]interface{})
+		if parts[1].(map[string]interface{})["type"] != "file" {
+			t.Error("Expected the PDF to stay a file part")
+		}
+		text := parts[2].(map[string]interface{})["text"]
+		if text != "<document name=\"data.csv\">\na,b\n1,2\n</document>" {
+			t.Errorf("Unexpected text part %q", text)
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := DocumentsAsText(req, func(string) bool { return false })
+		var unsupported *UnsupportedDocumentError
+		if !errors.As(err, &unsupported) || unsupported.Name != "report.pdf" || unsupported.MediaType != "application/pdf" {
+			t.Errorf("Expected an unsupported document error, got %v", err)
+		}
+	})
+}
+
+func TestConverseDocuments(t *testing.T) {
+	req := documentRequest(newFilePart("Q3 report (final).v2.pdf", "application/pdf", []byte("%PDF-1.7")))
+
+	providerReq, _, err := TranslateOpenAIToConverseAPI(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var converseReq ConverseRequest
+	if err := json.Unmarshal(providerReq.Body, &converseReq); err != nil {
+		t.Fatalf("Failed to parse Converse request: %v", err)
+	}
+	blocks := converseReq.Messages[0].Content
+	if len(blocks) != 2 || blocks[1].Document == nil {
+		t.Fatalf("Expected a document block, got %+v", blocks)
+	}
+	document := blocks[1].Document
+	if document.Format != "pdf" || document.Name != "Q3 report (final) v2" || document.Source.Bytes != "JVBERi0xLjc=" {
+		t.Errorf("Unexpected document block %+v", document)
+	}
+
+	// The inbound Converse API maps document blocks back to file parts
+	openAIReq, err := TranslateConverseRequestToOpenAI(&converseReq, "m", false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	parts := openAIReq.Messages[0].Content.([]interface{})
+	file, ok := ParseFilePart(parts[len(parts)-1].(map[string]interface{}))
+	if !ok || file.MediaType != "application/pdf" || file.Data != "JVBERi0xLjc=" {
+		t.Errorf("Unexpected file part %+v", file)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// EmbeddingRequest represents an OpenAI embeddings request
+type EmbeddingRequest struct {
+	Model          string      `json:"model"`
+	Input          interface{} `json:"input"`                     // string or array of strings
+	EncodingFormat string      `json:"encoding_format,omitempty"` // float or base64
+	Dimensions     int         `json:"dimensions,omitempty"`
+	User           string      `json:"user,omitempty"`
+
+	// InputType is the Cohere input type (search_document, search_query,
+	// classification or clustering); it is not sent to OpenAI-native providers
+	InputType string `json:"input_type,omitempty"`
+}
+
+// EmbeddingResponse represents an OpenAI embeddings response
+type EmbeddingResponse struct {
+	Object string          `json:"object"` // list
+	Data   []EmbeddingData `json:"data"`
+	Model  string          `json:"model"`
+	Usage  EmbeddingUsage  `json:"usage"`
+}
+
+// EmbeddingData is a single embedding in an EmbeddingResponse
+type EmbeddingData struct {
+	Object    string          `json:"object"` // embedding
+	Embedding EmbeddingVector `json:"embedding"`
+	Index     int             `json:"index"`
+}
+
+// EmbeddingUsage reports the tokens used by an embeddings request
+type EmbeddingUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// EmbeddingVector is an embedding that is encoded either as an array of
+// floats or, with Base64 set, as base64 of little-endian float32 values
+type EmbeddingVector struct {
+	Values []float64
+	Base64 bool
+}
+
+// MarshalJSON encodes the vector in the selected format
+func (v EmbeddingVector) MarshalJSON() ([]byte, error) {
+	if !v.Base64 {
+		if v.Values == nil {
+			return []byte("[]"), nil
+		}
+		return json.Marshal(v.Values)
+	}
+
+	buf := make([]byte, 4*len(v.Values))
+	for i, value := range v.Values {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(value)))
+	}
+	return json.Marshal(base64.StdEncoding.EncodeToString(buf))
+}
+
+// UnmarshalJSON accepts both encodings
+func (v *EmbeddingVector) UnmarshalJSON(data []byte) error {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		v.Base64 = false
+		return json.Unmarshal(data, &v.Values)
+	}
+
+	var encoded string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	buf, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("invalid base64 embedding: %w", err)
+	}
+	if len(buf)%4 != 0 {
+		return fmt.Errorf("invalid base64 embedding length %d", len(buf))
+	}
+
+	v.Base64 = true
+	v.Values = make([]float64, len(buf)/4)
+	for i := range v.Values {
+		v.Values[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:])))
+	}
+	return nil
+}
+
+// EmbeddingInputs returns the texts to embed. Token array inputs are not
+// accepted because only OpenAI-native providers could serve them.
+func EmbeddingInputs(input interface{}) ([]string, error) {
+	switch v := input.(type) {
+	case string:
+		if v == "" {
+			return nil, fmt.Errorf("input must not be empty")
+		}
+		return []string{v}, nil
+	case []string:
+		if len(v) == 0 {
+			return nil, fmt.Errorf("input must not be empty")
+		}
+		return v, nil
+	case []interface{}:
+		if len(v) == 0 {
+			return nil, fmt.Errorf("input must not be empty")
+		}
+		texts := make([]string, len(v))
+		for i, item := range v {
+			text, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("input must be a string or an array of strings; token arrays are not supported")
+			}
+			if text == "" {
+				return nil, fmt.Errorf("input[%d] must not be empty", i)
+			}
+			texts[i] = text
+		}
+		return texts, nil
+	case nil:
+		return nil, fmt.Errorf("input is required")
+	default:
+		return nil, fmt.Errorf("input must be a string or an array of strings")
+	}
+}
+
+// NewEmbeddingResponse builds an OpenAI embeddings response from vectors in input order
+func NewEmbeddingResponse(model string, vectors [][]float64, promptTokens int) *EmbeddingResponse {
+	resp := &EmbeddingResponse{
+		Object: "list",
+		Data:   make([]EmbeddingData, len(vectors)),
+		Model:  model,
+		Usage:  EmbeddingUsage{PromptTokens: promptTokens, TotalTokens: promptTokens},
+	}
+	for i, vector := range vectors {
+		resp.Data[i] = EmbeddingData{Object: "embedding", Embedding: EmbeddingVector{Values: vector}, Index: i}
+	}
+	return resp
+}
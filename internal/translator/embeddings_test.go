@@ -0,0 +1,117 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEmbeddingInputs(t *testing.T) {
+	var req EmbeddingRequest
+	json.Unmarshal([]byte(`{"model": "m", "input": ["a", "b"]}`), &req)
+	texts, err := EmbeddingInputs(req.Input)
+	if err != nil || strings.Join(texts, ",") != "a,b" {
+		t.Errorf("Unexpected inputs %v (%v)", texts, err)
+	}
+
+	if texts, err := EmbeddingInputs("hello"); err != nil || len(texts) != 1 {
+		t.Errorf("Unexpected inputs %v (%v)", texts, err)
+	}
+
+	for _, body := range []string{
+		`{"input": ""}`,
+		`{"input": []}`,
+		`{"input": [1, 2, 3]}`,
+		`{"input": ["a", ""]}`,
+		`{}`,
+	} {
+		var req EmbeddingRequest
+		json.Unmarshal([]byte(body), &req)
+		if _, err := EmbeddingInputs(req.Input); err == nil {
+			t.Errorf("Expected an error for %s", body)
+		}
+	}
+}
+
+func TestEmbeddingVector(t *testing.T) {
+	vector := EmbeddingVector{Values: []float64{0.5, -1, 2}}
+
+	data, _ := json.Marshal(vector)
+	if string(data) != "[0.5,-1,2]" {
+		t.Errorf("Unexpected float encoding %s", data)
+	}
+
+	vector.Base64 = true
+	data, _ = json.Marshal(vector)
+	// Little-endian float32: 0x3f000000, 0xbf800000, 0x40000000
+	if string(data) != `"AAAAPwAAgL8AAABA"` {
+		t.Errorf("Unexpected base64 encoding %s", data)
+	}
+
+	var decoded EmbeddingVector
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !decoded.Base64 || len(decoded.Values) != 3 || decoded.Values[1] != -1 {
+		t.Errorf("Unexpected decoded vector %+v", decoded)
+	}
+}
+
+func TestTranslateOpenAIToBedrockEmbeddings(t *testing.T) {
+	t.Run("Titan", func(t *testing.T) {
+		req := &EmbeddingRequest{Dimensions: 512}
+		providerReq, err := TranslateOpenAIToBedrockEmbeddings(req, "amazon.titan-embed-text-v2:0", []string{"hello"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if providerReq.Path != "/model/amazon.titan-embed-text-v2:0/invoke" || string(providerReq.Body) != `{"inputText":"hello","dimensions":512}` {
+			t.Errorf("Unexpected request %s %s", providerReq.Path, providerReq.Body)
+		}
+
+		resp, err := TranslateBedrockEmbeddingsToOpenAI("amazon.titan-embed-text-v2:0", []byte(`{"embedding":[0.1,0.2],"inputTextTokenCount":3}`), nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(resp.Data) != 1 || len(resp.Data[0].Embedding.Values) != 2 || resp.Usage.PromptTokens != 3 {
+			t.Errorf("Unexpected response %+v", resp)
+		}
+
+		if _, err := TranslateOpenAIToBedrockEmbeddings(&EmbeddingRequest{Dimensions: 300}, "amazon.titan-embed-text-v2:0", []string{"x"}); err == nil {
+			t.Error("Expected unsupported dimensions to be rejected")
+		}
+		if _, err := TranslateOpenAIToBedrockEmbeddings(req, "amazon.titan-embed-text-v2:0", []string{"a", "b"}); err == nil {
+			t.Error("Expected Titan to reject more than one input")
+		}
+	})
+
+	t.Run("Cohere", func(t *testing.T) {
+		model := "cohere.embed-english-v3"
+		if BedrockEmbeddingBatchSize(model) != 96 {
+			t.Errorf("Unexpected Cohere batch size %d", BedrockEmbeddingBatchSize(model))
+		}
+
+		providerReq, err := TranslateOpenAIToBedrockEmbeddings(&EmbeddingRequest{InputType: "search_query"}, model, []string{"a", "b"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if string(providerReq.Body) != `{"texts":["a","b"],"input_type":"search_query","truncate":"NONE"}` {
+			t.Errorf("Unexpected body %s", providerReq.Body)
+		}
+
+		headers := map[string]string{"X-Amzn-Bedrock-Input-Token-Count": "7"}
+		resp, err := TranslateBedrockEmbeddingsToOpenAI(model, []byte(`{"id":"x","embeddings":[[1],[2]]}`), headers)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(resp.Data) != 2 || resp.Data[1].Index != 1 || resp.Usage.TotalTokens != 7 {
+			t.Errorf("Unexpected response %+v", resp)
+		}
+	})
+
+	if _, err := TranslateOpenAIToBedrockEmbeddings(&EmbeddingRequest{}, "anthropic.claude-3-haiku", []string{"x"}); err == nil {
+		t.Error("Expected non-embedding models to be rejected")
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InlineImageURLs returns a copy of req in which every image_url part that
+// is not already a data URL is replaced by the URL resolve returns for it.
+// The request is returned unchanged when it references no remote images.
+// Errors name the offending part, e.g. messages[1].content[0].
+func InlineImageURLs(req *ChatCompletionRequest, resolve func(url string) (string, error)) (*ChatCompletionRequest, error) {
+	return rewriteParts(req, func(part map[string]interface{}) (interface{}, error) {
+		if part["type"] != "image_url" {
+			return nil, nil
+		}
+		imageURL, _ := part["image_url"].(map[string]interface{})
+		url, _ := imageURL["url"].(string)
+		if url == "" || strings.HasPrefix(url, "data:") {
+			return nil, nil
+		}
+
+		resolved, err := resolve(url)
+		if err != nil {
+			return nil, err
+		}
+		newImageURL := make(map[string]interface{}, len(imageURL))
+		for k, v := range imageURL {
+			newImageURL[k] = v
+		}
+		newImageURL["url"] = resolved
+		return map[string]interface{}{"type": "image_url", "image_url": newImageURL}, nil
+	})
+}
+
+// rewriteParts returns a copy of req in which content parts are replaced by
+// what rewrite returns; a nil replacement keeps the part. The request is
+// returned unchanged when no part is replaced.
+func rewriteParts(req *ChatCompletionRequest, rewrite func(part map[string]interface{}) (interface{}, error)) (*ChatCompletionRequest, error) {
+	var messages []ChatMessage
+	for i, msg := range req.Messages {
+		parts, ok := msg.Content.([]interface{})
+		if !ok {
+			continue
+		}
+
+		var rewritten []interface{}
+		for j, part := range parts {
+			partMap, ok := part.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			replacement, err := rewrite(partMap)
+			if err != nil {
+				return nil, fmt.Errorf("messages[%d].content[%d]: %w", i, j, err)
+			}
+			if replacement == nil {
+				continue
+			}
+
+			// Copy on first write so the caller's request is left untouched
+			if messages == nil {
+				messages = append([]ChatMessage{}, req.Messages...)
+			}
+			if rewritten == nil {
+				rewritten = append([]interface{}{}, parts...)
+			}
+			rewritten[j] = replacement
+		}
+		if rewritten != nil {
+			messages[i].Content = rewritten
+		}
+	}
+
+	if messages == nil {
+		return req, nil
+	}
+	rewrittenReq := *req
+	rewrittenReq.Messages = messages
+	return &rewrittenReq, nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestInlineImageURLs(t *testing.T) {
+	imagePart := func(url string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":      "image_url",
+			"image_url": map[string]interface{}{"url": url, "detail": "low"},
+		}
+	}
+	req := &ChatCompletionRequest{
+		Model: "m",
+		Messages: []ChatMessage{
+			{Role: "system", Content: "Describe images."},
+			{Role: "user", Content: []interface{}{
+				map[string]interface{}{"type": "text", "text": "What is this?"},
+				imagePart("https://example.com/cat.png"),
+				imagePart("data:image/png;base64,AAAA"),
+				imagePart("s3://bucket/dog.jpg"),
+			}},
+		},
+	}
+
+	t.Run("Resolved", func(t *testing.T) {
+		var urls []string
+		inlined, err := InlineImageURLs(req, func(url string) (string, error) {
+			urls = append(urls, url)
+			return "data:image/png;base64,QkJCQg==", nil
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if strings.Join(urls, ",") != "https://example.com/cat.png,s3://bucket/dog.jpg" {
+			t.Errorf("Unexpected resolved URLs %v", urls)
+		}
+
+		parts := inlined.Messages[1].Content.([]interface{})
+		for _, i := range []int{1, 3} {
+			imageURL := parts[i].(map[string]interface{})["image_url"].(map[string]interface{})
+			if imageURL["url"] != "data:image/png;base64,QkJCQg==" || imageURL["detail"] != "low" {
+				t.Errorf("Unexpected part %d: %v", i, imageURL)
+			}
+		}
+
+		original := req.Messages[1].Content.([]interface{})[1].(map[string]interface{})["image_url"].(map[string]interface{})
+		if original["url"] != "https://example.com/cat.png" {
+			t.Error("Expected the original request to be unchanged")
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := InlineImageURLs(req, func(url string) (string, error) {
+			return "", errors.New("too large")
+		})
+		if err == nil || err.Error() != "messages[1].content[1]: too large" {
+			t.Errorf("Unexpected error %v", err)
+		}
+	})
+
+	t.Run("No remote images", func(t *testing.T) {
+		plain := &ChatCompletionRequest{Messages: []ChatMessage{{Role: "user", Content: "hi"}}}
+		inlined, err := InlineImageURLs(plain, func(string) (string, error) {
+			t.Fatal("Expected no resolution")
+			return "", nil
+		})
+		if err != nil || inlined != plain {
+			t.Errorf("Expected the request to be returned as is, got %v", err)
+		}
+	})
+}
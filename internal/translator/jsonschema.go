@@ -0,0 +1,278 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// maxSchemaRefs bounds the $ref chain followed without descending into the
+// value, so that self-referencing schemas cannot loop
+const maxSchemaRefs = 32
+
+// ValidateJSONSchema checks a decoded JSON value against the subset of JSON
+// Schema used by tool parameters and structured outputs: type, properties,
+// required, additionalProperties, items, enum, const, allOf, anyOf, oneOf and
+// local $ref into $defs or definitions. Other keywords are ignored.
+func ValidateJSONSchema(value interface{}, schema map[string]interface{}) error {
+	v := &schemaValidator{root: schema}
+	return v.validate(value, schema, "$", 0)
+}
+
+// checkSchemaRefs returns an error for a $ref in schema that does not point
+// into the schema itself, since only local references can be validated
+func checkSchemaRefs(schema map[string]interface{}) error {
+	var check func(node interface{}) error
+	check = func(node interface{}) error {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			if ref, ok := n["$ref"].(string); ok {
+				if _, err := resolveSchemaRef(schema, ref); err != nil {
+					return err
+				}
+			}
+			for _, child := range n {
+				if err := check(child); err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			for _, child := range n {
+				if err := check(child); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return check(schema)
+}
+
+// resolveSchemaRef resolves a local reference such as "#/$defs/address"
+func resolveSchemaRef(root map[string]interface{}, ref string) (map[string]interface{}, error) {
+	pointer, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, fmt.Errorf("unsupported $ref %q: only references within the schema are supported", ref)
+	}
+
+	var node interface{} = root
+	if pointer != "" {
+		for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+			token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+			object, ok := node.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("$ref %q does not resolve", ref)
+			}
+			if node, ok = object[token]; !ok {
+				return nil, fmt.Errorf("$ref %q does not resolve", ref)
+			}
+		}
+	}
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("$ref %q does not point to a schema", ref)
+	}
+	return schema, nil
+}
+
+// schemaValidator validates values against a schema and the definitions its
+// references point to
+type schemaValidator struct {
+	root map[string]interface{}
+}
+
+// validate checks value at path against schema; refs counts the references
+// followed since the validator last descended into the value
+func (sv *schemaValidator) validate(value interface{}, schema map[string]interface{}, path string, refs int) error {
+	if len(schema) == 0 {
+		return nil
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		if refs == maxSchemaRefs {
+			return fmt.Errorf("%s: $ref %q is nested too deeply", path, ref)
+		}
+		target, err := resolveSchemaRef(sv.root, ref)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := sv.validate(value, target, path, refs+1); err != nil {
+			return err
+		}
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if matchesType(value, t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), jsonTypeName(value))
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, candidate := range enum {
+			if reflect.DeepEqual(value, candidate) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value is not one of the allowed values", path)
+		}
+	}
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(value, constant) {
+		return fmt.Errorf("%s: value does not match the expected constant", path)
+	}
+
+	if options, ok := schema["allOf"].([]interface{}); ok {
+		for _, option := range options {
+			if optionSchema, ok := option.(map[string]interface{}); ok {
+				if err := sv.validate(value, optionSchema, path, refs); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		options, ok := schema[keyword].([]interface{})
+		if !ok {
+			continue
+		}
+		matches := 0
+		for _, option := range options {
+			if optionSchema, ok := option.(map[string]interface{}); ok && sv.validate(value, optionSchema, path, refs) == nil {
+				matches++
+				if keyword == "anyOf" {
+					break
+				}
+			}
+		}
+		if matches == 0 {
+			return fmt.Errorf("%s: value does not match any allowed schema", path)
+		}
+		if matches > 1 {
+			return fmt.Errorf("%s: value matches %d oneOf schemas, expected exactly one", path, matches)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if key, ok := name.(string); ok {
+					if _, present := v[key]; !present {
+						return fmt.Errorf("%s: missing required property %q", path, key)
+					}
+				}
+			}
+		}
+
+		// Check properties in a stable order so errors are deterministic
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			propertySchema, known := properties[key].(map[string]interface{})
+			if !known {
+				if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+					return fmt.Errorf("%s: unexpected property %q", path, key)
+				}
+				if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+					propertySchema = additional
+				}
+			}
+			if err := sv.validate(v[key], propertySchema, path+"."+key, 0); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := sv.validate(item, items, fmt.Sprintf("%s[%d]", path, i), 0); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// schemaTypes returns the allowed types of a schema's type keyword
+func schemaTypes(keyword interface{}) []string {
+	switch t := keyword.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var types []string
+		for _, item := range t {
+			if name, ok := item.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	default:
+		return nil
+	}
+}
+
+func matchesType(value interface{}, schemaType string) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	default:
+		return true
+	}
+}
+
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64, json.Number:
+		return "number"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// sseDone is the terminal data payload of an OpenAI-style stream
+const sseDone = "[DONE]"
+
+// SSEEvent represents a single Server-Sent Event
+type SSEEvent struct {
+	Event string
+	Data  string
+}
+
+// SSEReader reads Server-Sent Events from a stream
+type SSEReader struct {
+	reader *bufio.Reader
+}
+
+// NewSSEReader creates a new SSE reader
+func NewSSEReader(r io.Reader) *SSEReader {
+	return &SSEReader{reader: bufio.NewReaderSize(r, 64*1024)}
+}
+
+// Next returns the next event, or io.EOF when the stream ends
+func (r *SSEReader) Next() (*SSEEvent, error) {
+	event := &SSEEvent{}
+	var data []string
+	hasFields := false
+
+	for {
+		line, err := r.reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			if err == io.EOF && hasFields {
+				event.Data = strings.Join(data, "\n")
+				return event, nil
+			}
+			return nil, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		// A blank line dispatches the event
+		if line == "" {
+			if !hasFields {
+				continue
+			}
+			event.Data = strings.Join(data, "\n")
+			return event, nil
+		}
+
+		// Comment line (used for keep-alives)
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event.Event = value
+			hasFields = true
+		case "data":
+			data = append(data, value)
+			hasFields = true
+		}
+	}
+}
+
+// ChatCompletionStreamReader reads OpenAI chat.completion.chunk events from an SSE stream
+type ChatCompletionStreamReader struct {
+	sse *SSEReader
+}
+
+// NewChatCompletionStreamReader creates a reader for an OpenAI-format SSE stream
+func NewChatCompletionStreamReader(r io.Reader) *ChatCompletionStreamReader {
+	return &ChatCompletionStreamReader{sse: NewSSEReader(r)}
+}
+
+// Next returns the next chunk, or io.EOF once [DONE] is received or the stream ends
+func (r *ChatCompletionStreamReader) Next() (*ChatCompletionStreamResponse, error) {
+	for {
+		event, err := r.sse.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		data := strings.TrimSpace(event.Data)
+		if data == "" {
+			continue
+		}
+		if data == sseDone {
+			return nil, io.EOF
+		}
+
+		// Upstream errors are delivered in-band as {"error": {...}}
+		if strings.HasPrefix(data, `{"error"`) {
+			var errResp ErrorResponse
+			if err := json.Unmarshal([]byte(data), &errResp); err == nil && errResp.Error.Message != "" {
+				return nil, fmt.Errorf("stream error: %s", errResp.Error.Message)
+			}
+		}
+
+		var chunk ChatCompletionStreamResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("failed to parse stream chunk: %w", err)
+		}
+		return &chunk, nil
+	}
+}
+
+// NewChatCompletionChunk builds a single-choice chat.completion.chunk
+func NewChatCompletionChunk(id, model string, created int64, delta ChatMessageDelta, finishReason string) *ChatCompletionStreamResponse {
+	choice := ChatCompletionStreamChoice{
+		Index: 0,
+		Delta: delta,
+	}
+	if finishReason != "" {
+		choice.FinishReason = &finishReason
+	}
+
+	return &ChatCompletionStreamResponse{
+		ID:      id,
+		Object:  "chat.completion.chunk",
+		Created: created,
+		Model:   model,
+		Choices: []ChatCompletionStreamChoice{choice},
+	}
+}
+
+// WriteStreamChunk writes a chunk as an SSE data line
+func WriteStreamChunk(w io.Writer, chunk *ChatCompletionStreamResponse) error {
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return fmt.Errorf("failed to marshal stream chunk: %w", err)
+	}
+	return writeSSEData(w, data)
+}
+
+// WriteStreamError writes an OpenAI-style error object as an SSE data line
+func WriteStreamError(w io.Writer, detail ErrorDetail) error {
+	data, err := json.Marshal(ErrorResponse{Error: detail})
+	if err != nil {
+		return fmt.Errorf("failed to marshal stream error: %w", err)
+	}
+	return writeSSEData(w, data)
+}
+
+// WriteStreamDone writes the terminal [DONE] marker
+func WriteStreamDone(w io.Writer) error {
+	return writeSSEData(w, []byte(sseDone))
+}
+
+// writeSSEData writes a single data-only SSE event
+func writeSSEData(w io.Writer, data []byte) error {
+	var buf bytes.Buffer
+	buf.Grow(len(data) + 8)
+	buf.WriteString("data: ")
+	buf.Write(data)
+	buf.WriteString("\n\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// streamBody pairs a pipe reader with the upstream body it is fed from so that
+// closing the translated stream also releases the upstream connection
+type streamBody struct {
+	*io.PipeReader
+	upstream io.Closer
+}
+
+// Close closes both the pipe and the upstream body
+func (s *streamBody) Close() error {
+	s.PipeReader.Close()
+	return s.upstream.Close()
+}
+
+// PipeStream runs translate in a goroutine, feeding its output to the returned
+// ReadCloser. Providers use it to expose their native stream as OpenAI SSE.
+func PipeStream(upstream io.ReadCloser, translate func(w io.Writer) error) io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	go func() {
+		defer upstream.Close()
+		pw.CloseWithError(translate(pw))
+	}()
+
+	return &streamBody{PipeReader: pr, upstream: upstream}
+}
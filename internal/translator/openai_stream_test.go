@@ -0,0 +1,175 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"strings"
+	"testing"
+)
+
+// encodeTestEventStreamMessage builds a binary event stream message with string headers
+func encodeTestEventStreamMessage(headers map[string]string, payload []byte) []byte {
+	var hdr bytes.Buffer
+	for name, value := range headers {
+		hdr.WriteByte(byte(len(name)))
+		hdr.WriteString(name)
+		hdr.WriteByte(7)
+		binary.Write(&hdr, binary.BigEndian, uint16(len(value)))
+		hdr.WriteString(value)
+	}
+
+	totalLength := uint32(12 + hdr.Len() + len(payload) + 4)
+	var msg bytes.Buffer
+	binary.Write(&msg, binary.BigEndian, totalLength)
+	binary.Write(&msg, binary.BigEndian, uint32(hdr.Len()))
+	binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	msg.Write(hdr.Bytes())
+	msg.Write(payload)
+	binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	return msg.Bytes()
+}
+
+func testConverseEvent(eventType, payload string) []byte {
+	return encodeTestEventStreamMessage(map[string]string{
+		":message-type": "event",
+		":event-type":   eventType,
+		":content-type": "application/json",
+	}, []byte(payload))
+}
+
+func TestSSEReader(t *testing.T) {
+	input := ": keep-alive\n\nevent: message_start\ndata: {\"a\":1}\n\ndata: line1\ndata: line2\r\n\r\ndata: tail"
+	reader := NewSSEReader(strings.NewReader(input))
+
+	expected := []SSEEvent{
+		{Event: "message_start", Data: `{"a":1}`},
+		{Data: "line1\nline2"},
+		{Data: "tail"},
+	}
+
+	for i, want := range expected {
+		event, err := reader.Next()
+		if err != nil {
+			t.Fatalf("event %d: unexpected error: %v", i, err)
+		}
+		if *event != want {
+			t.Errorf("event %d: expected %+v, got %+v", i, want, *event)
+		}
+	}
+
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestChatCompletionStreamReader(t *testing.T) {
+	t.Run("Stops at DONE", func(t *testing.T) {
+		input := "data: {\"id\":\"x\",\"object\":\"chat.completion.chunk\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"},\"finish_reason\":null}]}\n\n" +
+			"data: [DONE]\n\n" +
+			"data: {\"ignored\":true}\n\n"
+		reader := NewChatCompletionStreamReader(strings.NewReader(input))
+
+		chunk, err := reader.Next()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if chunk.Choices[0].Delta.Content != "Hi" {
+			t.Errorf("Expected content 'Hi', got %q", chunk.Choices[0].Delta.Content)
+		}
+
+		if _, err := reader.Next(); err != io.EOF {
+			t.Errorf("Expected io.EOF after [DONE], got %v", err)
+		}
+	})
+
+	t.Run("In-band error", func(t *testing.T) {
+		input := "data: {\"error\":{\"message\":\"overloaded\",\"type\":\"api_error\"}}\n\n"
+		reader := NewChatCompletionStreamReader(strings.NewReader(input))
+
+		_, err := reader.Next()
+		if err == nil || !strings.Contains(err.Error(), "overloaded") {
+			t.Errorf("Expected stream error containing 'overloaded', got %v", err)
+		}
+	})
+}
+
+func TestConverseStreamReader(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(testConverseEvent("messageStart", `{"role":"assistant"}`))
+	stream.Write(testConverseEvent("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"Hello"}}`))
+	stream.Write(testConverseEvent("contentBlockStop", `{"contentBlockIndex":0}`))
+	stream.Write(testConverseEvent("contentBlockStart", `{"contentBlockIndex":1,"start":{"toolUse":{"toolUseId":"tool-1","name":"get_weather"}}}`))
+	stream.Write(testConverseEvent("contentBlockDelta", `{"contentBlockIndex":1,"delta":{"toolUse":{"input":"{\"city\":"}}}`))
+	stream.Write(testConverseEvent("messageStop", `{"stopReason":"tool_use"}`))
+	stream.Write(testConverseEvent("metadata", `{"usage":{"inputTokens":10,"outputTokens":5,"totalTokens":15}}`))
+
+	reader := NewConverseStreamReader(&stream, "claude-3-sonnet", "chatcmpl-test")
+
+	var chunks []*ChatCompletionStreamResponse
+	for {
+		chunk, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	if len(chunks) != 6 {
+		t.Fatalf("Expected 6 chunks, got %d", len(chunks))
+	}
+
+	if chunks[0].Choices[0].Delta.Role != "assistant" {
+		t.Errorf("Expected role chunk first, got %+v", chunks[0].Choices[0].Delta)
+	}
+	if chunks[1].Choices[0].Delta.Content != "Hello" {
+		t.Errorf("Expected content 'Hello', got %q", chunks[1].Choices[0].Delta.Content)
+	}
+
+	toolStart := chunks[2].Choices[0].Delta.ToolCalls[0]
+	if toolStart.ID != "tool-1" || toolStart.Function.Name != "get_weather" || *toolStart.Index != 0 {
+		t.Errorf("Unexpected tool call start: %+v", toolStart)
+	}
+	toolDelta := chunks[3].Choices[0].Delta.ToolCalls[0]
+	if toolDelta.Function.Arguments != `{"city":` || *toolDelta.Index != 0 {
+		t.Errorf("Unexpected tool call delta: %+v", toolDelta)
+	}
+
+	if reason := chunks[4].Choices[0].FinishReason; reason == nil || *reason != "tool_calls" {
+		t.Errorf("Expected finish_reason 'tool_calls', got %v", reason)
+	}
+
+	if chunks[5].Usage == nil || chunks[5].Usage.TotalTokens != 15 {
+		t.Errorf("Expected usage chunk with 15 total tokens, got %+v", chunks[5].Usage)
+	}
+	if len(chunks[5].Choices) != 0 {
+		t.Errorf("Expected usage chunk to have no choices")
+	}
+}
+
+func TestConverseStreamReaderException(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(testConverseEvent("messageStart", `{"role":"assistant"}`))
+	stream.Write(encodeTestEventStreamMessage(map[string]string{
+		":message-type":   "exception",
+		":exception-type": "throttlingException",
+	}, []byte(`{"message":"Too many requests"}`)))
+
+	reader := NewConverseStreamReader(&stream, "claude-3-sonnet", "chatcmpl-test")
+
+	if _, err := reader.Next(); err != nil {
+		t.Fatalf("Unexpected error on first chunk: %v", err)
+	}
+
+	_, err := reader.Next()
+	if err == nil || !strings.Contains(err.Error(), "Too many requests") {
+		t.Errorf("Expected throttling error, got %v", err)
+	}
+}
@@ -14,6 +14,7 @@ type ChatCompletionRequest struct {
 	TopP             float64                `json:"top_p,omitempty"`
 	N                int                    `json:"n,omitempty"`
 	Stream           bool                   `json:"stream,omitempty"`
+	StreamOptions    *StreamOptions         `json:"stream_options,omitempty"`
 	Stop             []string               `json:"stop,omitempty"`
 	PresencePenalty  float64                `json:"presence_penalty,omitempty"`
 	FrequencyPenalty float64                `json:"frequency_penalty,omitempty"`
@@ -26,6 +27,11 @@ type ChatCompletionRequest struct {
 	ResponseFormat   *ResponseFormat        `json:"response_format,omitempty"`
 }
 
+// StreamOptions controls what a streamed completion includes
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"` // send a final chunk with token usage
+}
+
 // ChatMessage represents a message in the conversation
 type ChatMessage struct {
 	Role       string       `json:"role"` // system, user, assistant, function, tool
@@ -70,6 +76,7 @@ type FunctionCall struct {
 
 // ToolCall represents a tool call
 type ToolCall struct {
+	Index    *int         `json:"index,omitempty"` // position in a streamed tool_calls delta
 	ID       string       `json:"id"`
 	Type     string       `json:"type"` // function
 	Function FunctionCall `json:"function"`
@@ -77,7 +84,16 @@ type ToolCall struct {
 
 // ResponseFormat specifies the format of the response
 type ResponseFormat struct {
-	Type string `json:"type"` // text or json_object
+	Type       string            `json:"type"` // text, json_object or json_schema
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+// JSONSchemaFormat describes the schema of a json_schema response format
+type JSONSchemaFormat struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Schema      map[string]interface{} `json:"schema,omitempty"`
+	Strict      *bool                  `json:"strict,omitempty"`
 }
 
 // ChatCompletionResponse represents an OpenAI chat completion response
@@ -127,6 +143,7 @@ type ChatCompletionStreamResponse struct {
 	Model             string                      `json:"model"`
 	SystemFingerprint string                      `json:"system_fingerprint,omitempty"`
 	Choices           []ChatCompletionStreamChoice `json:"choices"`
+	Usage             *Usage                      `json:"usage,omitempty"` // set on the final usage-only chunk
 }
 
 // ChatCompletionStreamChoice represents a choice in a streaming response
@@ -0,0 +1,300 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Structured outputs (response_format json_schema) are served natively where
+// possible: OpenAI and Azure accept the schema, Vertex takes it as
+// responseSchema, and Bedrock and Anthropic are forced to call a tool whose
+// input schema is the response schema. Other providers get the schema in
+// the prompt. The gateway validates every output against the schema.
+
+// defaultStructuredOutputName names the forced tool when the schema has no name
+const defaultStructuredOutputName = "json_response"
+
+// StructuredOutputError reports output that does not match the response schema
+type StructuredOutputError struct {
+	Schema string
+	Err    error
+}
+
+func (e *StructuredOutputError) Error() string {
+	return fmt.Sprintf("model output does not match response schema %q: %v", e.Schema, e.Err)
+}
+
+func (e *StructuredOutputError) Unwrap() error {
+	return e.Err
+}
+
+// StructuredOutputSchema returns the json_schema format of a request, or nil
+// when the request does not ask for structured output
+func StructuredOutputSchema(req *ChatCompletionRequest) *JSONSchemaFormat {
+	if req.ResponseFormat == nil || req.ResponseFormat.Type != "json_schema" {
+		return nil
+	}
+	return req.ResponseFormat.JSONSchema
+}
+
+// ValidateResponseFormat checks the response_format of a request
+func ValidateResponseFormat(format *ResponseFormat) error {
+	if format == nil {
+		return nil
+	}
+	switch format.Type {
+	case "", "text", "json_object":
+		return nil
+	case "json_schema":
+		if format.JSONSchema == nil || format.JSONSchema.Schema == nil {
+			return fmt.Errorf("response_format.json_schema.schema is required for type json_schema")
+		}
+		if schemaType, ok := format.JSONSchema.Schema["type"]; ok && schemaType != "object" {
+			return fmt.Errorf("response_format.json_schema.schema must describe an object")
+		}
+		if err := checkSchemaRefs(format.JSONSchema.Schema); err != nil {
+			return fmt.Errorf("response_format.json_schema.schema: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported response_format type %q", format.Type)
+	}
+}
+
+// structuredOutputToolName is the name of the tool forced for a schema
+func structuredOutputToolName(format *JSONSchemaFormat) string {
+	if format.Name != "" {
+		return format.Name
+	}
+	return defaultStructuredOutputName
+}
+
+// StructuredOutputToolRequest returns a copy of req that forces a tool call
+// whose input schema is the response schema, for providers whose native
+// structured output is tool use. Requests that offer their own tools are
+// returned unchanged.
+func StructuredOutputToolRequest(req *ChatCompletionRequest) *ChatCompletionRequest {
+	format := StructuredOutputSchema(req)
+	if format == nil || len(req.Tools) > 0 {
+		return req
+	}
+
+	description := format.Description
+	if description == "" {
+		description = "Respond with a JSON object matching this schema."
+	}
+	name := structuredOutputToolName(format)
+
+	forced := *req
+	forced.ResponseFormat = nil
+	forced.Tools = []Tool{{
+		Type:     "function",
+		Function: Function{Name: name, Description: description, Parameters: format.Schema},
+	}}
+	forced.ToolChoice = map[string]interface{}{
+		"type":     "function",
+		"function": map[string]interface{}{"name": name},
+	}
+	return &forced
+}
+
+// StructuredOutputPromptRequest returns a copy of req with the response
+// schema described in the system prompt, for providers without native support
+func StructuredOutputPromptRequest(req *ChatCompletionRequest) *ChatCompletionRequest {
+	format := StructuredOutputSchema(req)
+	if format == nil {
+		return req
+	}
+
+	schema, _ := json.Marshal(format.Schema)
+	instructions := "Respond with only a JSON object, without code fences or other text, that matches this JSON Schema:\n" + string(schema)
+	if format.Description != "" {
+		instructions = format.Description + "\n" + instructions
+	}
+
+	prompted := *req
+	prompted.ResponseFormat = nil
+	prompted.Messages = make([]ChatMessage, 0, len(req.Messages)+1)
+	if len(req.Messages) > 0 && req.Messages[0].Role == "system" {
+		text := ""
+		if req.Messages[0].Content != nil {
+			text = extractTextContent(req.Messages[0].Content)
+		}
+		prompted.Messages = append(prompted.Messages, ChatMessage{Role: "system", Content: text + "\n\n" + instructions})
+		prompted.Messages = append(prompted.Messages, req.Messages[1:]...)
+	} else {
+		prompted.Messages = append(prompted.Messages, ChatMessage{Role: "system", Content: instructions})
+		prompted.Messages = append(prompted.Messages, req.Messages...)
+	}
+	return &prompted
+}
+
+// UnwrapStructuredOutput turns the forced tool call of a StructuredOutputToolRequest
+// back into message content
+func UnwrapStructuredOutput(resp *ChatCompletionResponse, req *ChatCompletionRequest) {
+	format := StructuredOutputSchema(req)
+	if format == nil || len(req.Tools) > 0 || len(resp.Choices) == 0 {
+		return
+	}
+
+	choice := &resp.Choices[0]
+	name := structuredOutputToolName(format)
+	for _, call := range choice.Message.ToolCalls {
+		if call.Function.Name == name {
+			choice.Message.Content = call.Function.Arguments
+			choice.Message.ToolCalls = nil
+			if choice.FinishReason == "tool_calls" {
+				choice.FinishReason = "stop"
+			}
+			return
+		}
+	}
+}
+
+// ValidateStructuredOutput checks the first choice of a response against the
+// requested schema. Code fences around the JSON are removed. Responses that
+// call tools are not checked, since the schema applies to message content.
+func ValidateStructuredOutput(resp *ChatCompletionResponse, req *ChatCompletionRequest) error {
+	format := StructuredOutputSchema(req)
+	if format == nil || len(resp.Choices) == 0 {
+		return nil
+	}
+	choice := &resp.Choices[0]
+	if len(choice.Message.ToolCalls) > 0 {
+		return nil
+	}
+
+	text := ""
+	if choice.Message.Content != nil {
+		text = extractTextContent(choice.Message.Content)
+	}
+	text = trimCodeFence(text)
+	if err := validateStructuredText(text, format, choice.FinishReason); err != nil {
+		return err
+	}
+	choice.Message.Content = text
+	return nil
+}
+
+// validateStructuredText parses and validates output text
+func validateStructuredText(text string, format *JSONSchemaFormat, finishReason string) error {
+	name := structuredOutputToolName(format)
+	if finishReason == "length" {
+		return &StructuredOutputError{Schema: name, Err: fmt.Errorf("output was truncated at the token limit")}
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return &StructuredOutputError{Schema: name, Err: fmt.Errorf("invalid JSON: %w", err)}
+	}
+	if err := ValidateJSONSchema(value, format.Schema); err != nil {
+		return &StructuredOutputError{Schema: name, Err: err}
+	}
+	return nil
+}
+
+// StructuredOutputRepairRequest returns a copy of req that shows the model its
+// invalid output and the validation error and asks for a corrected answer
+func StructuredOutputRepairRequest(req *ChatCompletionRequest, resp *ChatCompletionResponse, validationErr error) *ChatCompletionRequest {
+	output := ""
+	if len(resp.Choices) > 0 && resp.Choices[0].Message.Content != nil {
+		output = extractTextContent(resp.Choices[0].Message.Content)
+	}
+
+	reason := validationErr.Error()
+	var outputErr *StructuredOutputError
+	if errors.As(validationErr, &outputErr) {
+		reason = outputErr.Err.Error()
+	}
+
+	repair := *req
+	repair.Messages = append(append([]ChatMessage{}, req.Messages...),
+		ChatMessage{Role: "assistant", Content: output},
+		ChatMessage{Role: "user", Content: fmt.Sprintf(
+			"Your previous reply did not match the required JSON schema (%s). Reply again with only the corrected JSON object.", reason)},
+	)
+	return &repair
+}
+
+// trimCodeFence removes a markdown code fence around JSON output
+func trimCodeFence(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") {
+		return text
+	}
+	text = strings.TrimPrefix(text, "```json")
+	text = strings.TrimPrefix(text, "```")
+	text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	return strings.TrimSpace(text)
+}
+
+// StructuredOutputStreamReader passes a chunk stream through, turning the
+// forced tool call of a StructuredOutputToolRequest into content deltas, and
+// validates the complete output when the stream ends. Invalid output is
+// reported as a *StructuredOutputError instead of io.EOF; the content has
+// already been sent by then, so no repair is attempted.
+type StructuredOutputStreamReader struct {
+	next     func() (*ChatCompletionStreamResponse, error)
+	format   *JSONSchemaFormat
+	toolName string // empty when the request offers its own tools
+
+	content      strings.Builder
+	calledTools  bool
+	finishReason string
+}
+
+// NewStructuredOutputStreamReader wraps a chunk reader for a request with a json_schema response format
+func NewStructuredOutputStreamReader(next func() (*ChatCompletionStreamResponse, error), req *ChatCompletionRequest) *StructuredOutputStreamReader {
+	r := &StructuredOutputStreamReader{next: next, format: StructuredOutputSchema(req)}
+	if r.format != nil && len(req.Tools) == 0 {
+		r.toolName = structuredOutputToolName(r.format)
+	}
+	return r
+}
+
+// Next returns the next chunk, or io.EOF at the end of a valid stream
+func (r *StructuredOutputStreamReader) Next() (*ChatCompletionStreamResponse, error) {
+	chunk, err := r.next()
+	if err == io.EOF {
+		if r.format == nil || r.calledTools {
+			return nil, io.EOF
+		}
+		if err := validateStructuredText(trimCodeFence(r.content.String()), r.format, r.finishReason); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	if err != nil || r.format == nil {
+		return chunk, err
+	}
+
+	for i := range chunk.Choices {
+		choice := &chunk.Choices[i]
+		if r.toolName != "" && len(choice.Delta.ToolCalls) > 0 {
+			// The forced call is the only one, so its argument fragments are the content
+			for _, call := range choice.Delta.ToolCalls {
+				choice.Delta.Content += call.Function.Arguments
+			}
+			choice.Delta.ToolCalls = nil
+		}
+		if len(choice.Delta.ToolCalls) > 0 {
+			r.calledTools = true
+		}
+		r.content.WriteString(choice.Delta.Content)
+
+		if choice.FinishReason != nil && *choice.FinishReason != "" {
+			if r.toolName != "" && *choice.FinishReason == "tool_calls" {
+				stop := "stop"
+				choice.FinishReason = &stop
+			}
+			r.finishReason = *choice.FinishReason
+		}
+	}
+	return chunk, nil
+}
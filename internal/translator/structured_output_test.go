@@ -0,0 +1,230 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func structuredRequest() *ChatCompletionRequest {
+	return &ChatCompletionRequest{
+		Model:    "m",
+		Messages: []ChatMessage{{Role: "user", Content: "Weather in Paris?"}},
+		ResponseFormat: &ResponseFormat{
+			Type: "json_schema",
+			JSONSchema: &JSONSchemaFormat{
+				Name:   "weather",
+				Schema: weatherTool.Function.Parameters,
+			},
+		},
+	}
+}
+
+func TestValidateResponseFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		format *ResponseFormat
+		valid  bool
+	}{
+		{"Nil", nil, true},
+		{"JSON object", &ResponseFormat{Type: "json_object"}, true},
+		{"JSON schema", structuredRequest().ResponseFormat, true},
+		{"Missing schema", &ResponseFormat{Type: "json_schema"}, false},
+		{"Non-object schema", &ResponseFormat{Type: "json_schema", JSONSchema: &JSONSchemaFormat{Schema: map[string]interface{}{"type": "array"}}}, false},
+		{"Unknown type", &ResponseFormat{Type: "yaml"}, false},
+		{"Local reference", &ResponseFormat{Type: "json_schema", JSONSchema: &JSONSchemaFormat{Schema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"city": map[string]interface{}{"$ref": "#/$defs/city"}},
+			"$defs":      map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+		}}}, true},
+		{"Unresolved reference", &ResponseFormat{Type: "json_schema", JSONSchema: &JSONSchemaFormat{Schema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"city": map[string]interface{}{"$ref": "#/$defs/city"}},
+		}}}, false},
+		{"Remote reference", &ResponseFormat{Type: "json_schema", JSONSchema: &JSONSchemaFormat{Schema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"city": map[string]interface{}{"$ref": "https://example.com/city.json"}},
+		}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateResponseFormat(tt.format); (err == nil) != tt.valid {
+				t.Errorf("ValidateResponseFormat() = %v, want valid=%v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestStructuredOutputToolRequest(t *testing.T) {
+	req := structuredRequest()
+	forced := StructuredOutputToolRequest(req)
+	if forced.ResponseFormat != nil || len(forced.Tools) != 1 || forced.Tools[0].Function.Name != "weather" {
+		t.Fatalf("Unexpected forced request %+v", forced)
+	}
+	if req.ResponseFormat == nil || req.Tools != nil {
+		t.Error("Expected the original request to be unchanged")
+	}
+
+	// Bedrock maps the forced tool_choice to a specific tool
+	forced.Model = "claude-3-5-sonnet"
+	providerReq, _, err := TranslateOpenAIToConverseAPI(forced)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var converseReq ConverseRequest
+	if err := json.Unmarshal(providerReq.Body, &converseReq); err != nil {
+		t.Fatalf("Failed to parse Converse request: %v", err)
+	}
+	if converseReq.ToolConfig == nil || converseReq.ToolConfig.ToolChoice.Tool == nil || converseReq.ToolConfig.ToolChoice.Tool.Name != "weather" {
+		t.Errorf("Expected a forced tool choice, got %+v", converseReq.ToolConfig)
+	}
+
+	resp := &ChatCompletionResponse{Choices: []ChatCompletionChoice{{
+		Message: ChatMessage{Role: "assistant", ToolCalls: []ToolCall{{
+			ID: "call_1", Type: "function", Function: FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`},
+		}}},
+		FinishReason: "tool_calls",
+	}}}
+	UnwrapStructuredOutput(resp, req)
+	choice := resp.Choices[0]
+	if choice.Message.Content != `{"city":"Paris"}` || choice.Message.ToolCalls != nil || choice.FinishReason != "stop" {
+		t.Errorf("Unexpected unwrapped choice %+v", choice)
+	}
+
+	withTools := structuredRequest()
+	withTools.Tools = []Tool{weatherTool}
+	if StructuredOutputToolRequest(withTools) != withTools {
+		t.Error("Expected requests with their own tools to be left alone")
+	}
+}
+
+func TestStructuredOutputPromptRequest(t *testing.T) {
+	req := structuredRequest()
+	req.Messages = append([]ChatMessage{{Role: "system", Content: "Be brief."}}, req.Messages...)
+
+	prompted := StructuredOutputPromptRequest(req)
+	if prompted.ResponseFormat != nil || len(prompted.Messages) != 2 {
+		t.Fatalf("Unexpected prompted request %+v", prompted)
+	}
+	system := prompted.Messages[0].Content.(string)
+	if !strings.HasPrefix(system, "Be brief.") || !strings.Contains(system, `"required":["city"]`) {
+		t.Errorf("Unexpected system prompt:\n%s", system)
+	}
+}
+
+func TestValidateStructuredOutput(t *testing.T) {
+	response := func(content string, finish string) *ChatCompletionResponse {
+		return &ChatCompletionResponse{Choices: []ChatCompletionChoice{{
+			Message:      ChatMessage{Role: "assistant", Content: content},
+			FinishReason: finish,
+		}}}
+	}
+	req := structuredRequest()
+
+	t.Run("Valid", func(t *testing.T) {
+		resp := response("```json\n{\"city\": \"Paris\"}\n```", "stop")
+		if err := ValidateStructuredOutput(resp, req); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if resp.Choices[0].Message.Content != `{"city": "Paris"}` {
+			t.Errorf("Expected the code fence to be removed, got %q", resp.Choices[0].Message.Content)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, resp := range []*ChatCompletionResponse{
+			response(`{"unit": "C"}`, "stop"),
+			response(`The weather is nice`, "stop"),
+			response(`{"city": "Paris"}`, "length"),
+		} {
+			err := ValidateStructuredOutput(resp, req)
+			var outputErr *StructuredOutputError
+			if !errors.As(err, &outputErr) || outputErr.Schema != "weather" {
+				t.Errorf("Expected a StructuredOutputError, got %v", err)
+			}
+		}
+	})
+
+	t.Run("Repair", func(t *testing.T) {
+		resp := response(`{"unit": "C"}`, "stop")
+		repair := StructuredOutputRepairRequest(req, resp, ValidateStructuredOutput(resp, req))
+		if len(repair.Messages) != 3 || repair.Messages[1].Content != `{"unit": "C"}` {
+			t.Fatalf("Unexpected repair messages %+v", repair.Messages)
+		}
+		if !strings.Contains(repair.Messages[2].Content.(string), `missing required property "city"`) {
+			t.Errorf("Expected the validation error in the repair prompt, got %q", repair.Messages[2].Content)
+		}
+		if repair.ResponseFormat == nil || len(req.Messages) != 1 {
+			t.Error("Expected the repair request to keep the format and the original to be unchanged")
+		}
+	})
+
+	t.Run("Not structured", func(t *testing.T) {
+		if err := ValidateStructuredOutput(response("hi", "stop"), &ChatCompletionRequest{}); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
+
+func TestStructuredOutputStreamReader(t *testing.T) {
+	read := func(chunks []*ChatCompletionStreamResponse) (string, string, error) {
+		next := func() (*ChatCompletionStreamResponse, error) {
+			if len(chunks) == 0 {
+				return nil, io.EOF
+			}
+			chunk := chunks[0]
+			chunks = chunks[1:]
+			return chunk, nil
+		}
+		reader := NewStructuredOutputStreamReader(next, structuredRequest())
+		var text, finish string
+		for {
+			chunk, err := reader.Next()
+			if err == io.EOF {
+				return text, finish, nil
+			}
+			if err != nil {
+				return text, finish, err
+			}
+			for _, choice := range chunk.Choices {
+				if len(choice.Delta.ToolCalls) > 0 {
+					t.Error("Expected forced tool call deltas to become content")
+				}
+				text += choice.Delta.Content
+				if choice.FinishReason != nil {
+					finish = *choice.FinishReason
+				}
+			}
+		}
+	}
+	toolDelta := func(arguments string) *ChatCompletionStreamResponse {
+		return NewChatCompletionChunk("x", "m", 0, ChatMessageDelta{ToolCalls: []ToolCall{{Function: FunctionCall{Name: "weather", Arguments: arguments}}}}, "")
+	}
+
+	t.Run("Forced tool", func(t *testing.T) {
+		text, finish, err := read([]*ChatCompletionStreamResponse{
+			toolDelta(`{"city":`),
+			toolDelta(` "Oslo"}`),
+			NewChatCompletionChunk("x", "m", 0, ChatMessageDelta{}, "tool_calls"),
+		})
+		if err != nil || text != `{"city": "Oslo"}` || finish != "stop" {
+			t.Errorf("Unexpected text %q, finish %q, error %v", text, finish, err)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, _, err := read([]*ChatCompletionStreamResponse{
+			NewChatCompletionChunk("x", "m", 0, ChatMessageDelta{Content: `{"unit": "C"}`}, "stop"),
+		})
+		var outputErr *StructuredOutputError
+		if !errors.As(err, &outputErr) {
+			t.Errorf("Expected a StructuredOutputError, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,399 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Tool emulation lets models without native tool calling act as agents: tool
+// schemas are described in the system prompt, the model answers with a
+// <tool_calls> block, and the block is parsed back into OpenAI tool calls.
+// Earlier tool calls and results are rendered in the same text format.
+const (
+	toolCallsOpenTag  = "<tool_calls>"
+	toolCallsCloseTag = "</tool_calls>"
+)
+
+// UsesTools reports whether a request offers tools or carries tool call history
+func UsesTools(req *ChatCompletionRequest) bool {
+	if len(req.Tools) > 0 {
+		return true
+	}
+	for _, msg := range req.Messages {
+		if msg.Role == "tool" || len(msg.ToolCalls) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// emulatedToolCall is the format models are asked to write tool calls in
+type emulatedToolCall struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// EmulateTools returns a copy of req with tools described in the system
+// prompt and tool messages rendered as text, for providers without native
+// tool calling
+func EmulateTools(req *ChatCompletionRequest) *ChatCompletionRequest {
+	emulated := *req
+	emulated.Tools = nil
+	emulated.ToolChoice = nil
+	emulated.Messages = nil
+
+	instructions := toolInstructions(req.Tools, req.ToolChoice)
+	toolNames := map[string]string{} // tool call ID -> function name
+	var results []string
+
+	flushResults := func() {
+		if len(results) > 0 {
+			emulated.Messages = append(emulated.Messages, ChatMessage{Role: "user", Content: strings.Join(results, "\n\n")})
+			results = nil
+		}
+	}
+
+	for i, msg := range req.Messages {
+		switch {
+		case msg.Role == "system" && i == 0 && instructions != "":
+			// Extend the leading system prompt rather than adding a second one
+			text := ""
+			if msg.Content != nil {
+				text = extractTextContent(msg.Content)
+			}
+			emulated.Messages = append(emulated.Messages, ChatMessage{Role: "system", Content: text + "\n\n" + instructions})
+			instructions = ""
+		case msg.Role == "tool":
+			content := ""
+			if msg.Content != nil {
+				content = extractTextContent(msg.Content)
+			}
+			results = append(results, fmt.Sprintf("Tool result for %s (call %s):\n%s", toolNames[msg.ToolCallID], msg.ToolCallID, content))
+		case msg.Role == "assistant" && len(msg.ToolCalls) > 0:
+			flushResults()
+			calls := make([]emulatedToolCall, len(msg.ToolCalls))
+			for j, call := range msg.ToolCalls {
+				toolNames[call.ID] = call.Function.Name
+				arguments := json.RawMessage(call.Function.Arguments)
+				if !json.Valid(arguments) {
+					arguments = json.RawMessage("{}")
+				}
+				calls[j] = emulatedToolCall{Name: call.Function.Name, Arguments: arguments}
+			}
+			block, _ := json.Marshal(calls)
+
+			text := ""
+			if msg.Content != nil {
+				text = extractTextContent(msg.Content)
+				if text != "" {
+					text += "\n"
+				}
+			}
+			emulated.Messages = append(emulated.Messages, ChatMessage{
+				Role:    "assistant",
+				Content: text + toolCallsOpenTag + "\n" + string(block) + "\n" + toolCallsCloseTag,
+			})
+		default:
+			flushResults()
+			emulated.Messages = append(emulated.Messages, msg)
+		}
+	}
+	flushResults()
+
+	if instructions != "" {
+		emulated.Messages = append([]ChatMessage{{Role: "system", Content: instructions}}, emulated.Messages...)
+	}
+	if len(req.Tools) > 0 && req.ToolChoice != "none" {
+		// Stop after the block so the model cannot invent tool results
+		emulated.Stop = append(append([]string{}, req.Stop...), toolCallsCloseTag)
+	}
+	return &emulated
+}
+
+// toolInstructions describes the tools and the call format for the system prompt
+func toolInstructions(tools []Tool, toolChoice interface{}) string {
+	if len(tools) == 0 || toolChoice == "none" {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("You have access to the following tools. To call tools, reply with only a ")
+	b.WriteString(toolCallsOpenTag)
+	b.WriteString(" block containing a JSON array of calls, each with the tool \"name\" and an \"arguments\" object matching the tool's parameters schema:\n")
+	b.WriteString(toolCallsOpenTag + "\n[{\"name\": \"tool_name\", \"arguments\": {\"parameter\": \"value\"}}]\n" + toolCallsCloseTag + "\n")
+	b.WriteString("Results are returned in messages starting with \"Tool result\". If no tool is needed, answer normally without a ")
+	b.WriteString(toolCallsOpenTag)
+	b.WriteString(" block.\n")
+
+	switch choice := toolChoice.(type) {
+	case string:
+		if choice == "required" {
+			b.WriteString("You must call at least one tool.\n")
+		}
+	case map[string]interface{}:
+		if function, ok := choice["function"].(map[string]interface{}); ok {
+			b.WriteString(fmt.Sprintf("You must call the tool %q.\n", function["name"]))
+		}
+	}
+
+	b.WriteString("\nTools:\n")
+	for _, tool := range tools {
+		spec, _ := json.Marshal(map[string]interface{}{
+			"name":        tool.Function.Name,
+			"description": tool.Function.Description,
+			"parameters":  tool.Function.Parameters,
+		})
+		b.Write(spec)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// ParseEmulatedToolCalls converts a <tool_calls> block in the first choice of
+// an emulated response into tool calls. Responses whose block does not parse
+// or validate against the tool schemas are left unchanged.
+func ParseEmulatedToolCalls(resp *ChatCompletionResponse, tools []Tool) error {
+	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == nil {
+		return nil
+	}
+	choice := &resp.Choices[0]
+
+	text := extractTextContent(choice.Message.Content)
+	start := strings.Index(text, toolCallsOpenTag)
+	if start < 0 {
+		return nil
+	}
+
+	calls, err := parseToolCallBlock(text[start:], tools)
+	if err != nil {
+		return err
+	}
+
+	if prefix := strings.TrimSpace(text[:start]); prefix != "" {
+		choice.Message.Content = prefix
+	} else {
+		choice.Message.Content = nil
+	}
+	choice.Message.ToolCalls = calls
+	choice.FinishReason = "tool_calls"
+	return nil
+}
+
+// parseToolCallBlock parses and validates a block starting with the open
+// tag; the close tag may be missing when it was used as a stop sequence
+func parseToolCallBlock(block string, tools []Tool) ([]ToolCall, error) {
+	body := strings.TrimPrefix(block, toolCallsOpenTag)
+	if end := strings.Index(body, toolCallsCloseTag); end >= 0 {
+		body = body[:end]
+	}
+	body = strings.TrimSpace(body)
+
+	// Tolerate a fenced code block inside the tags
+	if strings.HasPrefix(body, "```") {
+		body = strings.TrimPrefix(body, "```json")
+		body = strings.TrimPrefix(body, "```")
+		body = strings.TrimSuffix(strings.TrimSpace(body), "```")
+		body = strings.TrimSpace(body)
+	}
+
+	var emulated []emulatedToolCall
+	if strings.HasPrefix(body, "{") {
+		var single emulatedToolCall
+		if err := json.Unmarshal([]byte(body), &single); err != nil {
+			return nil, fmt.Errorf("invalid tool call JSON: %w", err)
+		}
+		emulated = []emulatedToolCall{single}
+	} else if err := json.Unmarshal([]byte(body), &emulated); err != nil {
+		return nil, fmt.Errorf("invalid tool call JSON: %w", err)
+	}
+	if len(emulated) == 0 {
+		return nil, fmt.Errorf("empty tool call block")
+	}
+
+	schemas := make(map[string]map[string]interface{}, len(tools))
+	for _, tool := range tools {
+		schemas[tool.Function.Name] = tool.Function.Parameters
+	}
+
+	calls := make([]ToolCall, len(emulated))
+	for i, call := range emulated {
+		schema, known := schemas[call.Name]
+		if !known {
+			return nil, fmt.Errorf("unknown tool %q", call.Name)
+		}
+
+		arguments := call.Arguments
+		if len(arguments) == 0 || string(arguments) == "null" {
+			arguments = json.RawMessage("{}")
+		}
+		// Some models write the arguments as a JSON string
+		var encoded string
+		if json.Unmarshal(arguments, &encoded) == nil {
+			arguments = json.RawMessage(encoded)
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(arguments, &value); err != nil {
+			return nil, fmt.Errorf("invalid arguments for tool %q: %w", call.Name, err)
+		}
+		if _, ok := value.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("arguments for tool %q must be an object", call.Name)
+		}
+		if err := ValidateJSONSchema(value, schema); err != nil {
+			return nil, fmt.Errorf("invalid arguments for tool %q: %w", call.Name, err)
+		}
+		compact, _ := json.Marshal(value)
+
+		calls[i] = ToolCall{
+			ID:       "call_" + strings.ReplaceAll(uuid.New().String(), "-", "")[:24],
+			Type:     "function",
+			Function: FunctionCall{Name: call.Name, Arguments: string(compact)},
+		}
+	}
+	return calls, nil
+}
+
+// ToolEmulationStreamReader converts <tool_calls> blocks in a stream of
+// emulated chunks into tool call deltas. Text is passed through as it
+// arrives except for a possible tag prefix; a block is buffered until the
+// stream ends and then parsed.
+type ToolEmulationStreamReader struct {
+	next  func() (*ChatCompletionStreamResponse, error)
+	tools []Tool
+
+	pending      string // text held back because it may start the open tag
+	block        strings.Builder
+	inBlock      bool
+	finishReason string
+	trailer      []*ChatCompletionStreamResponse // usage chunks, sent last
+	queue        []*ChatCompletionStreamResponse
+	last         ChatCompletionStreamResponse // identity for generated chunks
+	done         bool
+}
+
+// NewToolEmulationStreamReader wraps a chunk reader such as ChatCompletionStreamReader.Next
+func NewToolEmulationStreamReader(next func() (*ChatCompletionStreamResponse, error), tools []Tool) *ToolEmulationStreamReader {
+	return &ToolEmulationStreamReader{next: next, tools: tools}
+}
+
+// Next returns the next chunk, or io.EOF at the end of the stream
+func (r *ToolEmulationStreamReader) Next() (*ChatCompletionStreamResponse, error) {
+	for len(r.queue) == 0 {
+		if r.done {
+			return nil, io.EOF
+		}
+
+		chunk, err := r.next()
+		if err == io.EOF {
+			r.finish()
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.process(chunk)
+	}
+
+	chunk := r.queue[0]
+	r.queue = r.queue[1:]
+	return chunk, nil
+}
+
+func (r *ToolEmulationStreamReader) process(chunk *ChatCompletionStreamResponse) {
+	r.last = ChatCompletionStreamResponse{ID: chunk.ID, Object: chunk.Object, Created: chunk.Created, Model: chunk.Model}
+
+	if len(chunk.Choices) == 0 {
+		if chunk.Usage != nil {
+			r.trailer = append(r.trailer, chunk)
+		}
+		return
+	}
+
+	choice := &chunk.Choices[0]
+	if choice.FinishReason != nil && *choice.FinishReason != "" {
+		// Held back: it becomes tool_calls if a block parses
+		r.finishReason = *choice.FinishReason
+		choice.FinishReason = nil
+	}
+	if chunk.Usage != nil {
+		r.trailer = append(r.trailer, &ChatCompletionStreamResponse{Choices: []ChatCompletionStreamChoice{}, Usage: chunk.Usage})
+		chunk.Usage = nil
+	}
+
+	text := choice.Delta.Content
+	choice.Delta.Content = r.passText(text)
+	if choice.Delta.Content != "" || choice.Delta.Role != "" || len(choice.Delta.ToolCalls) > 0 {
+		r.queue = append(r.queue, chunk)
+	}
+}
+
+// passText returns the part of text that can be sent now
+func (r *ToolEmulationStreamReader) passText(text string) string {
+	if r.inBlock {
+		r.block.WriteString(text)
+		return ""
+	}
+
+	r.pending += text
+	if start := strings.Index(r.pending, toolCallsOpenTag); start >= 0 {
+		out := r.pending[:start]
+		r.block.WriteString(r.pending[start:])
+		r.pending = ""
+		r.inBlock = true
+		return out
+	}
+
+	// Hold back the longest suffix that could begin the open tag
+	keep := 0
+	for n := min(len(r.pending), len(toolCallsOpenTag)-1); n > 0; n-- {
+		if strings.HasPrefix(toolCallsOpenTag, r.pending[len(r.pending)-n:]) {
+			keep = n
+			break
+		}
+	}
+	out := r.pending[:len(r.pending)-keep]
+	r.pending = r.pending[len(r.pending)-keep:]
+	return out
+}
+
+// finish flushes held text, emits parsed tool calls and the held chunks
+func (r *ToolEmulationStreamReader) finish() {
+	r.done = true
+	finishReason := r.finishReason
+	if finishReason == "" {
+		finishReason = "stop"
+	}
+
+	text := r.pending
+	if r.inBlock {
+		calls, err := parseToolCallBlock(r.block.String(), r.tools)
+		if err == nil {
+			for i, call := range calls {
+				index := i
+				call.Index = &index
+				r.queue = append(r.queue, r.chunk(ChatMessageDelta{ToolCalls: []ToolCall{call}}, ""))
+			}
+			finishReason = "tool_calls"
+		} else {
+			// Not a valid call; send the block as text
+			text += r.block.String()
+		}
+	}
+	if text != "" {
+		r.queue = append(r.queue, r.chunk(ChatMessageDelta{Content: text}, ""))
+	}
+
+	r.queue = append(r.queue, r.chunk(ChatMessageDelta{}, finishReason))
+	r.queue = append(r.queue, r.trailer...)
+}
+
+func (r *ToolEmulationStreamReader) chunk(delta ChatMessageDelta, finishReason string) *ChatCompletionStreamResponse {
+	return NewChatCompletionChunk(r.last.ID, r.last.Model, r.last.Created, delta, finishReason)
+}
@@ -0,0 +1,279 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package translator
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+var weatherTool = Tool{
+	Type: "function",
+	Function: Function{
+		Name: "get_weather",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"city": map[string]interface{}{"type": "string"},
+				"unit": map[string]interface{}{"type": "string", "enum": []interface{}{"C", "F"}},
+			},
+			"required":             []interface{}{"city"},
+			"additionalProperties": false,
+		},
+	},
+}
+
+func TestValidateJSONSchema(t *testing.T) {
+	schema := weatherTool.Function.Parameters
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{`{"city": "Paris"}`, true},
+		{`{"city": "Paris", "unit": "C"}`, true},
+		{`{"unit": "C"}`, false},
+		{`{"city": 42}`, false},
+		{`{"city": "Paris", "unit": "K"}`, false},
+		{`{"city": "Paris", "country": "FR"}`, false},
+		{`["Paris"]`, false},
+	}
+
+	for _, tt := range tests {
+		var value interface{}
+		json.Unmarshal([]byte(tt.value), &value)
+		err := ValidateJSONSchema(value, schema)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateJSONSchema(%s) = %v, want valid=%v", tt.value, err, tt.valid)
+		}
+	}
+
+	nested := map[string]interface{}{
+		"type":  "array",
+		"items": map[string]interface{}{"type": "integer"},
+	}
+	var value interface{}
+	json.Unmarshal([]byte(`[1, 2.5]`), &value)
+	if err := ValidateJSONSchema(value, nested); err == nil || !strings.Contains(err.Error(), "$[1]") {
+		t.Errorf("Expected an error at $[1], got %v", err)
+	}
+}
+
+func TestValidateJSONSchemaKeywords(t *testing.T) {
+	var schema map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"home": {"$ref": "#/$defs/address"},
+			"work": {"$ref": "#/definitions/address"},
+			"tree": {"$ref": "#/$defs/node"},
+			"id": {"oneOf": [{"type": "integer"}, {"type": "number", "minimum": 0}]},
+			"loop": {"$ref": "#/$defs/loop"},
+			"remote": {"$ref": "https://example.com/schema.json"}
+		},
+		"$defs": {
+			"address": {"type": "object", "properties": {"city": {"type": "string"}}, "required": ["city"]},
+			"node": {"type": "object", "properties": {"children": {"type": "array", "items": {"$ref": "#/$defs/node"}}}, "additionalProperties": false},
+			"loop": {"$ref": "#/$defs/loop"}
+		},
+		"definitions": {
+			"address": {"$ref": "#/$defs/address"}
+		}
+	}`), &schema)
+
+	tests := []struct {
+		name  string
+		value string
+		valid bool
+	}{
+		{"Referenced definition", `{"home": {"city": "Paris"}}`, true},
+		{"Referenced definition mismatch", `{"home": {"city": 42}}`, false},
+		{"Referenced required property", `{"home": {}}`, false},
+		{"Definitions chain", `{"work": {"city": "Paris"}}`, true},
+		{"Definitions chain mismatch", `{"work": {}}`, false},
+		{"Recursive definition", `{"tree": {"children": [{"children": []}]}}`, true},
+		{"Recursive definition mismatch", `{"tree": {"children": [{"name": "leaf"}]}}`, false},
+		{"One of matches one", `{"id": 1.5}`, true},
+		{"One of matches two", `{"id": 1}`, false},
+		{"One of matches none", `{"id": "one"}`, false},
+		{"Reference loop", `{"loop": 1}`, false},
+		{"Remote reference", `{"remote": 1}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			json.Unmarshal([]byte(tt.value), &value)
+			if err := ValidateJSONSchema(value, schema); (err == nil) != tt.valid {
+				t.Errorf("ValidateJSONSchema(%s) = %v, want valid=%v", tt.value, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestEmulateTools(t *testing.T) {
+	req := &ChatCompletionRequest{
+		Model: "m",
+		Messages: []ChatMessage{
+			{Role: "system", Content: "Be brief."},
+			{Role: "user", Content: "Weather in Paris?"},
+			{Role: "assistant", ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}}}},
+			{Role: "tool", ToolCallID: "call_1", Content: "18C"},
+		},
+		Tools:      []Tool{weatherTool},
+		ToolChoice: "required",
+	}
+	if !UsesTools(req) {
+		t.Fatal("Expected the request to use tools")
+	}
+
+	emulated := EmulateTools(req)
+	if emulated.Tools != nil || emulated.ToolChoice != nil {
+		t.Error("Expected tools to be removed from the request")
+	}
+	roles := make([]string, len(emulated.Messages))
+	for i, msg := range emulated.Messages {
+		roles[i] = msg.Role
+	}
+	if got := strings.Join(roles, ","); got != "system,user,assistant,user" {
+		t.Fatalf("Unexpected roles %s", got)
+	}
+
+	system := emulated.Messages[0].Content.(string)
+	if !strings.HasPrefix(system, "Be brief.") || !strings.Contains(system, `"name":"get_weather"`) || !strings.Contains(system, "must call at least one tool") {
+		t.Errorf("Unexpected system prompt:\n%s", system)
+	}
+	if got := emulated.Messages[2].Content; got != "<tool_calls>\n[{\"name\":\"get_weather\",\"arguments\":{\"city\":\"Paris\"}}]\n</tool_calls>" {
+		t.Errorf("Unexpected assistant message %q", got)
+	}
+	if got := emulated.Messages[3].Content; got != "Tool result for get_weather (call call_1):\n18C" {
+		t.Errorf("Unexpected tool result %q", got)
+	}
+	if len(emulated.Stop) != 1 || emulated.Stop[0] != "</tool_calls>" {
+		t.Errorf("Expected the close tag as a stop sequence, got %v", emulated.Stop)
+	}
+	if len(req.Messages) != 4 || req.Tools == nil {
+		t.Error("Expected the original request to be unchanged")
+	}
+}
+
+func TestParseEmulatedToolCalls(t *testing.T) {
+	response := func(content string) *ChatCompletionResponse {
+		return &ChatCompletionResponse{Choices: []ChatCompletionChoice{{
+			Message:      ChatMessage{Role: "assistant", Content: content},
+			FinishReason: "stop",
+		}}}
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		resp := response("Let me check.\n<tool_calls>\n```json\n[{\"name\": \"get_weather\", \"arguments\": \"{\\\"city\\\": \\\"Rome\\\"}\"}]\n```\n")
+		if err := ParseEmulatedToolCalls(resp, []Tool{weatherTool}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		choice := resp.Choices[0]
+		if choice.FinishReason != "tool_calls" || choice.Message.Content != "Let me check." || len(choice.Message.ToolCalls) != 1 {
+			t.Fatalf("Unexpected choice %+v", choice)
+		}
+		call := choice.Message.ToolCalls[0]
+		if call.Function.Name != "get_weather" || call.Function.Arguments != `{"city":"Rome"}` || !strings.HasPrefix(call.ID, "call_") || call.Index != nil {
+			t.Errorf("Unexpected tool call %+v", call)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, content := range []string{
+			`<tool_calls>[{"name": "get_time", "arguments": {}}]</tool_calls>`,
+			`<tool_calls>[{"name": "get_weather", "arguments": {"unit": "C"}}]</tool_calls>`,
+			`<tool_calls>[{"name": "get_weather", "arguments": {"city": </tool_calls>`,
+		} {
+			resp := response(content)
+			if err := ParseEmulatedToolCalls(resp, []Tool{weatherTool}); err == nil {
+				t.Errorf("Expected an error for %s", content)
+			}
+			if resp.Choices[0].Message.Content != content || resp.Choices[0].FinishReason != "stop" {
+				t.Errorf("Expected the response to be unchanged for %s", content)
+			}
+		}
+	})
+
+	t.Run("Plain text", func(t *testing.T) {
+		resp := response("It is sunny.")
+		if err := ParseEmulatedToolCalls(resp, []Tool{weatherTool}); err != nil || resp.Choices[0].Message.ToolCalls != nil {
+			t.Errorf("Expected plain text to be left alone, got %v", err)
+		}
+	})
+}
+
+func TestToolEmulationStreamReader(t *testing.T) {
+	stream := func(deltas ...string) []*ChatCompletionStreamResponse {
+		var chunks []*ChatCompletionStreamResponse
+		for _, delta := range deltas {
+			chunks = append(chunks, NewChatCompletionChunk("x", "m", 0, ChatMessageDelta{Content: delta}, ""))
+		}
+		chunks = append(chunks,
+			NewChatCompletionChunk("x", "m", 0, ChatMessageDelta{}, "stop"),
+			&ChatCompletionStreamResponse{Choices: []ChatCompletionStreamChoice{}, Usage: &Usage{TotalTokens: 9}},
+		)
+		return chunks
+	}
+	read := func(chunks []*ChatCompletionStreamResponse) (text string, calls []ToolCall, finish string, usage *Usage) {
+		next := func() (*ChatCompletionStreamResponse, error) {
+			if len(chunks) == 0 {
+				return nil, io.EOF
+			}
+			chunk := chunks[0]
+			chunks = chunks[1:]
+			return chunk, nil
+		}
+		reader := NewToolEmulationStreamReader(next, []Tool{weatherTool})
+		for {
+			chunk, err := reader.Next()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+			}
+			if len(chunk.Choices) == 0 {
+				continue
+			}
+			if usage != nil {
+				t.Error("Expected usage to be the last chunk")
+			}
+			text += chunk.Choices[0].Delta.Content
+			calls = append(calls, chunk.Choices[0].Delta.ToolCalls...)
+			if chunk.Choices[0].FinishReason != nil {
+				finish = *chunk.Choices[0].FinishReason
+			}
+		}
+	}
+
+	t.Run("Tool call", func(t *testing.T) {
+		text, calls, finish, usage := read(stream("Checking.", " <tool", "_calls>[{\"name\": \"get_weather\",", " \"arguments\": {\"city\": \"Oslo\"}}]"))
+		if text != "Checking. " || finish != "tool_calls" || usage == nil {
+			t.Errorf("Unexpected text %q, finish %q, usage %v", text, finish, usage)
+		}
+		if len(calls) != 1 || *calls[0].Index != 0 || calls[0].Function.Arguments != `{"city":"Oslo"}` {
+			t.Errorf("Unexpected tool calls %+v", calls)
+		}
+	})
+
+	t.Run("Text that resembles a tag", func(t *testing.T) {
+		text, calls, finish, _ := read(stream("a <to", "p> b"))
+		if text != "a <top> b" || calls != nil || finish != "stop" {
+			t.Errorf("Unexpected text %q, calls %v, finish %q", text, calls, finish)
+		}
+	})
+
+	t.Run("Invalid block", func(t *testing.T) {
+		text, calls, finish, _ := read(stream("<tool_calls>not json"))
+		if text != "<tool_calls>not json" || calls != nil || finish != "stop" {
+			t.Errorf("Unexpected text %q, calls %v, finish %q", text, calls, finish)
+		}
+	})
+}
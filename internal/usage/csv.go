@@ -0,0 +1,52 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package usage
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// csvHeader matches the JSON field names of Rollup
+var csvHeader = []string{
+	"day", "api_key_id", "user", "model", "provider", "requests",
+	"input_tokens", "output_tokens", "total_tokens", "input_cost", "output_cost", "total_cost",
+}
+
+// WriteCSV writes rollups as CSV with a header row
+func WriteCSV(w io.Writer, rollups []Rollup) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, r := range rollups {
+		err := writer.Write([]string{
+			r.Day,
+			strconv.FormatInt(r.APIKeyID, 10),
+			r.User,
+			r.Model,
+			r.Provider,
+			strconv.FormatInt(r.Requests, 10),
+			strconv.FormatInt(r.InputTokens, 10),
+			strconv.FormatInt(r.OutputTokens, 10),
+			strconv.FormatInt(r.TotalTokens, 10),
+			formatCost(r.InputCost),
+			formatCost(r.OutputCost),
+			formatCost(r.TotalCost),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatCost formats USD with enough precision for per-token prices
+func formatCost(cost float64) string {
+	return strconv.FormatFloat(cost, 'f', 6, 64)
+}
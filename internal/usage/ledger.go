@@ -0,0 +1,274 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package usage
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DayFormat is the layout of rollup days
+const DayFormat = "2006-01-02"
+
+// ledgerQueueSize bounds records waiting to be written
+const ledgerQueueSize = 1024
+
+// Record is the usage of a single completion
+type Record struct {
+	Timestamp     time.Time
+	RequestID     string
+	APIKeyID      int64 // 0 when not authenticated by a database key
+	User          string
+	Model         string // client-facing model name
+	Provider      string
+	ProviderModel string // provider's model ID
+	InputTokens   int
+	OutputTokens  int
+	TotalTokens   int
+	InputCost     float64 // USD
+	OutputCost    float64
+	TotalCost     float64
+	Streaming     bool
+	StatusCode    int
+}
+
+// Rollup aggregates usage for one day, key, model and provider
+type Rollup struct {
+	Day          string  `json:"day"`
+	APIKeyID     int64   `json:"api_key_id"`
+	User         string  `json:"user"`
+	Model        string  `json:"model"`
+	Provider     string  `json:"provider"`
+	Requests     int64   `json:"requests"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	TotalTokens  int64   `json:"total_tokens"`
+	InputCost    float64 `json:"input_cost"`
+	OutputCost   float64 `json:"output_cost"`
+	TotalCost    float64 `json:"total_cost"`
+}
+
+// Filter selects rollups. Days are inclusive; zero values match everything.
+type Filter struct {
+	From     time.Time
+	To       time.Time
+	APIKeyID int64
+	User     string
+	Model    string
+	Provider string
+}
+
+// Ledger stores per-request usage and daily rollups in SQLite. Records are
+// written by a single background goroutine so requests never wait on the
+// database and writes do not contend with each other.
+type Ledger struct {
+	db    *sql.DB
+	queue chan Record
+	wg    sync.WaitGroup
+}
+
+// NewLedger creates the ledger tables in db and starts the writer
+func NewLedger(db *sql.DB) (*Ledger, error) {
+	schema := `
+	CREATE TABLE IF NOT EXISTS usage_ledger (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		request_id TEXT,
+		api_key_id INTEGER NOT NULL DEFAULT 0,
+		user TEXT NOT NULL DEFAULT '',
+		model TEXT NOT NULL,
+		provider TEXT NOT NULL,
+		provider_model TEXT,
+		input_tokens INTEGER DEFAULT 0,
+		output_tokens INTEGER DEFAULT 0,
+		total_tokens INTEGER DEFAULT 0,
+		input_cost REAL DEFAULT 0,
+		output_cost REAL DEFAULT 0,
+		total_cost REAL DEFAULT 0,
+		streaming BOOLEAN DEFAULT 0,
+		status_code INTEGER,
+		timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_usage_key_timestamp ON usage_ledger(api_key_id, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_usage_timestamp ON usage_ledger(timestamp);
+
+	-- Daily rollups, maintained as records are written
+	CREATE TABLE IF NOT EXISTS usage_daily (
+		day TEXT NOT NULL,
+		api_key_id INTEGER NOT NULL DEFAULT 0,
+		user TEXT NOT NULL DEFAULT '',
+		model TEXT NOT NULL,
+		provider TEXT NOT NULL,
+		requests INTEGER DEFAULT 0,
+		input_tokens INTEGER DEFAULT 0,
+		output_tokens INTEGER DEFAULT 0,
+		total_tokens INTEGER DEFAULT 0,
+		input_cost REAL DEFAULT 0,
+		output_cost REAL DEFAULT 0,
+		total_cost REAL DEFAULT 0,
+		PRIMARY KEY (day, api_key_id, user, model, provider)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_usage_daily_key ON usage_daily(api_key_id, day);
+	`
+
+	if _, err := db.Exec(schema); err != nil {
+		return nil, fmt.Errorf("failed to create usage schema: %w", err)
+	}
+
+	l := &Ledger{db: db, queue: make(chan Record, ledgerQueueSize)}
+	l.wg.Add(1)
+	go l.run()
+	return l, nil
+}
+
+// Record queues a record for writing. Records are dropped if the queue is
+// full rather than blocking the request.
+func (l *Ledger) Record(rec Record) {
+	if rec.Timestamp.IsZero() {
+		rec.Timestamp = time.Now()
+	}
+
+	select {
+	case l.queue <- rec:
+	default:
+		log.Printf("Usage ledger queue full, dropping record for request %s", rec.RequestID)
+	}
+}
+
+// Close writes queued records and stops the writer. The database is left open.
+func (l *Ledger) Close() {
+	close(l.queue)
+	l.wg.Wait()
+}
+
+func (l *Ledger) run() {
+	defer l.wg.Done()
+	for rec := range l.queue {
+		if err := l.write(rec); err != nil {
+			log.Printf("Failed to write usage record for request %s: %v", rec.RequestID, err)
+		}
+	}
+}
+
+// write stores a record and folds it into its daily rollup
+func (l *Ledger) write(rec Record) error {
+	tx, err := l.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	timestamp := rec.Timestamp.UTC()
+	_, err = tx.Exec(`
+		INSERT INTO usage_ledger (request_id, api_key_id, user, model, provider, provider_model,
+			input_tokens, output_tokens, total_tokens, input_cost, output_cost, total_cost,
+			streaming, status_code, timestamp)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, rec.RequestID, rec.APIKeyID, rec.User, rec.Model, rec.Provider, rec.ProviderModel,
+		rec.InputTokens, rec.OutputTokens, rec.TotalTokens, rec.InputCost, rec.OutputCost, rec.TotalCost,
+		rec.Streaming, rec.StatusCode, timestamp)
+	if err != nil {
+		return fmt.Errorf("failed to insert usage record: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO usage_daily (day, api_key_id, user, model, provider, requests,
+			input_tokens, output_tokens, total_tokens, input_cost, output_cost, total_cost)
+		VALUES (?, ?, ?, ?, ?, 1, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(day, api_key_id, user, model, provider) DO UPDATE SET
+			requests = requests + 1,
+			input_tokens = input_tokens + excluded.input_tokens,
+			output_tokens = output_tokens + excluded.output_tokens,
+			total_tokens = total_tokens + excluded.total_tokens,
+			input_cost = input_cost + excluded.input_cost,
+			output_cost = output_cost + excluded.output_cost,
+			total_cost = total_cost + excluded.total_cost
+	`, timestamp.Format(DayFormat), rec.APIKeyID, rec.User, rec.Model, rec.Provider,
+		rec.InputTokens, rec.OutputTokens, rec.TotalTokens, rec.InputCost, rec.OutputCost, rec.TotalCost)
+	if err != nil {
+		return fmt.Errorf("failed to update usage rollup: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// DailyRollups returns the daily rollups matching filter, ordered by day
+func (l *Ledger) DailyRollups(filter Filter) ([]Rollup, error) {
+	var conditions []string
+	var args []interface{}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "day >= ?")
+		args = append(args, filter.From.UTC().Format(DayFormat))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "day <= ?")
+		args = append(args, filter.To.UTC().Format(DayFormat))
+	}
+	if filter.APIKeyID != 0 {
+		conditions = append(conditions, "api_key_id = ?")
+		args = append(args, filter.APIKeyID)
+	}
+	if filter.User != "" {
+		conditions = append(conditions, "user = ?")
+		args = append(args, filter.User)
+	}
+	if filter.Model != "" {
+		conditions = append(conditions, "model = ?")
+		args = append(args, filter.Model)
+	}
+	if filter.Provider != "" {
+		conditions = append(conditions, "provider = ?")
+		args = append(args, filter.Provider)
+	}
+
+	query := `
+		SELECT day, api_key_id, user, model, provider, requests,
+			input_tokens, output_tokens, total_tokens, input_cost, output_cost, total_cost
+		FROM usage_daily`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY day, api_key_id, user, model, provider"
+
+	rows, err := l.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query usage: %w", err)
+	}
+	defer rows.Close()
+
+	var rollups []Rollup
+	for rows.Next() {
+		var r Rollup
+		err := rows.Scan(
+			&r.Day, &r.APIKeyID, &r.User, &r.Model, &r.Provider, &r.Requests,
+			&r.InputTokens, &r.OutputTokens, &r.TotalTokens, &r.InputCost, &r.OutputCost, &r.TotalCost,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read usage: %w", err)
+		}
+		rollups = append(rollups, r)
+	}
+
+	return rollups, rows.Err()
+}
+
+// Sum totals rollups; the result has no day, key, model or provider
+func Sum(rollups []Rollup) Rollup {
+	var total Rollup
+	for _, r := range rollups {
+		total.Requests += r.Requests
+		total.InputTokens += r.InputTokens
+		total.OutputTokens += r.OutputTokens
+		total.TotalTokens += r.TotalTokens
+		total.InputCost += r.InputCost
+		total.OutputCost += r.OutputCost
+		total.TotalCost += r.TotalCost
+	}
+	return total
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 Bedrock Proxy Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package usage
+
+import (
+	"bytes"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestLedger(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "usage.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ledger, err := NewLedger(db)
+	if err != nil {
+		t.Fatalf("Failed to create ledger: %v", err)
+	}
+
+	day1 := time.Date(2025, 3, 1, 23, 30, 0, 0, time.UTC)
+	day2 := day1.Add(time.Hour)
+
+	records := []Record{
+		{Timestamp: day1, APIKeyID: 1, Model: "gpt-4", Provider: "openai", InputTokens: 100, OutputTokens: 50, TotalTokens: 150, InputCost: 0.003, OutputCost: 0.003, TotalCost: 0.006},
+		{Timestamp: day1.Add(time.Minute), APIKeyID: 1, Model: "gpt-4", Provider: "openai", InputTokens: 200, OutputTokens: 10, TotalTokens: 210, TotalCost: 0.0066},
+		{Timestamp: day1, APIKeyID: 2, Model: "gpt-4", Provider: "azure", InputTokens: 5, OutputTokens: 5, TotalTokens: 10},
+		{Timestamp: day2, APIKeyID: 1, Model: "claude-3-haiku", Provider: "bedrock", InputTokens: 1000, OutputTokens: 1000, TotalTokens: 2000, TotalCost: 0.0015},
+	}
+	for _, rec := range records {
+		ledger.Record(rec)
+	}
+	ledger.Close()
+
+	var rows int
+	db.QueryRow("SELECT COUNT(*) FROM usage_ledger").Scan(&rows)
+	if rows != len(records) {
+		t.Errorf("Expected %d ledger rows, got %d", len(records), rows)
+	}
+
+	t.Run("Daily rollups", func(t *testing.T) {
+		rollups, err := ledger.DailyRollups(Filter{})
+		if err != nil {
+			t.Fatalf("Failed to query rollups: %v", err)
+		}
+		if len(rollups) != 3 {
+			t.Fatalf("Expected 3 rollups, got %d: %+v", len(rollups), rollups)
+		}
+
+		first := rollups[0]
+		if first.Day != "2025-03-01" || first.APIKeyID != 1 || first.Requests != 2 {
+			t.Errorf("Unexpected first rollup: %+v", first)
+		}
+		if first.InputTokens != 300 || first.OutputTokens != 60 || first.TotalTokens != 360 {
+			t.Errorf("Unexpected token totals: %+v", first)
+		}
+		if diff := first.TotalCost - 0.0126; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("Expected total cost 0.0126, got %v", first.TotalCost)
+		}
+
+		// Rollups are keyed by UTC day
+		if rollups[2].Day != "2025-03-02" || rollups[2].Provider != "bedrock" {
+			t.Errorf("Unexpected last rollup: %+v", rollups[2])
+		}
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			filter   Filter
+			expected int64 // requests
+		}{
+			{"Date range", Filter{From: day2, To: day2}, 1},
+			{"API key", Filter{APIKeyID: 1}, 3},
+			{"Model", Filter{Model: "gpt-4"}, 3},
+			{"Provider", Filter{Provider: "azure"}, 1},
+			{"Combined", Filter{From: day1, To: day1, APIKeyID: 1, Provider: "openai"}, 2},
+			{"No match", Filter{From: day2.AddDate(0, 0, 1)}, 0},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rollups, err := ledger.DailyRollups(tt.filter)
+				if err != nil {
+					t.Fatalf("Failed to query rollups: %v", err)
+				}
+				if total := Sum(rollups); total.Requests != tt.expected {
+					t.Errorf("Expected %d requests, got %d", tt.expected, total.Requests)
+				}
+			})
+		}
+	})
+
+	t.Run("CSV export", func(t *testing.T) {
+		rollups, _ := ledger.DailyRollups(Filter{Provider: "azure"})
+
+		var buf bytes.Buffer
+		if err := WriteCSV(&buf, rollups); err != nil {
+			t.Fatalf("Failed to write CSV: %v", err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("Expected header and one row, got %q", buf.String())
+		}
+		if !strings.HasPrefix(lines[0], "day,api_key_id,user,model,provider,requests") {
+			t.Errorf("Unexpected header: %s", lines[0])
+		}
+		if lines[1] != "2025-03-01,2,,gpt-4,azure,1,5,5,10,0.000000,0.000000,0.000000" {
+			t.Errorf("Unexpected row: %s", lines[1])
+		}
+	})
+}
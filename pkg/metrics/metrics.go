@@ -80,6 +80,78 @@ var (
 		[]string{"model", "type"}, // type: input/output
 	)
 
+	// ProviderAttempts tracks provider invocation attempts by outcome
+	ProviderAttempts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_provider_attempts_total",
+			Help: "Total number of provider invocation attempts",
+		},
+		[]string{"provider", "outcome"}, // outcome: success, retry, failed, canceled, circuit_open
+	)
+
+	// CircuitBreakerState tracks circuit breaker state per provider and model deployment
+	CircuitBreakerState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "llm_circuit_breaker_state",
+			Help: "Circuit breaker state (0 = closed, 1 = half-open, 2 = open)",
+		},
+		[]string{"provider", "model"}, // model is empty for provider-level breakers
+	)
+
+	// TokensTotal tracks tokens used by completions
+	TokensTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_tokens_total",
+			Help: "Total number of tokens used by completions",
+		},
+		[]string{"provider", "model", "type"}, // type: input/output
+	)
+
+	// CostTotal tracks the estimated cost of completions
+	CostTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_cost_usd_total",
+			Help: "Total estimated cost of completions in USD",
+		},
+		[]string{"provider", "model"},
+	)
+
+	// CacheLookups tracks response cache lookups by outcome
+	CacheLookups = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_cache_lookups_total",
+			Help: "Total number of response cache lookups",
+		},
+		[]string{"cache", "model", "outcome"}, // outcome: hit, miss, bypass, coalesced
+	)
+
+	// CacheSize tracks the bytes held by each response cache
+	CacheSize = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "llm_cache_size_bytes",
+			Help: "Bytes held by the response cache",
+		},
+		[]string{"cache"},
+	)
+
+	// ConfigReloads tracks configuration reloads by result
+	ConfigReloads = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_config_reloads_total",
+			Help: "Total number of configuration reloads",
+		},
+		[]string{"config", "result"}, // result: success, failure
+	)
+
+	// ConfigHash tracks the hash of each configuration file in use
+	ConfigHash = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "llm_config_hash",
+			Help: "Hash of the configuration file in use",
+		},
+		[]string{"config"},
+	)
+
 	// ConnectedClients tracks number of connected clients
 	ConnectedClients = promauto.NewGauge(
 		prometheus.GaugeOpts{
@@ -114,6 +186,48 @@ func RecordTokensProcessed(modelID, tokenType string, count int) {
 	BedrockTokensProcessed.WithLabelValues(modelID, tokenType).Add(float64(count))
 }
 
+// RecordProviderAttempt records a provider invocation attempt
+func RecordProviderAttempt(provider, outcome string) {
+	ProviderAttempts.WithLabelValues(provider, outcome).Inc()
+}
+
+// SetCircuitBreakerState sets the state of a provider or model deployment circuit breaker
+func SetCircuitBreakerState(provider, model string, state int) {
+	CircuitBreakerState.WithLabelValues(provider, model).Set(float64(state))
+}
+
+// RecordUsage records the tokens and cost of a completion
+func RecordUsage(provider, model string, inputTokens, outputTokens int, cost float64) {
+	TokensTotal.WithLabelValues(provider, model, "input").Add(float64(inputTokens))
+	TokensTotal.WithLabelValues(provider, model, "output").Add(float64(outputTokens))
+	CostTotal.WithLabelValues(provider, model).Add(cost)
+}
+
+// RecordCacheLookup records the outcome of a response cache lookup
+func RecordCacheLookup(cache, model, outcome string) {
+	CacheLookups.WithLabelValues(cache, model, outcome).Inc()
+}
+
+// SetCacheSize sets the bytes held by a response cache
+func SetCacheSize(cache string, bytes int64) {
+	CacheSize.WithLabelValues(cache).Set(float64(bytes))
+}
+
+// RecordConfigReload records the result of a configuration reload
+func RecordConfigReload(config, result string) {
+	ConfigReloads.WithLabelValues(config, result).Inc()
+}
+
+// SetConfigHash sets the hash of the configuration file in use. The hash is
+// truncated to 48 bits so it is exact as a float.
+func SetConfigHash(config string, hash []byte) {
+	var value uint64
+	for _, b := range hash[:6] {
+		value = value<<8 | uint64(b)
+	}
+	ConfigHash.WithLabelValues(config).Set(float64(value))
+}
+
 // RecordCredentialRetrieval records AWS credential retrieval
 func RecordCredentialRetrieval(method, status string) {
 	AWSCredentialRetrievals.WithLabelValues(method, status).Inc()